@@ -0,0 +1,181 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package metrics implements 'agent metrics'.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+// clearScreen moves the cursor to the top left corner and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// cliParams are the command-line arguments for the top subcommand
+type cliParams struct {
+	*command.GlobalParams
+
+	sort       string
+	limit      int
+	delay      time.Duration
+	iterations int
+	jsonOutput bool
+}
+
+// Commands returns a slice of subcommands for the 'agent' command.
+func Commands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &cliParams{
+		GlobalParams: globalParams,
+	}
+
+	metricsCmd := &cobra.Command{
+		Use:   "metrics",
+		Short: "Inspect the metrics aggregated by the running agent",
+		Long:  ``,
+	}
+
+	topCmd := &cobra.Command{
+		Use:   "top",
+		Short: "Show the metrics, tag keys, origins and checks producing the most contexts",
+		Long: `Show the contexts currently tracked by the aggregator of the running agent, grouped by
+metric name, tag key, DogStatsD origin and check. The report is refreshed every --delay until
+interrupted, like top, unless --iterations or --json is set.`,
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(metricsTop,
+				fx.Supply(cliParams),
+				fx.Supply(command.GetDefaultCoreBundleParams(cliParams.GlobalParams)),
+				core.Bundle(),
+			)
+		},
+	}
+	topCmd.Flags().StringVarP(&cliParams.sort, "sort", "s", aggregator.ContextsTopSortByContexts, "sort the groups by contexts, values or name")
+	topCmd.Flags().IntVarP(&cliParams.limit, "limit", "l", 10, "number of rows shown for each group, 0 to show all of them")
+	topCmd.Flags().DurationVarP(&cliParams.delay, "delay", "d", 3*time.Second, "delay between refreshes")
+	topCmd.Flags().IntVarP(&cliParams.iterations, "iterations", "n", 0, "number of refreshes before exiting, 0 to refresh until interrupted")
+	topCmd.Flags().BoolVarP(&cliParams.jsonOutput, "json", "j", false, "print a single report as JSON")
+
+	metricsCmd.AddCommand(topCmd)
+
+	return []*cobra.Command{metricsCmd}
+}
+
+func metricsTop(_ log.Component, config config.Component, cliParams *cliParams) error {
+	if cliParams.delay <= 0 {
+		return errors.New("the delay must be positive")
+	}
+
+	endpoint, err := apiutil.NewIPCEndpoint(config, "/agent/metrics-top")
+	if err != nil {
+		return err
+	}
+	query := url.Values{}
+	query.Set("sort", cliParams.sort)
+	query.Set("limit", strconv.Itoa(cliParams.limit))
+
+	if cliParams.jsonOutput {
+		r, err := getMetricsTop(endpoint, query)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(r))
+		return nil
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	ticker := time.NewTicker(cliParams.delay)
+	defer ticker.Stop()
+
+	for i := 1; ; i++ {
+		r, err := getMetricsTop(endpoint, query)
+		if err != nil {
+			return err
+		}
+		var top aggregator.ContextsTop
+		if err := json.Unmarshal(r, &top); err != nil {
+			return fmt.Errorf("could not parse the report of the agent: %v", err)
+		}
+		if cliParams.iterations != 1 {
+			fmt.Print(clearScreen)
+		}
+		printMetricsTop(os.Stdout, &top, time.Now())
+
+		if cliParams.iterations > 0 && i >= cliParams.iterations {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func getMetricsTop(endpoint *apiutil.IPCEndpoint, query url.Values) ([]byte, error) {
+	r, err := endpoint.DoGet(apiutil.WithValues(query))
+	if err != nil {
+		return nil, fmt.Errorf("could not get the contexts from the agent, make sure the agent is running: %v", err)
+	}
+	return r, nil
+}
+
+// printMetricsTop renders the report as one table per group.
+func printMetricsTop(w io.Writer, top *aggregator.ContextsTop, now time.Time) {
+	fmt.Fprintf(w, "%s - %d contexts: %d from DogStatsD, %d from checks\n",
+		now.Format(time.TimeOnly), top.DogstatsdContexts+top.CheckContexts, top.DogstatsdContexts, top.CheckContexts)
+
+	tables := []struct {
+		title  string
+		values string
+		groups []aggregator.ContextsGroup
+	}{
+		{"METRIC", "TAG KEYS", top.Metrics},
+		{"TAG KEY", "VALUES", top.TagKeys},
+		{"ORIGIN", "", top.Origins},
+		{"CHECK", "", top.Checks},
+	}
+	for _, table := range tables {
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if table.values != "" {
+			fmt.Fprintf(tw, "CONTEXTS\t%s\t%s\n", table.values, table.title)
+		} else {
+			fmt.Fprintf(tw, "CONTEXTS\t%s\n", table.title)
+		}
+		for _, g := range table.groups {
+			if table.values != "" {
+				fmt.Fprintf(tw, "%d\t%d\t%s\n", g.Contexts, g.Values, g.Name)
+			} else {
+				fmt.Fprintf(tw, "%d\t%s\n", g.Contexts, g.Name)
+			}
+		}
+		if len(table.groups) == 0 {
+			fmt.Fprintln(tw, "-\t(none)")
+		}
+		tw.Flush()
+	}
+}
@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package metrics
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/agent/command"
+	"github.com/DataDog/datadog-agent/comp/core"
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"metrics", "top", "--sort", "values", "--limit", "5", "--json"},
+		metricsTop,
+		func(cliParams *cliParams, _ core.BundleParams, secretParams secrets.Params) {
+			require.Equal(t, aggregator.ContextsTopSortByValues, cliParams.sort)
+			require.Equal(t, 5, cliParams.limit)
+			require.Equal(t, 3*time.Second, cliParams.delay)
+			require.True(t, cliParams.jsonOutput)
+			require.Equal(t, false, secretParams.Enabled)
+		})
+}
+
+func TestPrintMetricsTop(t *testing.T) {
+	top := &aggregator.ContextsTop{
+		DogstatsdContexts: 3,
+		CheckContexts:     1,
+		Metrics:           []aggregator.ContextsGroup{{Name: "foo", Contexts: 3, Values: 2}},
+		TagKeys:           []aggregator.ContextsGroup{{Name: "env", Contexts: 3, Values: 1}},
+		Origins:           []aggregator.ContextsGroup{{Name: "none", Contexts: 3}},
+	}
+
+	var b bytes.Buffer
+	printMetricsTop(&b, top, time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC))
+
+	assert.Equal(t, `12:30:00 - 4 contexts: 3 from DogStatsD, 1 from checks
+
+CONTEXTS  TAG KEYS  METRIC
+3         2         foo
+
+CONTEXTS  VALUES  TAG KEY
+3         1       env
+
+CONTEXTS  ORIGIN
+3         none
+
+CONTEXTS  CHECK
+-         (none)
+`, b.String())
+}
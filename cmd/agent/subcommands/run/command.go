@@ -478,6 +478,7 @@ func getSharedFxOption() fx.Option {
 					"runtime_block_profile_rate":             commonsettings.NewRuntimeBlockProfileRate(),
 					"dogstatsd_stats":                        internalsettings.NewDsdStatsRuntimeSetting(serverDebug),
 					"dogstatsd_capture_duration":             internalsettings.NewDsdCaptureDurationRuntimeSetting("dogstatsd_capture_duration"),
+					"aggregator_recording_rules":             internalsettings.NewRecordingRulesRuntimeSetting(),
 					"log_payloads":                           commonsettings.NewLogPayloadsRuntimeSetting(),
 					"internal_profiling_goroutines":          commonsettings.NewProfilingGoroutines(),
 					"multi_region_failover.enabled":          internalsettings.NewMultiRegionFailoverRuntimeSetting("multi_region_failover.enabled", "Enable/disable Multi-Region Failover support."),
@@ -639,6 +640,10 @@ func startAgent(
 		return connectivity.Diagnose(diagCfg, log)
 	})
 
+	diagnosecatalog.Register(diagnose.ContextCardinality, func(_ diagnose.Config) []diagnose.Diagnosis {
+		return aggregator.DiagnoseContextCardinality(demultiplexer)
+	})
+
 	// start dependent services
 	go startDependentServices()
 
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+// RecordingRulesRuntimeSetting wraps operations to change the recording rules applied by the aggregator
+type RecordingRulesRuntimeSetting struct{}
+
+// NewRecordingRulesRuntimeSetting returns a new RecordingRulesRuntimeSetting
+func NewRecordingRulesRuntimeSetting() *RecordingRulesRuntimeSetting {
+	return &RecordingRulesRuntimeSetting{}
+}
+
+// Description returns the runtime setting's description
+func (r *RecordingRulesRuntimeSetting) Description() string {
+	return "Set the recording rules rolling up metrics at flush time, as a JSON or YAML list of rules."
+}
+
+// Hidden returns whether or not this setting is hidden from the list of runtime settings
+func (r *RecordingRulesRuntimeSetting) Hidden() bool {
+	return false
+}
+
+// Name returns the name of the runtime setting
+func (r *RecordingRulesRuntimeSetting) Name() string {
+	return aggregator.RecordingRulesConfigKey
+}
+
+// Get returns the current value of the runtime setting
+func (r *RecordingRulesRuntimeSetting) Get(config config.Component) (interface{}, error) {
+	return config.Get(aggregator.RecordingRulesConfigKey), nil
+}
+
+// Set changes the value of the runtime setting
+func (r *RecordingRulesRuntimeSetting) Set(config config.Component, v interface{}, source model.Source) error {
+	var raw []byte
+	var err error
+	switch value := v.(type) {
+	case string:
+		raw = []byte(value)
+	case []interface{}, []map[string]interface{}:
+		if raw, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("%s.Set: %v", r.Name(), err)
+		}
+	default:
+		return fmt.Errorf("%s.Set: Invalid data type", r.Name())
+	}
+
+	// JSON being a subset of YAML, the YAML decoder handles both
+	var rules []aggregator.RecordingRule
+	if err := yaml.Unmarshal(raw, &rules); err != nil {
+		return fmt.Errorf("Unsupported value for %s: %v", r.Name(), err)
+	}
+	if err := aggregator.ValidateRecordingRules(rules); err != nil {
+		return fmt.Errorf("Unsupported value for %s: %v", r.Name(), err)
+	}
+
+	value := make([]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		value = append(value, map[string]interface{}{
+			"metric":        rule.Metric,
+			"by":            rule.By,
+			"name":          rule.Name,
+			"keep_original": rule.KeepOriginal,
+		})
+	}
+	config.Set(r.Name(), value, source)
+	return nil
+}
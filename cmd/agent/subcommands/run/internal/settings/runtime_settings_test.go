@@ -24,6 +24,7 @@ import (
 	serverdebug "github.com/DataDog/datadog-agent/comp/dogstatsd/serverDebug"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
 	"github.com/DataDog/datadog-agent/pkg/config/model"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
@@ -103,3 +104,28 @@ func TestDogstatsdMetricsStats(t *testing.T) {
 	assert.Nil(err)
 	assert.Equal(v, true)
 }
+
+func TestRecordingRulesRuntimeSetting(t *testing.T) {
+	cfg := config.NewMock(t)
+	s := NewRecordingRulesRuntimeSetting()
+
+	err := s.Set(cfg, `[{"metric": "http.requests", "by": ["service"]}]`, model.SourceCLI)
+	assert.NoError(t, err)
+	rules, err := aggregator.GetRecordingRules(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []aggregator.RecordingRule{{Metric: "http.requests", By: []string{"service"}}}, rules)
+
+	err = s.Set(cfg, "- metric: http.errors\n  by: [env]\n  name: http.errors.by_env\n  keep_original: true\n", model.SourceCLI)
+	assert.NoError(t, err)
+	rules, err = aggregator.GetRecordingRules(cfg)
+	assert.NoError(t, err)
+	assert.Equal(t, []aggregator.RecordingRule{{Metric: "http.errors", By: []string{"env"}, Name: "http.errors.by_env", KeepOriginal: true}}, rules)
+
+	err = s.Set(cfg, `[{"metric": "http.errors", "keep_original": true}]`, model.SourceCLI)
+	assert.Error(t, err)
+	err = s.Set(cfg, 42, model.SourceCLI)
+	assert.Error(t, err)
+	rules, err = aggregator.GetRecordingRules(cfg)
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1, "invalid rules are not applied")
+}
@@ -82,6 +82,12 @@ func newDemultiplexer(deps dependencies) (provides, error) {
 		}
 	}
 	options := createAgentDemultiplexerOptions(deps.Config, deps.Params)
+
+	var exporter *openMetricsExporter
+	if deps.Config.GetBool("aggregator_openmetrics_exporter.enabled") {
+		exporter = newOpenMetricsExporter(deps.Config, deps.Log)
+		options.FlushObserver = exporter
+	}
 	agentDemultiplexer := aggregator.InitAndStartAgentDemultiplexer(
 		deps.Log,
 		deps.SharedForwarder,
@@ -100,6 +106,17 @@ func newDemultiplexer(deps dependencies) (provides, error) {
 		agentDemultiplexer.Stop(true)
 		return nil
 	}})
+	if exporter != nil {
+		deps.Lc.Append(fx.Hook{
+			OnStart: func(_ context.Context) error {
+				if err := exporter.start(); err != nil {
+					deps.Log.Errorf("Could not start the aggregator OpenMetrics exporter: %v", err)
+				}
+				return nil
+			},
+			OnStop: exporter.stop,
+		})
+	}
 
 	return provides{
 		Comp:                    demultiplexer,
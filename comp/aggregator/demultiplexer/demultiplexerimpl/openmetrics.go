@@ -0,0 +1,343 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package demultiplexerimpl
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+// openMetricsExporter mirrors the series and sketches flushed by the demultiplexer on an
+// OpenMetrics endpoint, so that the metrics sent to Datadog can also be scraped by a local
+// Prometheus server.
+//
+// Tags are mapped to labels, gauges and rates are exposed as gauges, counts are accumulated
+// into counters and sketches are exposed as summaries. Contexts which have not been flushed
+// for longer than the staleness timeout are removed from the endpoint, unless the timeout is
+// zero or negative.
+type openMetricsExporter struct {
+	log           log.Component
+	listenAddress string
+	staleness     time.Duration
+	quantiles     []float64
+	now           func() time.Time
+
+	mu        sync.Mutex
+	contexts  map[string]*openMetricsContext
+	lastPurge time.Time
+
+	server *http.Server
+}
+
+// openMetricsContext holds the latest state of a single exposed context.
+type openMetricsContext struct {
+	name     string
+	typ      dto.MetricType
+	labels   []*dto.LabelPair
+	lastSeen time.Time
+
+	// value is the last value of gauges and the cumulated value of counters
+	value float64
+
+	// summary data, the count and sum are cumulated while the quantiles are the ones of
+	// the last flushed sketch.
+	count     uint64
+	sum       float64
+	quantiles []float64
+}
+
+func newOpenMetricsExporter(cfg config.Component, log log.Component) *openMetricsExporter {
+	quantiles := cfg.GetFloat64Slice("aggregator_openmetrics_exporter.sketch_quantiles")
+	valid := quantiles[:0]
+	for _, q := range quantiles {
+		if q < 0 || q > 1 {
+			log.Warnf("Ignoring invalid quantile %v in aggregator_openmetrics_exporter.sketch_quantiles: quantiles must be between 0 and 1", q)
+			continue
+		}
+		valid = append(valid, q)
+	}
+	sort.Float64s(valid)
+
+	return &openMetricsExporter{
+		log:           log,
+		listenAddress: cfg.GetString("aggregator_openmetrics_exporter.listen_address"),
+		staleness:     time.Duration(cfg.GetInt("aggregator_openmetrics_exporter.staleness_timeout")) * time.Second,
+		quantiles:     valid,
+		now:           time.Now,
+		contexts:      make(map[string]*openMetricsContext),
+	}
+}
+
+// start starts serving the metrics on the configured address.
+func (e *openMetricsExporter) start() error {
+	listener, err := net.Listen("tcp", e.listenAddress)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", e.handler())
+	e.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := e.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			e.log.Errorf("Error serving the OpenMetrics endpoint: %v", err)
+		}
+	}()
+	e.log.Infof("Serving flushed metrics in the OpenMetrics format on http://%s/metrics", listener.Addr())
+	return nil
+}
+
+// stop stops serving the metrics.
+func (e *openMetricsExporter) stop(ctx context.Context) error {
+	if e.server == nil {
+		return nil
+	}
+	return e.server.Shutdown(ctx)
+}
+
+func (e *openMetricsExporter) handler() http.Handler {
+	return promhttp.HandlerFor(prometheus.GathererFunc(e.gather), promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// ObserveSerie implements aggregator.FlushObserver.
+func (e *openMetricsExporter) ObserveSerie(serie *metrics.Serie) {
+	if len(serie.Points) == 0 {
+		return
+	}
+	typ := dto.MetricType_GAUGE
+	if serie.MType == metrics.APICountType {
+		typ = dto.MetricType_COUNTER
+	}
+	name := sanitizeOpenMetricsName(serie.Name)
+	labels := openMetricsLabels(serie.Host, serie.Device, serie.Tags)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c := e.context(name, typ, labels)
+	if typ == dto.MetricType_COUNTER {
+		for _, p := range serie.Points {
+			c.value += p.Value
+		}
+	} else {
+		c.value = serie.Points[len(serie.Points)-1].Value
+	}
+}
+
+// ObserveSketch implements aggregator.FlushObserver.
+func (e *openMetricsExporter) ObserveSketch(sketch *metrics.SketchSeries) {
+	if len(sketch.Points) == 0 {
+		return
+	}
+	merged := &quantile.Sketch{}
+	for _, p := range sketch.Points {
+		if p.Sketch != nil {
+			merged.Merge(quantile.Default(), p.Sketch)
+		}
+	}
+	name := sanitizeOpenMetricsName(sketch.Name)
+	labels := openMetricsLabels(sketch.Host, "", sketch.Tags)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	c := e.context(name, dto.MetricType_SUMMARY, labels)
+	c.count += uint64(merged.Basic.Cnt)
+	c.sum += merged.Basic.Sum
+	c.quantiles = c.quantiles[:0]
+	for _, q := range e.quantiles {
+		c.quantiles = append(c.quantiles, merged.Quantile(quantile.Default(), q))
+	}
+}
+
+// context returns the context matching the given name and labels, creating it if needed,
+// and marks it as seen. It must be called with e.mu held.
+func (e *openMetricsExporter) context(name string, typ dto.MetricType, labels []*dto.LabelPair) *openMetricsContext {
+	now := e.now()
+	if e.staleness > 0 && now.Sub(e.lastPurge) > e.staleness {
+		e.purgeStale(now)
+	}
+
+	key := contextKey(name, labels)
+	c, ok := e.contexts[key]
+	if !ok || c.typ != typ {
+		c = &openMetricsContext{name: name, typ: typ, labels: labels}
+		e.contexts[key] = c
+	}
+	c.lastSeen = now
+	return c
+}
+
+// purgeStale removes the contexts which have not been seen for longer than the staleness
+// timeout. A timeout of zero or less disables the expiry. It must be called with e.mu held.
+func (e *openMetricsExporter) purgeStale(now time.Time) {
+	if e.staleness <= 0 {
+		return
+	}
+	for key, c := range e.contexts {
+		if now.Sub(c.lastSeen) > e.staleness {
+			delete(e.contexts, key)
+		}
+	}
+	e.lastPurge = now
+}
+
+// gather implements prometheus.Gatherer.
+func (e *openMetricsExporter) gather() ([]*dto.MetricFamily, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.purgeStale(e.now())
+
+	families := make(map[string]*dto.MetricFamily)
+	keys := make([]string, 0, len(e.contexts))
+	for key := range e.contexts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c := e.contexts[key]
+		family, ok := families[c.name]
+		if !ok {
+			family = &dto.MetricFamily{Name: proto.String(c.name), Type: c.typ.Enum()}
+			families[c.name] = family
+		} else if family.GetType() != c.typ {
+			e.log.Debugf("Not exposing a %s context of %s which is already exposed as a %s", c.typ, c.name, family.GetType())
+			continue
+		}
+		family.Metric = append(family.Metric, c.metric(e.quantiles))
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		result = append(result, family)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].GetName() < result[j].GetName() })
+	return result, nil
+}
+
+func (c *openMetricsContext) metric(quantiles []float64) *dto.Metric {
+	m := &dto.Metric{Label: c.labels}
+	switch c.typ {
+	case dto.MetricType_COUNTER:
+		m.Counter = &dto.Counter{Value: proto.Float64(c.value)}
+	case dto.MetricType_SUMMARY:
+		m.Summary = &dto.Summary{
+			SampleCount: proto.Uint64(c.count),
+			SampleSum:   proto.Float64(c.sum),
+		}
+		for i, v := range c.quantiles {
+			m.Summary.Quantile = append(m.Summary.Quantile, &dto.Quantile{
+				Quantile: proto.Float64(quantiles[i]),
+				Value:    proto.Float64(v),
+			})
+		}
+	default:
+		m.Gauge = &dto.Gauge{Value: proto.Float64(c.value)}
+	}
+	return m
+}
+
+// openMetricsLabels maps the host, device and tags of a context to sorted labels. Tags
+// without a value are mapped to a label with the "true" value, and the values of tags
+// sharing the same key are joined with commas.
+func openMetricsLabels(host, device string, tags tagset.CompositeTags) []*dto.LabelPair {
+	values := make(map[string][]string)
+	add := func(key, value string) {
+		key = sanitizeOpenMetricsLabelName(key)
+		for _, v := range values[key] {
+			if v == value {
+				return
+			}
+		}
+		values[key] = append(values[key], value)
+	}
+	if host != "" {
+		add("host", host)
+	}
+	if device != "" {
+		add("device", device)
+	}
+	tags.ForEach(func(tag string) {
+		key, value, found := strings.Cut(tag, ":")
+		if !found {
+			value = "true"
+		}
+		if key == "" || value == "" {
+			return
+		}
+		add(key, value)
+	})
+
+	labels := make([]*dto.LabelPair, 0, len(values))
+	for key, vs := range values {
+		sort.Strings(vs)
+		labels = append(labels, &dto.LabelPair{Name: proto.String(key), Value: proto.String(strings.Join(vs, ","))})
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].GetName() < labels[j].GetName() })
+	return labels
+}
+
+func contextKey(name string, labels []*dto.LabelPair) string {
+	var b strings.Builder
+	b.WriteString(name)
+	for _, l := range labels {
+		b.WriteByte(0xff)
+		b.WriteString(l.GetName())
+		b.WriteByte('=')
+		b.WriteString(l.GetValue())
+	}
+	return b.String()
+}
+
+// sanitizeOpenMetricsName turns a Datadog metric name into a valid OpenMetrics metric name
+// by replacing the unsupported characters, dots included, with underscores.
+func sanitizeOpenMetricsName(name string) string {
+	return sanitizeOpenMetrics(name, true)
+}
+
+// sanitizeOpenMetricsLabelName turns a tag key into a valid OpenMetrics label name. Names
+// starting with two underscores are reserved and get their leading underscores collapsed.
+func sanitizeOpenMetricsLabelName(name string) string {
+	name = sanitizeOpenMetrics(name, false)
+	if strings.HasPrefix(name, "__") {
+		name = "_" + strings.TrimLeft(name, "_")
+	}
+	return name
+}
+
+func sanitizeOpenMetrics(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(i > 0 && c >= '0' && c <= '9') || (allowColon && c == ':')
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return "_" + name[:1] + string(b[1:])
+	}
+	return string(b)
+}
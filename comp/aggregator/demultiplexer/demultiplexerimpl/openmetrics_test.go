@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package demultiplexerimpl
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func newTestOpenMetricsExporter(t *testing.T) (*openMetricsExporter, *time.Time) {
+	return newTestOpenMetricsExporterWithStaleness(t, 60)
+}
+
+func newTestOpenMetricsExporterWithStaleness(t *testing.T, staleness int) (*openMetricsExporter, *time.Time) {
+	cfg := config.NewMock(t)
+	cfg.SetWithoutSource("aggregator_openmetrics_exporter.staleness_timeout", staleness)
+	cfg.SetWithoutSource("aggregator_openmetrics_exporter.sketch_quantiles", []float64{0.5, 2, 0.99})
+	e := newOpenMetricsExporter(cfg, logmock.New(t))
+	now := time.Unix(1700000000, 0)
+	e.now = func() time.Time { return now }
+	return e, &now
+}
+
+func scrapeOpenMetrics(t *testing.T, e *openMetricsExporter) string {
+	rec := httptest.NewRecorder()
+	e.handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestOpenMetricsExporterSeries(t *testing.T) {
+	e, _ := newTestOpenMetricsExporter(t)
+
+	e.ObserveSerie(&metrics.Serie{
+		Name:   "system.load.1",
+		Points: []metrics.Point{{Ts: 1, Value: 0.5}},
+		Tags:   tagset.CompositeTagsFromSlice([]string{"env:prod", "role:web", "role:db", "standalone", "1bad-key:value"}),
+		Host:   "myhost",
+		MType:  metrics.APIGaugeType,
+	})
+	for i := 0; i < 2; i++ {
+		e.ObserveSerie(&metrics.Serie{
+			Name:   "dogstatsd.requests",
+			Points: []metrics.Point{{Ts: 1, Value: 3}},
+			Host:   "myhost",
+			MType:  metrics.APICountType,
+		})
+	}
+
+	out := scrapeOpenMetrics(t, e)
+	assert.Contains(t, out, "# TYPE system_load_1 gauge\n")
+	assert.Contains(t, out, `system_load_1{_1bad_key="value",env="prod",host="myhost",role="db,web",standalone="true"} 0.5`)
+	assert.Contains(t, out, "# TYPE dogstatsd_requests counter\n")
+	assert.Contains(t, out, `dogstatsd_requests{host="myhost"} 6`)
+}
+
+func TestOpenMetricsExporterSketches(t *testing.T) {
+	e, _ := newTestOpenMetricsExporter(t)
+	assert.Equal(t, []float64{0.5, 0.99}, e.quantiles, "invalid quantiles are ignored")
+
+	sketch := &quantile.Sketch{}
+	for i := 1; i <= 100; i++ {
+		sketch.Insert(quantile.Default(), float64(i))
+	}
+	e.ObserveSketch(&metrics.SketchSeries{
+		Name:   "request.latency",
+		Tags:   tagset.CompositeTagsFromSlice([]string{"service:api"}),
+		Points: []metrics.SketchPoint{{Ts: 1, Sketch: sketch}},
+	})
+
+	out := scrapeOpenMetrics(t, e)
+	assert.Contains(t, out, "# TYPE request_latency summary\n")
+	assert.Contains(t, out, `request_latency{service="api",quantile="0.5"}`)
+	assert.Contains(t, out, `request_latency{service="api",quantile="0.99"}`)
+	assert.Contains(t, out, `request_latency_sum{service="api"} 5050`)
+	assert.Contains(t, out, `request_latency_count{service="api"} 100`)
+}
+
+func TestOpenMetricsExporterStaleness(t *testing.T) {
+	e, now := newTestOpenMetricsExporter(t)
+
+	observe := func(name string) {
+		e.ObserveSerie(&metrics.Serie{
+			Name:   name,
+			Points: []metrics.Point{{Ts: 1, Value: 1}},
+			MType:  metrics.APIGaugeType,
+		})
+	}
+	observe("stops.reporting")
+	observe("keeps.reporting")
+	assert.Len(t, e.contexts, 2)
+
+	*now = now.Add(45 * time.Second)
+	observe("keeps.reporting")
+	*now = now.Add(45 * time.Second)
+
+	out := scrapeOpenMetrics(t, e)
+	assert.Contains(t, out, "keeps_reporting 1")
+	assert.NotContains(t, out, "stops_reporting")
+	assert.Len(t, e.contexts, 1)
+}
+
+func TestOpenMetricsExporterStalenessDisabled(t *testing.T) {
+	for _, staleness := range []int{0, -1} {
+		e, now := newTestOpenMetricsExporterWithStaleness(t, staleness)
+
+		observe := func(name string) {
+			e.ObserveSerie(&metrics.Serie{
+				Name:   name,
+				Points: []metrics.Point{{Ts: 1, Value: 1}},
+				MType:  metrics.APIGaugeType,
+			})
+		}
+		observe("first")
+		observe("second")
+		assert.Len(t, e.contexts, 2)
+
+		*now = now.Add(24 * time.Hour)
+		observe("third")
+
+		out := scrapeOpenMetrics(t, e)
+		assert.Contains(t, out, "first 1")
+		assert.Contains(t, out, "second 1")
+		assert.Contains(t, out, "third 1")
+		assert.Len(t, e.contexts, 3)
+	}
+}
+
+func TestSanitizeOpenMetrics(t *testing.T) {
+	assert.Equal(t, "system_cpu_user", sanitizeOpenMetricsName("system.cpu.user"))
+	assert.Equal(t, "ns:metric_name", sanitizeOpenMetricsName("ns:metric-name"))
+	assert.Equal(t, "_2xx_responses", sanitizeOpenMetricsName("2xx.responses"))
+	assert.Equal(t, "kube_namespace", sanitizeOpenMetricsLabelName("kube.namespace"))
+	assert.Equal(t, "a_b", sanitizeOpenMetricsLabelName("a:b"))
+	assert.Equal(t, "_reserved", sanitizeOpenMetricsLabelName("__reserved"))
+}
@@ -3,7 +3,7 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2023-present Datadog, Inc.
 
-// Package demultiplexerendpointimpl component provides the /dogstatsd-contexts-dump API endpoint that can register via Fx value groups.
+// Package demultiplexerendpointimpl component provides the /dogstatsd-contexts-dump and /metrics-top API endpoints that can register via Fx value groups.
 package demultiplexerendpointimpl
 
 import (
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"strconv"
 
 	"github.com/DataDog/zstd"
 
@@ -37,7 +38,8 @@ type demultiplexerEndpoint struct {
 
 // Provides defines the output of the demultiplexerendpoint component
 type Provides struct {
-	Endpoint api.AgentEndpointProvider
+	Endpoint           api.AgentEndpointProvider
+	MetricsTopEndpoint api.AgentEndpointProvider
 }
 
 // NewComponent creates a new demultiplexerendpoint component
@@ -49,7 +51,8 @@ func NewComponent(reqs Requires) Provides {
 	}
 
 	return Provides{
-		Endpoint: api.NewAgentEndpointProvider(endpoint.dumpDogstatsdContexts, "/dogstatsd-contexts-dump", "POST"),
+		Endpoint:           api.NewAgentEndpointProvider(endpoint.dumpDogstatsdContexts, "/dogstatsd-contexts-dump", "POST"),
+		MetricsTopEndpoint: api.NewAgentEndpointProvider(endpoint.metricsTop, "/metrics-top", "GET"),
 	}
 }
 
@@ -90,3 +93,31 @@ func (demuxendpoint demultiplexerEndpoint) writeDogstatsdContexts() (string, err
 
 	return path, nil
 }
+
+// metricsTop reports the contexts tracked by the aggregator, grouped by metric, tag key, origin
+// and check. The "sort" and "limit" query parameters control the order and the number of groups.
+func (demuxendpoint demultiplexerEndpoint) metricsTop(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if l := r.URL.Query().Get("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil {
+			httputils.SetJSONError(w, demuxendpoint.log.Errorf("Invalid limit %q: %v", l, err), 400)
+			return
+		}
+	}
+
+	top := demuxendpoint.demux.ContextsTop()
+	if err := top.Sort(r.URL.Query().Get("sort"), limit); err != nil {
+		httputils.SetJSONError(w, demuxendpoint.log.Errorf("Invalid sort order: %v", err), 400)
+		return
+	}
+
+	resp, err := json.Marshal(top)
+	if err != nil {
+		httputils.SetJSONError(w, demuxendpoint.log.Errorf("Failed to serialize response: %v", err), 500)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/runner"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
 	"github.com/DataDog/datadog-agent/pkg/collector/scheduler"
+	"github.com/DataDog/datadog-agent/pkg/collector/worker"
 	"github.com/DataDog/datadog-agent/pkg/sbom/collectors/host"
 	"github.com/DataDog/datadog-agent/pkg/sbom/scanner"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
@@ -242,7 +243,11 @@ func (c *collectorImpl) RunCheck(inner check.Check) (checkid.ID, error) {
 		return emptyID, fmt.Errorf("a check with ID %s is already running", ch.ID())
 	}
 
+	// resolve the run timeout of the check before its first run
+	worker.RegisterCheck(ch)
+
 	if err := c.scheduler.Enter(ch); err != nil {
+		worker.UnregisterCheck(ch.ID())
 		return emptyID, fmt.Errorf("unable to schedule the check: %s", err)
 	}
 
@@ -285,6 +290,9 @@ func (c *collectorImpl) StopCheck(id checkid.ID) error {
 	// remove the check from the stats map
 	defer expvars.RemoveCheckStats(id)
 
+	// forget the run timeout and the quarantine of the check
+	defer worker.UnregisterCheck(id)
+
 	stats, found := expvars.CheckStats(id)
 	if found {
 		stats.SetStateCancelling()
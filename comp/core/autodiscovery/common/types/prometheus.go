@@ -121,6 +121,9 @@ type OpenmetricsInstance struct {
 	PersistConnections               *bool                        `mapstructure:"persist_connections" yaml:"persist_connections,omitempty" json:"persist_connections,omitempty"`
 	AllowRedirects                   bool                         `mapstructure:"allow_redirects" yaml:"allow_redirects,omitempty" json:"allow_redirects,omitempty"`
 	AuthToken                        map[string]interface{}       `mapstructure:"auth_token" yaml:"auth_token,omitempty" json:"auth_token,omitempty"`
+
+	// Loader selects the implementation of the check, `core` for the Go check
+	Loader string `mapstructure:"loader" yaml:"loader,omitempty" json:"loader,omitempty"`
 }
 
 // LabelJoinsConfig contains the label join configuration fields
@@ -19,12 +19,15 @@ import (
 const (
 	openmetricsCheckName  = "openmetrics"
 	openmetricsInitConfig = "{}"
+	// openmetricsCoreLoader is the loader of the Go implementation of the openmetrics check
+	openmetricsCoreLoader = "core"
 )
 
 // buildInstances generates check config instances based on the Prometheus config and the object annotations
 // The second returned value is true if more than one instance is found
 func buildInstances(pc *types.PrometheusCheck, annotations map[string]string, namespacedName string) ([]integration.Data, bool) {
 	openmetricsVersion := pkgconfigsetup.Datadog().GetInt("prometheus_scrape.version")
+	useCoreCheck := pkgconfigsetup.Datadog().GetBool("prometheus_scrape.use_core_check")
 
 	instances := []integration.Data{}
 	for k, v := range pc.AD.KubeAnnotations.Incl {
@@ -48,6 +51,9 @@ func buildInstances(pc *types.PrometheusCheck, annotations map[string]string, na
 						}
 					}
 				}
+				if useCoreCheck && instanceValues.Loader == "" {
+					instanceValues.Loader = openmetricsCoreLoader
+				}
 				// The `PrometheusCheck` config may come from two sources:
 				// Either it comes from the `DD_PROMETHEUS_SCRAPE_CHECKS` environment variable.
 				//   In this case, it has been parsed by JSON decoder
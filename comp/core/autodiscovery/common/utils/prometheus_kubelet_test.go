@@ -521,3 +521,35 @@ func TestConfigsForPod(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigsForPodCoreCheck(t *testing.T) {
+	pkgconfigsetup.Datadog().SetWithoutSource("prometheus_scrape.version", 2)
+	pkgconfigsetup.Datadog().SetWithoutSource("prometheus_scrape.use_core_check", true)
+	defer pkgconfigsetup.Datadog().SetWithoutSource("prometheus_scrape.use_core_check", false)
+
+	check := &types.PrometheusCheck{
+		Instances: []*types.OpenmetricsInstance{{Metrics: []interface{}{".*"}}},
+	}
+	check.Init(2)
+	pod := &kubelet.Pod{
+		Metadata: kubelet.PodMetadata{
+			Name:        "foo-pod",
+			Annotations: map[string]string{"prometheus.io/scrape": "true"},
+		},
+		Status: kubelet.Status{
+			Containers:    []kubelet.ContainerStatus{{Name: "foo-ctr", ID: "foo-ctr-id"}},
+			AllContainers: []kubelet.ContainerStatus{{Name: "foo-ctr", ID: "foo-ctr-id"}},
+		},
+	}
+
+	assert.ElementsMatch(t, []integration.Config{
+		{
+			Name:          "openmetrics",
+			InitConfig:    integration.Data("{}"),
+			Instances:     []integration.Data{integration.Data(`{"namespace":"","metrics":[".*"],"openmetrics_endpoint":"http://%%host%%:%%port%%/metrics","loader":"core"}`)},
+			Provider:      names.PrometheusPods,
+			Source:        "prometheus_pods:foo-ctr-id",
+			ADIdentifiers: []string{"foo-ctr-id"},
+		},
+	}, ConfigsForPod(check, pod))
+}
@@ -39,6 +39,7 @@ var templateVariables = map[string]variableGetter{
 	"env":      getEnvvar,
 	"extra":    getAdditionalTplVariables,
 	"kube":     getAdditionalTplVariables,
+	"image":    getImage,
 }
 
 // NoServiceError represents an error that indicates that there's a problem with a service
@@ -614,6 +615,22 @@ func getAdditionalTplVariables(_ context.Context, tplVar string, svc listeners.S
 	return value, nil
 }
 
+// getImage returns an attribute of the image of the container of the service, like its tag for `%%image_tag%%`
+func getImage(_ context.Context, tplVar string, svc listeners.Service) (string, error) {
+	if svc == nil {
+		return "", NewNoServiceError("No service. %%%%image_*%%%% is not allowed")
+	}
+	if tplVar == "" {
+		return "", fmt.Errorf("image attribute is missing, skipping service %s", svc.GetServiceID())
+	}
+
+	value, err := svc.GetExtraConfig(listeners.ImageExtraConfigPrefix + tplVar)
+	if err != nil {
+		return "", fmt.Errorf("failed to get image info for service %s, skipping config - %s", svc.GetServiceID(), err)
+	}
+	return value, nil
+}
+
 // getEnvvar returns a system environment variable if found
 func getEnvvar(_ context.Context, envVar string, svc listeners.Service) (string, error) {
 	if len(envVar) == 0 {
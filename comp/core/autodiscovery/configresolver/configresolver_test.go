@@ -631,6 +631,25 @@ func TestResolve(t *testing.T) {
 				ServiceID:     "a5901276aed1",
 			},
 		},
+		{
+			testName: "kube_* metadata and image_* config",
+			svc: &dummyService{
+				ID:            "a5901276aed1",
+				ADIdentifiers: []string{"redis"},
+				ExtraConfig:   map[string]string{"label_app": "cache", "deployment": "redis", "image_tag": "7.2-alpine"},
+			},
+			tpl: integration.Config{
+				Name:          "redis",
+				ADIdentifiers: []string{"redis"},
+				Instances:     []integration.Data{integration.Data("app: %%kube_label_app%%\ndeployment: %%kube_deployment%%\nversion: %%image_tag%%")},
+			},
+			out: integration.Config{
+				Name:          "redis",
+				ADIdentifiers: []string{"redis"},
+				Instances:     []integration.Data{integration.Data("app: cache\ndeployment: redis\ntags:\n- foo:bar\nversion: 7.2-alpine\n")},
+				ServiceID:     "a5901276aed1",
+			},
+		},
 		{
 			testName: "IPv6 %%host%%",
 			svc: &dummyService{
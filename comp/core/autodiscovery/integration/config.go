@@ -118,6 +118,7 @@ type CommonInstanceConfig struct {
 	Name                  string   `yaml:"name"`
 	Namespace             string   `yaml:"namespace"`
 	NoIndex               bool     `yaml:"no_index"`
+	RunTimeout            int      `yaml:"run_timeout"`
 }
 
 // CommonGlobalConfig holds the reserved fields for the yaml init_config data
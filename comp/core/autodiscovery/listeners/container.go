@@ -125,9 +125,11 @@ func (l *ContainerListener) createContainerService(entity workloadmeta.Entity) {
 		pid:      container.PID,
 		hostname: container.Hostname,
 		tagger:   l.tagger,
+		image:    &containerImg,
 	}
 
 	if pod != nil {
+		svc.pod = pod
 		svc.hosts = map[string]string{"pod": pod.IP}
 		svc.ready = pod.Ready
 
@@ -177,6 +177,7 @@ func TestCreateContainerService(t *testing.T) {
 					service: &service{
 						tagger: taggerComponent,
 						entity: basicContainer,
+						image:  &basicContainer.Image,
 						adIdentifiers: []string{
 							"docker://foobarquux",
 							"gcr.io/foobar",
@@ -214,6 +215,7 @@ func TestCreateContainerService(t *testing.T) {
 					service: &service{
 						tagger: taggerComponent,
 						entity: runningContainerWithFinishedAtTime,
+						image:  &runningContainerWithFinishedAtTime.Image,
 						adIdentifiers: []string{
 							"docker://foobarquux",
 							"gcr.io/foobar",
@@ -234,6 +236,7 @@ func TestCreateContainerService(t *testing.T) {
 					service: &service{
 						tagger: taggerComponent,
 						entity: multiplePortsContainer,
+						image:  &multiplePortsContainer.Image,
 						adIdentifiers: []string{
 							"docker://foobarquux",
 							"foobar",
@@ -263,6 +266,8 @@ func TestCreateContainerService(t *testing.T) {
 					service: &service{
 						tagger: taggerComponent,
 						entity: kubernetesContainer,
+						image:  &kubernetesContainer.Image,
+						pod:    pod,
 						adIdentifiers: []string{
 							"docker://foo",
 							"gcr.io/foobar",
@@ -102,6 +102,7 @@ func (l *KubeletListener) createPodService(
 		ports:         ports,
 		ready:         true,
 		tagger:        l.tagger,
+		pod:           pod,
 	}
 
 	svcID := buildSvcID(pod.GetID())
@@ -186,6 +187,8 @@ func (l *KubeletListener) createContainerService(
 			pod.Namespace,
 		),
 		tagger: l.tagger,
+		pod:    pod,
+		image:  &containerImg,
 	}
 
 	adIdentifier := containerName
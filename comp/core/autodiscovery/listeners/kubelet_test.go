@@ -87,6 +87,7 @@ func TestKubeletCreatePodService(t *testing.T) {
 							"pod": "127.0.0.1",
 						},
 						ready:  true,
+						pod:    pod,
 						tagger: taggerComponent,
 					},
 				},
@@ -262,6 +263,8 @@ func TestKubeletCreateContainerService(t *testing.T) {
 							"pod_name":  podName,
 							"pod_uid":   podID,
 						},
+						pod:    pod,
+						image:  &imageWithShortname,
 						tagger: taggerComponent,
 					},
 				},
@@ -295,6 +298,8 @@ func TestKubeletCreateContainerService(t *testing.T) {
 							"pod_name":  podName,
 							"pod_uid":   podID,
 						},
+						pod:    pod,
+						image:  &basicImage,
 						tagger: taggerComponent,
 					},
 				},
@@ -350,6 +355,8 @@ func TestKubeletCreateContainerService(t *testing.T) {
 							"pod_name":  podName,
 							"pod_uid":   podID,
 						},
+						pod:    pod,
+						image:  &basicImage,
 						tagger: taggerComponent,
 					},
 				},
@@ -391,6 +398,8 @@ func TestKubeletCreateContainerService(t *testing.T) {
 							"pod_name":  podName,
 							"pod_uid":   podID,
 						},
+						pod:    pod,
+						image:  &basicImage,
 						tagger: taggerComponent,
 					},
 				},
@@ -425,6 +434,8 @@ func TestKubeletCreateContainerService(t *testing.T) {
 							"pod_name":  podName,
 							"pod_uid":   podID,
 						},
+						pod:    podWithAnnotations,
+						image:  &basicImage,
 						tagger: taggerComponent,
 					},
 				},
@@ -471,6 +482,8 @@ func TestKubeletCreateContainerService(t *testing.T) {
 							"pod_uid":   podID,
 						},
 						metricsExcluded: true,
+						pod:             podWithMetricsExcludeAnnotation,
+						image:           &basicImage,
 						tagger:          taggerComponent,
 					},
 				},
@@ -506,6 +519,8 @@ func TestKubeletCreateContainerService(t *testing.T) {
 							"pod_uid":   podID,
 						},
 						logsExcluded: true,
+						pod:          podWithLogsExcludeAnnotation,
+						image:        &basicImage,
 						tagger:       taggerComponent,
 					},
 				},
@@ -9,6 +9,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/providers/names"
@@ -18,6 +20,7 @@ import (
 	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -38,6 +41,16 @@ type service struct {
 	metricsExcluded bool
 	logsExcluded    bool
 	tagger          tagger.Component
+
+	// pod is the Kubernetes pod of the service, and image the image of its container, they resolve the `kube_*`
+	// and `image_*` template variables
+	pod   *workloadmeta.KubernetesPod
+	image *workloadmeta.ContainerImage
+
+	// metadataKeys are the keys of GetExtraConfig resolved from the pod and image metadata by the templates of the
+	// service, only the changes of their values update it
+	metadataKeysLock sync.Mutex
+	metadataKeys     map[string]struct{}
 }
 
 var _ Service = &service{}
@@ -57,7 +70,33 @@ func (s *service) Equal(o Service) bool {
 		reflect.DeepEqual(s.checkNames, s2.checkNames) &&
 		s.hostname == s2.hostname &&
 		s.pid == s2.pid &&
-		s.ready == s2.ready
+		s.ready == s2.ready &&
+		s.templateMetadataEqual(s2)
+}
+
+// templateMetadataEqual returns whether the pod and image metadata resolved by the templates of the two services are
+// equal, so that the changes of the other labels or annotations of a pod do not reschedule its configs
+func (s *service) templateMetadataEqual(o *service) bool {
+	for _, key := range append(s.resolvedMetadataKeys(), o.resolvedMetadataKeys()...) {
+		value, err := s.getMetadata(key)
+		otherValue, otherErr := o.getMetadata(key)
+		if value != otherValue || (err == nil) != (otherErr == nil) {
+			return false
+		}
+	}
+	return true
+}
+
+// resolvedMetadataKeys returns the keys of GetExtraConfig resolved from the pod and image metadata
+func (s *service) resolvedMetadataKeys() []string {
+	s.metadataKeysLock.Lock()
+	defer s.metadataKeysLock.Unlock()
+
+	keys := make([]string, 0, len(s.metadataKeys))
+	for key := range s.metadataKeys {
+		keys = append(keys, key)
+	}
+	return keys
 }
 
 // GetServiceID returns the AD entity ID of the service.
@@ -208,10 +247,102 @@ func (s *service) filterTemplatesContainerCollectAll(configs map[string]integrat
 
 // GetExtraConfig returns extra configuration associated with the service.
 func (s *service) GetExtraConfig(key string) (string, error) {
-	result, found := s.extraConfig[key]
-	if !found {
-		return "", fmt.Errorf("extra config %q is not supported", key)
+	if result, found := s.extraConfig[key]; found {
+		return result, nil
+	}
+
+	s.metadataKeysLock.Lock()
+	if s.metadataKeys == nil {
+		s.metadataKeys = make(map[string]struct{})
+	}
+	s.metadataKeys[key] = struct{}{}
+	s.metadataKeysLock.Unlock()
+
+	return s.getMetadata(key)
+}
+
+// getMetadata returns an attribute of the image of the container of the service if the key has the image prefix,
+// or else a metadata of its pod
+func (s *service) getMetadata(key string) (string, error) {
+	if attribute, found := strings.CutPrefix(key, ImageExtraConfigPrefix); found {
+		return s.getImageMetadata(attribute)
+	}
+	return s.getPodMetadata(key)
+}
+
+// getPodMetadata returns the metadata of the pod of the service: its namespace, name, UID, owner, deployment, or
+// one of its labels or annotations
+func (s *service) getPodMetadata(key string) (string, error) {
+	if s.pod == nil {
+		return "", fmt.Errorf("extra config %q is not supported: the service is not a Kubernetes pod or container", key)
 	}
+	podName := s.pod.Namespace + "/" + s.pod.Name
 
-	return result, nil
+	switch key {
+	case "namespace":
+		return s.pod.Namespace, nil
+	case "pod_name":
+		return s.pod.Name, nil
+	case "pod_uid":
+		return s.pod.ID, nil
+	case "owner_kind", "owner_name":
+		if len(s.pod.Owners) == 0 {
+			return "", fmt.Errorf("pod %s has no owner", podName)
+		}
+		if key == "owner_kind" {
+			return s.pod.Owners[0].Kind, nil
+		}
+		return s.pod.Owners[0].Name, nil
+	case "deployment":
+		for _, owner := range s.pod.Owners {
+			if owner.Kind != kubernetes.ReplicaSetKind {
+				continue
+			}
+			if deployment := kubernetes.ParseDeploymentForReplicaSet(owner.Name); deployment != "" {
+				return deployment, nil
+			}
+		}
+		return "", fmt.Errorf("pod %s is not owned by a deployment", podName)
+	}
+
+	if name, found := strings.CutPrefix(key, "label_"); found {
+		if value, found := s.pod.Labels[name]; found {
+			return value, nil
+		}
+		return "", fmt.Errorf("pod %s has no label %q", podName, name)
+	}
+	if name, found := strings.CutPrefix(key, "annotation_"); found {
+		if value, found := s.pod.Annotations[name]; found {
+			return value, nil
+		}
+		return "", fmt.Errorf("pod %s has no annotation %q", podName, name)
+	}
+	return "", fmt.Errorf("extra config %q is not supported", key)
+}
+
+// getImageMetadata returns an attribute of the image of the container of the service
+func (s *service) getImageMetadata(attribute string) (string, error) {
+	if s.image == nil {
+		return "", fmt.Errorf("image %q is not supported: the service is not a container", attribute)
+	}
+
+	var value string
+	switch attribute {
+	case "name":
+		value = s.image.Name
+	case "short_name":
+		value = s.image.ShortName
+	case "tag":
+		value = s.image.Tag
+	case "registry":
+		value = s.image.Registry
+	case "digest":
+		value = s.image.RepoDigest
+	default:
+		return "", fmt.Errorf("image %q is not supported, expected name, short_name, tag, registry or digest", attribute)
+	}
+	if value == "" {
+		return "", fmt.Errorf("image %s has no %s", s.image.RawName, attribute)
+	}
+	return value, nil
 }
@@ -150,3 +150,112 @@ func TestServiceFilterTemplatesCCA(t *testing.T) {
 			filterDrops(&service{}, noLogsTpl, logsTpl, ccaTpl))
 	})
 }
+
+func TestServiceGetExtraConfig(t *testing.T) {
+	pod := &workloadmeta.KubernetesPod{
+		EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindKubernetesPod, ID: "pod-uid"},
+		EntityMeta: workloadmeta.EntityMeta{
+			Name:        "web-7d9c8b6f5-x2x4z",
+			Namespace:   "prod",
+			Labels:      map[string]string{"app": "web"},
+			Annotations: map[string]string{"team": "frontend"},
+		},
+		Owners: []workloadmeta.KubernetesPodOwner{{Kind: "ReplicaSet", Name: "web-7d9c8b6f5"}},
+	}
+	image := &workloadmeta.ContainerImage{
+		RawName:   "gcr.io/acme/web:1.2.3",
+		Name:      "gcr.io/acme/web",
+		ShortName: "web",
+		Registry:  "gcr.io",
+		Tag:       "1.2.3",
+	}
+	svc := &service{
+		pod:         pod,
+		image:       image,
+		extraConfig: map[string]string{"namespace": "from-extra-config"},
+	}
+
+	tests := []struct {
+		key           string
+		expectedValue string
+		expectedError string
+	}{
+		{key: "namespace", expectedValue: "from-extra-config"},
+		{key: "pod_name", expectedValue: "web-7d9c8b6f5-x2x4z"},
+		{key: "pod_uid", expectedValue: "pod-uid"},
+		{key: "owner_kind", expectedValue: "ReplicaSet"},
+		{key: "owner_name", expectedValue: "web-7d9c8b6f5"},
+		{key: "deployment", expectedValue: "web"},
+		{key: "label_app", expectedValue: "web"},
+		{key: "label_version", expectedError: `pod prod/web-7d9c8b6f5-x2x4z has no label "version"`},
+		{key: "annotation_team", expectedValue: "frontend"},
+		{key: "annotation_owner", expectedError: `pod prod/web-7d9c8b6f5-x2x4z has no annotation "owner"`},
+		{key: "unknown", expectedError: `extra config "unknown" is not supported`},
+		{key: "image_name", expectedValue: "gcr.io/acme/web"},
+		{key: "image_short_name", expectedValue: "web"},
+		{key: "image_tag", expectedValue: "1.2.3"},
+		{key: "image_registry", expectedValue: "gcr.io"},
+		{key: "image_digest", expectedError: "image gcr.io/acme/web:1.2.3 has no digest"},
+		{key: "image_size", expectedError: `image "size" is not supported, expected name, short_name, tag, registry or digest`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			value, err := svc.GetExtraConfig(tt.key)
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedValue, value)
+		})
+	}
+
+	t.Run("pod without deployment", func(t *testing.T) {
+		svc := &service{pod: &workloadmeta.KubernetesPod{EntityMeta: workloadmeta.EntityMeta{Name: "job-abcde", Namespace: "prod"}}}
+
+		_, err := svc.GetExtraConfig("deployment")
+		assert.EqualError(t, err, "pod prod/job-abcde is not owned by a deployment")
+		_, err = svc.GetExtraConfig("owner_name")
+		assert.EqualError(t, err, "pod prod/job-abcde has no owner")
+	})
+
+	t.Run("service without pod nor image", func(t *testing.T) {
+		svc := &service{}
+
+		_, err := svc.GetExtraConfig("label_app")
+		assert.EqualError(t, err, `extra config "label_app" is not supported: the service is not a Kubernetes pod or container`)
+		_, err = svc.GetExtraConfig("image_tag")
+		assert.EqualError(t, err, `image "tag" is not supported: the service is not a container`)
+	})
+}
+
+func TestServiceEqualTemplateMetadata(t *testing.T) {
+	entity := &workloadmeta.Container{EntityID: workloadmeta.EntityID{Kind: workloadmeta.KindContainer, ID: "testy"}}
+	newService := func(labels, annotations map[string]string, tag string) *service {
+		return &service{
+			entity: entity,
+			pod: &workloadmeta.KubernetesPod{
+				EntityMeta: workloadmeta.EntityMeta{Name: "web", Namespace: "prod", Labels: labels, Annotations: annotations},
+			},
+			image: &workloadmeta.ContainerImage{RawName: "web:" + tag, Tag: tag},
+		}
+	}
+
+	old := newService(map[string]string{"app": "web"}, map[string]string{"last-applied": "1"}, "1.0")
+	assert.True(t, newService(map[string]string{"app": "cache"}, map[string]string{"last-applied": "2"}, "1.1").Equal(old),
+		"metadata that no template resolved must not update the service")
+
+	_, err := old.GetExtraConfig("label_app")
+	assert.NoError(t, err)
+	_, err = old.GetExtraConfig("image_tag")
+	assert.NoError(t, err)
+	_, err = old.GetExtraConfig("annotation_team")
+	assert.Error(t, err)
+
+	assert.True(t, newService(map[string]string{"app": "web"}, map[string]string{"last-applied": "2"}, "1.0").Equal(old))
+	assert.False(t, newService(map[string]string{"app": "cache"}, nil, "1.0").Equal(old))
+	assert.False(t, newService(map[string]string{"app": "web"}, nil, "1.1").Equal(old))
+	assert.False(t, newService(map[string]string{"app": "web"}, map[string]string{"team": "frontend"}, "1.0").Equal(old),
+		"a metadata that failed to resolve must update the service when it is set")
+}
@@ -92,5 +92,9 @@ func Register(name string,
 	serviceListenerFactories[name] = factory
 }
 
+// ImageExtraConfigPrefix prefixes the keys of Service.GetExtraConfig that return an attribute of the image of a
+// container, like `image_tag`
+const ImageExtraConfigPrefix = "image_"
+
 // ErrNotSupported is thrown if listener doesn't support the asked variable
 var ErrNotSupported = errors.New("AD: variable not supported by listener")
@@ -38,6 +38,8 @@ const (
 	EventPlatformConnectivity = "connectivity-datadog-event-platform"
 	// PortConflict is the suite name for the port-conflict suite
 	PortConflict = "port-conflict"
+	// ContextCardinality is the suite name for the dogstatsd-context-cardinality suite
+	ContextCardinality = "dogstatsd-context-cardinality"
 )
 
 // AllSuites is a list of all available suites
@@ -47,6 +49,7 @@ var AllSuites = []string{
 	CoreEndpointsConnectivity,
 	EventPlatformConnectivity,
 	PortConflict,
+	ContextCardinality,
 }
 
 var catalog *Catalog
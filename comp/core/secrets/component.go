@@ -3,7 +3,7 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2016-present Datadog, Inc.
 
-// Package secrets decodes secret values by invoking the configured executable command
+// Package secrets decodes secret values by invoking the configured executable command or built-in backend
 package secrets
 
 import (
@@ -24,11 +24,15 @@ type ConfigParams struct {
 	RemoveLinebreak        bool
 	RunPath                string
 	AuditFileMaxSize       int
+	// Type selects a built-in secret backend used instead of Command, see secret_backend_type
+	Type string
+	// Config is the configuration of the built-in secret backend
+	Config map[string]interface{}
 }
 
 // Component is the component type.
 type Component interface {
-	// Configure the executable command that is used for decoding secrets
+	// Configure the executable command or the built-in backend that is used for decoding secrets
 	Configure(config ConfigParams)
 	// Get debug information and write it to the parameter
 	GetDebugInfo(w io.Writer)
@@ -0,0 +1,236 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// Types of the built-in secret backends, selected by secret_backend_type
+const (
+	backendTypeFile  = "file"
+	backendTypeEnv   = "env"
+	backendTypeJSON  = "json"
+	backendTypeYAML  = "yaml"
+	backendTypeVault = "vault"
+	backendTypeHTTP  = "http"
+)
+
+// secretBackend fetches secrets within the agent process instead of executing the secret_backend_command. Like the
+// output of the command, the result holds the value or the error of every handle, while the returned error means
+// that no secret could be fetched.
+type secretBackend interface {
+	fetchSecrets(ctx context.Context, handles []string) (map[string]secrets.SecretVal, error)
+}
+
+// newSecretBackend creates the built-in backend of the given type from its secret_backend_config
+func newSecretBackend(backendType string, config map[string]interface{}, maxSize int) (secretBackend, error) {
+	switch backendType {
+	case backendTypeFile:
+		backend := &fileBackend{maxSize: maxSize}
+		if err := decodeBackendConfig(config, &backend.config); err != nil {
+			return nil, err
+		}
+		if backend.config.SecretsPath == "" {
+			return nil, fmt.Errorf("secret_backend_config.secrets_path is required by the %s secret backend", backendType)
+		}
+		return backend, nil
+	case backendTypeEnv:
+		backend := &envBackend{}
+		if err := decodeBackendConfig(config, &backend.config); err != nil {
+			return nil, err
+		}
+		return backend, nil
+	case backendTypeJSON, backendTypeYAML:
+		backend := &structuredFileBackend{format: backendType, maxSize: maxSize}
+		if err := decodeBackendConfig(config, &backend.config); err != nil {
+			return nil, err
+		}
+		if backend.config.FilePath == "" {
+			return nil, fmt.Errorf("secret_backend_config.file_path is required by the %s secret backend", backendType)
+		}
+		if backend.config.KeySeparator == "" {
+			backend.config.KeySeparator = "."
+		}
+		return backend, nil
+	case backendTypeVault:
+		return newVaultBackend(config, maxSize)
+	case backendTypeHTTP:
+		return newHTTPBackend(config, maxSize)
+	}
+	return nil, fmt.Errorf("unknown secret_backend_type '%s', expected one of %s", backendType,
+		strings.Join([]string{backendTypeFile, backendTypeEnv, backendTypeJSON, backendTypeYAML, backendTypeVault, backendTypeHTTP}, ", "))
+}
+
+// decodeBackendConfig decodes secret_backend_config into the configuration of a backend, rejecting unknown settings
+func decodeBackendConfig(config map[string]interface{}, out interface{}) error {
+	raw, err := yaml.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("could not serialize secret_backend_config: %s", err)
+	}
+	if err := yaml.UnmarshalStrict(raw, out); err != nil {
+		return fmt.Errorf("invalid secret_backend_config: %s", err)
+	}
+	return nil
+}
+
+// readFileWithLimit reads a file, failing if it is larger than maxSize bytes
+func readFileWithLimit(path string, maxSize int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxSize {
+		return nil, fmt.Errorf("file '%s' is too large: exceeded %d bytes", path, maxSize)
+	}
+	return content, nil
+}
+
+type fileBackendConfig struct {
+	SecretsPath string `yaml:"secrets_path"`
+}
+
+// fileBackend reads every secret from the file named after its handle in a directory, like the secrets mounted by
+// Docker or Kubernetes
+type fileBackend struct {
+	config  fileBackendConfig
+	maxSize int
+}
+
+func (b *fileBackend) fetchSecrets(_ context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	res := make(map[string]secrets.SecretVal, len(handles))
+	for _, handle := range handles {
+		// handles are file names, they must not reach outside of the directory
+		if !filepath.IsLocal(handle) || strings.ContainsAny(handle, `/\`) {
+			res[handle] = secrets.SecretVal{ErrorMsg: "the handle is not a file name"}
+			continue
+		}
+		content, err := readFileWithLimit(filepath.Join(b.config.SecretsPath, handle), b.maxSize)
+		if err != nil {
+			res[handle] = secrets.SecretVal{ErrorMsg: err.Error()}
+			continue
+		}
+		res[handle] = secrets.SecretVal{Value: string(content)}
+	}
+	return res, nil
+}
+
+type envBackendConfig struct {
+	// Prefix is prepended to the handles to get the names of the environment variables
+	Prefix string `yaml:"prefix"`
+}
+
+// envBackend reads every secret from the environment variable named after its handle
+type envBackend struct {
+	config envBackendConfig
+}
+
+func (b *envBackend) fetchSecrets(_ context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	res := make(map[string]secrets.SecretVal, len(handles))
+	for _, handle := range handles {
+		name := b.config.Prefix + handle
+		if value, ok := os.LookupEnv(name); ok {
+			res[handle] = secrets.SecretVal{Value: value}
+		} else {
+			res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("environment variable '%s' is not set", name)}
+		}
+	}
+	return res, nil
+}
+
+type structuredFileBackendConfig struct {
+	FilePath string `yaml:"file_path"`
+	// KeySeparator separates the keys of the nested objects in the handles, it defaults to "."
+	KeySeparator string `yaml:"key_separator"`
+}
+
+// structuredFileBackend reads the secrets from a JSON or YAML file, the handles are the paths of the keys of the
+// secrets in the file, for instance `database.password`
+type structuredFileBackend struct {
+	config  structuredFileBackendConfig
+	format  string
+	maxSize int
+}
+
+func (b *structuredFileBackend) fetchSecrets(_ context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	// the file is read on every fetch so a refresh picks up the rotated secrets
+	content, err := readFileWithLimit(b.config.FilePath, b.maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var document interface{}
+	if b.format == backendTypeJSON {
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&document)
+	} else {
+		err = yaml.Unmarshal(content, &document)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse '%s': %s", b.config.FilePath, err)
+	}
+
+	res := make(map[string]secrets.SecretVal, len(handles))
+	for _, handle := range handles {
+		value, err := lookupKeyPath(document, handle, b.config.KeySeparator)
+		if err != nil {
+			res[handle] = secrets.SecretVal{ErrorMsg: err.Error()}
+			continue
+		}
+		res[handle] = secrets.SecretVal{Value: value}
+	}
+	return res, nil
+}
+
+// lookupKeyPath returns the scalar found at a path of keys in a decoded JSON or YAML document. The keys of lists are
+// the indexes of their elements.
+func lookupKeyPath(document interface{}, keyPath string, separator string) (string, error) {
+	keys := strings.Split(keyPath, separator)
+	current := document
+	for i, key := range keys {
+		var found bool
+		switch node := current.(type) {
+		case map[string]interface{}:
+			current, found = node[key]
+		case map[interface{}]interface{}:
+			current, found = node[key]
+		case []interface{}:
+			if index, err := strconv.Atoi(key); err == nil && index >= 0 && index < len(node) {
+				current, found = node[index], true
+			}
+		}
+		if !found {
+			return "", fmt.Errorf("key '%s' not found", strings.Join(keys[:i+1], separator))
+		}
+	}
+
+	switch value := current.(type) {
+	case string:
+		return value, nil
+	case json.Number, int, int64, uint64, float64, bool:
+		return fmt.Sprint(value), nil
+	}
+	return "", fmt.Errorf("the value of '%s' is not a string", keyPath)
+}
@@ -0,0 +1,156 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+// backendTLSConfig holds the TLS settings shared by the backends fetching secrets over HTTP
+type backendTLSConfig struct {
+	// CAFile is a PEM file of the certificate authorities trusted in addition to the system ones
+	CAFile        string `yaml:"tls_ca_file"`
+	TLSSkipVerify bool   `yaml:"tls_skip_verify"`
+}
+
+// newBackendHTTPClient returns a client honoring the proxy environment variables. Its requests are bounded by the
+// context of the fetch, which expires after secret_backend_timeout.
+func newBackendHTTPClient(tlsConfig backendTLSConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: tlsConfig.TLSSkipVerify, //nolint:gosec // explicitly enabled by the user
+	}
+	if tlsConfig.CAFile != "" {
+		pem, err := os.ReadFile(tlsConfig.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read secret_backend_config.tls_ca_file: %s", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in secret_backend_config.tls_ca_file '%s'", tlsConfig.CAFile)
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// httpStatusError is returned by doJSONRequest when the server answers with an error status
+type httpStatusError struct {
+	statusCode int
+	body       string
+}
+
+func (e *httpStatusError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("unexpected status code %d", e.statusCode)
+	}
+	return fmt.Sprintf("unexpected status code %d: %s", e.statusCode, e.body)
+}
+
+// doJSONRequest sends a request with an optional JSON body and decodes the JSON response, which is limited to
+// maxSize bytes
+func doJSONRequest(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body interface{}, maxSize int, response interface{}) error {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%s %s: request timeout", method, req.URL.Redacted())
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)+1))
+	if err != nil {
+		return err
+	}
+	if len(content) > maxSize {
+		return fmt.Errorf("response was too long: exceeded %d bytes", maxSize)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &httpStatusError{statusCode: resp.StatusCode, body: strings.TrimSpace(string(content))}
+	}
+	if err := json.Unmarshal(content, response); err != nil {
+		return fmt.Errorf("could not unmarshal the response: %s", err)
+	}
+	return nil
+}
+
+type httpBackendConfig struct {
+	URL string `yaml:"url"`
+	// Headers are added to the requests, to authenticate the agent for instance
+	Headers          map[string]string `yaml:"headers"`
+	backendTLSConfig `yaml:",inline"`
+}
+
+// httpBackend posts the payload of the secret_backend_command to a URL, which answers like the command would
+type httpBackend struct {
+	config  httpBackendConfig
+	client  *http.Client
+	maxSize int
+}
+
+func newHTTPBackend(config map[string]interface{}, maxSize int) (*httpBackend, error) {
+	backend := &httpBackend{maxSize: maxSize}
+	if err := decodeBackendConfig(config, &backend.config); err != nil {
+		return nil, err
+	}
+	if backend.config.URL == "" {
+		return nil, fmt.Errorf("secret_backend_config.url is required by the %s secret backend", backendTypeHTTP)
+	}
+	client, err := newBackendHTTPClient(backend.config.backendTLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	backend.client = client
+	return backend, nil
+}
+
+func (b *httpBackend) fetchSecrets(ctx context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	payload := map[string]interface{}{
+		"version": secrets.PayloadVersion,
+		"secrets": handles,
+	}
+	res := map[string]secrets.SecretVal{}
+	if err := doJSONRequest(ctx, b.client, http.MethodPost, b.config.URL, b.config.Headers, payload, b.maxSize, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,310 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	nooptelemetry "github.com/DataDog/datadog-agent/comp/core/telemetry/noopsimpl"
+	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
+)
+
+func TestNewSecretBackendErrors(t *testing.T) {
+	for _, tc := range []struct {
+		backendType string
+		config      map[string]interface{}
+		err         string
+	}{
+		{"keyring", nil, "unknown secret_backend_type 'keyring'"},
+		{backendTypeFile, nil, "secrets_path is required"},
+		{backendTypeFile, map[string]interface{}{"secret_path": "/run/secrets"}, "invalid secret_backend_config"},
+		{backendTypeJSON, nil, "file_path is required"},
+		{backendTypeHTTP, nil, "url is required"},
+		{backendTypeVault, map[string]interface{}{"address": "http://127.0.0.1:8200"}, "requires a token"},
+	} {
+		t.Run(tc.backendType, func(t *testing.T) {
+			t.Setenv("VAULT_ADDR", "")
+			t.Setenv("VAULT_TOKEN", "")
+			_, err := newSecretBackend(tc.backendType, tc.config, SecretBackendOutputMaxSizeDefault)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestFileBackend(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api_key"), []byte("0123456789abcdef\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "large"), []byte(strings.Repeat("a", 40)), 0600))
+
+	backend, err := newSecretBackend(backendTypeFile, map[string]interface{}{"secrets_path": dir}, 32)
+	require.NoError(t, err)
+
+	res, err := backend.fetchSecrets(context.Background(), []string{"api_key", "large", "missing", "../outside"})
+	require.NoError(t, err)
+	assert.Equal(t, secrets.SecretVal{Value: "0123456789abcdef\n"}, res["api_key"])
+	assert.Contains(t, res["large"].ErrorMsg, "too large")
+	assert.NotEmpty(t, res["missing"].ErrorMsg)
+	assert.Equal(t, "the handle is not a file name", res["../outside"].ErrorMsg)
+}
+
+func TestEnvBackend(t *testing.T) {
+	t.Setenv("DD_SECRET_DB_PASSWORD", "hunter2")
+
+	backend, err := newSecretBackend(backendTypeEnv, map[string]interface{}{"prefix": "DD_SECRET_"}, SecretBackendOutputMaxSizeDefault)
+	require.NoError(t, err)
+
+	res, err := backend.fetchSecrets(context.Background(), []string{"DB_PASSWORD", "DB_USER"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]secrets.SecretVal{
+		"DB_PASSWORD": {Value: "hunter2"},
+		"DB_USER":     {ErrorMsg: "environment variable 'DD_SECRET_DB_USER' is not set"},
+	}, res)
+}
+
+func TestStructuredFileBackend(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "secrets.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"db": {"password": "hunter2", "port": 5432, "hosts": ["a", "b"]}}`), 0600))
+	yamlPath := filepath.Join(dir, "secrets.yaml")
+	require.NoError(t, os.WriteFile(yamlPath, []byte("db:\n  password: hunter2\n  port: 5432\n  hosts: [a, b]\n"), 0600))
+
+	for backendType, config := range map[string]map[string]interface{}{
+		backendTypeJSON: {"file_path": jsonPath},
+		backendTypeYAML: {"file_path": yamlPath, "key_separator": "/"},
+	} {
+		t.Run(backendType, func(t *testing.T) {
+			backend, err := newSecretBackend(backendType, config, SecretBackendOutputMaxSizeDefault)
+			require.NoError(t, err)
+
+			sep := backend.(*structuredFileBackend).config.KeySeparator
+			handles := []string{"db" + sep + "password", "db" + sep + "port", "db" + sep + "hosts" + sep + "1", "db", "db" + sep + "user"}
+			res, err := backend.fetchSecrets(context.Background(), handles)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]secrets.SecretVal{
+				handles[0]: {Value: "hunter2"},
+				handles[1]: {Value: "5432"},
+				handles[2]: {Value: "b"},
+				handles[3]: {ErrorMsg: "the value of 'db' is not a string"},
+				handles[4]: {ErrorMsg: "key '" + handles[4] + "' not found"},
+			}, res)
+		})
+	}
+
+	backend, err := newSecretBackend(backendTypeJSON, map[string]interface{}{"file_path": yamlPath}, SecretBackendOutputMaxSizeDefault)
+	require.NoError(t, err)
+	_, err = backend.fetchSecrets(context.Background(), []string{"db.password"})
+	assert.ErrorContains(t, err, "could not parse")
+}
+
+func TestHTTPBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var payload struct {
+			Version string   `json:"version"`
+			Secrets []string `json:"secrets"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		assert.Equal(t, secrets.PayloadVersion, payload.Version)
+
+		res := map[string]secrets.SecretVal{}
+		for _, handle := range payload.Secrets {
+			if handle == "unknown" {
+				res[handle] = secrets.SecretVal{ErrorMsg: "not found"}
+			} else {
+				res[handle] = secrets.SecretVal{Value: "value of " + handle}
+			}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer server.Close()
+
+	backend, err := newSecretBackend(backendTypeHTTP, map[string]interface{}{
+		"url":     server.URL,
+		"headers": map[string]interface{}{"Authorization": "Bearer token"},
+	}, SecretBackendOutputMaxSizeDefault)
+	require.NoError(t, err)
+	res, err := backend.fetchSecrets(context.Background(), []string{"api_key", "unknown"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]secrets.SecretVal{
+		"api_key": {Value: "value of api_key"},
+		"unknown": {ErrorMsg: "not found"},
+	}, res)
+
+	backend, err = newSecretBackend(backendTypeHTTP, map[string]interface{}{"url": server.URL}, SecretBackendOutputMaxSizeDefault)
+	require.NoError(t, err)
+	_, err = backend.fetchSecrets(context.Background(), []string{"api_key"})
+	assert.ErrorContains(t, err, "unexpected status code 401")
+}
+
+// newVaultServer starts a stand-in for a Vault server with a KV version 2 secrets engine at `secret`, and an AppRole
+// authentication issuing tokens that can be revoked
+func newVaultServer(t *testing.T, data map[string]map[string]interface{}) (*httptest.Server, *atomic.Int32, func()) {
+	var logins, reads atomic.Int32
+	var validToken atomic.Value
+	validToken.Store("root")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/approle/login", func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		if payload["role_id"] != "agent" || payload["secret_id"] != "s3cr3t" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid role or secret ID"]}`))
+			return
+		}
+		token := fmt.Sprintf("token-%d", logins.Add(1))
+		validToken.Store(token)
+		json.NewEncoder(w).Encode(map[string]interface{}{"auth": map[string]interface{}{"client_token": token, "lease_duration": 3600}})
+	})
+	mux.HandleFunc("/v1/secret/data/", func(w http.ResponseWriter, r *http.Request) {
+		reads.Add(1)
+		if r.Header.Get("X-Vault-Token") != validToken.Load().(string) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		secret, ok := data[strings.TrimPrefix(r.URL.Path, "/v1/secret/data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"data": secret, "metadata": map[string]interface{}{"version": 1}}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &reads, func() { validToken.Store("revoked") }
+}
+
+func TestVaultBackendToken(t *testing.T) {
+	server, reads, _ := newVaultServer(t, map[string]map[string]interface{}{
+		"datadog/agent": {"api_key": "0123456789abcdef", "port": 8080},
+	})
+
+	backend, err := newSecretBackend(backendTypeVault, map[string]interface{}{"address": server.URL + "/", "token": "root"}, SecretBackendOutputMaxSizeDefault)
+	require.NoError(t, err)
+
+	res, err := backend.fetchSecrets(context.Background(), []string{"datadog/agent#api_key", "datadog/agent#app_key", "datadog/agent#port", "datadog/other#api_key", "datadog/agent"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]secrets.SecretVal{
+		"datadog/agent#api_key": {Value: "0123456789abcdef"},
+		"datadog/agent#app_key": {ErrorMsg: "key 'app_key' not found in secret 'datadog/agent'"},
+		"datadog/agent#port":    {ErrorMsg: "the value of key 'port' in secret 'datadog/agent' is not a string"},
+		"datadog/other#api_key": {ErrorMsg: "could not read secret 'datadog/other': unexpected status code 404: {\"errors\":[]}"},
+		"datadog/agent":         {ErrorMsg: "the handle is not in the <path>#<key> format"},
+	}, res)
+	assert.EqualValues(t, 2, reads.Load(), "every secret is read once")
+
+	backend, err = newSecretBackend(backendTypeVault, map[string]interface{}{"address": server.URL, "token": "other"}, SecretBackendOutputMaxSizeDefault)
+	require.NoError(t, err)
+	res, err = backend.fetchSecrets(context.Background(), []string{"datadog/agent#api_key"})
+	require.NoError(t, err)
+	assert.Contains(t, res["datadog/agent#api_key"].ErrorMsg, "unexpected status code 403")
+}
+
+func TestVaultBackendAppRole(t *testing.T) {
+	server, _, revoke := newVaultServer(t, map[string]map[string]interface{}{
+		"datadog/agent": {"api_key": "0123456789abcdef"},
+	})
+	secretIDFile := filepath.Join(t.TempDir(), "secret_id")
+	require.NoError(t, os.WriteFile(secretIDFile, []byte("s3cr3t\n"), 0600))
+
+	backend, err := newSecretBackend(backendTypeVault, map[string]interface{}{
+		"address": server.URL,
+		"approle": map[string]interface{}{"role_id": "agent", "secret_id_file": secretIDFile},
+	}, SecretBackendOutputMaxSizeDefault)
+	require.NoError(t, err)
+	vault := backend.(*vaultBackend)
+	now := time.Now()
+	vault.now = func() time.Time { return now }
+
+	fetch := func() map[string]secrets.SecretVal {
+		res, err := backend.fetchSecrets(context.Background(), []string{"datadog/agent#api_key"})
+		require.NoError(t, err)
+		assert.Equal(t, secrets.SecretVal{Value: "0123456789abcdef"}, res["datadog/agent#api_key"])
+		return res
+	}
+
+	fetch()
+	assert.Equal(t, "token-1", vault.appRoleToken)
+	fetch()
+	assert.Equal(t, "token-1", vault.appRoleToken, "the token is reused until it expires")
+
+	now = now.Add(time.Hour)
+	fetch()
+	assert.Equal(t, "token-2", vault.appRoleToken, "the agent logs in again when the token expires")
+
+	revoke()
+	fetch()
+	assert.Equal(t, "token-3", vault.appRoleToken, "the agent logs in again when the token is revoked")
+
+	require.NoError(t, os.WriteFile(secretIDFile, []byte("wrong"), 0600))
+	revoke()
+	_, err = backend.fetchSecrets(context.Background(), []string{"datadog/agent#api_key"})
+	assert.ErrorContains(t, err, "could not log in to Vault with the AppRole")
+}
+
+func TestResolveThenRefreshWithBackend(t *testing.T) {
+	// disable the allowlist for the test, let any secret changes happen
+	originalValue := isAllowlistEnabled()
+	setAllowlistEnabled(false)
+	defer func() {
+		setAllowlistEnabled(originalValue)
+	}()
+
+	secretsPath := filepath.Join(t.TempDir(), "secrets.yaml")
+	require.NoError(t, os.WriteFile(secretsPath, []byte("db:\n  password: password1\n"), 0600))
+
+	tel := fxutil.Test[telemetry.Component](t, nooptelemetry.Module())
+	resolver := newEnabledSecretResolver(tel)
+	resolver.Configure(secrets.ConfigParams{
+		Type:    backendTypeYAML,
+		Config:  map[string]interface{}{"file_path": secretsPath},
+		RunPath: t.TempDir(),
+	})
+
+	changes := []string{}
+	resolver.SubscribeToChanges(func(handle, _ string, path []string, oldValue, newValue any) {
+		changes = append(changes, fmt.Sprintf("%s %s: '%s' -> '%s'", handle, strings.Join(path, "/"), oldValue, newValue))
+	})
+
+	resolved, err := resolver.Resolve([]byte("instances:\n- password: ENC[db.password]\n"), "test")
+	require.NoError(t, err)
+	assert.Equal(t, "instances:\n- password: password1\n", string(resolved))
+
+	// rotate the secret
+	require.NoError(t, os.WriteFile(secretsPath, []byte("db:\n  password: password2\n"), 0600))
+	output, err := resolver.Refresh()
+	require.NoError(t, err)
+	assert.Contains(t, output, "'db.password'")
+	assert.Equal(t, []string{
+		"db.password instances/0/password: '' -> 'password1'",
+		"db.password instances/0/password: 'password1' -> 'password2'",
+	}, changes)
+
+	resolver.Configure(secrets.ConfigParams{Type: backendTypeYAML, RunPath: t.TempDir()})
+	_, err = resolver.Resolve([]byte("password: ENC[other]\n"), "test")
+	assert.ErrorContains(t, err, "invalid configuration of the yaml secret backend")
+}
@@ -0,0 +1,238 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package secretsimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/secrets"
+)
+
+const (
+	vaultDefaultMountPath        = "secret"
+	vaultDefaultAppRoleMountPath = "approle"
+	// vaultHandleKeySeparator separates the path of a secret from its key in the handles
+	vaultHandleKeySeparator = "#"
+)
+
+type vaultAppRoleConfig struct {
+	RoleID       string `yaml:"role_id"`
+	SecretID     string `yaml:"secret_id"`
+	SecretIDFile string `yaml:"secret_id_file"`
+	MountPath    string `yaml:"mount_path"`
+}
+
+type vaultBackendConfig struct {
+	// Address defaults to the VAULT_ADDR environment variable
+	Address   string `yaml:"address"`
+	Namespace string `yaml:"namespace"`
+	// MountPath is the path of the KV version 2 secrets engine
+	MountPath string `yaml:"mount_path"`
+	// Token defaults to the VAULT_TOKEN environment variable, TokenFile is read on every fetch to support tokens
+	// renewed by a Vault agent
+	Token            string             `yaml:"token"`
+	TokenFile        string             `yaml:"token_file"`
+	AppRole          vaultAppRoleConfig `yaml:"approle"`
+	backendTLSConfig `yaml:",inline"`
+}
+
+// vaultBackend reads the secrets from a KV version 2 secrets engine of HashiCorp Vault. The handles are the path of a
+// secret and one of its keys, for instance `datadog/agent#api_key`.
+type vaultBackend struct {
+	config  vaultBackendConfig
+	client  *http.Client
+	maxSize int
+
+	// token obtained with the AppRole authentication, the resolver lock serializes the fetches that renew it
+	appRoleToken       string
+	appRoleTokenExpiry time.Time
+	now                func() time.Time
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+type vaultLoginResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+	} `json:"auth"`
+}
+
+func newVaultBackend(config map[string]interface{}, maxSize int) (*vaultBackend, error) {
+	backend := &vaultBackend{maxSize: maxSize, now: time.Now}
+	if err := decodeBackendConfig(config, &backend.config); err != nil {
+		return nil, err
+	}
+	if backend.config.Address == "" {
+		backend.config.Address = os.Getenv("VAULT_ADDR")
+	}
+	if backend.config.Address == "" {
+		return nil, fmt.Errorf("secret_backend_config.address is required by the %s secret backend", backendTypeVault)
+	}
+	backend.config.Address = strings.TrimSuffix(backend.config.Address, "/")
+	if backend.config.MountPath == "" {
+		backend.config.MountPath = vaultDefaultMountPath
+	}
+	if backend.config.AppRole.MountPath == "" {
+		backend.config.AppRole.MountPath = vaultDefaultAppRoleMountPath
+	}
+	if backend.config.AppRole.RoleID == "" && backend.config.Token == "" && backend.config.TokenFile == "" {
+		backend.config.Token = os.Getenv("VAULT_TOKEN")
+		if backend.config.Token == "" {
+			return nil, fmt.Errorf("the %s secret backend requires a token, a token_file or an approle in secret_backend_config", backendTypeVault)
+		}
+	}
+
+	client, err := newBackendHTTPClient(backend.config.backendTLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	backend.client = client
+	return backend, nil
+}
+
+func (b *vaultBackend) fetchSecrets(ctx context.Context, handles []string) (map[string]secrets.SecretVal, error) {
+	// every secret is read once, whatever the number of its keys used as handles
+	keysBySecret := map[string][]string{}
+	res := make(map[string]secrets.SecretVal, len(handles))
+	for _, handle := range handles {
+		secretPath, key, found := strings.Cut(handle, vaultHandleKeySeparator)
+		if !found || secretPath == "" || key == "" {
+			res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("the handle is not in the <path>%s<key> format", vaultHandleKeySeparator)}
+			continue
+		}
+		keysBySecret[secretPath] = append(keysBySecret[secretPath], key)
+	}
+
+	for secretPath, keys := range keysBySecret {
+		data, err := b.readSecret(ctx, secretPath)
+		if err != nil {
+			var statusErr *httpStatusError
+			if !errors.As(err, &statusErr) {
+				// the server is not reachable, or the agent could not log in
+				return nil, err
+			}
+			for _, key := range keys {
+				res[secretPath+vaultHandleKeySeparator+key] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("could not read secret '%s': %s", secretPath, err)}
+			}
+			continue
+		}
+		for _, key := range keys {
+			handle := secretPath + vaultHandleKeySeparator + key
+			switch value := data[key].(type) {
+			case string:
+				res[handle] = secrets.SecretVal{Value: value}
+			case nil:
+				res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("key '%s' not found in secret '%s'", key, secretPath)}
+			default:
+				res[handle] = secrets.SecretVal{ErrorMsg: fmt.Sprintf("the value of key '%s' in secret '%s' is not a string", key, secretPath)}
+			}
+		}
+	}
+	return res, nil
+}
+
+// readSecret returns the data of the latest version of a secret, logging in again once if the AppRole token was
+// revoked
+func (b *vaultBackend) readSecret(ctx context.Context, secretPath string) (map[string]interface{}, error) {
+	secretURL := fmt.Sprintf("%s/v1/%s/data/%s", b.config.Address, strings.Trim(b.config.MountPath, "/"), strings.Trim(secretPath, "/"))
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var headers map[string]string
+		headers, err = b.headers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var response vaultKVResponse
+		err = doJSONRequest(ctx, b.client, http.MethodGet, secretURL, headers, nil, b.maxSize, &response)
+		if err == nil {
+			return response.Data.Data, nil
+		}
+
+		var statusErr *httpStatusError
+		if !errors.As(err, &statusErr) || statusErr.statusCode != http.StatusForbidden || b.config.AppRole.RoleID == "" {
+			break
+		}
+		b.appRoleToken = ""
+	}
+	return nil, err
+}
+
+// headers returns the headers authenticating the requests to Vault
+func (b *vaultBackend) headers(ctx context.Context) (map[string]string, error) {
+	token, err := b.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	headers := map[string]string{"X-Vault-Token": token}
+	if b.config.Namespace != "" {
+		headers["X-Vault-Namespace"] = b.config.Namespace
+	}
+	return headers, nil
+}
+
+// token returns the token of the agent, logging in with the AppRole when its token expired
+func (b *vaultBackend) token(ctx context.Context) (string, error) {
+	if b.config.AppRole.RoleID == "" {
+		if b.config.TokenFile == "" {
+			return b.config.Token, nil
+		}
+		token, err := readFileWithLimit(b.config.TokenFile, b.maxSize)
+		if err != nil {
+			return "", fmt.Errorf("could not read the Vault token: %s", err)
+		}
+		return strings.TrimSpace(string(token)), nil
+	}
+
+	if b.appRoleToken != "" && (b.appRoleTokenExpiry.IsZero() || b.now().Before(b.appRoleTokenExpiry)) {
+		return b.appRoleToken, nil
+	}
+
+	secretID := b.config.AppRole.SecretID
+	if b.config.AppRole.SecretIDFile != "" {
+		content, err := readFileWithLimit(b.config.AppRole.SecretIDFile, b.maxSize)
+		if err != nil {
+			return "", fmt.Errorf("could not read the AppRole secret ID: %s", err)
+		}
+		secretID = strings.TrimSpace(string(content))
+	}
+	payload := map[string]string{"role_id": b.config.AppRole.RoleID}
+	if secretID != "" {
+		payload["secret_id"] = secretID
+	}
+	var headers map[string]string
+	if b.config.Namespace != "" {
+		headers = map[string]string{"X-Vault-Namespace": b.config.Namespace}
+	}
+
+	loginURL := fmt.Sprintf("%s/v1/auth/%s/login", b.config.Address, strings.Trim(b.config.AppRole.MountPath, "/"))
+	var response vaultLoginResponse
+	if err := doJSONRequest(ctx, b.client, http.MethodPost, loginURL, headers, payload, b.maxSize, &response); err != nil {
+		return "", fmt.Errorf("could not log in to Vault with the AppRole: %s", err)
+	}
+	if response.Auth.ClientToken == "" {
+		return "", errors.New("could not log in to Vault with the AppRole: no token in the response")
+	}
+
+	b.appRoleToken = response.Auth.ClientToken
+	b.appRoleTokenExpiry = time.Time{}
+	if lease := time.Duration(response.Auth.LeaseDuration) * time.Second; lease > 0 {
+		// log in again a bit before the token expires
+		b.appRoleTokenExpiry = b.now().Add(lease - lease/10)
+	}
+	return b.appRoleToken, nil
+}
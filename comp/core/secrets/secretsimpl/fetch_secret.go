@@ -102,9 +102,8 @@ func (r *secretResolver) execCommand(inputPayload string) ([]byte, error) {
 	return stdout.buf.Bytes(), nil
 }
 
-// fetchSecret receives a list of secrets name to fetch, exec a custom
-// executable to fetch the actual secrets and returns them.
-func (r *secretResolver) fetchSecret(secretsHandle []string) (map[string]string, error) {
+// fetchFromCommand executes the secret_backend_command to fetch secrets
+func (r *secretResolver) fetchFromCommand(secretsHandle []string) (map[string]secrets.SecretVal, error) {
 	payload := map[string]interface{}{
 		"version": secrets.PayloadVersion,
 		"secrets": secretsHandle,
@@ -118,19 +117,64 @@ func (r *secretResolver) fetchSecret(secretsHandle []string) (map[string]string,
 		return nil, err
 	}
 
-	secrets := map[string]secrets.SecretVal{}
-	err = json.Unmarshal(output, &secrets)
+	values := map[string]secrets.SecretVal{}
+	err = json.Unmarshal(output, &values)
 	if err != nil {
 		r.tlmSecretUnmarshalError.Inc()
 		return nil, fmt.Errorf("could not unmarshal 'secret_backend_command' output: %s", err)
 	}
+	return values, nil
+}
+
+// fetchFromBackend fetches secrets with the built-in backend
+func (r *secretResolver) fetchFromBackend(secretsHandle []string) (map[string]secrets.SecretVal, error) {
+	if r.backendErr != nil {
+		return nil, fmt.Errorf("invalid configuration of the %s secret backend: %s", r.backendType, r.backendErr)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(),
+		time.Duration(r.backendTimeout)*time.Second)
+	defer cancel()
+
+	log.Debugf("%s | fetching %d secrets from the %s secret backend", time.Now().String(), len(secretsHandle), r.backendType)
+	start := time.Now()
+	values, err := r.backend.fetchSecrets(ctx, secretsHandle)
+	elapsed := time.Since(start)
+	log.Debugf("%s | %s secret backend completed in %s", time.Now().String(), r.backendType, elapsed)
+
+	if err != nil {
+		status := "error"
+		if ctx.Err() == context.DeadlineExceeded {
+			status = "timeout"
+		}
+		r.tlmSecretBackendElapsed.Add(float64(elapsed.Milliseconds()), r.backendType, status)
+		return nil, fmt.Errorf("error while fetching secrets from the %s secret backend: %s", r.backendType, err)
+	}
+	r.tlmSecretBackendElapsed.Add(float64(elapsed.Milliseconds()), r.backendType, "0")
+	return values, nil
+}
+
+// fetchSecret receives a list of secrets name to fetch, exec a custom
+// executable or query the built-in backend to fetch the actual secrets
+// and returns them.
+func (r *secretResolver) fetchSecret(secretsHandle []string) (map[string]string, error) {
+	backendName := "secret_backend_command"
+	fetch := r.fetchFromCommand
+	if r.backendType != "" {
+		backendName = r.backendType + " secret backend"
+		fetch = r.fetchFromBackend
+	}
+	values, err := fetch(secretsHandle)
+	if err != nil {
+		return nil, err
+	}
 
 	res := map[string]string{}
 	for _, sec := range secretsHandle {
-		v, ok := secrets[sec]
+		v, ok := values[sec]
 		if !ok {
 			r.tlmSecretResolveError.Inc("missing", sec)
-			return nil, fmt.Errorf("secret handle '%s' was not resolved by the secret_backend_command", sec)
+			return nil, fmt.Errorf("secret handle '%s' was not resolved by the %s", sec, backendName)
 		}
 
 		if v.ErrorMsg != "" {
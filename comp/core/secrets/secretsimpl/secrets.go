@@ -95,6 +95,11 @@ type secretResolver struct {
 	removeTrailingLinebreak bool
 	// responseMaxSize defines max size of the JSON output from a secrets reader backend
 	responseMaxSize int
+	// backendType is the type of the built-in backend used instead of the command, backendErr is set when its
+	// configuration is invalid
+	backendType string
+	backend     secretBackend
+	backendErr  error
 	// refresh secrets at a regular interval
 	refreshInterval        time.Duration
 	refreshIntervalScatter bool
@@ -234,6 +239,18 @@ func (r *secretResolver) Configure(params secrets.ConfigParams) {
 	if r.commandAllowGroupExec {
 		log.Warnf("Agent configuration relax permissions constraint on the secret backend cmd, Group can read and exec")
 	}
+	r.backendType = params.Type
+	r.backend = nil
+	r.backendErr = nil
+	if r.backendType != "" {
+		if r.backendCommand != "" {
+			log.Warnf("Both secret_backend_command and secret_backend_type are set, secrets are fetched with the %s secret backend", r.backendType)
+		}
+		r.backend, r.backendErr = newSecretBackend(r.backendType, params.Config, r.responseMaxSize)
+		if r.backendErr != nil {
+			log.Errorf("Invalid configuration of the %s secret backend: %s", r.backendType, r.backendErr)
+		}
+	}
 	r.auditFilename = filepath.Join(params.RunPath, auditFileBasename)
 	r.auditFileMaxSize = params.AuditFileMaxSize
 	if r.auditFileMaxSize == 0 {
@@ -289,8 +306,13 @@ func (r *secretResolver) SubscribeToChanges(cb secrets.SecretChangeCallback) {
 	r.subscriptions = append(r.subscriptions, cb)
 }
 
-// Resolve replaces all encoded secrets in data by executing "secret_backend_command" once if all secrets aren't
-// present in the cache.
+// isBackendConfigured returns true if a secret_backend_command or a built-in backend is configured
+func (r *secretResolver) isBackendConfigured() bool {
+	return r.backendCommand != "" || r.backendType != ""
+}
+
+// Resolve replaces all encoded secrets in data by executing "secret_backend_command", or querying the built-in
+// backend, once if all secrets aren't present in the cache.
 func (r *secretResolver) Resolve(data []byte, origin string) ([]byte, error) {
 	r.lock.Lock()
 	defer r.lock.Unlock()
@@ -299,7 +321,7 @@ func (r *secretResolver) Resolve(data []byte, origin string) ([]byte, error) {
 		log.Infof("Agent secrets is disabled by caller")
 		return nil, nil
 	}
-	if data == nil || r.backendCommand == "" {
+	if data == nil || !r.isBackendConfigured() {
 		return data, nil
 	}
 
@@ -605,6 +627,8 @@ func isLikelyAPIOrAppKey(handle, secretValue string, origin handleToContext) boo
 }
 
 type secretInfo struct {
+	BackendType                  string
+	BackendStatus                string
 	Executable                   string
 	ExecutablePermissions        string
 	ExecutablePermissionsDetails interface{}
@@ -638,7 +662,7 @@ func (r *secretResolver) GetDebugInfo(w io.Writer) {
 		fmt.Fprintf(w, "Agent secrets is disabled by caller\n")
 		return
 	}
-	if r.backendCommand == "" {
+	if !r.isBackendConfigured() {
 		fmt.Fprintf(w, "No secret_backend_command set: secrets feature is not enabled\n")
 		return
 	}
@@ -656,22 +680,30 @@ func (r *secretResolver) GetDebugInfo(w io.Writer) {
 		return
 	}
 
-	err = checkRights(r.backendCommand, r.commandAllowGroupExec)
-
-	permissions := "OK, the executable has the correct permissions"
-	if err != nil {
-		permissions = fmt.Sprintf("error: %s", err)
-	}
-
-	details, err := r.getExecutablePermissions()
 	info := secretInfo{
-		Executable:                   r.backendCommand,
-		ExecutablePermissions:        permissions,
-		ExecutablePermissionsDetails: details,
-		Handles:                      map[string][][]string{},
+		BackendType: r.backendType,
+		Handles:     map[string][][]string{},
 	}
-	if err != nil {
-		info.ExecutablePermissionsError = err.Error()
+	if r.backendType != "" {
+		info.BackendStatus = "OK"
+		if r.backendErr != nil {
+			info.BackendStatus = fmt.Sprintf("error: %s", r.backendErr)
+		}
+	} else {
+		err = checkRights(r.backendCommand, r.commandAllowGroupExec)
+
+		permissions := "OK, the executable has the correct permissions"
+		if err != nil {
+			permissions = fmt.Sprintf("error: %s", err)
+		}
+
+		details, err := r.getExecutablePermissions()
+		info.Executable = r.backendCommand
+		info.ExecutablePermissions = permissions
+		info.ExecutablePermissionsDetails = details
+		if err != nil {
+			info.ExecutablePermissionsError = err.Error()
+		}
 	}
 
 	// we sort handles so the output is consistent and testable
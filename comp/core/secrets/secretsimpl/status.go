@@ -36,22 +36,29 @@ func (s secretsStatus) populateStatus(stats map[string]interface{}) {
 		return
 	}
 
-	if r.backendCommand == "" {
+	if !r.isBackendConfigured() {
 		stats["message"] = "No secret_backend_command set: secrets feature is not enabled\n"
 		return
 	}
 
-	stats["executable"] = r.backendCommand
-
-	correctPermission := true
-	permissionMsg := "OK, the executable has the correct permissions"
-	err := checkRights(r.backendCommand, r.commandAllowGroupExec)
-	if err != nil {
-		correctPermission = false
-		permissionMsg = fmt.Sprintf("error: %s", err)
+	if r.backendType != "" {
+		stats["backend_type"] = r.backendType
+		if r.backendErr != nil {
+			stats["backend_error"] = r.backendErr.Error()
+		}
+	} else {
+		stats["executable"] = r.backendCommand
+
+		correctPermission := true
+		permissionMsg := "OK, the executable has the correct permissions"
+		err := checkRights(r.backendCommand, r.commandAllowGroupExec)
+		if err != nil {
+			correctPermission = false
+			permissionMsg = fmt.Sprintf("error: %s", err)
+		}
+		stats["executable_correct_permissions"] = correctPermission
+		stats["executable_permissions_message"] = permissionMsg
 	}
-	stats["executable_correct_permissions"] = correctPermission
-	stats["executable_permissions_message"] = permissionMsg
 
 	handleMap := make(map[string][][]string)
 	orderedHandles := make([]string, 0, len(r.origin))
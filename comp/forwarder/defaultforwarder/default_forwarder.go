@@ -26,6 +26,7 @@ import (
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/config/utils"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 	"github.com/DataDog/datadog-agent/pkg/util/scrubber"
 	"github.com/DataDog/datadog-agent/pkg/version"
@@ -222,7 +223,8 @@ type DefaultForwarder struct {
 
 	domainForwarders map[string]*domainForwarder
 	domainResolvers  map[string]pkgresolver.DomainResolver
-	localForwarder   *domainForwarder // domain forward used for communication with the local cluster-agent
+	localForwarder   *domainForwarder                // domain forward used for communication with the local cluster-agent
+	routes           map[string]utils.ForwarderRoute // forwarder routes by domain, restricting the payloads sent to it
 	healthChecker    *forwarderHealth
 	internalState    *atomic.Uint32
 	m                sync.Mutex // To control Start/Stop races
@@ -255,7 +257,18 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 		completionHandler: options.CompletionHandler,
 		agentName:         agentName,
 		localForwarder:    nil,
+		routes:            map[string]utils.ForwarderRoute{},
 	}
+
+	routes, err := utils.GetForwarderRoutes(config)
+	if err != nil {
+		log.Errorf("Ignoring the forwarder routes: %v", err)
+	}
+	for _, route := range routes {
+		domain, _ := utils.AddAgentVersionToDomain(route.Domain, "app")
+		f.routes[domain] = route
+	}
+
 	var optionalRemovalPolicy *retry.FileRemovalPolicy
 	storageMaxSize := config.GetInt64("forwarder_storage_max_size_in_bytes")
 	var diskUsageLimit *retry.DiskUsageLimit
@@ -291,10 +304,15 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 	}
 
 	flushToDiskMemRatio := config.GetFloat64("forwarder_flush_to_disk_mem_ratio")
+	storageCompressionKind := getStorageCompressionKind(config, log)
+	storageJournal := config.GetBool("forwarder_storage_journal")
 	domainForwarderSort := transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: true}
 	transactionContainerSort := transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: false}
 
+	authenticatedDomains := getAuthenticatedDomains(config)
+
 	for domain, resolver := range options.DomainResolvers {
+		_, authenticate := authenticatedDomains[strings.TrimSuffix(domain, "/")]
 		isMRF := false
 		if config.GetBool("multi_region_failover.enabled") {
 			log.Infof("MRF is enabled, checking site: %v ", domain)
@@ -324,6 +342,19 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 				}
 			}
 
+			var journal *retry.TransactionJournal
+			var replayedTransactions []transaction.Transaction
+			if storageJournal && domainFolderPath != "" && diskUsageLimit != nil {
+				journal, err = retry.NewTransactionJournal(log, retry.NewHTTPTransactionsSerializer(log, resolver), domainFolderPath, diskUsageLimit)
+				if err == nil {
+					replayedTransactions, err = journal.Replay()
+				}
+				if err != nil {
+					log.Errorf("Transaction journal disabled for the domain '%v': %v", domain, err)
+					journal = nil
+				}
+			}
+
 			pointCountTelemetry := retry.NewPointCountTelemetry(domain)
 			transactionContainer := retry.BuildTransactionRetryQueue(
 				log,
@@ -331,6 +362,8 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 				flushToDiskMemRatio,
 				domainFolderPath,
 				diskUsageLimit,
+				storageCompressionKind,
+				journal,
 				transactionContainerSort,
 				resolver,
 				pointCountTelemetry)
@@ -345,12 +378,20 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 				domain,
 				isMRF,
 				isLocal,
+				authenticate,
 				transactionContainer,
+				journal,
 				numberOfWorkers,
 				options.ConnectionResetInterval,
 				domainForwarderSort,
 				pointCountTelemetry)
 			f.domainForwarders[domain] = fwd
+			if len(replayedTransactions) > 0 {
+				log.Infof("Retrying %d transactions of the journal for the domain '%v'", len(replayedTransactions), domain)
+				for _, t := range replayedTransactions {
+					fwd.addToTransactionRetryQueue(t)
+				}
+			}
 			// Register all alternate domains for each forwarder
 			for _, v := range resolver.GetAlternateDomains() {
 				f.domainForwarders[v] = fwd
@@ -392,6 +433,36 @@ func NewDefaultForwarder(config config.Component, log log.Component, options *Op
 	return f
 }
 
+// getAuthenticatedDomains returns the intake URLs whose requests are authenticated with
+// `forwarder_authentication`: the URLs of `forwarder_authentication.domains`, or the main intake
+// when it is not set, so that the credentials of a gateway are not sent to the other intakes.
+func getAuthenticatedDomains(config config.Component) map[string]struct{} {
+	domains := config.GetStringSlice("forwarder_authentication.domains")
+	if len(domains) == 0 {
+		domains = []string{utils.GetInfraEndpoint(config)}
+	}
+	authenticatedDomains := make(map[string]struct{}, len(domains))
+	for _, domain := range domains {
+		authenticatedDomains[strings.TrimSuffix(domain, "/")] = struct{}{}
+	}
+	return authenticatedDomains
+}
+
+// getStorageCompressionKind returns the compression of the transactions stored on disk, the files
+// being named after it. An unknown kind falls back to no compression.
+func getStorageCompressionKind(config config.Component, log log.Component) string {
+	kind := config.GetString("forwarder_storage_compression_kind")
+	switch kind {
+	case compression.NoneKind, compression.Lz4Kind, compression.SnappyKind, compression.ZstdKind, compression.ZlibKind, compression.GzipKind:
+		return kind
+	case "":
+		return compression.NoneKind
+	default:
+		log.Warnf("Unknown forwarder_storage_compression_kind '%s', the transactions stored on disk are not compressed", kind)
+		return compression.NoneKind
+	}
+}
+
 func getAgentName(options *Options) string {
 	if HasFeature(options.EnabledFeatures, CoreFeatures) {
 		return "core"
@@ -488,6 +559,38 @@ func (f *DefaultForwarder) State() uint32 {
 	return f.internalState.Load()
 }
 
+// routeAccepts returns whether the payload is sent to the domain according to the forwarder routes.
+// Payloads built for a route only go to its domain, the others go to every domain whose route
+// selects their type without filtering their content.
+func (f *DefaultForwarder) routeAccepts(domain string, payload *transaction.BytesPayload, kind transaction.Kind) bool {
+	route, routed := f.routes[domain]
+	if !routed {
+		return payload.Route == ""
+	}
+	if payload.Route != "" {
+		return payload.Route == route.Domain
+	}
+	isMetric := kind == transaction.Series || kind == transaction.Sketches
+	return route.AcceptsPayloadType(routePayloadType(kind)) && !(isMetric && route.HasMetricPredicates())
+}
+
+func routePayloadType(kind transaction.Kind) string {
+	switch kind {
+	case transaction.Series:
+		return utils.RoutePayloadSeries
+	case transaction.Sketches:
+		return utils.RoutePayloadSketches
+	case transaction.ServiceChecks, transaction.CheckRuns:
+		return utils.RoutePayloadServiceChecks
+	case transaction.Events:
+		return utils.RoutePayloadEvents
+	case transaction.Metadata:
+		return utils.RoutePayloadMetadata
+	default:
+		return utils.RoutePayloadProcess
+	}
+}
+
 func (f *DefaultForwarder) createHTTPTransactions(endpoint transaction.Endpoint, payloads transaction.BytesPayloads, kind transaction.Kind, extra http.Header) []*transaction.HTTPTransaction {
 	return f.createAdvancedHTTPTransactions(endpoint, payloads, extra, transaction.TransactionPriorityNormal, kind, true)
 }
@@ -498,6 +601,9 @@ func (f *DefaultForwarder) createAdvancedHTTPTransactions(endpoint transaction.E
 
 	for _, payload := range payloads {
 		for domain, dr := range f.domainResolvers {
+			if !f.routeAccepts(domain, payload, kind) {
+				continue
+			}
 			drDomain, destinationType := dr.Resolve(endpoint) // drDomain is the domain with agent version if not local
 			if payload.Destination == transaction.LocalOnly {
 				// if it is local payload, we should not send it to the remote endpoint
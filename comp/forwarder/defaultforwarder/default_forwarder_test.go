@@ -11,6 +11,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/endpoints"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
 	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -55,3 +57,60 @@ func TestDefaultForwarderUpdateAPIKey(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, expectData, string(data))
 }
+
+func TestDefaultForwarderStorageJournal(t *testing.T) {
+	mockConfig := config.NewMock(t)
+	mockConfig.SetWithoutSource("forwarder_storage_max_size_in_bytes", 1024*1024)
+	mockConfig.SetWithoutSource("forwarder_storage_path", t.TempDir())
+	mockConfig.SetWithoutSource("forwarder_storage_journal", true)
+	log := logmock.New(t)
+
+	domain := "http://example1.com"
+	newForwarder := func() (*DefaultForwarder, *domainForwarder) {
+		options := NewOptions(mockConfig, log, map[string][]string{domain: {"api_key1"}})
+		options.EnabledFeatures = SetFeature(options.EnabledFeatures, CoreFeatures)
+		forwarder := NewDefaultForwarder(mockConfig, log, options)
+		require.Contains(t, forwarder.domainForwarders, domain)
+		return forwarder, forwarder.domainForwarders[domain]
+	}
+
+	forwarder, domainForwarder := newForwarder()
+	payload := []byte("payload")
+	payloads := transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&payload})
+	for _, tr := range forwarder.createHTTPTransactions(endpoints.SeriesEndpoint, payloads, transaction.Series, nil) {
+		// The forwarder is not started: the transaction goes to the retry queue
+		domainForwarder.sendHTTPTransactions(tr)
+	}
+	require.Equal(t, 1, domainForwarder.retryQueue.GetTransactionCount())
+	// Wait for the journal to be written
+	require.NoError(t, domainForwarder.journal.Close())
+
+	// The transactions of the journal are retried after a crash
+	_, domainForwarder = newForwarder()
+	transactions, err := domainForwarder.retryQueue.ExtractTransactions()
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	assert.Equal(t, payload, transactions[0].(*transaction.HTTPTransaction).Payload.GetContent())
+
+	// Until they are sent
+	domainForwarder.journal.Release(transactions...)
+	require.NoError(t, domainForwarder.journal.Close())
+	_, domainForwarder = newForwarder()
+	assert.Equal(t, 0, domainForwarder.retryQueue.GetTransactionCount())
+}
+
+func TestGetStorageCompressionKind(t *testing.T) {
+	for kind, expected := range map[string]string{
+		"":       "none",
+		"none":   "none",
+		"lz4":    "lz4",
+		"snappy": "snappy",
+		"zstd":   "zstd",
+		"brotli": "none",
+		"../foo": "none",
+	} {
+		mockConfig := config.NewMock(t)
+		mockConfig.SetWithoutSource("forwarder_storage_compression_kind", kind)
+		assert.Equal(t, expected, getStorageCompressionKind(mockConfig, logmock.New(t)), kind)
+	}
+}
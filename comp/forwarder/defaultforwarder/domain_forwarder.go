@@ -45,6 +45,7 @@ type domainForwarder struct {
 	Client                    *SharedConnection
 	workers                   []*Worker
 	retryQueue                *retry.TransactionRetryQueue
+	journal                   *retry.TransactionJournal
 	connectionResetInterval   time.Duration
 	internalState             uint32
 	m                         sync.Mutex // To control Start/Stop races
@@ -59,7 +60,9 @@ func newDomainForwarder(
 	domain string,
 	mrf bool,
 	isLocal bool,
+	authenticate bool,
 	retryQueue *retry.TransactionRetryQueue,
+	journal *retry.TransactionJournal,
 	numberOfWorkers int,
 	connectionResetInterval time.Duration,
 	transactionPrioritySorter retry.TransactionPrioritySorter,
@@ -73,12 +76,13 @@ func newDomainForwarder(
 		domain:                    domain,
 		numberOfWorkers:           numberOfWorkers,
 		retryQueue:                retryQueue,
+		journal:                   journal,
 		connectionResetInterval:   connectionResetInterval,
 		internalState:             Stopped,
 		blockedList:               newBlockedEndpoints(config, log),
 		transactionPrioritySorter: transactionPrioritySorter,
 		pointCountTelemetry:       pointCountTelemetry,
-		Client:                    NewSharedConnection(log, isLocal, numberOfWorkers, config),
+		Client:                    NewSharedConnection(log, isLocal, authenticate, numberOfWorkers, config),
 	}
 }
 
@@ -215,9 +219,13 @@ func (f *domainForwarder) Start() error {
 
 	// reset internal state to purge transactions from past starts
 	f.init()
+	if err := f.journal.Start(); err != nil {
+		f.log.Errorf("Error when opening the transaction journal: %v", err)
+	}
 
 	for i := 0; i < f.numberOfWorkers; i++ {
 		w := NewWorker(f.config, f.log, f.highPrio, f.lowPrio, f.requeuedTransaction, f.blockedList, f.pointCountTelemetry, f.Client)
+		w.journal = f.journal
 		w.Start()
 		f.workers = append(f.workers, w)
 	}
@@ -306,9 +314,22 @@ func (f *domainForwarder) Stop(purgeHighPrio bool) {
 	for t := range f.requeuedTransaction {
 		f.requeueTransaction(t)
 	}
+	if f.config.GetBool("forwarder_storage_flush_on_shutdown") {
+		// The transactions not sent yet are stored on disk with the retry queue
+		// and retried when the forwarder starts again.
+		for t := range f.highPrio {
+			f.addToTransactionRetryQueue(t)
+		}
+		for t := range f.lowPrio {
+			f.addToTransactionRetryQueue(t)
+		}
+	}
 	if err := f.retryQueue.FlushToDisk(); err != nil {
 		f.log.Errorf("Error when flushing the retry queue to disk: %v", err)
 	}
+	if err := f.journal.Close(); err != nil {
+		f.log.Errorf("Error when closing the transaction journal: %v", err)
+	}
 
 	f.log.Info("domainForwarder stopped")
 	f.internalState = Stopped
@@ -357,6 +378,8 @@ func (f *domainForwarder) sendHTTPTransactions(t transaction.Transaction) {
 		return
 	}
 
+	f.journal.Record(t)
+
 	// We don't want to block the collector if the highPrio queue is full
 	select {
 	case f.highPrio <- t:
@@ -226,6 +226,44 @@ func TestCreateHTTPTransactionsWithMultipleDomains(t *testing.T) {
 	assert.Equal(t, txBar[0].Headers.Get("DD-Api-Key"), "api-key-3")
 }
 
+func TestCreateHTTPTransactionsWithRoutes(t *testing.T) {
+	mockConfig := mock.New(t)
+	mockConfig.SetWithoutSource("forwarder_routes", []map[string]interface{}{
+		{"domain": "datadog.bar", "payload_types": []string{"series", "service_checks"}, "metric_prefixes": []string{"system."}},
+	})
+	log := logmock.New(t)
+	forwarder := NewDefaultForwarder(mockConfig, log, NewOptionsWithResolvers(mockConfig, log, resolver.NewSingleDomainResolvers(keysWithMultipleDomains)))
+	endpoint := transaction.Endpoint{Route: "/api/foo", Name: "foo"}
+	domains := func(transactions []*transaction.HTTPTransaction) []string {
+		var domains []string
+		for _, t := range transactions {
+			domains = append(domains, t.Domain)
+		}
+		return domains
+	}
+
+	p1 := []byte("A payload")
+	p2 := []byte("A routed payload")
+	payloads := transaction.NewBytesPayloadsWithoutMetaData([]*[]byte{&p1, &p2})
+	payloads[1].Route = "datadog.bar"
+
+	// series are filtered by the serializer: the routed domain only receives the routed payload
+	transactions := forwarder.createHTTPTransactions(endpoint, payloads, transaction.Series, nil)
+	require.Len(t, transactions, 3)
+	assert.Equal(t, []string{testVersionDomain, testVersionDomain}, domains(transactions[:2]))
+	assert.Equal(t, p1, transactions[0].Payload.GetContent())
+	assert.Equal(t, "datadog.bar", transactions[2].Domain)
+	assert.Equal(t, p2, transactions[2].Payload.GetContent())
+
+	// service checks are selected by type only
+	transactions = forwarder.createHTTPTransactions(endpoint, payloads[:1], transaction.CheckRuns, nil)
+	assert.ElementsMatch(t, []string{testVersionDomain, testVersionDomain, "datadog.bar"}, domains(transactions))
+
+	// other types are not sent to the routed domain
+	transactions = forwarder.createHTTPTransactions(endpoint, payloads[:1], transaction.Events, nil)
+	assert.Equal(t, []string{testVersionDomain, testVersionDomain}, domains(transactions))
+}
+
 func TestCreateHTTPTransactionsWithDifferentResolvers(t *testing.T) {
 	resolvers := resolver.NewSingleDomainResolvers(keysWithMultipleDomains)
 	additionalResolver := resolver.NewMultiDomainResolver("datadog.vector", []string{"api-key-4"})
@@ -12,21 +12,32 @@ import (
 	"path/filepath"
 	"slices"
 	"sort"
+	"strings"
 	"time"
 
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+	implnoop "github.com/DataDog/datadog-agent/pkg/util/compression/impl-noop"
+	"github.com/DataDog/datadog-agent/pkg/util/compression/selector"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 )
 
 const retryTransactionsExtension = ".retry"
 const retryFileFormat = "2006_01_02__15_04_05_"
 
+// retryFileCompressionLevel is the level of the compressors which have one. Retry files are
+// written when the intake cannot be reached, so the speed matters more than the ratio.
+const retryFileCompressionLevel = 1
+
 type onDiskRetryQueue struct {
 	log                 log.Component
 	serializer          *HTTPTransactionsSerializer
 	storagePath         string
 	diskUsageLimit      *DiskUsageLimit
+	journal             *TransactionJournal
+	compressionKind     string
+	compressors         map[string]compression.Compressor
 	filenames           []string
 	currentSizeInBytes  int64
 	telemetry           onDiskRetryQueueTelemetry
@@ -38,22 +49,37 @@ func newOnDiskRetryQueue(
 	serializer *HTTPTransactionsSerializer,
 	storagePath string,
 	diskUsageLimit *DiskUsageLimit,
+	journal *TransactionJournal,
+	compressionKind string,
 	telemetry onDiskRetryQueueTelemetry,
 	pointCountTelemetry *PointCountTelemetry) (*onDiskRetryQueue, error) {
 
 	if err := os.MkdirAll(storagePath, 0700); err != nil {
 		return nil, err
 	}
+	if compressionKind == "" {
+		compressionKind = compression.NoneKind
+	}
 
 	storage := &onDiskRetryQueue{
 		log:                 log,
 		serializer:          serializer,
 		storagePath:         storagePath,
 		diskUsageLimit:      diskUsageLimit,
+		journal:             journal,
+		compressionKind:     compressionKind,
+		compressors:         make(map[string]compression.Compressor),
 		telemetry:           telemetry,
 		pointCountTelemetry: pointCountTelemetry,
 	}
 
+	// Builds without the zlib or zstd tags fall back to the noop compressor for these kinds:
+	// name the retry files after the compression actually applied.
+	if _, ok := storage.compressor(compressionKind).(*implnoop.NoopStrategy); ok && compressionKind != compression.NoneKind {
+		log.Warnf("The %s compression is not available in this build, the retry files are not compressed", compressionKind)
+		storage.compressionKind = compression.NoneKind
+	}
+
 	if err := storage.reloadExistingRetryFiles(); err != nil {
 		return nil, err
 	}
@@ -83,6 +109,11 @@ func (s *onDiskRetryQueue) Store(transactions []transaction.Transaction) error {
 	if err != nil {
 		return err
 	}
+	if s.compressionKind != compression.NoneKind {
+		if bytes, err = s.compressor(s.compressionKind).Compress(bytes); err != nil {
+			return err
+		}
+	}
 	bufferSize := int64(len(bytes))
 
 	if err := s.makeRoomFor(bufferSize); err != nil {
@@ -90,7 +121,7 @@ func (s *onDiskRetryQueue) Store(transactions []transaction.Transaction) error {
 	}
 
 	filename := time.Now().UTC().Format(retryFileFormat)
-	file, err := os.CreateTemp(s.storagePath, filename+"*"+retryTransactionsExtension)
+	file, err := os.CreateTemp(s.storagePath, filename+"*"+retryFileExtension(s.compressionKind))
 	if err != nil {
 		return err
 	}
@@ -131,7 +162,7 @@ func (s *onDiskRetryQueue) ExtractLast() ([]transaction.Transaction, error) {
 		return nil, err
 	}
 
-	transactions, errorsCount, err := s.serializer.Deserialize(bytes)
+	transactions, errorsCount, err := s.deserialize(path, bytes)
 	if err != nil {
 		return nil, err
 	}
@@ -147,9 +178,9 @@ func (s *onDiskRetryQueue) getFilesCount() int {
 	return len(s.filenames)
 }
 
-// GetDiskSpaceUsed() returns the current disk space used.
+// GetDiskSpaceUsed() returns the current disk space used, including the transaction journal.
 func (s *onDiskRetryQueue) GetDiskSpaceUsed() int64 {
-	return s.currentSizeInBytes
+	return s.currentSizeInBytes + s.journal.Size()
 }
 
 func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64) error {
@@ -158,11 +189,13 @@ func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64) error {
 		return fmt.Errorf("The payload is too big. Current:%v Maximum:%v", bufferSize, maxSizeInBytes)
 	}
 
-	maxStorageInBytes, err := s.diskUsageLimit.computeAvailableSpace(s.currentSizeInBytes)
+	// The journal shares the disk space of the retry files
+	journalSize := s.journal.Size()
+	maxStorageInBytes, err := s.diskUsageLimit.computeAvailableSpace(s.currentSizeInBytes + journalSize)
 	if err != nil {
 		return err
 	}
-	for len(s.filenames) > 0 && s.currentSizeInBytes+bufferSize > maxStorageInBytes {
+	for len(s.filenames) > 0 && s.currentSizeInBytes+journalSize+bufferSize > maxStorageInBytes {
 		index := 0
 		filename := s.filenames[index]
 		s.log.Errorf("Maximum disk space for retry transactions is reached. Removing %s", filename)
@@ -170,7 +203,7 @@ func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64) error {
 		bytes, err := os.ReadFile(filename)
 		if err != nil {
 			s.log.Errorf("Cannot read the file %v: %v", filename, err)
-		} else if transactions, _, errDeserialize := s.serializer.Deserialize(bytes); errDeserialize == nil {
+		} else if transactions, _, errDeserialize := s.deserialize(filename, bytes); errDeserialize == nil {
 			pointDroppedCount := 0
 			for _, tr := range transactions {
 				pointDroppedCount += tr.GetPointCount()
@@ -189,6 +222,47 @@ func (s *onDiskRetryQueue) makeRoomFor(bufferSize int64) error {
 	return nil
 }
 
+// deserialize returns the transactions of the given retry file, decompressing them first
+// according to the extension of the file.
+func (s *onDiskRetryQueue) deserialize(filename string, bytes []byte) ([]transaction.Transaction, int, error) {
+	if kind := retryFileCompressionKind(filename); kind != compression.NoneKind {
+		var err error
+		if bytes, err = s.compressor(kind).Decompress(bytes); err != nil {
+			return nil, 0, fmt.Errorf("cannot decompress the %s retry file: %v", kind, err)
+		}
+	}
+	return s.serializer.Deserialize(bytes)
+}
+
+// compressor returns the compressor of the given kind, creating it on first use.
+func (s *onDiskRetryQueue) compressor(kind string) compression.Compressor {
+	c, ok := s.compressors[kind]
+	if !ok {
+		c = selector.NewCompressor(kind, retryFileCompressionLevel)
+		s.compressors[kind] = c
+	}
+	return c
+}
+
+// retryFileExtension returns the extension of the retry files compressed with the given kind,
+// for instance `.zstd.retry`. Uncompressed files keep the `.retry` extension.
+func retryFileExtension(compressionKind string) string {
+	if compressionKind == compression.NoneKind {
+		return retryTransactionsExtension
+	}
+	return "." + compressionKind + retryTransactionsExtension
+}
+
+// retryFileCompressionKind returns the compression kind of a retry file from its extension.
+// Files written before the retry files could be compressed have no compression extension.
+func retryFileCompressionKind(filename string) string {
+	kind := filepath.Ext(strings.TrimSuffix(filename, retryTransactionsExtension))
+	if kind == "" {
+		return compression.NoneKind
+	}
+	return kind[1:]
+}
+
 func (s *onDiskRetryQueue) onPointDropped(count int) {
 	s.telemetry.addPointDroppedCount(count)
 	s.pointCountTelemetry.OnPointDropped(count)
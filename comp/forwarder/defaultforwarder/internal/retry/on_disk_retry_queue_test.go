@@ -16,6 +16,7 @@ import (
 	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/resolver"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 )
 
@@ -116,6 +117,53 @@ func createHTTPTransactionCollectionTests(endpoints ...string) []transaction.Tra
 	return transactions
 }
 
+func TestOnDiskRetryQueueCompression(t *testing.T) {
+	a := assert.New(t)
+	path := t.TempDir()
+
+	q := newTestCompressedOnDiskRetryQueue(t, a, path, 1000, compression.NoneKind)
+	a.NoError(q.Store(createHTTPTransactionCollectionTests("endpoint1")))
+	q = newTestCompressedOnDiskRetryQueue(t, a, path, 1000, compression.Lz4Kind)
+	a.NoError(q.Store(createHTTPTransactionCollectionTests("endpoint2")))
+	q = newTestCompressedOnDiskRetryQueue(t, a, path, 1000, compression.SnappyKind)
+	a.NoError(q.Store(createHTTPTransactionCollectionTests("endpoint3")))
+
+	// the retry files are reloaded whatever their compression
+	q = newTestCompressedOnDiskRetryQueue(t, a, path, 1000, compression.NoneKind)
+	a.Equal(3, q.getFilesCount())
+	var kinds, endpoints []string
+	for _, filename := range q.filenames {
+		kinds = append(kinds, retryFileCompressionKind(filename))
+	}
+	a.ElementsMatch([]string{compression.NoneKind, compression.Lz4Kind, compression.SnappyKind}, kinds)
+
+	for q.getFilesCount() > 0 {
+		transactions, err := q.ExtractLast()
+		a.NoError(err)
+		endpoints = append(endpoints, getEndpointsFromTransactions(transactions)...)
+	}
+	a.ElementsMatch([]string{"endpoint1", "endpoint2", "endpoint3"}, endpoints)
+}
+
+func TestOnDiskRetryQueueUnavailableCompression(t *testing.T) {
+	a := assert.New(t)
+	path := t.TempDir()
+
+	// unknown kinds, like zlib and zstd in builds without their tags, use the noop compressor
+	q := newTestCompressedOnDiskRetryQueue(t, a, path, 1000, "unavailable")
+	a.Equal(compression.NoneKind, q.compressionKind)
+	a.NoError(q.Store(createHTTPTransactionCollectionTests("endpoint1")))
+	a.Equal(1, q.getFilesCount())
+	a.Equal(compression.NoneKind, retryFileCompressionKind(q.filenames[0]))
+	a.NotContains(q.filenames[0], "unavailable")
+}
+
+func TestRetryFileCompressionKind(t *testing.T) {
+	assert.Equal(t, compression.NoneKind, retryFileCompressionKind("/tmp/2025_01_01__10_00_00_123.retry"))
+	assert.Equal(t, compression.Lz4Kind, retryFileCompressionKind("/tmp/2025_01_01__10_00_00_123"+retryFileExtension(compression.Lz4Kind)))
+	assert.Equal(t, ".retry", retryFileExtension(compression.NoneKind))
+}
+
 func getEndpointsFromTransactions(transactions []transaction.Transaction) []string {
 	var endpoints []string
 	for _, t := range transactions {
@@ -126,6 +174,10 @@ func getEndpointsFromTransactions(transactions []transaction.Transaction) []stri
 }
 
 func newTestOnDiskRetryQueue(t *testing.T, a *assert.Assertions, path string, maxSizeInBytes int64) *onDiskRetryQueue {
+	return newTestCompressedOnDiskRetryQueue(t, a, path, maxSizeInBytes, compression.NoneKind)
+}
+
+func newTestCompressedOnDiskRetryQueue(t *testing.T, a *assert.Assertions, path string, maxSizeInBytes int64, compressionKind string) *onDiskRetryQueue {
 	telemetry := newOnDiskRetryQueueTelemetry("domain")
 	disk := diskUsageRetrieverMock{
 		diskUsage: &filesystem.DiskUsage{
@@ -134,7 +186,7 @@ func newTestOnDiskRetryQueue(t *testing.T, a *assert.Assertions, path string, ma
 		}}
 	diskUsageLimit := NewDiskUsageLimit("", disk, maxSizeInBytes, 1)
 	log := logmock.New(t)
-	storage, err := newOnDiskRetryQueue(log, NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver(domainName, nil)), path, diskUsageLimit, telemetry, NewPointCountTelemetryMock())
+	storage, err := newOnDiskRetryQueue(log, NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver(domainName, nil)), path, diskUsageLimit, nil, compressionKind, telemetry, NewPointCountTelemetryMock())
 	a.NoError(err)
 	return storage
 }
@@ -0,0 +1,499 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package retry
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+)
+
+const journalFileName = "transactions.journal"
+
+// journalCompactionMinSize is the size of the journal file from which the records of the
+// released transactions are removed. A full journal is compacted whatever its size.
+const journalCompactionMinSize = 4 * 1024 * 1024
+
+// A journal record is made of a header followed by the serialized transaction for `journalRecordAdd`.
+// Header: record type (1 byte), transaction id (8 bytes), payload length (4 bytes), payload CRC32 (4 bytes).
+const journalRecordHeaderSize = 17
+
+const (
+	journalRecordAdd byte = iota + 1
+	journalRecordDone
+)
+
+// journalRecordsBufferSize is the number of transactions waiting to be written to the journal.
+// The transactions sent while it is full are not recorded.
+const journalRecordsBufferSize = 1000
+
+type journalEntry struct {
+	id     uint64
+	offset int64
+	size   int64
+}
+
+// TransactionJournal writes the transactions of a domain to disk before they are first sent, and
+// forgets them once they are sent, dropped or stored in the on-disk retry queue. The transactions
+// still in the journal when the Agent starts are replayed, so that a crash of the Agent does not
+// lose the transactions buffered in memory. Some transactions may be sent twice after a crash.
+//
+// The operations are written by a goroutine of the journal, which syncs the file after each batch
+// of operations, so that neither recording nor releasing a transaction waits for the disk. The
+// released transactions are accumulated until the goroutine writes them. The size of the
+// journal is limited by `DiskUsageLimit`, the transactions are not recorded beyond it.
+//
+// All the methods can be called on a nil journal and do nothing in this case.
+type TransactionJournal struct {
+	log            log.Component
+	serializer     *HTTPTransactionsSerializer
+	path           string
+	diskUsageLimit *DiskUsageLimit
+
+	// opsMutex prevents the operations from being sent while the journal is closed
+	opsMutex sync.RWMutex
+	records  chan transaction.Transaction
+	released chan struct{}
+	stopped  chan struct{}
+	closed   bool
+
+	// releasesMutex protects the transactions released and not written yet
+	releasesMutex sync.Mutex
+	releases      []transaction.Transaction
+
+	// mutex protects the fields below, which are used by the goroutine of the journal
+	mutex    sync.Mutex
+	file     *os.File
+	buffer   []byte
+	size     atomic.Int64
+	maxSize  int64
+	full     bool
+	liveSize int64
+	nextID   uint64
+	entries  map[transaction.Transaction]journalEntry
+}
+
+// NewTransactionJournal opens the journal of the domain stored in `domainFolderPath`. Replay must be
+// called before recording new transactions.
+func NewTransactionJournal(log log.Component, serializer *HTTPTransactionsSerializer, domainFolderPath string, diskUsageLimit *DiskUsageLimit) (*TransactionJournal, error) {
+	if err := os.MkdirAll(domainFolderPath, 0700); err != nil {
+		return nil, err
+	}
+
+	j := &TransactionJournal{
+		log:            log,
+		serializer:     serializer,
+		path:           path.Join(domainFolderPath, journalFileName),
+		diskUsageLimit: diskUsageLimit,
+		closed:         true,
+		entries:        make(map[transaction.Transaction]journalEntry),
+	}
+	if err := j.Start(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Replay returns the transactions which were not sent by a previous run of the Agent, by priority
+// and creation time as they are retried. The returned transactions remain in the journal.
+func (j *TransactionJournal) Replay() ([]transaction.Transaction, error) {
+	if j == nil {
+		return nil, nil
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	content, err := os.ReadFile(j.path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the records in order, keeping the transactions added and not released
+	var ids []uint64
+	pending := make(map[uint64][]byte)
+	offset := 0
+	for offset+journalRecordHeaderSize <= len(content) {
+		header := content[offset : offset+journalRecordHeaderSize]
+		recordType := header[0]
+		id := binary.LittleEndian.Uint64(header[1:9])
+		length := int(binary.LittleEndian.Uint32(header[9:13]))
+		checksum := binary.LittleEndian.Uint32(header[13:17])
+
+		end := offset + journalRecordHeaderSize + length
+		if end > len(content) || crc32.ChecksumIEEE(content[offset+journalRecordHeaderSize:end]) != checksum {
+			// The Agent stopped while writing this record
+			break
+		}
+		switch recordType {
+		case journalRecordAdd:
+			ids = append(ids, id)
+			pending[id] = content[offset+journalRecordHeaderSize : end]
+		case journalRecordDone:
+			delete(pending, id)
+		}
+		offset = end
+	}
+	if offset < len(content) {
+		j.log.Warnf("Ignoring the %d bytes at the end of the transaction journal %s which cannot be read", len(content)-offset, j.path)
+	}
+
+	var transactions []transaction.Transaction
+	for _, id := range ids {
+		payload, found := pending[id]
+		if !found {
+			continue
+		}
+		replayed, _, err := j.serializer.Deserialize(payload)
+		if err != nil {
+			j.log.Errorf("Cannot deserialize a transaction of the journal %s: %v", j.path, err)
+			continue
+		}
+		transactions = append(transactions, replayed...)
+	}
+
+	// Rewrite the journal with the transactions to replay only
+	if err := j.reset(); err != nil {
+		return nil, err
+	}
+	j.updateMaxSize()
+	for _, t := range transactions {
+		if err := j.record(t); err != nil {
+			return nil, err
+		}
+	}
+	if err := j.sync(); err != nil {
+		return nil, err
+	}
+
+	transaction.SortByCreatedTimeAndPriority{HighPriorityFirst: true}.Sort(transactions)
+	return transactions, nil
+}
+
+// Record writes a transaction to the journal. Transactions which cannot be stored on disk are ignored,
+// as well as the transactions recorded while the journal is busy or full.
+func (j *TransactionJournal) Record(t transaction.Transaction) {
+	if j == nil {
+		return
+	}
+	j.opsMutex.RLock()
+	defer j.opsMutex.RUnlock()
+	if j.closed {
+		return
+	}
+
+	select {
+	case j.records <- t:
+	default:
+		j.log.Debugf("The transaction journal %s is busy, a transaction is not recorded", j.path)
+	}
+}
+
+// Release removes the transactions from the journal. It does not block: the transactions are
+// removed by the goroutine of the journal.
+func (j *TransactionJournal) Release(transactions ...transaction.Transaction) {
+	if j == nil || len(transactions) == 0 {
+		return
+	}
+	j.opsMutex.RLock()
+	defer j.opsMutex.RUnlock()
+	if j.closed {
+		return
+	}
+
+	j.releasesMutex.Lock()
+	j.releases = append(j.releases, transactions...)
+	j.releasesMutex.Unlock()
+
+	// A pending notification is enough for the goroutine to see these releases
+	select {
+	case j.released <- struct{}{}:
+	default:
+	}
+}
+
+// Size returns the size of the journal file.
+func (j *TransactionJournal) Size() int64 {
+	if j == nil {
+		return 0
+	}
+	return j.size.Load()
+}
+
+// Start opens the journal file if it is closed and starts writing the operations to it.
+func (j *TransactionJournal) Start() error {
+	if j == nil {
+		return nil
+	}
+	j.opsMutex.Lock()
+	defer j.opsMutex.Unlock()
+	if !j.closed {
+		return nil
+	}
+
+	j.mutex.Lock()
+	err := j.open()
+	j.mutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	j.records = make(chan transaction.Transaction, journalRecordsBufferSize)
+	j.released = make(chan struct{}, 1)
+	j.stopped = make(chan struct{})
+	j.closed = false
+	go j.run(j.records, j.released, j.stopped)
+	return nil
+}
+
+// Close writes the pending operations to the journal and closes its file.
+func (j *TransactionJournal) Close() error {
+	if j == nil {
+		return nil
+	}
+	j.opsMutex.Lock()
+	defer j.opsMutex.Unlock()
+	if j.closed {
+		return nil
+	}
+
+	j.closed = true
+	close(j.records)
+	<-j.stopped
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// run writes the operations to the journal until `records` is closed. The file is synced once the
+// transactions waiting in `records` and the released transactions are written.
+func (j *TransactionJournal) run(records <-chan transaction.Transaction, released <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+
+	for {
+		select {
+		case t, ok := <-records:
+			if !ok {
+				j.writeBatch(nil, records)
+				return
+			}
+			j.writeBatch(t, records)
+		case <-released:
+			j.writeBatch(nil, records)
+		}
+	}
+}
+
+// writeBatch writes `t` if it is not nil, the transactions waiting in `records` and the released
+// transactions, then syncs the journal file.
+func (j *TransactionJournal) writeBatch(t transaction.Transaction, records <-chan transaction.Transaction) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	// The releases are taken before reading `records`, so that a transaction recorded and then
+	// released is written before its release.
+	j.releasesMutex.Lock()
+	releases := j.releases
+	j.releases = nil
+	j.releasesMutex.Unlock()
+
+	j.updateMaxSize()
+	if t != nil {
+		j.recordOrLog(t)
+	}
+	for pending := len(records); pending > 0; pending-- {
+		if t, ok := <-records; ok {
+			j.recordOrLog(t)
+		}
+	}
+	for _, t := range releases {
+		j.release(t)
+	}
+
+	if err := j.sync(); err != nil {
+		j.log.Errorf("Cannot write to the journal %s: %v", j.path, err)
+	}
+	if (j.full || j.size.Load() > journalCompactionMinSize) && 2*j.liveSize < j.size.Load() {
+		if err := j.compact(); err != nil {
+			j.log.Errorf("Cannot compact the journal %s: %v", j.path, err)
+		}
+	}
+}
+
+func (j *TransactionJournal) recordOrLog(t transaction.Transaction) {
+	if err := j.record(t); err != nil {
+		j.log.Errorf("Cannot write the transaction to the journal %s: %v", j.path, err)
+	}
+}
+
+func (j *TransactionJournal) release(t transaction.Transaction) {
+	entry, found := j.entries[t]
+	if !found {
+		return
+	}
+	delete(j.entries, t)
+	j.liveSize -= entry.size
+	j.write(journalRecordDone, entry.id, nil)
+}
+
+// updateMaxSize computes the space available for the journal on disk, the on-disk retry queue
+// making room for the journal when it stores transactions.
+func (j *TransactionJournal) updateMaxSize() {
+	maxSize, err := j.diskUsageLimit.computeAvailableSpace(j.size.Load())
+	if err != nil {
+		j.log.Errorf("Cannot compute the disk space available for the journal %s: %v", j.path, err)
+		return
+	}
+	j.maxSize = maxSize
+}
+
+func (j *TransactionJournal) record(t transaction.Transaction) error {
+	if _, found := j.entries[t]; found {
+		return nil
+	}
+
+	// Reset the serializer in case some transactions were serialized
+	// but `GetBytesAndReset` was not called because of an error.
+	_, _ = j.serializer.GetBytesAndReset()
+	if err := t.SerializeTo(j.log, j.serializer); err != nil {
+		return err
+	}
+	if len(j.serializer.collection.Values) == 0 {
+		// The transaction is not storable on disk
+		return nil
+	}
+	payload, err := j.serializer.GetBytesAndReset()
+	if err != nil {
+		return err
+	}
+
+	entry := journalEntry{id: j.nextID, offset: j.size.Load(), size: int64(journalRecordHeaderSize + len(payload))}
+	if entry.offset+entry.size > j.maxSize {
+		if !j.full {
+			j.log.Warnf("Maximum disk space for the transaction journal %s is reached: the transactions are not recorded until some are sent", j.path)
+			j.full = true
+		}
+		return nil
+	}
+	j.full = false
+	j.nextID++
+	j.write(journalRecordAdd, entry.id, payload)
+	j.entries[t] = entry
+	j.liveSize += entry.size
+	return nil
+}
+
+// write adds a record to the buffer written to the journal by `sync`.
+func (j *TransactionJournal) write(recordType byte, id uint64, payload []byte) {
+	record := make([]byte, journalRecordHeaderSize+len(payload))
+	record[0] = recordType
+	binary.LittleEndian.PutUint64(record[1:9], id)
+	binary.LittleEndian.PutUint32(record[9:13], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[13:17], crc32.ChecksumIEEE(payload))
+	copy(record[journalRecordHeaderSize:], payload)
+
+	j.buffer = append(j.buffer, record...)
+	j.size.Add(int64(len(record)))
+}
+
+// sync writes the buffered records to the journal file and flushes it to disk.
+func (j *TransactionJournal) sync() error {
+	if len(j.buffer) == 0 {
+		return nil
+	}
+	fileSize := j.size.Load() - int64(len(j.buffer))
+	_, err := j.file.Write(j.buffer)
+	j.buffer = nil
+	if err != nil {
+		// Remove the records not written, so that the next records can be replayed
+		for t, entry := range j.entries {
+			if entry.offset >= fileSize {
+				delete(j.entries, t)
+				j.liveSize -= entry.size
+			}
+		}
+		j.size.Store(fileSize)
+		return errors.Join(err, j.file.Truncate(fileSize))
+	}
+	return j.file.Sync()
+}
+
+// compact rewrites the journal with the records of the transactions not released yet.
+func (j *TransactionJournal) compact() error {
+	tmp, err := os.CreateTemp(path.Dir(j.path), journalFileName+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	entries := make(map[transaction.Transaction]journalEntry, len(j.entries))
+	offset := int64(0)
+	for t, entry := range j.entries {
+		record := make([]byte, entry.size)
+		if _, err := j.file.ReadAt(record, entry.offset); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		if _, err := tmp.Write(record); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+		entry.offset = offset
+		entries[t] = entry
+		offset += entry.size
+	}
+	if err := errors.Join(tmp.Sync(), tmp.Close()); err != nil {
+		return err
+	}
+
+	if err := j.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		// Keep using the previous journal
+		return errors.Join(err, j.open())
+	}
+	j.entries = entries
+	j.liveSize = offset
+	return j.open()
+}
+
+// reset empties the journal.
+func (j *TransactionJournal) reset() error {
+	if err := j.file.Truncate(0); err != nil {
+		return err
+	}
+	j.buffer = nil
+	j.size.Store(0)
+	j.liveSize = 0
+	j.entries = make(map[transaction.Transaction]journalEntry)
+	return nil
+}
+
+func (j *TransactionJournal) open() error {
+	file, err := os.OpenFile(j.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("cannot get the size of the journal: %v", err)
+	}
+	j.file = file
+	j.size.Store(info.Size())
+	return nil
+}
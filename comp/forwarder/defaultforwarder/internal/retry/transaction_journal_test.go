@@ -0,0 +1,188 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package retry
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/resolver"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
+)
+
+func TestTransactionJournalReplay(t *testing.T) {
+	folder := t.TempDir()
+
+	j := newTestTransactionJournal(t, folder, 10*1024*1024)
+	transactions, err := j.Replay()
+	require.NoError(t, err)
+	require.Empty(t, transactions)
+
+	now := time.Now()
+	transactions = createHTTPTransactionCollectionTests("old", "new", "high", "sent")
+	transactions[0].(*transaction.HTTPTransaction).CreatedAt = now.Add(-2 * time.Minute)
+	transactions[1].(*transaction.HTTPTransaction).CreatedAt = now.Add(-time.Minute)
+	transactions[2].(*transaction.HTTPTransaction).CreatedAt = now.Add(-3 * time.Minute)
+	transactions[2].(*transaction.HTTPTransaction).Priority = transaction.TransactionPriorityHigh
+	notStorable := createHTTPTransactionCollectionTests("not storable")[0]
+	notStorable.(*transaction.HTTPTransaction).StorableOnDisk = false
+
+	for _, tr := range append(transactions, notStorable) {
+		j.Record(tr)
+	}
+	j.Release(transactions[3])
+	require.NoError(t, j.Close())
+
+	// Simulate a crash while writing a record
+	file, err := os.OpenFile(path.Join(folder, journalFileName), os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{journalRecordAdd, 1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	j = newTestTransactionJournal(t, folder, 10*1024*1024)
+	replayed, err := j.Replay()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"high", "new", "old"}, getEndpointsFromTransactions(replayed))
+
+	// The replayed transactions remain in the journal until they are released
+	j.Release(replayed[0])
+	require.NoError(t, j.Close())
+	j = newTestTransactionJournal(t, folder, 10*1024*1024)
+	replayed, err = j.Replay()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"new", "old"}, getEndpointsFromTransactions(replayed))
+	require.NoError(t, j.Close())
+}
+
+func TestTransactionJournalCompaction(t *testing.T) {
+	folder := t.TempDir()
+	j := newTestTransactionJournal(t, folder, 10*1024*1024)
+	_, err := j.Replay()
+	require.NoError(t, err)
+
+	kept := createHTTPTransactionCollectionTests("kept")[0]
+	j.Record(kept)
+	// Write more than journalCompactionMinSize
+	for i := 0; i < 100; i++ {
+		tr := createHTTPTransactionCollectionTests("sent")[0]
+		tr.(*transaction.HTTPTransaction).Payload = transaction.NewBytesPayload(make([]byte, 64*1024), 1)
+		j.Record(tr)
+		j.Release(tr)
+		flushTransactionJournal(t, j)
+	}
+	assert.Less(t, j.Size(), int64(journalCompactionMinSize))
+	assert.Len(t, j.entries, 1)
+
+	j.Record(createHTTPTransactionCollectionTests("recorded after the compaction")[0])
+	require.NoError(t, j.Close())
+
+	j = newTestTransactionJournal(t, folder, 10*1024*1024)
+	replayed, err := j.Replay()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"kept", "recorded after the compaction"}, getEndpointsFromTransactions(replayed))
+	require.NoError(t, j.Close())
+}
+
+func TestTransactionJournalNil(t *testing.T) {
+	var j *TransactionJournal
+	transactions, err := j.Replay()
+	assert.NoError(t, err)
+	assert.Empty(t, transactions)
+	j.Record(createHTTPTransactionCollectionTests("endpoint")[0])
+	j.Release(createHTTPTransactionCollectionTests("endpoint")[0])
+	assert.NoError(t, j.Close())
+}
+
+func TestTransactionJournalMaxSize(t *testing.T) {
+	j := newTestTransactionJournal(t, t.TempDir(), 100)
+	_, err := j.Replay()
+	require.NoError(t, err)
+
+	// The transactions are not recorded once the maximum size is reached
+	transactions := createHTTPTransactionCollectionTests("first", "second", "third", "fourth")
+	for _, tr := range transactions {
+		j.Record(tr)
+	}
+	flushTransactionJournal(t, j)
+	recorded := len(j.entries)
+	assert.Less(t, recorded, len(transactions))
+	assert.LessOrEqual(t, j.Size(), int64(100))
+
+	// Until some transactions are released and the full journal is compacted
+	size := j.Size()
+	j.Release(transactions[0])
+	flushTransactionJournal(t, j)
+	assert.Less(t, j.Size(), size)
+	j.Record(transactions[recorded])
+	flushTransactionJournal(t, j)
+	assert.Len(t, j.entries, recorded)
+	require.NoError(t, j.Close())
+}
+
+func TestTransactionJournalReleaseDoesNotBlock(t *testing.T) {
+	j := newTestTransactionJournal(t, t.TempDir(), 10*1024*1024)
+	_, err := j.Replay()
+	require.NoError(t, err)
+
+	transactions := createHTTPTransactionCollectionTests("first", "second")
+	for _, tr := range transactions {
+		j.Record(tr)
+	}
+	flushTransactionJournal(t, j)
+	require.Len(t, j.entries, 2)
+
+	// Releasing does not wait for the goroutine of the journal, even when it is busy writing
+	j.mutex.Lock()
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		for i := 0; i < 2*journalRecordsBufferSize; i++ {
+			j.Release(transactions[0])
+		}
+		j.Release(transactions[1])
+	}()
+	select {
+	case <-released:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Release blocked while the journal was busy")
+	}
+	j.mutex.Unlock()
+
+	// The releases are coalesced and all written
+	flushTransactionJournal(t, j)
+	assert.Empty(t, j.entries)
+	assert.Empty(t, j.releases)
+	require.NoError(t, j.Close())
+}
+
+func newTestTransactionJournal(t *testing.T, folder string, maxSizeInBytes int64) *TransactionJournal {
+	log := logmock.New(t)
+	disk := diskUsageRetrieverMock{
+		diskUsage: &filesystem.DiskUsage{
+			Available: 100 * 1024 * 1024,
+			Total:     100 * 1024 * 1024,
+		}}
+	diskUsageLimit := NewDiskUsageLimit("", disk, maxSizeInBytes, 1)
+	j, err := NewTransactionJournal(log, NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver(domainName, nil)), folder, diskUsageLimit)
+	require.NoError(t, err)
+	return j
+}
+
+// flushTransactionJournal waits for the operations sent to the journal to be written.
+func flushTransactionJournal(t *testing.T, j *TransactionJournal) {
+	require.NoError(t, j.Close())
+	require.NoError(t, j.Start())
+}
@@ -37,6 +37,7 @@ type TransactionRetryQueue struct {
 	flushToStorageRatio   float64
 	dropPrioritySorter    TransactionPrioritySorter
 	optionalStorage       TransactionDiskStorage
+	journal               *TransactionJournal
 	telemetry             TransactionRetryQueueTelemetry
 	pointCountTelemetry   *PointCountTelemetry
 	mutex                 sync.RWMutex
@@ -49,6 +50,8 @@ func BuildTransactionRetryQueue(
 	flushToStorageRatio float64,
 	optionalDomainFolderPath string,
 	optionalDiskUsageLimit *DiskUsageLimit,
+	storageCompressionKind string,
+	journal *TransactionJournal,
 	dropPrioritySorter TransactionPrioritySorter,
 	resolver resolver.DomainResolver,
 	pointCountTelemetry *PointCountTelemetry) *TransactionRetryQueue {
@@ -58,7 +61,7 @@ func BuildTransactionRetryQueue(
 
 	if optionalDomainFolderPath != "" && optionalDiskUsageLimit != nil {
 		serializer := NewHTTPTransactionsSerializer(log, resolver)
-		storage, err = newOnDiskRetryQueue(log, serializer, optionalDomainFolderPath, optionalDiskUsageLimit, newOnDiskRetryQueueTelemetry(resolver.GetBaseDomain()), pointCountTelemetry)
+		storage, err = newOnDiskRetryQueue(log, serializer, optionalDomainFolderPath, optionalDiskUsageLimit, journal, storageCompressionKind, newOnDiskRetryQueueTelemetry(resolver.GetBaseDomain()), pointCountTelemetry)
 
 		// If the storage on disk cannot be used, log the error and continue.
 		// Returning `nil, err` would mean not using `TransactionRetryQueue` and so not using `forwarder_retry_queue_payloads_max_size` config.
@@ -67,13 +70,15 @@ func BuildTransactionRetryQueue(
 		}
 	}
 
-	return NewTransactionRetryQueue(
+	queue := NewTransactionRetryQueue(
 		dropPrioritySorter,
 		storage,
 		maxMemSizeInBytes,
 		flushToStorageRatio,
 		NewTransactionRetryQueueTelemetry(domain),
 		pointCountTelemetry)
+	queue.journal = journal
+	return queue
 }
 
 // NewTransactionRetryQueue creates a new instance of NewTransactionRetryQueue
@@ -113,7 +118,10 @@ func (tc *TransactionRetryQueue) Add(t transaction.Transaction) (int, error) {
 	if tc.optionalStorage != nil {
 		payloadsGroupToFlush := tc.extractTransactionsForDisk(payloadSize)
 		for _, payloads := range payloadsGroupToFlush {
-			if err := tc.optionalStorage.Store(payloads); err != nil {
+			// The transactions are either on disk or dropped: the journal does not need them anymore
+			err := tc.optionalStorage.Store(payloads)
+			tc.journal.Release(payloads...)
+			if err != nil {
 				diskErr = multierror.Append(diskErr, err)
 				// Assuming all payloads failed during serialization
 				pointCountDroppped := 0
@@ -139,6 +147,7 @@ func (tc *TransactionRetryQueue) Add(t transaction.Transaction) (int, error) {
 			pointCountDroppped += tr.GetPointCount()
 		}
 		tc.onDropPoints(pointCountDroppped)
+		tc.journal.Release(transactions...)
 		inMemTransactionDroppedCount = len(transactions)
 		tc.telemetry.addTransactionsDroppedCount(inMemTransactionDroppedCount)
 	}
@@ -175,6 +184,10 @@ func (tc *TransactionRetryQueue) ExtractTransactions() ([]transaction.Transactio
 			tc.telemetry.incErrorsCount()
 			return nil, err
 		}
+		// The retry file is removed: keep the transactions in the journal until they are sent
+		for _, t := range transactions {
+			tc.journal.Record(t)
+		}
 	}
 	tc.currentMemSizeInBytes = 0
 	tc.telemetry.setCurrentMemSizeInBytes(tc.currentMemSizeInBytes)
@@ -215,11 +228,13 @@ func (tc *TransactionRetryQueue) FlushToDisk() error {
 	if tc.optionalStorage == nil {
 		return nil
 	}
-	tc.mutex.RLock()
-	defer tc.mutex.RUnlock()
+	tc.mutex.Lock()
+	defer tc.mutex.Unlock()
 
-	transactions := tc.extractTransactionsFromMemory(tc.GetMaxMemSizeInBytes())
-	return tc.optionalStorage.Store(transactions)
+	transactions := tc.extractTransactionsFromMemory(tc.maxMemSizeInBytes)
+	err := tc.optionalStorage.Store(transactions)
+	tc.journal.Release(transactions...)
+	return err
 }
 
 func (tc *TransactionRetryQueue) extractTransactionsForDisk(payloadSize int) [][]transaction.Transaction {
@@ -15,6 +15,7 @@ import (
 	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/resolver"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
 	"github.com/DataDog/datadog-agent/pkg/util/filesystem"
 )
 
@@ -102,6 +103,53 @@ func TestTransactionRetryQueueFlushAllToDisk(t *testing.T) {
 	a.Equal(int64(0), q.GetDiskSpaceUsed())
 }
 
+func TestTransactionRetryQueueJournal(t *testing.T) {
+	a := assert.New(t)
+	q := newOnDiskRetryQueueTest(t, a)
+	log := logmock.New(t)
+	journal, err := NewTransactionJournal(log, NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver("", nil)), t.TempDir(), q.diskUsageLimit)
+	a.NoError(err)
+	defer journal.Close()
+	_, err = journal.Replay()
+	a.NoError(err)
+	q.journal = journal
+
+	container := NewTransactionRetryQueue(createDropPrioritySorter(), q, 50, 0.1, NewTransactionRetryQueueTelemetry("domain"), NewPointCountTelemetryMock())
+	container.journal = journal
+
+	// The transactions stored on disk are released from the journal
+	for _, payloadSize := range []int{9, 10, 11, 40} {
+		tr := createTransactionWithPayloadSize(payloadSize)
+		journal.Record(tr)
+		flushTransactionJournal(t, journal)
+		container.Add(tr)
+	}
+	flushTransactionJournal(t, journal)
+	a.Equal(3, q.getFilesCount())
+	a.Len(journal.entries, 1)
+
+	// The journal is counted in the disk space used
+	a.Equal(q.currentSizeInBytes+journal.Size(), q.GetDiskSpaceUsed())
+
+	// The transactions extracted from the disk are recorded again until they are sent
+	assertPayloadSizeFromExtractTransactions(a, container, []int{40})
+	assertPayloadSizeFromExtractTransactions(a, container, []int{11})
+	flushTransactionJournal(t, journal)
+	a.Len(journal.entries, 2)
+
+	// Flushing to disk releases the transactions
+	for _, payloadSize := range []int{5, 6} {
+		tr := createTransactionWithPayloadSize(payloadSize)
+		journal.Record(tr)
+		container.Add(tr)
+	}
+	flushTransactionJournal(t, journal)
+	a.Len(journal.entries, 4)
+	a.NoError(container.FlushToDisk())
+	flushTransactionJournal(t, journal)
+	a.Len(journal.entries, 2)
+}
+
 func TestTransactionRetryQueueNoTransactionStorage(t *testing.T) {
 	a := assert.New(t)
 	pointDropped := transactionContainerPointDroppedCountTelemetry.expvar.Value()
@@ -185,6 +233,8 @@ func newOnDiskRetryQueueTest(t *testing.T, a *assert.Assertions) *onDiskRetryQue
 		NewHTTPTransactionsSerializer(log, resolver.NewSingleDomainResolver("", nil)),
 		path,
 		diskUsageLimit,
+		nil,
+		compression.NoneKind,
 		newOnDiskRetryQueueTelemetry("domain"),
 		NewPointCountTelemetryMock())
 	a.NoError(err)
@@ -11,6 +11,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
 )
 
 // SharedConnection holds a shared http.Client that is used by each worker.
@@ -22,13 +23,17 @@ type SharedConnection struct {
 	isLocal         bool
 	numberOfWorkers int
 	config          config.Component
+	// authenticator is shared by the successive clients so that its tokens survive connection resets
+	authenticator httputils.Authenticator
 }
 
 // NewSharedConnection creates a new shared connection with the given
-// http.Client.
+// http.Client. The requests are authenticated with `forwarder_authentication`
+// when authenticate is set.
 func NewSharedConnection(
 	log log.Component,
 	isLocal bool,
+	authenticate bool,
 	numberOfWorkers int,
 	config config.Component,
 ) *SharedConnection {
@@ -40,6 +45,14 @@ func NewSharedConnection(
 		config:          config,
 	}
 
+	if !isLocal && authenticate {
+		authenticator, err := httputils.NewAuthenticator(config, "forwarder_authentication")
+		if err != nil {
+			log.Errorf("Misconfiguration of the forwarder authentication, the requests will not be authenticated: %v", err)
+		}
+		sc.authenticator = authenticator
+	}
+
 	sc.client = sc.newClient()
 
 	return sc
@@ -67,5 +80,7 @@ func (sc *SharedConnection) newClient() *http.Client {
 		return newBearerAuthHTTPClient(sc.numberOfWorkers)
 	}
 
-	return NewHTTPClient(sc.config, sc.numberOfWorkers, sc.log)
+	client := NewHTTPClient(sc.config, sc.numberOfWorkers, sc.log)
+	client.Transport = httputils.WithAuthentication(client.Transport, sc.authenticator)
+	return client
 }
@@ -0,0 +1,109 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package defaultforwarder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/endpoints"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	mock "github.com/DataDog/datadog-agent/pkg/config/mock"
+)
+
+func TestSharedConnectionAuthentication(t *testing.T) {
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header)
+	}))
+	defer server.Close()
+
+	config := mock.New(t)
+	config.SetWithoutSource("forwarder_authentication.type", "static_headers")
+	config.SetWithoutSource("forwarder_authentication.headers", map[string]string{"X-Gateway-Key": "secret"})
+	log := logmock.New(t)
+
+	tr := transaction.NewHTTPTransaction()
+	tr.Domain = server.URL
+	tr.Endpoint = endpoints.SeriesEndpoint
+	tr.Headers.Set("DD-Api-Key", "api_key")
+	payload := []byte("payload")
+	tr.Payload = transaction.NewBytesPayloadWithoutMetaData(payload)
+
+	sc := NewSharedConnection(log, false, true, 1, config)
+	require.NoError(t, tr.Process(context.Background(), config, log, sc.GetClient()))
+	// The authentication survives the connection resets
+	sc.ResetClient()
+	require.NoError(t, tr.Process(context.Background(), config, log, sc.GetClient()))
+
+	require.Len(t, received, 2)
+	for _, headers := range received {
+		assert.Equal(t, "secret", headers.Get("X-Gateway-Key"))
+		assert.Equal(t, "api_key", headers.Get("DD-Api-Key"))
+	}
+	// The authentication headers are not stored in the transaction
+	assert.Empty(t, tr.Headers.Get("X-Gateway-Key"))
+
+	// The local cluster-agent connection is not authenticated
+	received = nil
+	require.NoError(t, tr.Process(context.Background(), config, log, NewSharedConnection(log, true, true, 1, config).GetClient()))
+	require.Len(t, received, 1)
+	assert.Empty(t, received[0].Get("X-Gateway-Key"))
+}
+
+func TestAuthenticationScopedToDomains(t *testing.T) {
+	newServer := func(received *[]http.Header) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			*received = append(*received, r.Header)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	var mainReceived, additionalReceived []http.Header
+	mainServer := newServer(&mainReceived)
+	additionalServer := newServer(&additionalReceived)
+
+	for name, tc := range map[string]struct {
+		domains                 []string
+		mainAuthenticated       bool
+		additionalAuthenticated bool
+	}{
+		"main intake by default": {mainAuthenticated: true},
+		"listed domains":         {domains: []string{additionalServer.URL + "/"}, additionalAuthenticated: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			mainReceived, additionalReceived = nil, nil
+			config := mock.New(t)
+			config.SetWithoutSource("dd_url", mainServer.URL)
+			config.SetWithoutSource("forwarder_authentication.type", "static_headers")
+			config.SetWithoutSource("forwarder_authentication.headers", map[string]string{"Authorization": "Bearer gateway"})
+			if tc.domains != nil {
+				config.SetWithoutSource("forwarder_authentication.domains", tc.domains)
+			}
+
+			forwarder := NewSyncForwarder(config, logmock.New(t), map[string][]string{
+				mainServer.URL:       {"api_key1"},
+				additionalServer.URL: {"api_key2"},
+			}, time.Second)
+			require.NoError(t, forwarder.SubmitSeries(transaction.BytesPayloads{transaction.NewBytesPayloadWithoutMetaData([]byte("payload"))}, make(http.Header)))
+
+			require.Len(t, mainReceived, 1)
+			require.Len(t, additionalReceived, 1)
+			assert.Equal(t, tc.mainAuthenticated, mainReceived[0].Get("Authorization") != "")
+			assert.Equal(t, tc.additionalAuthenticated, additionalReceived[0].Get("Authorization") != "")
+			assert.Equal(t, "api_key2", additionalReceived[0].Get("DD-Api-Key"))
+		})
+	}
+}
@@ -24,11 +24,13 @@ type SyncForwarder struct {
 	log              log.Component
 	defaultForwarder *DefaultForwarder
 	client           *http.Client
+	// authenticatedClients are the clients of the domains authenticated with `forwarder_authentication`
+	authenticatedClients map[string]*http.Client
 }
 
 // NewSyncForwarder returns a new synchronous forwarder.
 func NewSyncForwarder(config config.Component, log log.Component, keysPerDomain map[string][]string, timeout time.Duration) *SyncForwarder {
-	return &SyncForwarder{
+	f := &SyncForwarder{
 		config:           config,
 		log:              log,
 		defaultForwarder: NewDefaultForwarder(config, log, NewOptions(config, log, keysPerDomain)),
@@ -36,7 +38,25 @@ func NewSyncForwarder(config config.Component, log log.Component, keysPerDomain
 			Timeout:   timeout,
 			Transport: utilhttp.CreateHTTPTransport(config),
 		},
+		authenticatedClients: make(map[string]*http.Client),
 	}
+	for domain, fwd := range f.defaultForwarder.domainForwarders {
+		if fwd.Client.authenticator != nil {
+			f.authenticatedClients[domain] = &http.Client{
+				Timeout:   timeout,
+				Transport: utilhttp.WithAuthentication(utilhttp.CreateHTTPTransport(config), fwd.Client.authenticator),
+			}
+		}
+	}
+	return f
+}
+
+// clientFor returns the client sending the transactions of the given domain
+func (f *SyncForwarder) clientFor(domain string) *http.Client {
+	if client, ok := f.authenticatedClients[domain]; ok {
+		return client
+	}
+	return f.client
 }
 
 // Start starts the sync forwarder: nothing to do.
@@ -50,13 +70,14 @@ func (f *SyncForwarder) Stop() {
 
 func (f *SyncForwarder) sendHTTPTransactions(transactions []*transaction.HTTPTransaction) error {
 	for _, t := range transactions {
-		if err := t.Process(context.Background(), f.config, f.log, f.client); err != nil {
+		client := f.clientFor(t.Domain)
+		if err := t.Process(context.Background(), f.config, f.log, client); err != nil {
 			f.log.Debugf("SyncForwarder.sendHTTPTransactions first attempt: %s", err)
 			// Retry once after error
 			// The intake may have closed the connection between Lambda invocations.
 			// If so, the first attempt will fail because the closed connection will still be cached.
 			f.log.Debug("Retrying transaction")
-			if err := t.Process(context.Background(), f.config, f.log, f.client); err != nil {
+			if err := t.Process(context.Background(), f.config, f.log, client); err != nil {
 				f.log.Warnf("SyncForwarder.sendHTTPTransactions failed to send: %s", err)
 			}
 		}
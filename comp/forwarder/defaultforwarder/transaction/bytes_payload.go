@@ -11,6 +11,9 @@ type BytesPayload struct {
 	content     []byte
 	pointCount  int
 	Destination Destination
+	// Route is the domain of the forwarder route the payload was built for, empty for
+	// payloads sent to every domain without a route
+	Route string
 }
 
 // NewBytesPayload creates a new instance of BytesPayload.
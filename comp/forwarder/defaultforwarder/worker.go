@@ -15,6 +15,7 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/internal/retry"
 	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
 )
 
@@ -37,6 +38,8 @@ type Worker struct {
 	stopped               chan struct{}
 	blockedList           *blockedEndpoints
 	pointSuccessfullySent PointSuccessfullySent
+	// journal forgets the transactions once they are processed, when the crash-safe mode is enabled
+	journal *retry.TransactionJournal
 
 	// The maximum number of HTTP requests we can have inflight at any one time.
 	maxConcurrentRequests *semaphore.Weighted
@@ -200,6 +203,7 @@ func (w *Worker) process(ctx context.Context, t transaction.Transaction) {
 	} else {
 		w.pointSuccessfullySent.OnPointSuccessfullySent(t.GetPointCount())
 		w.blockedList.recover(target)
+		w.journal.Release(t)
 	}
 }
 
@@ -208,5 +212,6 @@ func (w *Worker) requeue(t transaction.Transaction) {
 	case w.RequeueChan <- t:
 	default:
 		w.log.Errorf("dropping transaction because the retry goroutine is too busy to handle another one")
+		w.journal.Release(t)
 	}
 }
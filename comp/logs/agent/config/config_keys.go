@@ -112,7 +112,7 @@ func (l *LogsConfigKeys) devModeUseProto() bool {
 func (l *LogsConfigKeys) compressionKind() string {
 	compressionKind := l.getConfig().GetString(l.getConfigKey("compression_kind"))
 	switch compressionKind {
-	case "zstd", "gzip":
+	case "zstd", "gzip", "lz4", "snappy":
 		log.Debugf("Logs agent is using: %s compression", compressionKind)
 		return compressionKind
 	default:
@@ -655,6 +655,9 @@ func (suite *EndpointsTestSuite) TestLogCompressionKind() {
 	suite.config.SetWithoutSource("logs_config.compression_kind", "zstd")
 	suite.Equal(logsConfig.compressionKind(), "zstd")
 
+	suite.config.SetWithoutSource("logs_config.compression_kind", "lz4")
+	suite.Equal(logsConfig.compressionKind(), "lz4")
+
 	suite.config.SetWithoutSource("logs_config.compression_kind", "")
 	suite.Equal(logsConfig.compressionKind(), pkgconfigsetup.DefaultLogCompressionKind)
 
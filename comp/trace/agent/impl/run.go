@@ -81,6 +81,11 @@ func runAgentSidekicks(ag component) error {
 		},
 	})
 
+	if h := ag.Agent.DryRunHandler("/debug/dryrun"); h != nil {
+		// In dry run mode, payloads are kept locally and can be inspected through the debug server.
+		ag.Agent.DebugServer.AddRoute("/debug/dryrun/", h)
+	}
+
 	if secrets, ok := ag.secrets.Get(); ok {
 		// Adding a route to trigger a secrets refresh from the CLI.
 		// TODO - components: the secrets comp already export a route but it requires the API component which is not
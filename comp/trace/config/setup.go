@@ -269,6 +269,7 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	}
 
 	c.ComputeStatsBySpanKind = core.GetBool("apm_config.compute_stats_by_span_kind")
+	c.ExceptionSpanEventsAsErrors = core.GetBool("apm_config.compute_stats_exception_span_events")
 
 	if core.IsSet("apm_config.peer_tags") {
 		c.PeerTags = core.GetStringSlice("apm_config.peer_tags")
@@ -290,6 +291,21 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	if core.IsSet("apm_config.errors_per_second") {
 		c.ErrorTPS = core.GetFloat64("apm_config.errors_per_second")
 	}
+	if k := "apm_config.priority_sampler.budgets"; core.IsSet(k) {
+		budgets := make([]*config.SamplerBudget, 0)
+		if err := structure.UnmarshalKey(core, k, &budgets); err != nil {
+			log.Errorf("Bad format for %q it should be of the form '[{\"service\": \"service_name\",\"env\":\"env_name\",\"weight\":2,\"max_tps\":5}]', error: %v", k, err)
+		} else {
+			c.PrioritySamplerBudgets = budgets
+		}
+	}
+	if k := "apm_config.priority_sampler.min_rate"; core.IsSet(k) {
+		if v := core.GetFloat64(k); v >= 0 && v <= 1 {
+			c.PrioritySamplerMinRate = v
+		} else {
+			log.Errorf("Invalid value for %q: %v, it must be between 0 and 1", k, v)
+		}
+	}
 	if core.IsSet("apm_config.enable_rare_sampler") {
 		c.RareSamplerEnabled = core.GetBool("apm_config.enable_rare_sampler")
 	}
@@ -531,6 +547,8 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	if core.IsSet("apm_config.sync_flushing") {
 		c.SynchronousFlushing = core.GetBool("apm_config.sync_flushing")
 	}
+	c.DryRun = core.GetBool("apm_config.dry_run.enabled")
+	c.DryRunBufferSize = core.GetInt("apm_config.dry_run.buffer_size")
 
 	// undocumented deprecated
 	if core.IsSet("apm_config.analyzed_rate_by_service") {
@@ -224,6 +224,7 @@ func init() {
 	tagsetTlm = newTagsetTelemetry([]uint64{90, 100})
 
 	aggregatorExpvars.Set("MetricTags", expvar.Func(expMetricTags))
+	aggregatorExpvars.Set("RecordingRules", expvar.Func(expRecordingRules))
 }
 
 // BufferedAggregator aggregates metrics in buckets for dogstatsd Metrics
@@ -905,6 +906,16 @@ func (agg *BufferedAggregator) configIDTags() []string {
 	return tag
 }
 
+// visitCheckContextResolvers calls visit with the context resolver of every check sampler.
+func (agg *BufferedAggregator) visitCheckContextResolvers(visit func(check string, cr *contextResolver)) {
+	agg.mu.Lock()
+	defer agg.mu.Unlock()
+
+	for id, sampler := range agg.checkSamplers {
+		visit(string(id), sampler.contextResolver.resolver)
+	}
+}
+
 func (agg *BufferedAggregator) updateChecksTelemetry() {
 	agg.mu.Lock()
 	defer agg.mu.Unlock()
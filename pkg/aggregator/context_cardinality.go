@@ -0,0 +1,135 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"sort"
+	"strings"
+)
+
+// ContextCardinality summarizes the contexts tracked by the DogStatsD time samplers.
+type ContextCardinality struct {
+	// Contexts is the total number of contexts.
+	Contexts int
+	// GlobalLimit is the sum of the global context limits of the time samplers, 0 when unlimited.
+	GlobalLimit int
+	// Metrics holds the summary of each metric, by decreasing number of contexts.
+	Metrics []MetricCardinality
+}
+
+// MetricCardinality summarizes the contexts of a metric.
+type MetricCardinality struct {
+	Name     string
+	Contexts int
+	// Limit is the context limit of the metric in each time sampler, 0 when unlimited.
+	Limit int
+	// LimitedSamples is the number of samples which reached a context limit since the start.
+	LimitedSamples uint64
+	// TagKeys holds the number of distinct values of each tag key, by decreasing cardinality.
+	TagKeys []TagKeyCardinality
+}
+
+// TagKeyCardinality is the number of distinct values of a tag key.
+type TagKeyCardinality struct {
+	Key    string
+	Values int
+}
+
+// metricContexts accumulates the contexts of a metric, possibly from several resolvers.
+type metricContexts struct {
+	contexts       int
+	limitedSamples uint64
+	tagValues      map[string]map[string]struct{}
+}
+
+// cardinalityAccumulator accumulates the contexts of several resolvers.
+type cardinalityAccumulator struct {
+	contexts    int
+	globalLimit int
+	metricLimit func(string) int
+	metrics     map[string]*metricContexts
+}
+
+func newCardinalityAccumulator() *cardinalityAccumulator {
+	return &cardinalityAccumulator{
+		metricLimit: func(string) int { return 0 },
+		metrics:     make(map[string]*metricContexts),
+	}
+}
+
+func (a *cardinalityAccumulator) metric(name string) *metricContexts {
+	m, ok := a.metrics[name]
+	if !ok {
+		m = &metricContexts{tagValues: make(map[string]map[string]struct{})}
+		a.metrics[name] = m
+	}
+	return m
+}
+
+// addResolver adds the contexts of the given resolver. It must be called from the goroutine
+// owning the resolver.
+func (a *cardinalityAccumulator) addResolver(cr *contextResolver) {
+	a.contexts += len(cr.contextsByKey)
+	for _, entry := range cr.contextsByKey {
+		m := a.metric(entry.context.Name)
+		m.contexts++
+		entry.context.Tags().ForEach(func(tag string) {
+			key, value, _ := strings.Cut(tag, ":")
+			if m.tagValues[key] == nil {
+				m.tagValues[key] = make(map[string]struct{})
+			}
+			m.tagValues[key][value] = struct{}{}
+		})
+	}
+
+	if cr.limiter != nil {
+		limits := cr.limiter.limits
+		a.globalLimit += limits.global
+		a.metricLimit = limits.metricLimit
+		for name, count := range cr.limiter.limitedSamples {
+			a.metric(name).limitedSamples += count
+		}
+	}
+}
+
+// result returns the accumulated summary.
+func (a *cardinalityAccumulator) result() ContextCardinality {
+	result := ContextCardinality{
+		Contexts:    a.contexts,
+		GlobalLimit: a.globalLimit,
+		Metrics:     make([]MetricCardinality, 0, len(a.metrics)),
+	}
+	for name, m := range a.metrics {
+		mc := MetricCardinality{
+			Name:           name,
+			Contexts:       m.contexts,
+			Limit:          a.metricLimit(name),
+			LimitedSamples: m.limitedSamples,
+			TagKeys:        make([]TagKeyCardinality, 0, len(m.tagValues)),
+		}
+		for key, values := range m.tagValues {
+			mc.TagKeys = append(mc.TagKeys, TagKeyCardinality{Key: key, Values: len(values)})
+		}
+		sort.Slice(mc.TagKeys, func(i, j int) bool {
+			if mc.TagKeys[i].Values != mc.TagKeys[j].Values {
+				return mc.TagKeys[i].Values > mc.TagKeys[j].Values
+			}
+			return mc.TagKeys[i].Key < mc.TagKeys[j].Key
+		})
+		result.Metrics = append(result.Metrics, mc)
+	}
+	sort.Slice(result.Metrics, func(i, j int) bool {
+		mi, mj := result.Metrics[i], result.Metrics[j]
+		if mi.Contexts != mj.Contexts {
+			return mi.Contexts > mj.Contexts
+		}
+		if mi.LimitedSamples != mj.LimitedSamples {
+			return mi.LimitedSamples > mj.LimitedSamples
+		}
+		return mi.Name < mj.Name
+	})
+	return result
+}
@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"strings"
+
+	diagnose "github.com/DataDog/datadog-agent/comp/core/diagnose/def"
+)
+
+const (
+	// cardinalityTopMetrics is the number of metrics reported by the diagnosis
+	cardinalityTopMetrics = 10
+	// cardinalityTopTagKeys is the number of tag keys reported for each metric
+	cardinalityTopTagKeys = 3
+	// cardinalityWarningRatio is the ratio of a context limit above which a warning is reported
+	cardinalityWarningRatio = 0.8
+)
+
+// DiagnoseContextCardinality returns the diagnoses of the cardinality of the DogStatsD contexts:
+// one for all the contexts, and one for each of the metrics with the most contexts.
+func DiagnoseContextCardinality(demux DemultiplexerWithAggregator) []diagnose.Diagnosis {
+	return diagnoseContextCardinality(demux.DogstatsdContextCardinality())
+}
+
+func diagnoseContextCardinality(c ContextCardinality) []diagnose.Diagnosis {
+	remediation := "Remove the tags with the most values from the metrics, or raise the limits in the dogstatsd_context_limits settings."
+
+	global := diagnose.Diagnosis{
+		Status:    diagnose.DiagnosisSuccess,
+		Name:      "DogStatsD contexts",
+		Category:  "dogstatsd",
+		Diagnosis: fmt.Sprintf("%d contexts for %d metrics", c.Contexts, len(c.Metrics)),
+	}
+	if c.GlobalLimit > 0 {
+		global.Diagnosis += fmt.Sprintf(", the global limit is %d", c.GlobalLimit)
+		if float64(c.Contexts) >= cardinalityWarningRatio*float64(c.GlobalLimit) {
+			global.Status = diagnose.DiagnosisWarning
+			global.Remediation = remediation
+		}
+	}
+	diagnoses := []diagnose.Diagnosis{global}
+
+	for i, m := range c.Metrics {
+		if i == cardinalityTopMetrics {
+			break
+		}
+		d := diagnose.Diagnosis{
+			Status:    diagnose.DiagnosisSuccess,
+			Name:      "DogStatsD contexts of " + m.Name,
+			Category:  "dogstatsd",
+			Diagnosis: fmt.Sprintf("%d contexts", m.Contexts),
+		}
+		if m.Limit > 0 {
+			d.Diagnosis += fmt.Sprintf(" for a limit of %d", m.Limit)
+			if float64(m.Contexts) >= cardinalityWarningRatio*float64(m.Limit) {
+				d.Status = diagnose.DiagnosisWarning
+			}
+		}
+		if m.LimitedSamples > 0 {
+			d.Diagnosis += fmt.Sprintf(", %d samples reached a context limit", m.LimitedSamples)
+			d.Status = diagnose.DiagnosisWarning
+		}
+		if len(m.TagKeys) > 0 {
+			keys := make([]string, 0, cardinalityTopTagKeys)
+			for j, k := range m.TagKeys {
+				if j == cardinalityTopTagKeys {
+					break
+				}
+				keys = append(keys, fmt.Sprintf("%s (%d values)", k.Key, k.Values))
+			}
+			d.Diagnosis += ", top tag keys: " + strings.Join(keys, ", ")
+		}
+		if d.Status != diagnose.DiagnosisSuccess {
+			d.Remediation = remediation
+		}
+		diagnoses = append(diagnoses, d)
+	}
+	return diagnoses
+}
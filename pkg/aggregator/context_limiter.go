@@ -0,0 +1,294 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// contextLimitOverflowTag is the only tag of the contexts new contexts are folded into
+	// once a limit is reached.
+	contextLimitOverflowTag = "context_limit:overflow"
+
+	// maxLimitedMetrics bounds the number of metric names for which limited samples are counted.
+	maxLimitedMetrics = 1000
+
+	contextLimitReasonGlobal = "global"
+	contextLimitReasonMetric = "metric"
+)
+
+var tlmContextLimitSamples = telemetry.NewCounter("aggregator", "context_limit_samples",
+	[]string{"shard", "reason", "action"}, "Count the samples of new contexts which reached a context limit, by action taken")
+
+// contextLimitPolicy is what happens to a new context once a limit is reached.
+type contextLimitPolicy int
+
+const (
+	// contextLimitDrop drops the samples of the new context.
+	contextLimitDrop contextLimitPolicy = iota
+	// contextLimitStripTag removes the tags with the highest cardinality from the new context
+	// until it matches an existing context, or fits in the budget of stripped contexts.
+	contextLimitStripTag
+	// contextLimitOverflow folds the new context into a single overflow context per metric. Once
+	// the global limit is reached, the metrics without an overflow context are dropped when the
+	// budget of limited contexts is exhausted.
+	contextLimitOverflow
+)
+
+func (p contextLimitPolicy) String() string {
+	switch p {
+	case contextLimitStripTag:
+		return "strip_tag"
+	case contextLimitOverflow:
+		return "overflow"
+	default:
+		return "drop"
+	}
+}
+
+// contextLimits holds the configured context limits. A limit of 0 means no limit.
+type contextLimits struct {
+	global       int
+	perMetric    int
+	metricLimits map[string]int
+	policy       contextLimitPolicy
+}
+
+// contextLimitsFromConfig returns the DogStatsD context limits found in the configuration,
+// or nil when no limit is configured.
+func contextLimitsFromConfig(cfg model.Reader) *contextLimits {
+	l := &contextLimits{
+		global:       cfg.GetInt("dogstatsd_context_limits.global"),
+		perMetric:    cfg.GetInt("dogstatsd_context_limits.per_metric"),
+		metricLimits: make(map[string]int),
+	}
+	for name, value := range cfg.GetStringMapString("dogstatsd_context_limits.metric_limits") {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			log.Warnf("Ignoring invalid context limit %q for metric %s", value, name)
+			continue
+		}
+		l.metricLimits[name] = limit
+	}
+
+	switch policy := cfg.GetString("dogstatsd_context_limits.policy"); policy {
+	case "", "drop":
+		l.policy = contextLimitDrop
+	case "strip_tag":
+		l.policy = contextLimitStripTag
+	case "overflow":
+		l.policy = contextLimitOverflow
+	default:
+		log.Warnf("Unknown dogstatsd_context_limits.policy %q, dropping the new contexts instead", policy)
+	}
+
+	if l.global <= 0 && l.perMetric <= 0 && len(l.metricLimits) == 0 {
+		return nil
+	}
+	return l
+}
+
+// metricLimit returns the limit of contexts of the given metric.
+func (l *contextLimits) metricLimit(name string) int {
+	if limit, ok := l.metricLimits[name]; ok {
+		return limit
+	}
+	return l.perMetric
+}
+
+// contextLimiter enforces context limits on the contexts tracked by a contextResolver.
+//
+// Contexts created by the strip_tag policy are counted apart, in a budget of the same size
+// as the limit which was reached, so that the memory used by the resolver stays bounded. The
+// overflow contexts created once the global limit is reached share the budget of the global
+// limit, every new metric name needing its own overflow context.
+type contextLimiter struct {
+	limits *contextLimits
+	shard  string
+
+	// contexts and contextsByName count the regular contexts
+	contexts       int
+	contextsByName map[string]int
+	// limitedContexts and limitedByName count the contexts created by the strip_tag and overflow policies
+	limitedContexts int
+	limitedByName   map[string]int
+
+	// limitedSamples counts the samples which reached a limit, by metric name
+	limitedSamples map[string]uint64
+	// tagKeyRankings caches the tag keys of each limited metric, sorted by decreasing
+	// cardinality. It is reset every time contexts expire.
+	tagKeyRankings map[string][]string
+}
+
+func newContextLimiter(limits *contextLimits, shard string) *contextLimiter {
+	return &contextLimiter{
+		limits:         limits,
+		shard:          shard,
+		contextsByName: make(map[string]int),
+		limitedByName:  make(map[string]int),
+		limitedSamples: make(map[string]uint64),
+		tagKeyRankings: make(map[string][]string),
+	}
+}
+
+// add accounts for a new context.
+func (l *contextLimiter) add(c *Context) {
+	if c.limited {
+		l.limitedContexts++
+		l.limitedByName[c.Name]++
+		return
+	}
+	l.contexts++
+	l.contextsByName[c.Name]++
+}
+
+// remove accounts for a removed context.
+func (l *contextLimiter) remove(c *Context) {
+	counts, total := l.contextsByName, &l.contexts
+	if c.limited {
+		counts, total = l.limitedByName, &l.limitedContexts
+	}
+	*total--
+	if counts[c.Name] <= 1 {
+		delete(counts, c.Name)
+	} else {
+		counts[c.Name]--
+	}
+}
+
+// expired is called once the expired contexts have been removed.
+func (l *contextLimiter) expired() {
+	clear(l.tagKeyRankings)
+}
+
+// exceeded returns the limit a new context of the given metric would exceed, and its value.
+func (l *contextLimiter) exceeded(name string) (string, int) {
+	if l.limits.global > 0 && l.contexts >= l.limits.global {
+		return contextLimitReasonGlobal, l.limits.global
+	}
+	if limit := l.limits.metricLimit(name); limit > 0 && l.contextsByName[name] >= limit {
+		return contextLimitReasonMetric, limit
+	}
+	return "", 0
+}
+
+// hasStrippedBudget returns whether a new context can be created by the strip_tag policy.
+func (l *contextLimiter) hasStrippedBudget(name, reason string, limit int) bool {
+	if reason == contextLimitReasonGlobal {
+		return l.limitedContexts < limit
+	}
+	return l.limitedByName[name] < limit
+}
+
+// limit applies the limits to a new context, whose tags are in the tagger and metric buffers
+// of the resolver. It returns the keys of the context the sample must be aggregated in, whether
+// this context was created because of a limit, and false if the sample must be dropped.
+func (l *contextLimiter) limit(cr *contextResolver, sample metrics.MetricSampleContext, contextKey ckey.ContextKey, taggerKey, metricKey ckey.TagsKey) (ckey.ContextKey, ckey.TagsKey, ckey.TagsKey, bool, bool) {
+	name := sample.GetName()
+	reason, limit := l.exceeded(name)
+	if reason == "" {
+		return contextKey, taggerKey, metricKey, false, true
+	}
+
+	if len(l.limitedSamples) < maxLimitedMetrics || l.limitedSamples[name] > 0 {
+		l.limitedSamples[name]++
+	}
+
+	switch l.limits.policy {
+	case contextLimitStripTag:
+		for _, key := range l.tagKeyRanking(cr, name) {
+			// strip the key from both buffers
+			stripped := stripTagKey(cr.taggerBuffer, key)
+			stripped = stripTagKey(cr.metricBuffer, key) || stripped
+			if !stripped {
+				continue
+			}
+			contextKey, taggerKey, metricKey = cr.generateContextKey(sample)
+			if _, ok := cr.contextsByKey[contextKey]; ok || l.hasStrippedBudget(name, reason, limit) {
+				tlmContextLimitSamples.Inc(l.shard, reason, "stripped")
+				return contextKey, taggerKey, metricKey, true, true
+			}
+		}
+		fallthrough
+	case contextLimitOverflow:
+		cr.taggerBuffer.Reset()
+		cr.metricBuffer.Reset()
+		cr.metricBuffer.Append(contextLimitOverflowTag)
+		contextKey, taggerKey, metricKey = cr.generateContextKey(sample)
+		if _, ok := cr.contextsByKey[contextKey]; !ok && reason == contextLimitReasonGlobal && !l.hasStrippedBudget(name, reason, limit) {
+			tlmContextLimitSamples.Inc(l.shard, reason, "dropped")
+			return contextKey, taggerKey, metricKey, false, false
+		}
+		tlmContextLimitSamples.Inc(l.shard, reason, "overflow")
+		return contextKey, taggerKey, metricKey, true, true
+	default:
+		tlmContextLimitSamples.Inc(l.shard, reason, "dropped")
+		return contextKey, taggerKey, metricKey, false, false
+	}
+}
+
+// tagKeyRanking returns the keys of the tags of the given metric, sorted by decreasing number
+// of distinct values.
+func (l *contextLimiter) tagKeyRanking(cr *contextResolver, name string) []string {
+	if ranking, ok := l.tagKeyRankings[name]; ok {
+		return ranking
+	}
+
+	values := make(map[string]map[string]struct{})
+	for _, entry := range cr.contextsByKey {
+		if entry.context.Name != name {
+			continue
+		}
+		entry.context.Tags().ForEach(func(tag string) {
+			key, value, _ := strings.Cut(tag, ":")
+			if values[key] == nil {
+				values[key] = make(map[string]struct{})
+			}
+			values[key][value] = struct{}{}
+		})
+	}
+	ranking := make([]string, 0, len(values))
+	for key := range values {
+		ranking = append(ranking, key)
+	}
+	sort.Slice(ranking, func(i, j int) bool {
+		if len(values[ranking[i]]) != len(values[ranking[j]]) {
+			return len(values[ranking[i]]) > len(values[ranking[j]])
+		}
+		return ranking[i] < ranking[j]
+	})
+
+	l.tagKeyRankings[name] = ranking
+	return ranking
+}
+
+// stripTagKey removes the tags with the given key from the accumulator, and returns whether
+// any tag was removed.
+func stripTagKey(acc *tagset.HashingTagsAccumulator, key string) bool {
+	tags := acc.Get()
+	kept := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if k, _, _ := strings.Cut(tag, ":"); k != key {
+			kept = append(kept, tag)
+		}
+	}
+	if len(kept) == len(tags) {
+		return false
+	}
+	acc.Reset()
+	acc.Append(kept...)
+	return true
+}
@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package aggregator
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	diagnose "github.com/DataDog/datadog-agent/comp/core/diagnose/def"
+	nooptagger "github.com/DataDog/datadog-agent/comp/core/tagger/impl-noop"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newLimitedTestResolver(store *tags.Store, limits *contextLimits) *timestampContextResolver {
+	return newTimestampContextResolver(nooptagger.NewComponent(), store, "test", 2, 4, limits)
+}
+
+func trackLimitedSample(cr *timestampContextResolver, name string, timestamp int64, tags ...string) (*Context, bool) {
+	key, ok := cr.trackContext(&metrics.MetricSample{Name: name, Tags: tags, Mtype: metrics.GaugeType}, timestamp)
+	if !ok {
+		return nil, false
+	}
+	context, _ := cr.get(key)
+	return context, true
+}
+
+func TestContextLimitsFromConfig(t *testing.T) {
+	cfg := configmock.New(t)
+	assert.Nil(t, contextLimitsFromConfig(cfg), "no limit by default")
+
+	cfg.SetWithoutSource("dogstatsd_context_limits.per_metric", 10)
+	cfg.SetWithoutSource("dogstatsd_context_limits.metric_limits", map[string]interface{}{"big.metric": 100, "bad.metric": "lots"})
+	cfg.SetWithoutSource("dogstatsd_context_limits.policy", "strip_tag")
+	limits := contextLimitsFromConfig(cfg)
+	require.NotNil(t, limits)
+	assert.Equal(t, contextLimitStripTag, limits.policy)
+	assert.Equal(t, 0, limits.global)
+	assert.Equal(t, 10, limits.metricLimit("some.metric"))
+	assert.Equal(t, 100, limits.metricLimit("big.metric"))
+	assert.Equal(t, 10, limits.metricLimit("bad.metric"))
+
+	cfg.SetWithoutSource("dogstatsd_context_limits.policy", "unknown")
+	assert.Equal(t, contextLimitDrop, contextLimitsFromConfig(cfg).policy)
+}
+
+func testContextLimitDrop(t *testing.T, store *tags.Store) {
+	cr := newLimitedTestResolver(store, &contextLimits{global: 3, perMetric: 2, policy: contextLimitDrop})
+
+	for i := 0; i < 3; i++ {
+		_, ok := trackLimitedSample(cr, "foo", 0, "pod:"+strconv.Itoa(i))
+		assert.Equal(t, i < 2, ok)
+	}
+	_, ok := trackLimitedSample(cr, "foo", 0, "pod:0")
+	assert.True(t, ok, "existing contexts are still tracked")
+
+	_, ok = trackLimitedSample(cr, "bar", 0)
+	assert.True(t, ok)
+	_, ok = trackLimitedSample(cr, "baz", 0)
+	assert.False(t, ok, "global limit reached")
+	assert.Equal(t, 3, cr.length())
+	assert.Equal(t, map[string]uint64{"foo": 1, "baz": 1}, cr.resolver.limiter.limitedSamples)
+
+	// expired contexts free some room
+	trackLimitedSample(cr, "foo", 10, "pod:0")
+	cr.expireContexts(10)
+	assert.Equal(t, 1, cr.length())
+	assert.Equal(t, map[string]int{"foo": 1}, cr.resolver.limiter.contextsByName)
+	_, ok = trackLimitedSample(cr, "baz", 10)
+	assert.True(t, ok)
+}
+
+func TestContextLimitDrop(t *testing.T) {
+	testWithTagsStore(t, testContextLimitDrop)
+}
+
+func testContextLimitOverflow(t *testing.T, store *tags.Store) {
+	cr := newLimitedTestResolver(store, &contextLimits{perMetric: 2, policy: contextLimitOverflow})
+
+	trackLimitedSample(cr, "foo", 0, "pod:0", "env:prod")
+	trackLimitedSample(cr, "foo", 0, "pod:1", "env:prod")
+	overflow1, ok := trackLimitedSample(cr, "foo", 0, "pod:2", "env:prod")
+	require.True(t, ok)
+	overflow2, ok := trackLimitedSample(cr, "foo", 0, "pod:3", "env:staging")
+	require.True(t, ok)
+
+	assert.Same(t, overflow1, overflow2)
+	assertContext(t, overflow1, "foo", []string{contextLimitOverflowTag}, "")
+	assert.True(t, overflow1.limited)
+	assert.Equal(t, 3, cr.length())
+	assert.Equal(t, 1, cr.resolver.limiter.limitedContexts)
+}
+
+func TestContextLimitOverflow(t *testing.T) {
+	testWithTagsStore(t, testContextLimitOverflow)
+}
+
+func testContextLimitGlobalOverflow(t *testing.T, store *tags.Store) {
+	cr := newLimitedTestResolver(store, &contextLimits{global: 10, policy: contextLimitOverflow})
+
+	// flood the resolver with distinct metric names
+	tracked := 0
+	for i := 0; i < 1000; i++ {
+		if _, ok := trackLimitedSample(cr, "metric."+strconv.Itoa(i), 0, "pod:"+strconv.Itoa(i)); ok {
+			tracked++
+		}
+	}
+	assert.Equal(t, 20, tracked, "10 regular contexts and 10 overflow contexts")
+	assert.Equal(t, 20, cr.length())
+	assert.Equal(t, 10, cr.resolver.limiter.limitedContexts)
+
+	// the existing overflow contexts are still used
+	overflow, ok := trackLimitedSample(cr, "metric.10", 0, "pod:other")
+	require.True(t, ok)
+	assertContext(t, overflow, "metric.10", []string{contextLimitOverflowTag}, "")
+	assert.Equal(t, 20, cr.length())
+}
+
+func TestContextLimitGlobalOverflow(t *testing.T) {
+	testWithTagsStore(t, testContextLimitGlobalOverflow)
+}
+
+func testContextLimitStripTag(t *testing.T, store *tags.Store) {
+	cr := newLimitedTestResolver(store, &contextLimits{perMetric: 3, policy: contextLimitStripTag})
+
+	trackLimitedSample(cr, "foo", 0, "pod:0", "env:prod", "service:web")
+	trackLimitedSample(cr, "foo", 0, "pod:1", "env:prod", "service:web")
+	trackLimitedSample(cr, "foo", 0, "pod:2", "env:prod", "service:api")
+	assert.Equal(t, []string{"pod", "service", "env"}, cr.resolver.limiter.tagKeyRanking(cr.resolver, "foo"))
+
+	// pod has the most values, it is stripped first
+	stripped, ok := trackLimitedSample(cr, "foo", 0, "pod:3", "env:prod", "service:web")
+	require.True(t, ok)
+	assertContext(t, stripped, "foo", []string{"env:prod", "service:web"}, "")
+	assert.True(t, stripped.limited)
+
+	again, _ := trackLimitedSample(cr, "foo", 0, "pod:4", "env:prod", "service:web")
+	assert.Same(t, stripped, again)
+
+	trackLimitedSample(cr, "foo", 0, "pod:5", "env:prod", "service:db")
+	trackLimitedSample(cr, "foo", 0, "pod:6", "env:prod", "service:worker")
+	assert.Equal(t, 3, cr.resolver.limiter.limitedContexts)
+
+	// the budget of stripped contexts is exhausted: pod then service are stripped, and the
+	// context is folded in the overflow context
+	overflow, ok := trackLimitedSample(cr, "foo", 0, "pod:7", "env:prod", "service:queue")
+	require.True(t, ok)
+	assertContext(t, overflow, "foo", []string{contextLimitOverflowTag}, "")
+	assert.Equal(t, 7, cr.length())
+}
+
+func TestContextLimitStripTag(t *testing.T) {
+	testWithTagsStore(t, testContextLimitStripTag)
+}
+
+func TestContextCardinality(t *testing.T) {
+	store := tags.NewStore(true, "test")
+	limits := &contextLimits{global: 10, perMetric: 3, policy: contextLimitDrop}
+	cr1 := newLimitedTestResolver(store, limits)
+	cr2 := newLimitedTestResolver(store, limits)
+	for i := 0; i < 4; i++ {
+		trackLimitedSample(cr1, "foo", 0, "pod:"+strconv.Itoa(i), "env:prod")
+	}
+	trackLimitedSample(cr2, "foo", 0, "pod:10", "env:staging")
+	trackLimitedSample(cr2, "bar", 0)
+
+	acc := newCardinalityAccumulator()
+	acc.addResolver(cr1.resolver)
+	acc.addResolver(cr2.resolver)
+	result := acc.result()
+
+	assert.Equal(t, ContextCardinality{
+		Contexts:    5,
+		GlobalLimit: 20,
+		Metrics: []MetricCardinality{
+			{
+				Name:           "foo",
+				Contexts:       4,
+				Limit:          3,
+				LimitedSamples: 1,
+				TagKeys:        []TagKeyCardinality{{Key: "pod", Values: 4}, {Key: "env", Values: 2}},
+			},
+			{Name: "bar", Contexts: 1, Limit: 3, TagKeys: []TagKeyCardinality{}},
+		},
+	}, result)
+
+	diagnoses := diagnoseContextCardinality(result)
+	require.Len(t, diagnoses, 3)
+	assert.Equal(t, diagnose.DiagnosisSuccess, diagnoses[0].Status)
+	assert.Equal(t, "5 contexts for 2 metrics, the global limit is 20", diagnoses[0].Diagnosis)
+	assert.Equal(t, diagnose.DiagnosisWarning, diagnoses[1].Status)
+	assert.Equal(t, "4 contexts for a limit of 3, 1 samples reached a context limit, top tag keys: pod (4 values), env (2 values)", diagnoses[1].Diagnosis)
+	assert.NotEmpty(t, diagnoses[1].Remediation)
+	assert.Equal(t, diagnose.DiagnosisSuccess, diagnoses[2].Status)
+	assert.Equal(t, "1 contexts for a limit of 3", diagnoses[2].Diagnosis)
+}
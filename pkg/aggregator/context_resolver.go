@@ -26,7 +26,9 @@ type Context struct {
 	taggerTags *tags.Entry
 	metricTags *tags.Entry
 	noIndex    bool
-	source     metrics.MetricSource
+	// limited is true for the contexts created because of a context limit
+	limited bool
+	source  metrics.MetricSource
 }
 
 type resolverEntry struct {
@@ -76,6 +78,8 @@ type contextResolver struct {
 	keyGenerator     *ckey.KeyGenerator
 	taggerBuffer     *tagset.HashingTagsAccumulator
 	metricBuffer     *tagset.HashingTagsAccumulator
+	// limiter enforces the context limits, it is nil when there is no limit
+	limiter *contextLimiter
 }
 
 // generateContextKey generates the contextKey associated with the context of the metricSample
@@ -99,15 +103,27 @@ func newContextResolver(tagger tagger.Component, cache *tags.Store, id string) *
 	}
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, timestamp int64) ckey.ContextKey {
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context.
+// It returns false when the sample reached a context limit and must be dropped.
+func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, timestamp int64) (ckey.ContextKey, bool) {
 	metricSampleContext.GetTags(cr.taggerBuffer, cr.metricBuffer, cr.tagger.EnrichTags) // tags here are not sorted and can contain duplicates
 	defer cr.taggerBuffer.Reset()
 	defer cr.metricBuffer.Reset()
 
 	contextKey, taggerKey, metricKey := cr.generateContextKey(metricSampleContext) // the generator will remove duplicates (and doesn't mind the order)
 
-	if entry, ok := cr.contextsByKey[contextKey]; !ok {
+	entry, ok := cr.contextsByKey[contextKey]
+	limited := false
+	if !ok && cr.limiter != nil {
+		var tracked bool
+		contextKey, taggerKey, metricKey, limited, tracked = cr.limiter.limit(cr, metricSampleContext, contextKey, taggerKey, metricKey)
+		if !tracked {
+			return contextKey, false
+		}
+		entry, ok = cr.contextsByKey[contextKey]
+	}
+
+	if !ok {
 		mtype := metricSampleContext.GetMetricType()
 		context := &Context{
 			Name:       metricSampleContext.GetName(),
@@ -116,12 +132,16 @@ func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSample
 			Host:       metricSampleContext.GetHost(),
 			mtype:      mtype,
 			noIndex:    metricSampleContext.IsNoIndex(),
+			limited:    limited,
 			source:     metricSampleContext.GetSource(),
 		}
 		cr.contextsByKey[contextKey] = resolverEntry{
 			lastSeen: timestamp,
 			context:  context,
 		}
+		if cr.limiter != nil {
+			cr.limiter.add(context)
+		}
 
 		cr.seendByMtype[mtype] = true
 		cr.countsByMtype[mtype]++
@@ -135,7 +155,7 @@ func (cr *contextResolver) trackContext(metricSampleContext metrics.MetricSample
 		}
 	}
 
-	return contextKey
+	return contextKey, true
 }
 
 func (cr *contextResolver) get(key ckey.ContextKey) (*Context, bool) {
@@ -155,6 +175,9 @@ func (cr *contextResolver) remove(expiredContextKey ckey.ContextKey) {
 		cr.countsByMtype[context.mtype]--
 		cr.bytesByMtype[context.mtype] -= uint64(context.SizeInBytes())
 		cr.dataBytesByMtype[context.mtype] -= uint64(context.DataSizeInBytes())
+		if cr.limiter != nil {
+			cr.limiter.remove(context)
+		}
 		context.release()
 	}
 }
@@ -221,19 +244,23 @@ type timestampContextResolver struct {
 	counterExpireTime int64
 }
 
-func newTimestampContextResolver(tagger tagger.Component, cache *tags.Store, id string, contextExpireTime, counterExpireTime int64) *timestampContextResolver {
+func newTimestampContextResolver(tagger tagger.Component, cache *tags.Store, id string, contextExpireTime, counterExpireTime int64, limits *contextLimits) *timestampContextResolver {
+	resolver := newContextResolver(tagger, cache, id)
+	if limits != nil {
+		resolver.limiter = newContextLimiter(limits, id)
+	}
 	return &timestampContextResolver{
-		resolver: newContextResolver(tagger, cache, id),
+		resolver: resolver,
 
 		contextExpireTime: contextExpireTime,
 		counterExpireTime: counterExpireTime,
 	}
 }
 
-// trackContext returns the contextKey associated with the context of the metricSample and tracks that context
-func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp int64) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext, currentTimestamp)
-	return contextKey
+// trackContext returns the contextKey associated with the context of the metricSample and tracks that context.
+// It returns false when the sample reached a context limit and must be dropped.
+func (cr *timestampContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext, currentTimestamp int64) (ckey.ContextKey, bool) {
+	return cr.resolver.trackContext(metricSampleContext, currentTimestamp)
 }
 
 func (cr *timestampContextResolver) length() int {
@@ -259,6 +286,9 @@ func (cr *timestampContextResolver) expireContexts(timestamp int64) {
 			cr.resolver.remove(ck)
 		}
 	}
+	if cr.resolver.limiter != nil {
+		cr.resolver.limiter.expired()
+	}
 }
 
 func (cr *timestampContextResolver) sendOriginTelemetry(timestamp float64, series metrics.SerieSink, hostname string, tags []string) {
@@ -300,7 +330,8 @@ func (cr *countBasedContextResolver) updateMetrics(countsByMTypeGauge telemetry.
 
 // trackContext returns the contextKey associated with the context of the metricSample and tracks that context
 func (cr *countBasedContextResolver) trackContext(metricSampleContext metrics.MetricSampleContext) ckey.ContextKey {
-	contextKey := cr.resolver.trackContext(metricSampleContext, cr.expireCount)
+	// check samplers don't enforce context limits, every context is tracked
+	contextKey, _ := cr.resolver.trackContext(metricSampleContext, cr.expireCount)
 	return contextKey
 }
 
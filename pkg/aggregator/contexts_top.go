@@ -0,0 +1,195 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+)
+
+const (
+	// ContextsTopSortByContexts sorts the groups by decreasing number of contexts.
+	ContextsTopSortByContexts = "contexts"
+	// ContextsTopSortByValues sorts the groups by decreasing number of tag values.
+	ContextsTopSortByValues = "values"
+	// ContextsTopSortByName sorts the groups by name.
+	ContextsTopSortByName = "name"
+
+	// contextsTopNoOrigin is the name of the group of the DogStatsD contexts without origin tags.
+	contextsTopNoOrigin = "none"
+)
+
+// ContextsTop reports the contexts currently tracked by the aggregator, grouped by metric name,
+// tag key, DogStatsD origin and check.
+type ContextsTop struct {
+	// DogstatsdContexts is the number of contexts of the DogStatsD time samplers.
+	DogstatsdContexts int `json:"dogstatsd_contexts"`
+	// CheckContexts is the number of contexts of the check samplers.
+	CheckContexts int `json:"check_contexts"`
+
+	Metrics []ContextsGroup `json:"metrics"`
+	TagKeys []ContextsGroup `json:"tag_keys"`
+	// Origins groups the DogStatsD contexts by their origin tags.
+	Origins []ContextsGroup `json:"origins"`
+	Checks  []ContextsGroup `json:"checks"`
+}
+
+// ContextsGroup is the number of contexts sharing a metric name, a tag key, an origin or a check.
+type ContextsGroup struct {
+	Name     string `json:"name"`
+	Contexts int    `json:"contexts"`
+	// Values is the number of distinct values of the tag key, or of tag keys for other groups.
+	Values int `json:"values"`
+}
+
+// Sort sorts the groups of the report and keeps the first limit of each, all of them when limit
+// is 0 or less.
+func (t *ContextsTop) Sort(by string, limit int) error {
+	var less func(a, b ContextsGroup) bool
+	switch by {
+	case "", ContextsTopSortByContexts:
+		less = func(a, b ContextsGroup) bool {
+			if a.Contexts != b.Contexts {
+				return a.Contexts > b.Contexts
+			}
+			return a.Name < b.Name
+		}
+	case ContextsTopSortByValues:
+		less = func(a, b ContextsGroup) bool {
+			if a.Values != b.Values {
+				return a.Values > b.Values
+			}
+			return a.Name < b.Name
+		}
+	case ContextsTopSortByName:
+		less = func(a, b ContextsGroup) bool { return a.Name < b.Name }
+	default:
+		return fmt.Errorf("unknown sort order %q, expected one of %s, %s or %s", by, ContextsTopSortByContexts, ContextsTopSortByValues, ContextsTopSortByName)
+	}
+
+	for _, groups := range []*[]ContextsGroup{&t.Metrics, &t.TagKeys, &t.Origins, &t.Checks} {
+		g := *groups
+		sort.Slice(g, func(i, j int) bool { return less(g[i], g[j]) })
+		if limit > 0 && len(g) > limit {
+			*groups = g[:limit]
+		}
+	}
+	return nil
+}
+
+// contextsGroupAccumulator counts the contexts and distinct values of a group.
+type contextsGroupAccumulator struct {
+	contexts int
+	values   map[string]struct{}
+}
+
+func (g *contextsGroupAccumulator) addValue(value string) {
+	if g.values == nil {
+		g.values = make(map[string]struct{})
+	}
+	g.values[value] = struct{}{}
+}
+
+// contextsTopAccumulator accumulates the contexts of several resolvers into a ContextsTop.
+type contextsTopAccumulator struct {
+	dogstatsdContexts int
+	checkContexts     int
+
+	metrics map[string]*contextsGroupAccumulator
+	tagKeys map[string]*contextsGroupAccumulator
+	origins map[string]int
+	checks  map[string]int
+}
+
+func newContextsTopAccumulator() *contextsTopAccumulator {
+	return &contextsTopAccumulator{
+		metrics: make(map[string]*contextsGroupAccumulator),
+		tagKeys: make(map[string]*contextsGroupAccumulator),
+		origins: make(map[string]int),
+		checks:  make(map[string]int),
+	}
+}
+
+func contextsGroupFor(groups map[string]*contextsGroupAccumulator, name string) *contextsGroupAccumulator {
+	g, ok := groups[name]
+	if !ok {
+		g = &contextsGroupAccumulator{}
+		groups[name] = g
+	}
+	return g
+}
+
+// addContexts adds the metrics and tag keys of the contexts of the given resolver.
+func (a *contextsTopAccumulator) addContexts(cr *contextResolver) {
+	for _, entry := range cr.contextsByKey {
+		m := contextsGroupFor(a.metrics, entry.context.Name)
+		m.contexts++
+		entry.context.Tags().ForEach(func(tag string) {
+			key, value, _ := strings.Cut(tag, ":")
+			m.addValue(key)
+			k := contextsGroupFor(a.tagKeys, key)
+			k.contexts++
+			k.addValue(value)
+		})
+	}
+}
+
+// addDogstatsdResolver adds the contexts of a DogStatsD time sampler. It must be called from
+// the goroutine owning the resolver.
+func (a *contextsTopAccumulator) addDogstatsdResolver(cr *contextResolver) {
+	a.dogstatsdContexts += len(cr.contextsByKey)
+	a.addContexts(cr)
+
+	// Within the resolver, each set of origin tags is represented by a unique pointer.
+	perOrigin := make(map[*tags.Entry]int)
+	for _, entry := range cr.contextsByKey {
+		perOrigin[entry.context.taggerTags]++
+	}
+	for entry, count := range perOrigin {
+		origin := contextsTopNoOrigin
+		if originTags := entry.Tags(); len(originTags) > 0 {
+			origin = strings.Join(originTags, ",")
+		}
+		a.origins[origin] += count
+	}
+}
+
+// addCheckResolver adds the contexts of the sampler of the given check. It must be called
+// with the aggregator lock held.
+func (a *contextsTopAccumulator) addCheckResolver(check string, cr *contextResolver) {
+	a.checkContexts += len(cr.contextsByKey)
+	a.checks[check] += len(cr.contextsByKey)
+	a.addContexts(cr)
+}
+
+// result returns the accumulated report, sorted by decreasing number of contexts.
+func (a *contextsTopAccumulator) result() ContextsTop {
+	t := ContextsTop{
+		DogstatsdContexts: a.dogstatsdContexts,
+		CheckContexts:     a.checkContexts,
+		Metrics:           make([]ContextsGroup, 0, len(a.metrics)),
+		TagKeys:           make([]ContextsGroup, 0, len(a.tagKeys)),
+		Origins:           make([]ContextsGroup, 0, len(a.origins)),
+		Checks:            make([]ContextsGroup, 0, len(a.checks)),
+	}
+	for name, g := range a.metrics {
+		t.Metrics = append(t.Metrics, ContextsGroup{Name: name, Contexts: g.contexts, Values: len(g.values)})
+	}
+	for name, g := range a.tagKeys {
+		t.TagKeys = append(t.TagKeys, ContextsGroup{Name: name, Contexts: g.contexts, Values: len(g.values)})
+	}
+	for name, count := range a.origins {
+		t.Origins = append(t.Origins, ContextsGroup{Name: name, Contexts: count})
+	}
+	for name, count := range a.checks {
+		t.Checks = append(t.Checks, ContextsGroup{Name: name, Contexts: count})
+	}
+	_ = t.Sort(ContextsTopSortByContexts, 0)
+	return t
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package aggregator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	nooptagger "github.com/DataDog/datadog-agent/comp/core/tagger/impl-noop"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/internal/tags"
+)
+
+func TestContextsTopAccumulator(t *testing.T) {
+	store := tags.NewStore(true, "test")
+	statsd := newContextResolver(nooptagger.NewComponent(), store, "test")
+	statsd.trackContext(&mockSample{"foo", []string{"pod_name:a"}, []string{"env:prod", "version:1"}}, 0)
+	statsd.trackContext(&mockSample{"foo", []string{"pod_name:a"}, []string{"env:prod", "version:2"}}, 0)
+	statsd.trackContext(&mockSample{"foo", []string{"pod_name:b"}, []string{"env:staging"}}, 0)
+	statsd.trackContext(&mockSample{"bar", nil, []string{"env:prod"}}, 0)
+
+	check := newContextResolver(nooptagger.NewComponent(), store, "test")
+	check.trackContext(&mockSample{"baz", nil, []string{"env:prod"}}, 0)
+	check.trackContext(&mockSample{"baz", nil, nil}, 0)
+
+	acc := newContextsTopAccumulator()
+	acc.addDogstatsdResolver(statsd)
+	acc.addCheckResolver("disk:abcdef", check)
+	top := acc.result()
+
+	assert.Equal(t, 4, top.DogstatsdContexts)
+	assert.Equal(t, 2, top.CheckContexts)
+	assert.Equal(t, []ContextsGroup{
+		{Name: "foo", Contexts: 3, Values: 3},
+		{Name: "baz", Contexts: 2, Values: 1},
+		{Name: "bar", Contexts: 1, Values: 1},
+	}, top.Metrics)
+	assert.Equal(t, []ContextsGroup{
+		{Name: "env", Contexts: 5, Values: 2},
+		{Name: "pod_name", Contexts: 3, Values: 2},
+		{Name: "version", Contexts: 2, Values: 2},
+	}, top.TagKeys)
+	assert.Equal(t, []ContextsGroup{
+		{Name: "pod_name:a", Contexts: 2},
+		{Name: "none", Contexts: 1},
+		{Name: "pod_name:b", Contexts: 1},
+	}, top.Origins)
+	assert.Equal(t, []ContextsGroup{{Name: "disk:abcdef", Contexts: 2}}, top.Checks)
+}
+
+func TestContextsTopSort(t *testing.T) {
+	top := ContextsTop{
+		Metrics: []ContextsGroup{
+			{Name: "a", Contexts: 1, Values: 5},
+			{Name: "b", Contexts: 3, Values: 1},
+			{Name: "c", Contexts: 2, Values: 5},
+		},
+		TagKeys: []ContextsGroup{{Name: "env", Contexts: 1, Values: 1}},
+	}
+
+	require.NoError(t, top.Sort(ContextsTopSortByValues, 0))
+	assert.Equal(t, []string{"a", "c", "b"}, contextsGroupNames(top.Metrics))
+
+	require.NoError(t, top.Sort(ContextsTopSortByContexts, 2))
+	assert.Equal(t, []string{"b", "c"}, contextsGroupNames(top.Metrics))
+	assert.Len(t, top.TagKeys, 1)
+
+	require.NoError(t, top.Sort(ContextsTopSortByName, 1))
+	assert.Equal(t, []string{"b"}, contextsGroupNames(top.Metrics))
+
+	assert.Error(t, top.Sort("size", 0))
+}
+
+func contextsGroupNames(groups []ContextsGroup) []string {
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	return names
+}
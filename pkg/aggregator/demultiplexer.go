@@ -83,12 +83,21 @@ type flushTrigger struct {
 	seriesSink   metrics.SerieSink
 }
 
+// FlushObserver is notified of every serie and sketch flushed to the serializer, once
+// the host tags have been applied. Its methods are called from the flushing goroutines
+// and must not keep references to the given serie or sketch.
+type FlushObserver interface {
+	ObserveSerie(serie *metrics.Serie)
+	ObserveSketch(sketch *metrics.SketchSeries)
+}
+
 func createIterableMetrics(
 	flushAndSerializeInParallel FlushAndSerializeInParallel,
 	serializer serializer.MetricSerializer,
 	logPayloads bool,
 	isServerless bool,
 	hostTagProvider *HostTagProvider,
+	observer FlushObserver,
 ) (*metrics.IterableSeries, *metrics.IterableSketches) {
 	var series *metrics.IterableSeries
 	var sketches *metrics.IterableSketches
@@ -103,6 +112,9 @@ func createIterableMetrics(
 				se.Tags = tagset.CombineCompositeTagsAndSlice(se.Tags, hostTagProvider.GetHostTags())
 			}
 			tagsetTlm.updateHugeSerieTelemetry(se)
+			if observer != nil {
+				observer.ObserveSerie(se)
+			}
 		}, flushAndSerializeInParallel.BufferSize, flushAndSerializeInParallel.ChannelSize)
 	}
 	if serializer.AreSketchesEnabled() {
@@ -117,6 +129,9 @@ func createIterableMetrics(
 				sketch.Tags = tagset.CombineCompositeTagsAndSlice(sketch.Tags, hostTagProvider.GetHostTags())
 			}
 			tagsetTlm.updateHugeSketchesTelemetry(sketch)
+			if observer != nil {
+				observer.ObserveSketch(sketch)
+			}
 		}, flushAndSerializeInParallel.BufferSize, flushAndSerializeInParallel.ChannelSize)
 	}
 	return series, sketches
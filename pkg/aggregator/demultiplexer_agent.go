@@ -42,6 +42,8 @@ type DemultiplexerWithAggregator interface {
 	GetEventPlatformForwarder() (eventplatform.Forwarder, error)
 	GetEventsAndServiceChecksChannels() (chan []*event.Event, chan []*servicecheck.ServiceCheck)
 	DumpDogstatsdContexts(io.Writer) error
+	DogstatsdContextCardinality() ContextCardinality
+	ContextsTop() ContextsTop
 }
 
 // AgentDemultiplexer is the demultiplexer implementation for the main Agent.
@@ -67,6 +69,9 @@ type AgentDemultiplexer struct {
 
 	hostTagProvider *HostTagProvider
 
+	// recordingRules are the rules rolling up metrics at flush time
+	recordingRules *recordingRules
+
 	// sharded statsd time samplers
 	statsd
 }
@@ -81,6 +86,9 @@ type AgentDemultiplexerOptions struct {
 
 	UseDogstatsdContextLimiter bool
 	DogstatsdMaxMetricsTags    int
+
+	// FlushObserver, when set, is notified of all the series and sketches flushed to the serializer.
+	FlushObserver FlushObserver
 }
 
 // DefaultAgentDemultiplexerOptions returns the default options to initialize an AgentDemultiplexer.
@@ -193,9 +201,16 @@ func initAgentDemultiplexer(log log.Component,
 			noAggSerializer,
 			agg.flushAndSerializeInParallel,
 			tagger,
+			options.FlushObserver,
 		)
 	}
 
+	// recording rules, reloaded each time they are updated at runtime
+	// --
+
+	rules := newRecordingRules()
+	rules.subscribe(pkgconfigsetup.Datadog())
+
 	// --
 	demux := &AgentDemultiplexer{
 		log:       log,
@@ -216,6 +231,7 @@ func initAgentDemultiplexer(log log.Component,
 
 		hostTagProvider: NewHostTagProvider(),
 		senders:         newSenders(agg),
+		recordingRules:  rules,
 
 		// statsd time samplers
 		statsd: statsd{
@@ -366,8 +382,17 @@ func (d *AgentDemultiplexer) Stop(flush bool) {
 		}
 	}
 
+	// serializers
+
+	for _, metricSerializer := range []serializer.MetricSerializer{d.dataOutputs.sharedSerializer, d.dataOutputs.noAggSerializer} {
+		if s, ok := metricSerializer.(*serializer.Serializer); ok {
+			s.Stop()
+		}
+	}
+
 	// misc
 
+	d.recordingRules.unsubscribe()
 	d.dataOutputs.sharedSerializer = nil
 	d.senders = nil
 }
@@ -407,11 +432,20 @@ func (d *AgentDemultiplexer) flushToSerializer(start time.Time, waitForSerialize
 	}
 
 	logPayloads := pkgconfigsetup.Datadog().GetBool("log_payloads")
-	series, sketches := createIterableMetrics(d.aggregator.flushAndSerializeInParallel, d.sharedSerializer, logPayloads, false, d.hostTagProvider)
+	series, sketches := createIterableMetrics(d.aggregator.flushAndSerializeInParallel, d.sharedSerializer, logPayloads, false, d.hostTagProvider, d.options.FlushObserver)
 	metrics.Serialize(
 		series,
 		sketches,
 		func(seriesSink metrics.SerieSink, sketchesSink metrics.SketchesSink) {
+			// apply the recording rules on everything flushed
+			// ------------------------------------------------
+
+			rollups := d.recordingRules.newFlush(seriesSink, sketchesSink)
+			if rollups != nil {
+				seriesSink, sketchesSink = rollups.seriesSink(), rollups.sketchesSink()
+				defer rollups.done()
+			}
+
 			// flush DogStatsD pipelines (statsd/time samplers)
 			// ------------------------------------------------
 
@@ -563,6 +597,47 @@ func (d *AgentDemultiplexer) DumpDogstatsdContexts(dest io.Writer) error {
 	return nil
 }
 
+// DogstatsdContextCardinality returns a summary of the contexts tracked by the DogStatsD
+// time samplers.
+//
+// Like DumpDogstatsdContexts, this blocks metrics processing while the contexts are counted.
+// The summary is empty once the demultiplexer is stopped.
+func (d *AgentDemultiplexer) DogstatsdContextCardinality() ContextCardinality {
+	acc := newCardinalityAccumulator()
+
+	// the time samplers are stopped while the lock is held, and the aggregator is unset
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	if d.aggregator != nil {
+		for _, w := range d.statsd.workers {
+			w.visitContextResolver(acc.addResolver)
+		}
+	}
+	return acc.result()
+}
+
+// ContextsTop returns the contexts currently tracked by the DogStatsD time samplers and the
+// check samplers, grouped by metric name, tag key, origin and check.
+//
+// Like DumpDogstatsdContexts, this blocks metrics processing while the contexts are counted.
+// The result is empty once the demultiplexer is stopped.
+func (d *AgentDemultiplexer) ContextsTop() ContextsTop {
+	acc := newContextsTopAccumulator()
+
+	// see DogstatsdContextCardinality
+	d.m.RLock()
+	defer d.m.RUnlock()
+
+	if d.aggregator != nil {
+		for _, w := range d.statsd.workers {
+			w.visitContextResolver(acc.addDogstatsdResolver)
+		}
+		d.aggregator.visitCheckContextResolvers(acc.addCheckResolver)
+	}
+	return acc.result()
+}
+
 // GetSender returns a sender.Sender with passed ID, properly registered with the aggregator
 // If no error is returned here, DestroySender must be called with the same ID
 // once the sender is not used anymore
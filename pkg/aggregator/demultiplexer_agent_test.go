@@ -9,9 +9,11 @@ package aggregator
 
 import (
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
 
@@ -28,6 +30,7 @@ import (
 	logscompression "github.com/DataDog/datadog-agent/comp/serializer/logscompression/fx-mock"
 	compression "github.com/DataDog/datadog-agent/comp/serializer/metricscompression/def"
 	metricscompression "github.com/DataDog/datadog-agent/comp/serializer/metricscompression/fx-mock"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/util/fxutil"
 )
@@ -113,6 +116,119 @@ func TestDemuxNoAggOptionEnabled(t *testing.T) {
 	}
 }
 
+// the recording rules are not applied on the series of the no-aggregation pipeline.
+func TestDemuxNoAggRecordingRules(t *testing.T) {
+	require := require.New(t)
+
+	noAggWorkerStreamCheckFrequency = 100 * time.Millisecond
+
+	opts := demuxTestOptions()
+	mockSerializer := &MockSerializerIterableSerie{}
+	mockSerializer.On("AreSeriesEnabled").Return(true)
+	mockSerializer.On("AreSketchesEnabled").Return(true)
+	opts.EnableNoAggregationPipeline = true
+	deps := createDemultiplexerAgentTestDeps(t)
+	pkgconfigsetup.Datadog().SetWithoutSource(RecordingRulesConfigKey, []interface{}{
+		map[string]interface{}{"metric": "first", "by": []string{}},
+	})
+	demux := initAgentDemultiplexer(deps.Log, NewForwarderTest(deps.Log), deps.OrchestratorFwd, opts, deps.EventPlatform, deps.HaAgent, deps.Compressor, deps.Tagger, "")
+	demux.statsd.noAggStreamWorker.serializer = mockSerializer
+	require.Contains(demux.recordingRules.byMetric, "first")
+
+	go demux.run()
+
+	batch := testDemuxSamples(t)
+	demux.SendSamplesWithoutAggregation(batch)
+	time.Sleep(200 * time.Millisecond) // give some time for the automatic flush to trigger
+	demux.Stop(true)
+
+	require.Len(mockSerializer.series, 3)
+	require.Equal("first", mockSerializer.series[0].Name)
+	require.ElementsMatch(batch[0].Tags, mockSerializer.series[0].Tags.UnsafeToReadOnlySliceString())
+}
+
+// the downsampling is enabled, the samples are combined per context and interval.
+func TestDemuxNoAggDownsampling(t *testing.T) {
+	require := require.New(t)
+
+	noAggWorkerStreamCheckFrequency = 100 * time.Millisecond
+
+	opts := demuxTestOptions()
+	mockSerializer := &MockSerializerIterableSerie{}
+	mockSerializer.On("AreSeriesEnabled").Return(true)
+	mockSerializer.On("AreSketchesEnabled").Return(true)
+	opts.EnableNoAggregationPipeline = true
+	deps := createDemultiplexerAgentTestDeps(t)
+	pkgconfigsetup.Datadog().SetWithoutSource("dogstatsd_no_aggregation_pipeline_downsampling.interval", 10)
+	demux := initAgentDemultiplexer(deps.Log, NewForwarderTest(deps.Log), deps.OrchestratorFwd, opts, deps.EventPlatform, deps.HaAgent, deps.Compressor, deps.Tagger, "")
+	demux.statsd.noAggStreamWorker.serializer = mockSerializer
+	require.NotNil(demux.statsd.noAggStreamWorker.downsampler)
+
+	go demux.run()
+
+	batch := testDemuxSamples(t)
+	batch = append(batch, metrics.MetricSample{
+		Name:      "second",
+		Value:     10,
+		Mtype:     metrics.CounterType,
+		Timestamp: 1657099129.0,
+		Tags:      []string{"tag:4", "tag:3"},
+	})
+	demux.SendSamplesWithoutAggregation(batch)
+	time.Sleep(200 * time.Millisecond) // give some time for the automatic flush to trigger
+	demux.Stop(true)
+
+	require.Len(mockSerializer.series, 3)
+	points := map[string][]metrics.Point{}
+	for _, serie := range mockSerializer.series {
+		points[serie.Name] = serie.Points
+	}
+	require.Equal(map[string][]metrics.Point{
+		"first":  {{Ts: 1657099120.0, Value: 1}},
+		"second": {{Ts: 1657099120.0, Value: 3}},
+		"third":  {{Ts: 1657099120.0, Value: 6}},
+	}, points)
+}
+
+type recordingFlushObserver struct {
+	mu     sync.Mutex
+	series []string
+}
+
+func (o *recordingFlushObserver) ObserveSerie(serie *metrics.Serie) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.series = append(o.series, serie.Name)
+}
+
+func (o *recordingFlushObserver) ObserveSketch(_ *metrics.SketchSeries) {}
+
+func TestDemuxFlushObserver(t *testing.T) {
+	noAggWorkerStreamCheckFrequency = 100 * time.Millisecond
+
+	observer := &recordingFlushObserver{}
+	opts := demuxTestOptions()
+	opts.EnableNoAggregationPipeline = true
+	opts.FlushObserver = observer
+	mockSerializer := &MockSerializerIterableSerie{}
+	mockSerializer.On("AreSeriesEnabled").Return(true)
+	mockSerializer.On("AreSketchesEnabled").Return(true)
+	deps := createDemultiplexerAgentTestDeps(t)
+	demux := initAgentDemultiplexer(deps.Log, NewForwarderTest(deps.Log), deps.OrchestratorFwd, opts, deps.EventPlatform, deps.HaAgent, deps.Compressor, deps.Tagger, "")
+	demux.statsd.noAggStreamWorker.serializer = mockSerializer
+
+	go demux.run()
+
+	demux.SendSamplesWithoutAggregation(testDemuxSamples(t))
+	time.Sleep(200 * time.Millisecond) // give some time for the automatic flush to trigger
+	demux.Stop(true)
+
+	observer.mu.Lock()
+	defer observer.mu.Unlock()
+	// the no aggregation pipeline and the final flush of the aggregator are both mirrored
+	require.Subset(t, observer.series, []string{"first", "second", "third", "datadog.agent.running"})
+}
+
 func TestDemuxNoAggOptionIsDisabledByDefault(t *testing.T) {
 	opts := demuxTestOptions()
 	deps := fxutil.Test[TestDeps](t,
@@ -127,6 +243,32 @@ func TestDemuxNoAggOptionIsDisabledByDefault(t *testing.T) {
 	demux.Stop(false)
 }
 
+func TestDemuxContextsAfterStop(t *testing.T) {
+	opts := demuxTestOptions()
+	deps := fxutil.Test[TestDeps](t,
+		defaultforwarder.MockModule(),
+		core.MockBundle(),
+		haagentmock.Module(),
+		logscompression.MockModule(),
+		metricscompression.MockModule())
+	demux := InitAndStartAgentDemultiplexerForTest(deps, opts, "")
+	demux.ContextsTop()
+	demux.Stop(false)
+
+	// the time samplers are stopped: the contexts are not visited
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.Empty(t, demux.ContextsTop().Metrics)
+		assert.Empty(t, demux.DogstatsdContextCardinality().Metrics)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the contexts visit is blocked")
+	}
+}
+
 func TestMetricSampleTypeConversion(t *testing.T) {
 	require := require.New(t)
 
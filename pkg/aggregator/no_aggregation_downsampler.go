@@ -0,0 +1,180 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/ckey"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+// downsamplingAggregation is how the gauge samples of a bucket are combined by the downsampler
+type downsamplingAggregation string
+
+// Aggregations supported for the gauges by the downsampler of the no-aggregation pipeline
+const (
+	downsamplingLast  downsamplingAggregation = "last"
+	downsamplingSum   downsamplingAggregation = "sum"
+	downsamplingMin   downsamplingAggregation = "min"
+	downsamplingMax   downsamplingAggregation = "max"
+	downsamplingCount downsamplingAggregation = "count"
+)
+
+func parseDownsamplingAggregation(s string) (downsamplingAggregation, error) {
+	switch a := downsamplingAggregation(s); a {
+	case downsamplingLast, downsamplingSum, downsamplingMin, downsamplingMax, downsamplingCount:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown downsampling aggregation %q, expected one of last, sum, min, max or count", s)
+	}
+}
+
+// downsamplingKey identifies a bucket: a context and the start of the interval it covers
+type downsamplingKey struct {
+	context ckey.ContextKey
+	start   int64
+}
+
+// downsamplingBucket holds the samples received for one context during one interval
+type downsamplingBucket struct {
+	serie *metrics.Serie
+	// lastTs is the timestamp of the sample kept by the `last` aggregation
+	lastTs float64
+	value  float64
+	count  int
+}
+
+// noAggregationDownsampler combines the timestamped samples of the no-aggregation pipeline into
+// one point per context and per interval, aligned on the interval boundaries.
+//
+// Counts and rates are always summed over the interval and sent as a rate over the interval,
+// gauges are combined with the configured aggregation.
+// A bucket is flushed once the samples of its interval are not expected anymore, that is
+// `maxDelay` seconds after its end: later samples for this interval are dropped, sending
+// a second point with the same timestamp would overwrite the first one in the intake.
+//
+// Not safe for concurrent usage.
+type noAggregationDownsampler struct {
+	interval         int64
+	maxDelay         int64
+	gaugeAggregation downsamplingAggregation
+
+	keyGenerator *ckey.KeyGenerator
+	tagsBuffer   *tagset.HashingTagsAccumulator
+	buckets      map[downsamplingKey]*downsamplingBucket
+	// flushedUntil is the end of the last interval flushed: samples before it are late
+	flushedUntil int64
+}
+
+func newNoAggregationDownsampler(interval, maxDelay int64, gaugeAggregation downsamplingAggregation) *noAggregationDownsampler {
+	return &noAggregationDownsampler{
+		interval:         interval,
+		maxDelay:         maxDelay,
+		gaugeAggregation: gaugeAggregation,
+		keyGenerator:     ckey.NewKeyGenerator(),
+		tagsBuffer:       tagset.NewHashingTagsAccumulator(),
+		buckets:          make(map[downsamplingKey]*downsamplingBucket),
+	}
+}
+
+// addSample adds a sample to the bucket of its context and interval, tags are the enriched tags of
+// the sample. It returns false when the sample has been dropped because its interval is already flushed.
+func (d *noAggregationDownsampler) addSample(sample *metrics.MetricSample, mtype metrics.APIMetricType, tags []string) bool {
+	start := int64(math.Floor(sample.Timestamp/float64(d.interval))) * d.interval
+	if start+d.interval <= d.flushedUntil {
+		return false
+	}
+
+	d.tagsBuffer.Append(tags...)
+	contextKey := d.keyGenerator.Generate(sample.Name, sample.Host, d.tagsBuffer)
+	d.tagsBuffer.Reset()
+
+	key := downsamplingKey{context: contextKey, start: start}
+	bucket, found := d.buckets[key]
+	if !found {
+		bucket = &downsamplingBucket{
+			serie: &metrics.Serie{
+				Name:     sample.Name,
+				Tags:     tagset.CompositeTagsFromSlice(append([]string(nil), tags...)),
+				Host:     sample.Host,
+				MType:    mtype,
+				Interval: d.interval,
+			},
+		}
+		d.buckets[key] = bucket
+	}
+	bucket.add(sample.Value, sample.Timestamp, d.aggregation(mtype))
+	return true
+}
+
+// aggregation returns how the samples of the given type are combined
+func (d *noAggregationDownsampler) aggregation(mtype metrics.APIMetricType) downsamplingAggregation {
+	if mtype == metrics.APIGaugeType {
+		return d.gaugeAggregation
+	}
+	// summing is the only aggregation keeping the meaning of counts and rates
+	return downsamplingSum
+}
+
+func (b *downsamplingBucket) add(value, ts float64, aggregation downsamplingAggregation) {
+	b.count++
+	switch aggregation {
+	case downsamplingLast:
+		if b.count == 1 || ts >= b.lastTs {
+			b.value = value
+			b.lastTs = ts
+		}
+	case downsamplingSum:
+		b.value += value
+	case downsamplingMin:
+		if b.count == 1 || value < b.value {
+			b.value = value
+		}
+	case downsamplingMax:
+		if b.count == 1 || value > b.value {
+			b.value = value
+		}
+	case downsamplingCount:
+		b.value = float64(b.count)
+	}
+}
+
+// flush appends to the sink the buckets of the intervals which ended `maxDelay` seconds before now
+// and returns how many series have been appended.
+func (d *noAggregationDownsampler) flush(now int64, sink metrics.SerieSink) int {
+	cutoff := (now - d.maxDelay) / d.interval * d.interval
+	if cutoff > d.flushedUntil {
+		d.flushedUntil = cutoff
+	}
+	return d.flushBuckets(func(key downsamplingKey) bool { return key.start+d.interval <= cutoff }, sink)
+}
+
+// flushAll appends all the buckets to the sink and returns how many series have been appended
+func (d *noAggregationDownsampler) flushAll(sink metrics.SerieSink) int {
+	return d.flushBuckets(func(downsamplingKey) bool { return true }, sink)
+}
+
+func (d *noAggregationDownsampler) flushBuckets(shouldFlush func(downsamplingKey) bool, sink metrics.SerieSink) int {
+	flushed := 0
+	for key, bucket := range d.buckets {
+		if !shouldFlush(key) {
+			continue
+		}
+		value := bucket.value
+		// a rate is sent per second over the interval
+		if bucket.serie.MType == metrics.APIRateType {
+			value /= float64(d.interval)
+		}
+		bucket.serie.Points = []metrics.Point{{Ts: float64(key.start), Value: value}}
+		sink.Append(bucket.serie)
+		delete(d.buckets, key)
+		flushed++
+	}
+	return flushed
+}
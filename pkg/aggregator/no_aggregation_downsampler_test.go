@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package aggregator
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func addDownsamplingSamples(d *noAggregationDownsampler, name string, mtype metrics.APIMetricType, tags []string, samples ...[2]float64) {
+	for _, s := range samples {
+		d.addSample(&metrics.MetricSample{Name: name, Host: "host", Timestamp: s[0], Value: s[1]}, mtype, tags)
+	}
+}
+
+func flushedSeries(t *testing.T, d *noAggregationDownsampler, now int64) metrics.Series {
+	var series metrics.Series
+	n := d.flush(now, &series)
+	require.Len(t, series, n)
+	sort.Slice(series, func(i, j int) bool {
+		if series[i].Name != series[j].Name {
+			return series[i].Name < series[j].Name
+		}
+		return series[i].Points[0].Ts < series[j].Points[0].Ts
+	})
+	return series
+}
+
+func TestParseDownsamplingAggregation(t *testing.T) {
+	for _, s := range []string{"last", "sum", "min", "max", "count"} {
+		a, err := parseDownsamplingAggregation(s)
+		require.NoError(t, err)
+		assert.Equal(t, downsamplingAggregation(s), a)
+	}
+	_, err := parseDownsamplingAggregation("avg")
+	assert.Error(t, err)
+}
+
+func TestNoAggregationDownsamplerGaugeAggregations(t *testing.T) {
+	samples := [][2]float64{{1001, 3}, {1003, 1}, {1002, 5}, {1009.5, 2}}
+	for aggregation, expected := range map[downsamplingAggregation]float64{
+		downsamplingLast:  2,
+		downsamplingSum:   11,
+		downsamplingMin:   1,
+		downsamplingMax:   5,
+		downsamplingCount: 4,
+	} {
+		t.Run(string(aggregation), func(t *testing.T) {
+			d := newNoAggregationDownsampler(10, 0, aggregation)
+			addDownsamplingSamples(d, "gauge", metrics.APIGaugeType, []string{"a:b"}, samples...)
+
+			series := flushedSeries(t, d, 1010)
+			require.Len(t, series, 1)
+			assert.Equal(t, []metrics.Point{{Ts: 1000, Value: expected}}, series[0].Points)
+			assert.Equal(t, metrics.APIGaugeType, series[0].MType)
+			assert.Equal(t, int64(10), series[0].Interval)
+			assert.Equal(t, "host", series[0].Host)
+			assert.Equal(t, []string{"a:b"}, series[0].Tags.UnsafeToReadOnlySliceString())
+		})
+	}
+}
+
+func TestNoAggregationDownsamplerRates(t *testing.T) {
+	// counts are summed whatever the gauge aggregation
+	d := newNoAggregationDownsampler(60, 0, downsamplingMax)
+	addDownsamplingSamples(d, "count", metrics.APIRateType, nil, [2]float64{1200, 30}, [2]float64{1230, 60}, [2]float64{1259, 30})
+
+	series := flushedSeries(t, d, 1260)
+	require.Len(t, series, 1)
+	assert.Equal(t, metrics.APIRateType, series[0].MType)
+	assert.Equal(t, int64(60), series[0].Interval)
+	assert.Equal(t, []metrics.Point{{Ts: 1200, Value: 2}}, series[0].Points)
+}
+
+func TestNoAggregationDownsamplerContexts(t *testing.T) {
+	d := newNoAggregationDownsampler(10, 0, downsamplingSum)
+	addDownsamplingSamples(d, "a", metrics.APIGaugeType, []string{"env:prod", "service:x"}, [2]float64{1001, 1}, [2]float64{1011, 2})
+	// the order of the tags does not matter
+	addDownsamplingSamples(d, "a", metrics.APIGaugeType, []string{"service:x", "env:prod"}, [2]float64{1002, 10})
+	addDownsamplingSamples(d, "a", metrics.APIGaugeType, []string{"env:dev"}, [2]float64{1003, 100})
+	addDownsamplingSamples(d, "b", metrics.APIGaugeType, []string{"env:prod", "service:x"}, [2]float64{1004, 1000})
+
+	series := flushedSeries(t, d, 1030)
+	require.Len(t, series, 4)
+	values := map[string][]metrics.Point{}
+	for _, serie := range series {
+		values[serie.Name+serie.Tags.Join(",")] = append(values[serie.Name+serie.Tags.Join(",")], serie.Points...)
+	}
+	assert.Equal(t, map[string][]metrics.Point{
+		"aenv:prod,service:x": {{Ts: 1000, Value: 11}, {Ts: 1010, Value: 2}},
+		"aenv:dev":            {{Ts: 1000, Value: 100}},
+		"benv:prod,service:x": {{Ts: 1000, Value: 1000}},
+	}, values)
+}
+
+func TestNoAggregationDownsamplerFlush(t *testing.T) {
+	d := newNoAggregationDownsampler(10, 5, downsamplingLast)
+	addDownsamplingSamples(d, "gauge", metrics.APIGaugeType, nil, [2]float64{1005, 1}, [2]float64{1015, 2})
+
+	// the intervals are flushed max_delay seconds after their end
+	assert.Empty(t, flushedSeries(t, d, 1014))
+	series := flushedSeries(t, d, 1015)
+	require.Len(t, series, 1)
+	assert.Equal(t, []metrics.Point{{Ts: 1000, Value: 1}}, series[0].Points)
+
+	// late samples are dropped
+	assert.False(t, d.addSample(&metrics.MetricSample{Name: "gauge", Host: "host", Timestamp: 1009, Value: 3}, metrics.APIGaugeType, nil))
+	assert.True(t, d.addSample(&metrics.MetricSample{Name: "gauge", Host: "host", Timestamp: 1019, Value: 3}, metrics.APIGaugeType, nil))
+
+	var all metrics.Series
+	assert.Equal(t, 1, d.flushAll(&all))
+	require.Len(t, all, 1)
+	assert.Equal(t, []metrics.Point{{Ts: 1010, Value: 3}}, all[0].Points)
+	assert.Empty(t, d.buckets)
+}
@@ -51,6 +51,11 @@ type noAggregationStreamWorker struct {
 
 	hostTagProvider *HostTagProvider
 	tagger          tagger.Component
+	observer        FlushObserver
+
+	// downsampler combines the samples per context and interval before sending them, nil when the
+	// samples are sent as received.
+	downsampler *noAggregationDownsampler
 
 	logThrottling util.SimpleThrottler
 }
@@ -65,11 +70,13 @@ var (
 	noaggExpvars                               = expvar.NewMap("no_aggregation")
 	expvarNoAggSamplesProcessedOk              = expvar.Int{}
 	expvarNoAggSamplesProcessedUnsupportedType = expvar.Int{}
+	expvarNoAggSamplesProcessedLate            = expvar.Int{}
 	expvarNoAggFlush                           = expvar.Int{}
 
 	tlmNoAggSamplesProcessed                = telemetry.NewCounter("no_aggregation", "processed", []string{"state"}, "Count the number of samples processed by the no-aggregation pipeline worker")
 	tlmNoAggSamplesProcessedOk              = tlmNoAggSamplesProcessed.WithValues("ok")
 	tlmNoAggSamplesProcessedUnsupportedType = tlmNoAggSamplesProcessed.WithValues("unsupported_type")
+	tlmNoAggSamplesProcessedLate            = tlmNoAggSamplesProcessed.WithValues("late")
 
 	tlmNoAggFlush = telemetry.NewSimpleCounter("no_aggregation", "flush", "Count the number of flushes done by the no-aggregation pipeline worker")
 )
@@ -77,13 +84,14 @@ var (
 func init() {
 	noaggExpvars.Set("ProcessedOk", &expvarNoAggSamplesProcessedOk)
 	noaggExpvars.Set("ProcessedUnsupportedType", &expvarNoAggSamplesProcessedUnsupportedType)
+	noaggExpvars.Set("ProcessedLate", &expvarNoAggSamplesProcessedLate)
 	noaggExpvars.Set("Flush", &expvarNoAggFlush)
 }
 
 //nolint:revive // TODO(AML) Fix revive linter
 func newNoAggregationStreamWorker(maxMetricsPerPayload int, _ *metrics.MetricSamplePool,
 	serializer serializer.MetricSerializer, flushConfig FlushAndSerializeInParallel,
-	tagger tagger.Component,
+	tagger tagger.Component, observer FlushObserver,
 ) *noAggregationStreamWorker {
 	return &noAggregationStreamWorker{
 		serializer:           serializer,
@@ -104,10 +112,33 @@ func newNoAggregationStreamWorker(maxMetricsPerPayload int, _ *metrics.MetricSam
 		// every 5 minutes.
 		logThrottling: util.NewSimpleThrottler(200, 5*time.Minute, "Pausing the unsupported metric type warning message for 5m"),
 
-		tagger: tagger,
+		tagger:   tagger,
+		observer: observer,
+
+		downsampler: newNoAggregationDownsamplerFromConfig(),
 	}
 }
 
+// newNoAggregationDownsamplerFromConfig returns the downsampler configured in the
+// `dogstatsd_no_aggregation_pipeline_downsampling` section, or nil when the downsampling is disabled.
+func newNoAggregationDownsamplerFromConfig() *noAggregationDownsampler {
+	config := pkgconfigsetup.Datadog()
+	interval := config.GetInt64("dogstatsd_no_aggregation_pipeline_downsampling.interval")
+	if interval <= 0 {
+		return nil
+	}
+
+	gaugeAggregation, err := parseDownsamplingAggregation(config.GetString("dogstatsd_no_aggregation_pipeline_downsampling.gauge_aggregation"))
+	if err != nil {
+		log.Errorf("Invalid dogstatsd_no_aggregation_pipeline_downsampling.gauge_aggregation, using %q: %v", downsamplingLast, err)
+		gaugeAggregation = downsamplingLast
+	}
+	maxDelay := max(config.GetInt64("dogstatsd_no_aggregation_pipeline_downsampling.max_delay"), 0)
+
+	log.Infof("Downsampling the no-aggregation pipeline samples to %ds intervals, aggregating gauges with %q", interval, gaugeAggregation)
+	return newNoAggregationDownsampler(interval, maxDelay, gaugeAggregation)
+}
+
 func (w *noAggregationStreamWorker) addSamples(samples metrics.MetricSampleBatch) {
 	if len(samples) == 0 {
 		return
@@ -141,6 +172,8 @@ func (w *noAggregationStreamWorker) stop(wait bool) {
 //   - it also checks every 2 seconds if it has stopped receiving samples, if so, it stops streaming to the
 //     the serializer for a while in order to let the serializer sends the payloads up to the forwarder, and starts
 //     the streaming mainloop again
+//   - when downsampling, the samples are kept in the downsampler and the complete intervals are sent to
+//     the serializer every 2 seconds
 //   - listens for a stop signal
 //   - listens for a flush signal
 //
@@ -152,7 +185,7 @@ func (w *noAggregationStreamWorker) run() {
 	ticker := time.NewTicker(noAggWorkerStreamCheckFrequency)
 	defer ticker.Stop()
 	logPayloads := pkgconfigsetup.Datadog().GetBool("log_payloads")
-	w.seriesSink, w.sketchesSink = createIterableMetrics(w.flushConfig, w.serializer, logPayloads, false, w.hostTagProvider)
+	w.seriesSink, w.sketchesSink = createIterableMetrics(w.flushConfig, w.serializer, logPayloads, false, w.hostTagProvider, w.observer)
 
 	stopped := false
 	var stopBlockChan chan struct{}
@@ -172,12 +205,23 @@ func (w *noAggregationStreamWorker) run() {
 
 					// stop signal
 					case trigger := <-w.stopChan:
+						if w.downsampler != nil {
+							w.downsampler.flushAll(w.seriesSink)
+						}
 						stopped = true
 						stopBlockChan = trigger.blockChan
 						break mainloop // end `Serialize` call and trigger a flush to the forwarder
 
 					case <-ticker.C:
 						n := time.Now()
+						// the samples are continuously received while downsampling: flush as soon as
+						// some intervals are complete
+						if w.downsampler != nil && w.downsampler.flush(n.Unix(), w.seriesSink) > 0 {
+							log.Debug("noAggregationStreamWorker: triggering a payloads flush to the forwarder (downsampled intervals complete)")
+							tlmNoAggFlush.Add(1)
+							expvarNoAggFlush.Add(1)
+							break mainloop // end `Serialize` call and trigger a flush to the forwarder
+						}
 						if serializedSamples > 0 && lastStream.Before(n.Add(-time.Second*1)) {
 							log.Debug("noAggregationStreamWorker: triggering an automatic payloads flush to the forwarder (no traffic since 1s)")
 							tlmNoAggFlush.Add(1)
@@ -190,6 +234,7 @@ func (w *noAggregationStreamWorker) run() {
 						log.Tracef("Streaming %d metrics from the no-aggregation pipeline", len(samples))
 						countProcessed := 0
 						countUnsupportedType := 0
+						countLate := 0
 
 						for _, sample := range samples {
 							mtype, supported := metricSampleAPIType(sample)
@@ -206,6 +251,17 @@ func (w *noAggregationStreamWorker) run() {
 							sample.GetTags(w.taggerBuffer, w.metricBuffer, w.tagger.EnrichTags)
 							w.metricBuffer.AppendHashlessAccumulator(w.taggerBuffer)
 
+							if w.downsampler != nil {
+								if w.downsampler.addSample(&sample, mtype, w.metricBuffer.Get()) {
+									countProcessed++
+								} else {
+									countLate++
+								}
+								w.taggerBuffer.Reset()
+								w.metricBuffer.Reset()
+								continue
+							}
+
 							// if the value is a rate, we have to account for the 10s interval
 							if mtype == metrics.APIRateType {
 								sample.Value /= bucketSize
@@ -228,12 +284,17 @@ func (w *noAggregationStreamWorker) run() {
 
 						lastStream = time.Now()
 
-						serializedSamples += countProcessed
+						// downsampled samples are sent to the serializer when their interval is flushed
+						if w.downsampler == nil {
+							serializedSamples += countProcessed
+						}
 
 						tlmNoAggSamplesProcessedOk.Add(float64(countProcessed))
 						expvarNoAggSamplesProcessedOk.Add(int64(countProcessed))
 						tlmNoAggSamplesProcessedUnsupportedType.Add(float64(countUnsupportedType))
 						expvarNoAggSamplesProcessedUnsupportedType.Add(int64(countUnsupportedType))
+						tlmNoAggSamplesProcessedLate.Add(float64(countLate))
+						expvarNoAggSamplesProcessedLate.Add(int64(countLate))
 
 						w.metricSamplePool.PutBatch(samples) // return the sample batch back to the pool for reuse
 
@@ -253,7 +314,7 @@ func (w *noAggregationStreamWorker) run() {
 			break
 		}
 
-		w.seriesSink, w.sketchesSink = createIterableMetrics(w.flushConfig, w.serializer, logPayloads, false, w.hostTagProvider)
+		w.seriesSink, w.sketchesSink = createIterableMetrics(w.flushConfig, w.serializer, logPayloads, false, w.hostTagProvider, w.observer)
 	}
 
 	if stopBlockChan != nil {
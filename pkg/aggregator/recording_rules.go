@@ -0,0 +1,410 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package aggregator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/config/structure"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// RecordingRulesConfigKey is the configuration key holding the recording rules.
+const RecordingRulesConfigKey = "aggregator_recording_rules"
+
+// RecordingRule rolls up the contexts of a metric at flush time: the series of the metric
+// are summed, and its sketches merged, across all the tags which are not listed in By.
+type RecordingRule struct {
+	// Metric is the name of the flushed metric the rule applies to.
+	Metric string `mapstructure:"metric" json:"metric" yaml:"metric"`
+	// By lists the keys of the tags kept on the rolled up contexts.
+	By []string `mapstructure:"by" json:"by" yaml:"by"`
+	// Name is the name of the rolled up metric. It defaults to Metric.
+	Name string `mapstructure:"name" json:"name,omitempty" yaml:"name,omitempty"`
+	// KeepOriginal keeps sending the original contexts along with the rolled up ones.
+	KeepOriginal bool `mapstructure:"keep_original" json:"keep_original,omitempty" yaml:"keep_original,omitempty"`
+}
+
+// outputName returns the name of the rolled up metric.
+func (r *RecordingRule) outputName() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return r.Metric
+}
+
+// ValidateRecordingRules checks that the given recording rules can be used together.
+func ValidateRecordingRules(rules []RecordingRule) error {
+	outputs := make(map[string]struct{}, len(rules))
+	for i, r := range rules {
+		if r.Metric == "" {
+			return fmt.Errorf("recording rule #%d: metric is required", i)
+		}
+		name := r.outputName()
+		if name == r.Metric && r.KeepOriginal {
+			return fmt.Errorf("recording rule #%d: the rolled up %s metric needs a different name to keep the original one", i, r.Metric)
+		}
+		if _, ok := outputs[name]; ok {
+			return fmt.Errorf("recording rule #%d: %s is produced by several recording rules", i, name)
+		}
+		outputs[name] = struct{}{}
+	}
+	return nil
+}
+
+// GetRecordingRules returns the validated recording rules found in the configuration.
+func GetRecordingRules(cfg model.Reader) ([]RecordingRule, error) {
+	var rules []RecordingRule
+	if !cfg.IsSet(RecordingRulesConfigKey) {
+		return nil, nil
+	}
+	if err := structure.UnmarshalKey(cfg, RecordingRulesConfigKey, &rules); err != nil {
+		return nil, err
+	}
+	if err := ValidateRecordingRules(rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// recordingRuleStats holds the number of contexts processed by a recording rule during
+// the last flush.
+type recordingRuleStats struct {
+	Metric         string
+	InputContexts  int64
+	OutputContexts int64
+}
+
+// recordingRulesStatus holds the stats of the recording rules exposed in the aggregator expvars.
+var recordingRulesStatus = struct {
+	sync.Mutex
+	stats map[string]recordingRuleStats
+}{}
+
+func expRecordingRules() interface{} {
+	recordingRulesStatus.Lock()
+	defer recordingRulesStatus.Unlock()
+	return recordingRulesStatus.stats
+}
+
+// recordingRulesSubscriptions holds the recording rules reloaded when the configuration is
+// updated. As the configuration listeners cannot be removed, a single listener is registered
+// per configuration, and the rules are unsubscribed when their demultiplexer stops.
+var recordingRulesSubscriptions = struct {
+	sync.Mutex
+	listened map[model.Config]struct{}
+	rules    map[*recordingRules]model.Config
+}{
+	listened: make(map[model.Config]struct{}),
+	rules:    make(map[*recordingRules]model.Config),
+}
+
+// subscribe loads the rules of the configuration, and reloads them each time they are updated
+// until unsubscribe is called.
+func (r *recordingRules) subscribe(cfg model.Config) {
+	r.load(cfg)
+
+	recordingRulesSubscriptions.Lock()
+	defer recordingRulesSubscriptions.Unlock()
+	recordingRulesSubscriptions.rules[r] = cfg
+	if _, ok := recordingRulesSubscriptions.listened[cfg]; ok {
+		return
+	}
+	recordingRulesSubscriptions.listened[cfg] = struct{}{}
+	cfg.OnUpdate(func(setting string, _, _ any) {
+		if setting != RecordingRulesConfigKey {
+			return
+		}
+		recordingRulesSubscriptions.Lock()
+		defer recordingRulesSubscriptions.Unlock()
+		for rules, rulesCfg := range recordingRulesSubscriptions.rules {
+			if rulesCfg == cfg {
+				rules.load(cfg)
+			}
+		}
+	})
+}
+
+// unsubscribe stops reloading the rules.
+func (r *recordingRules) unsubscribe() {
+	recordingRulesSubscriptions.Lock()
+	defer recordingRulesSubscriptions.Unlock()
+	delete(recordingRulesSubscriptions.rules, r)
+}
+
+// recordingRules holds the recording rules applied on the flushed series and sketches. Rules
+// can be reloaded at any time, the new rules are used starting with the next flush.
+//
+// The rules are not applied on the series of the no-aggregation pipeline, which are sent as
+// they are received and cannot be rolled up with the other contexts of a flush.
+type recordingRules struct {
+	mu       sync.RWMutex
+	byMetric map[string][]*RecordingRule
+}
+
+func newRecordingRules() *recordingRules {
+	return &recordingRules{}
+}
+
+// load replaces the recording rules by the ones found in the configuration. The current
+// rules are kept when the configured ones are invalid.
+func (r *recordingRules) load(cfg model.Reader) {
+	rules, err := GetRecordingRules(cfg)
+	if err != nil {
+		log.Errorf("Invalid %s, keeping the current recording rules: %v", RecordingRulesConfigKey, err)
+		return
+	}
+	r.update(rules)
+	if len(rules) > 0 {
+		log.Infof("Loaded %d recording rules", len(rules))
+	}
+}
+
+func (r *recordingRules) update(rules []RecordingRule) {
+	byMetric := make(map[string][]*RecordingRule, len(rules))
+	for i := range rules {
+		rule := rules[i]
+		byMetric[rule.Metric] = append(byMetric[rule.Metric], &rule)
+	}
+	r.mu.Lock()
+	r.byMetric = byMetric
+	r.mu.Unlock()
+}
+
+// newFlush returns a recordingRulesFlush applying the current rules on the series and sketches
+// appended to it before forwarding them to the given sinks, or nil when there is no rule.
+func (r *recordingRules) newFlush(series metrics.SerieSink, sketches metrics.SketchesSink) *recordingRulesFlush {
+	r.mu.RLock()
+	byMetric := r.byMetric
+	r.mu.RUnlock()
+	if len(byMetric) == 0 {
+		recordingRulesStatus.Lock()
+		recordingRulesStatus.stats = nil
+		recordingRulesStatus.Unlock()
+		return nil
+	}
+	return &recordingRulesFlush{
+		rules:        byMetric,
+		series:       series,
+		sketches:     sketches,
+		seriesGroups: make(map[string]*rolledUpSerie),
+		sketchGroups: make(map[string]*rolledUpSketch),
+		stats:        make(map[*RecordingRule]*recordingRuleStats),
+	}
+}
+
+// recordingRulesFlush rolls up the series and sketches of a single flush. The contexts
+// matched by a rule are held until done is called, the other ones are forwarded right away.
+type recordingRulesFlush struct {
+	rules    map[string][]*RecordingRule
+	series   metrics.SerieSink
+	sketches metrics.SketchesSink
+
+	mu           sync.Mutex
+	seriesGroups map[string]*rolledUpSerie
+	sketchGroups map[string]*rolledUpSketch
+	stats        map[*RecordingRule]*recordingRuleStats
+}
+
+type rolledUpSerie struct {
+	serie  *metrics.Serie
+	points map[float64]float64
+}
+
+type rolledUpSketch struct {
+	sketch *metrics.SketchSeries
+	points map[int64]*quantile.Sketch
+}
+
+type serieSinkFunc func(*metrics.Serie)
+
+// Append implements metrics.SerieSink.
+func (f serieSinkFunc) Append(serie *metrics.Serie) { f(serie) }
+
+type sketchesSinkFunc func(*metrics.SketchSeries)
+
+// Append implements metrics.SketchesSink.
+func (f sketchesSinkFunc) Append(sketch *metrics.SketchSeries) { f(sketch) }
+
+// seriesSink returns the sink the series of the flush must be appended to.
+func (f *recordingRulesFlush) seriesSink() metrics.SerieSink {
+	return serieSinkFunc(f.appendSerie)
+}
+
+// sketchesSink returns the sink the sketches of the flush must be appended to.
+func (f *recordingRulesFlush) sketchesSink() metrics.SketchesSink {
+	return sketchesSinkFunc(f.appendSketch)
+}
+
+func (f *recordingRulesFlush) appendSerie(serie *metrics.Serie) {
+	rules, ok := f.rules[serie.Name]
+	if !ok {
+		f.series.Append(serie)
+		return
+	}
+	keepOriginal := false
+	f.mu.Lock()
+	for _, rule := range rules {
+		tags := rolledUpTags(rule, serie.Tags)
+		key := rolledUpKey(rule, fmt.Sprint(serie.MType), serie.Host, tags)
+		group, ok := f.seriesGroups[key]
+		if !ok {
+			group = &rolledUpSerie{
+				serie: &metrics.Serie{
+					Name:           rule.outputName(),
+					Tags:           tagset.CompositeTagsFromSlice(tags),
+					Host:           serie.Host,
+					MType:          serie.MType,
+					Interval:       serie.Interval,
+					SourceTypeName: serie.SourceTypeName,
+					Source:         serie.Source,
+				},
+				points: make(map[float64]float64),
+			}
+			f.seriesGroups[key] = group
+			f.ruleStats(rule).OutputContexts++
+		}
+		for _, p := range serie.Points {
+			group.points[p.Ts] += p.Value
+		}
+		f.ruleStats(rule).InputContexts++
+		keepOriginal = keepOriginal || rule.KeepOriginal
+	}
+	f.mu.Unlock()
+	if keepOriginal {
+		f.series.Append(serie)
+	}
+}
+
+func (f *recordingRulesFlush) appendSketch(sketch *metrics.SketchSeries) {
+	rules, ok := f.rules[sketch.Name]
+	if !ok {
+		f.sketches.Append(sketch)
+		return
+	}
+	keepOriginal := false
+	f.mu.Lock()
+	for _, rule := range rules {
+		tags := rolledUpTags(rule, sketch.Tags)
+		key := rolledUpKey(rule, "sketch", sketch.Host, tags)
+		group, ok := f.sketchGroups[key]
+		if !ok {
+			group = &rolledUpSketch{
+				sketch: &metrics.SketchSeries{
+					Name:     rule.outputName(),
+					Tags:     tagset.CompositeTagsFromSlice(tags),
+					Host:     sketch.Host,
+					Interval: sketch.Interval,
+					Source:   sketch.Source,
+				},
+				points: make(map[int64]*quantile.Sketch),
+			}
+			f.sketchGroups[key] = group
+			f.ruleStats(rule).OutputContexts++
+		}
+		for _, p := range sketch.Points {
+			if p.Sketch == nil {
+				continue
+			}
+			merged, ok := group.points[p.Ts]
+			if !ok {
+				merged = &quantile.Sketch{}
+				group.points[p.Ts] = merged
+			}
+			merged.Merge(quantile.Default(), p.Sketch)
+		}
+		f.ruleStats(rule).InputContexts++
+		keepOriginal = keepOriginal || rule.KeepOriginal
+	}
+	f.mu.Unlock()
+	if keepOriginal {
+		f.sketches.Append(sketch)
+	}
+}
+
+// ruleStats must be called with f.mu held.
+func (f *recordingRulesFlush) ruleStats(rule *RecordingRule) *recordingRuleStats {
+	stats, ok := f.stats[rule]
+	if !ok {
+		stats = &recordingRuleStats{Metric: rule.Metric}
+		f.stats[rule] = stats
+	}
+	return stats
+}
+
+// done sends the rolled up series and sketches to the underlying sinks. It must be called
+// once all the series and sketches of the flush have been appended.
+func (f *recordingRulesFlush) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, group := range f.seriesGroups {
+		ts := make([]float64, 0, len(group.points))
+		for t := range group.points {
+			ts = append(ts, t)
+		}
+		sort.Float64s(ts)
+		for _, t := range ts {
+			group.serie.Points = append(group.serie.Points, metrics.Point{Ts: t, Value: group.points[t]})
+		}
+		f.series.Append(group.serie)
+	}
+	for _, group := range f.sketchGroups {
+		ts := make([]int64, 0, len(group.points))
+		for t := range group.points {
+			ts = append(ts, t)
+		}
+		sort.Slice(ts, func(i, j int) bool { return ts[i] < ts[j] })
+		for _, t := range ts {
+			group.sketch.Points = append(group.sketch.Points, metrics.SketchPoint{Ts: t, Sketch: group.points[t]})
+		}
+		f.sketches.Append(group.sketch)
+	}
+
+	stats := make(map[string]recordingRuleStats, len(f.stats))
+	for rule, s := range f.stats {
+		stats[rule.outputName()] = *s
+	}
+	recordingRulesStatus.Lock()
+	recordingRulesStatus.stats = stats
+	recordingRulesStatus.Unlock()
+}
+
+// rolledUpTags returns the sorted tags of a context kept by a rule.
+func rolledUpTags(rule *RecordingRule, tags tagset.CompositeTags) []string {
+	var kept []string
+	tags.ForEach(func(tag string) {
+		key, _, _ := strings.Cut(tag, ":")
+		for _, by := range rule.By {
+			if key == by {
+				kept = append(kept, tag)
+				return
+			}
+		}
+	})
+	sort.Strings(kept)
+	// remove duplicates
+	j := 0
+	for i := range kept {
+		if i == 0 || kept[i] != kept[j-1] {
+			kept[j] = kept[i]
+			j++
+		}
+	}
+	return kept[:j]
+}
+
+func rolledUpKey(rule *RecordingRule, kind, host string, tags []string) string {
+	return rule.outputName() + "\x00" + kind + "\x00" + host + "\x00" + strings.Join(tags, ",")
+}
@@ -0,0 +1,163 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package aggregator
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func recordingRulesTestSerie(name string, value float64, tags ...string) *metrics.Serie {
+	return &metrics.Serie{
+		Name:   name,
+		Points: []metrics.Point{{Ts: 10, Value: value}},
+		Tags:   tagset.CompositeTagsFromSlice(tags),
+		Host:   "myhost",
+		MType:  metrics.APICountType,
+	}
+}
+
+func TestValidateRecordingRules(t *testing.T) {
+	assert.NoError(t, ValidateRecordingRules([]RecordingRule{
+		{Metric: "http.requests", By: []string{"service"}},
+		{Metric: "http.requests", By: []string{"env"}, Name: "http.requests.by_env", KeepOriginal: true},
+	}))
+	assert.ErrorContains(t, ValidateRecordingRules([]RecordingRule{{By: []string{"service"}}}), "metric is required")
+	assert.ErrorContains(t, ValidateRecordingRules([]RecordingRule{{Metric: "http.requests", KeepOriginal: true}}), "needs a different name")
+	assert.ErrorContains(t, ValidateRecordingRules([]RecordingRule{
+		{Metric: "http.requests", Name: "requests"},
+		{Metric: "http.errors", Name: "requests"},
+	}), "produced by several recording rules")
+}
+
+func TestRecordingRulesSeries(t *testing.T) {
+	rules := newRecordingRules()
+	rules.update([]RecordingRule{
+		{Metric: "http.requests", By: []string{"service"}},
+		{Metric: "http.errors", By: []string{"env"}, Name: "http.errors.by_env", KeepOriginal: true},
+	})
+
+	var series metrics.Series
+	flush := rules.newFlush(&series, &metrics.SketchSeriesList{})
+	require.NotNil(t, flush)
+	sink := flush.seriesSink()
+	sink.Append(recordingRulesTestSerie("http.requests", 1, "service:api", "pod:a"))
+	sink.Append(recordingRulesTestSerie("http.requests", 2, "service:api", "pod:b"))
+	sink.Append(recordingRulesTestSerie("http.requests", 4, "service:web", "pod:c"))
+	sink.Append(recordingRulesTestSerie("http.errors", 1, "env:prod", "pod:a"))
+	sink.Append(recordingRulesTestSerie("http.errors", 1, "env:prod", "pod:b"))
+	sink.Append(recordingRulesTestSerie("other", 1, "pod:a"))
+	flush.done()
+
+	got := make(map[string]float64)
+	for _, s := range series {
+		require.Len(t, s.Points, 1)
+		got[s.Name+"|"+s.Tags.Join(",")] += s.Points[0].Value
+	}
+	assert.Equal(t, map[string]float64{
+		"http.requests|service:api":   3,
+		"http.requests|service:web":   4,
+		"http.errors.by_env|env:prod": 2,
+		"http.errors|env:prod,pod:a":  1,
+		"http.errors|env:prod,pod:b":  1,
+		"other|pod:a":                 1,
+	}, got)
+
+	stats := expRecordingRules().(map[string]recordingRuleStats)
+	assert.Equal(t, recordingRuleStats{Metric: "http.requests", InputContexts: 3, OutputContexts: 2}, stats["http.requests"])
+	assert.Equal(t, recordingRuleStats{Metric: "http.errors", InputContexts: 2, OutputContexts: 1}, stats["http.errors.by_env"])
+}
+
+func TestRecordingRulesSketches(t *testing.T) {
+	rules := newRecordingRules()
+	rules.update([]RecordingRule{{Metric: "request.latency", By: []string{"service"}}})
+
+	newSketch := func(from, to int) *metrics.SketchSeries {
+		sketch := &quantile.Sketch{}
+		for i := from; i <= to; i++ {
+			sketch.Insert(quantile.Default(), float64(i))
+		}
+		return &metrics.SketchSeries{
+			Name:   "request.latency",
+			Tags:   tagset.CompositeTagsFromSlice([]string{"service:api", "pod:" + string(rune('a'+from))}),
+			Points: []metrics.SketchPoint{{Ts: 10, Sketch: sketch}},
+		}
+	}
+
+	var sketches metrics.SketchSeriesList
+	flush := rules.newFlush(&metrics.Series{}, &sketches)
+	require.NotNil(t, flush)
+	flush.sketchesSink().Append(newSketch(1, 50))
+	flush.sketchesSink().Append(newSketch(51, 100))
+	flush.done()
+
+	require.Len(t, sketches, 1)
+	assert.Equal(t, "request.latency", sketches[0].Name)
+	assert.Equal(t, []string{"service:api"}, sketches[0].Tags.UnsafeToReadOnlySliceString())
+	require.Len(t, sketches[0].Points, 1)
+	assert.EqualValues(t, 100, sketches[0].Points[0].Sketch.Basic.Cnt)
+	assert.EqualValues(t, 5050, sketches[0].Points[0].Sketch.Basic.Sum)
+}
+
+func TestRecordingRulesLoad(t *testing.T) {
+	cfg := configmock.New(t)
+	rules := newRecordingRules()
+	rules.load(cfg)
+	assert.Nil(t, rules.newFlush(&metrics.Series{}, &metrics.SketchSeriesList{}), "no rule configured")
+
+	cfg.SetWithoutSource(RecordingRulesConfigKey, []interface{}{
+		map[string]interface{}{"metric": "http.requests", "by": []string{"service"}},
+	})
+	rules.load(cfg)
+	require.Contains(t, rules.byMetric, "http.requests")
+	assert.Equal(t, []string{"service"}, rules.byMetric["http.requests"][0].By)
+
+	// invalid rules are ignored
+	cfg.SetWithoutSource(RecordingRulesConfigKey, []interface{}{
+		map[string]interface{}{"by": []string{"service"}},
+	})
+	rules.load(cfg)
+	keys := make([]string, 0, len(rules.byMetric))
+	for k := range rules.byMetric {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{"http.requests"}, keys)
+}
+
+func TestRecordingRulesSubscribe(t *testing.T) {
+	cfg := configmock.New(t)
+	rules := newRecordingRules()
+	stopped := newRecordingRules()
+	rules.subscribe(cfg)
+	stopped.subscribe(cfg)
+	stopped.unsubscribe()
+	defer rules.unsubscribe()
+
+	cfg.Set(RecordingRulesConfigKey, []interface{}{
+		map[string]interface{}{"metric": "http.requests", "by": []string{"service"}},
+	}, model.SourceAgentRuntime)
+
+	assert.Contains(t, rules.byMetric, "http.requests")
+	assert.Empty(t, stopped.byMetric, "the rules of a stopped demultiplexer are not reloaded")
+
+	recordingRulesSubscriptions.Lock()
+	defer recordingRulesSubscriptions.Unlock()
+	_, subscribed := recordingRulesSubscriptions.rules[stopped]
+	assert.False(t, subscribed)
+	assert.Contains(t, recordingRulesSubscriptions.listened, model.Config(cfg))
+}
@@ -58,7 +58,7 @@ func NewTimeSampler(id TimeSamplerID, interval int64, cache *tags.Store, tagger
 
 	s := &TimeSampler{
 		interval:           interval,
-		contextResolver:    newTimestampContextResolver(tagger, cache, idString, contextExpireTime, counterExpireTime),
+		contextResolver:    newTimestampContextResolver(tagger, cache, idString, contextExpireTime, counterExpireTime, contextLimitsFromConfig(pkgconfigsetup.Datadog())),
 		metricsByTimestamp: map[int64]metrics.ContextMetrics{},
 		sketchMap:          make(sketchMap),
 		id:                 id,
@@ -84,7 +84,10 @@ func (s *TimeSampler) sample(metricSample *metrics.MetricSample, timestamp float
 	}
 
 	// Keep track of the context
-	contextKey := s.contextResolver.trackContext(metricSample, int64(timestamp))
+	contextKey, ok := s.contextResolver.trackContext(metricSample, int64(timestamp))
+	if !ok {
+		return
+	}
 	bucketStart := s.calculateBucketStart(timestamp)
 
 	switch metricSample.Mtype {
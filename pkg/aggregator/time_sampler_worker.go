@@ -39,6 +39,8 @@ type timeSamplerWorker struct {
 	stopChan chan struct{}
 	// channel to trigger interactive dump of the context resolver
 	dumpChan chan dumpTrigger
+	// channel to trigger an interactive visit of the context resolver
+	visitChan chan visitTrigger
 
 	// tagsStore shard used to store tag slices for this worker
 	tagsStore *tags.Store
@@ -49,6 +51,11 @@ type dumpTrigger struct {
 	done chan error
 }
 
+type visitTrigger struct {
+	visit func(*contextResolver)
+	done  chan struct{}
+}
+
 func newTimeSamplerWorker(sampler *TimeSampler, flushInterval time.Duration, bufferSize int,
 	metricSamplePool *metrics.MetricSamplePool,
 	parallelSerialization FlushAndSerializeInParallel, tagsStore *tags.Store) *timeSamplerWorker {
@@ -65,6 +72,8 @@ func newTimeSamplerWorker(sampler *TimeSampler, flushInterval time.Duration, buf
 		flushChan:   make(chan flushTrigger),
 		dumpChan:    make(chan dumpTrigger),
 
+		visitChan: make(chan visitTrigger),
+
 		tagsStore: tagsStore,
 	}
 }
@@ -96,6 +105,9 @@ func (w *timeSamplerWorker) run() {
 			w.tagsStore.Shrink()
 		case trigger := <-w.dumpChan:
 			trigger.done <- w.sampler.dumpContexts(trigger.dest)
+		case trigger := <-w.visitChan:
+			trigger.visit(w.sampler.contextResolver.resolver)
+			trigger.done <- struct{}{}
 		}
 	}
 }
@@ -114,3 +126,11 @@ func (w *timeSamplerWorker) dumpContexts(dest io.Writer) error {
 	w.dumpChan <- dumpTrigger{dest: dest, done: done}
 	return <-done
 }
+
+// visitContextResolver calls visit with the context resolver of the time sampler, from the
+// goroutine of the worker.
+func (w *timeSamplerWorker) visitContextResolver(visit func(*contextResolver)) {
+	done := make(chan struct{})
+	w.visitChan <- visitTrigger{visit: visit, done: done}
+	<-done
+}
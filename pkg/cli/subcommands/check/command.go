@@ -117,6 +117,10 @@ type cliParams struct {
 	discoveryRetryInterval    uint
 	discoveryMinInstances     uint
 	generateIntegrationTraces bool
+	snapshotFile              string
+	verifyFile                string
+	snapshotTolerance         float64
+	snapshotIgnoreTags        []string
 }
 
 // GlobalParams contains the values of agent-global Cobra flags.
@@ -219,6 +223,11 @@ func MakeCommand(globalParamsGetter func() GlobalParams) *cobra.Command {
 	cmd.Flags().UintVarP(&cliParams.discoveryTimeout, "discovery-timeout", "", 5, "max retry duration until Autodiscovery resolves the check template (in seconds)")
 	cmd.Flags().UintVarP(&cliParams.discoveryRetryInterval, "discovery-retry-interval", "", 1, "(unused)")
 	cmd.Flags().UintVarP(&cliParams.discoveryMinInstances, "discovery-min-instances", "", 1, "minimum number of config instances to be discovered before running the check(s)")
+	cmd.Flags().StringVar(&cliParams.snapshotFile, "snapshot", "", "record the metrics, service checks, events and event platform payloads of the run to a JSON file")
+	cmd.Flags().StringVar(&cliParams.verifyFile, "verify", "", "compare the output of the run to a JSON file recorded with --snapshot, and fail if they differ")
+	cmd.Flags().Float64Var(&cliParams.snapshotTolerance, "tolerance", 0, "maximum relative difference between the recorded and the verified metric values, overrides the one of the snapshot")
+	cmd.Flags().StringSliceVar(&cliParams.snapshotIgnoreTags, "ignore-tags", nil, "tags ignored when verifying a snapshot, either 'key' or 'key:value' where the value can end with a '*' wildcard")
+	cmd.MarkFlagsMutuallyExclusive("snapshot", "verify")
 
 	// Power user flags - mark as hidden
 	createHiddenStringFlag(cmd, &cliParams.profileMemoryDir, "m-dir", "", "an existing directory in which to store memory profiling data, ignoring clean-up")
@@ -466,6 +475,11 @@ func run(
 		return err
 	}
 
+	var output *snapshot
+	if cliParams.snapshotFile != "" || cliParams.verifyFile != "" {
+		output = newSnapshot(cliParams.checkName, cliParams.snapshotTolerance, cliParams.snapshotIgnoreTags)
+	}
+
 	checkRuns := collectorData["runnerStats"].(map[string]interface{})["Checks"].(map[string]interface{})
 	for _, c := range cs {
 		s := runCheck(cliParams, c, printer)
@@ -487,7 +501,9 @@ func run(
 		// Sleep for a while to allow the aggregator to finish ingesting all the metrics/events/sc
 		time.Sleep(time.Duration(cliParams.checkDelay) * time.Millisecond)
 
-		if cliParams.formatJSON {
+		if output != nil {
+			output.record(printer)
+		} else if cliParams.formatJSON {
 			aggregatorData := printer.GetMetricsDataForPrint()
 
 			// There is only one checkID per run so we'll just access that
@@ -585,7 +601,11 @@ func run(
 		standalone.PrintWindowsUserWarning("check")
 	}
 
-	if cliParams.formatJSON {
+	if output != nil {
+		if err := writeOrVerifySnapshot(cliParams, output); err != nil {
+			return err
+		}
+	} else if cliParams.formatJSON {
 		instancesJSON, _ := json.MarshalIndent(instancesData, "", "  ")
 		instanceJSONString := string(instancesJSON)
 
@@ -645,6 +665,37 @@ func runCheck(cliParams *cliParams, c check.Check, _ aggregator.Demultiplexer) *
 	return s
 }
 
+// writeOrVerifySnapshot saves the output of the runs with --snapshot, or compares it to the snapshot given to --verify
+func writeOrVerifySnapshot(cliParams *cliParams, output *snapshot) error {
+	if cliParams.snapshotFile != "" {
+		if err := output.write(cliParams.snapshotFile); err != nil {
+			return fmt.Errorf("unable to write the snapshot: %w", err)
+		}
+		fmt.Printf("Snapshot of %d metrics, %d service checks, %d events and %d event platform payloads written to %s\n",
+			len(output.Metrics), len(output.ServiceChecks), len(output.Events), len(output.EventPlatformEvents), cliParams.snapshotFile)
+		return nil
+	}
+
+	expected, err := readSnapshot(cliParams.verifyFile)
+	if err != nil {
+		return err
+	}
+	if cliParams.cmd != nil && cliParams.cmd.Flags().Changed("tolerance") {
+		expected.Rules.Tolerance = cliParams.snapshotTolerance
+	}
+	expected.Rules.IgnoreTags = append(expected.Rules.IgnoreTags, cliParams.snapshotIgnoreTags...)
+
+	diffs := expected.verify(output)
+	if len(diffs) == 0 {
+		fmt.Fprintf(color.Output, "%s: the output of the check matches the snapshot %s\n", color.GreenString("OK"), cliParams.verifyFile)
+		return nil
+	}
+	for _, diff := range diffs {
+		fmt.Fprintf(color.Output, "* %s\n", diff)
+	}
+	return fmt.Errorf("the output of the check differs from the snapshot %s in %d places", cliParams.verifyFile, len(diffs))
+}
+
 func writeCheckToFile(checkName string, checkFileOutput *bytes.Buffer) {
 	_ = os.Mkdir(defaultpaths.CheckFlareDirectory, os.ModeDir)
 
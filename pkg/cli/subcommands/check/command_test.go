@@ -43,4 +43,14 @@ func TestCommand(t *testing.T) {
 			require.True(t, cliParams.saveFlare)
 			require.Equal(t, true, secretParams.Enabled)
 		})
+
+	fxutil.TestOneShotSubcommand(t,
+		commands,
+		[]string{"check", "cleopatra", "--verify", "snapshot.json", "--tolerance", "0.1", "--ignore-tags", "pid,pod_name:web-*"},
+		run,
+		func(cliParams *cliParams) {
+			require.Equal(t, "snapshot.json", cliParams.verifyFile)
+			require.Equal(t, 0.1, cliParams.snapshotTolerance)
+			require.Equal(t, []string{"pid", "pod_name:web-*"}, cliParams.snapshotIgnoreTags)
+		})
 }
@@ -0,0 +1,358 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package check
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+)
+
+// snapshot is the output of the runs of a check, recorded with `--snapshot` and compared to the output of a
+// subsequent run with `--verify`. The hosts and the timestamps are not recorded since they change between runs.
+type snapshot struct {
+	Check               string                       `json:"check"`
+	Rules               snapshotRules                `json:"rules"`
+	Metrics             []snapshotMetric             `json:"metrics"`
+	ServiceChecks       []snapshotServiceCheck       `json:"service_checks"`
+	Events              []snapshotEvent              `json:"events"`
+	EventPlatformEvents []snapshotEventPlatformEvent `json:"event_platform_events"`
+}
+
+// snapshotRules define how much the output of a run can differ from a snapshot
+type snapshotRules struct {
+	// Tolerance is the maximum relative difference between the recorded and the verified values of the metrics
+	Tolerance float64 `json:"tolerance"`
+	// IgnoreTags are the tags ignored when matching the outputs: `key` ignores all the values of a tag, and
+	// `key:value` a single value, which can end with a `*` wildcard
+	IgnoreTags []string `json:"ignore_tags,omitempty"`
+	// Metrics overrides the rules of some metrics, by name
+	Metrics map[string]snapshotMetricRule `json:"metrics,omitempty"`
+}
+
+// snapshotMetricRule overrides the rules of a metric
+type snapshotMetricRule struct {
+	Tolerance   *float64 `json:"tolerance,omitempty"`
+	IgnoreValue bool     `json:"ignore_value,omitempty"`
+}
+
+type snapshotMetric struct {
+	Name  string   `json:"name"`
+	Type  string   `json:"type"`
+	Tags  []string `json:"tags"`
+	Value float64  `json:"value"`
+	// Count is the number of values of a distribution, whose Value is the sum
+	Count int64 `json:"count,omitempty"`
+}
+
+type snapshotServiceCheck struct {
+	Name    string   `json:"name"`
+	Status  string   `json:"status"`
+	Tags    []string `json:"tags"`
+	Message string   `json:"message,omitempty"`
+}
+
+type snapshotEvent struct {
+	Title          string   `json:"title"`
+	AlertType      string   `json:"alert_type,omitempty"`
+	SourceTypeName string   `json:"source_type_name,omitempty"`
+	Tags           []string `json:"tags"`
+	Text           string   `json:"text,omitempty"`
+}
+
+// snapshotEventPlatformEvent is an event platform payload. Only the number of payloads of each type is verified
+// since their contents change between runs.
+type snapshotEventPlatformEvent struct {
+	EventType string `json:"event_type"`
+	Payload   string `json:"payload"`
+}
+
+func newSnapshot(checkName string, tolerance float64, ignoreTags []string) *snapshot {
+	return &snapshot{
+		Check:               checkName,
+		Rules:               snapshotRules{Tolerance: tolerance, IgnoreTags: ignoreTags},
+		Metrics:             []snapshotMetric{},
+		ServiceChecks:       []snapshotServiceCheck{},
+		Events:              []snapshotEvent{},
+		EventPlatformEvents: []snapshotEventPlatformEvent{},
+	}
+}
+
+// record adds the output buffered in the aggregator to the snapshot, emptying the aggregator
+func (s *snapshot) record(demux aggregator.DemultiplexerWithAggregator) {
+	agg := demux.Aggregator()
+
+	series, sketches := agg.GetSeriesAndSketches(time.Now())
+	for _, serie := range series {
+		if len(serie.Points) == 0 {
+			continue
+		}
+		s.Metrics = append(s.Metrics, snapshotMetric{
+			Name:  serie.Name,
+			Type:  serie.MType.String(),
+			Tags:  sortedTags(serie.Tags.UnsafeToReadOnlySliceString()),
+			Value: serie.Points[len(serie.Points)-1].Value,
+		})
+	}
+	for _, sketch := range sketches {
+		if len(sketch.Points) == 0 || sketch.Points[len(sketch.Points)-1].Sketch == nil {
+			continue
+		}
+		basic := sketch.Points[len(sketch.Points)-1].Sketch.Basic
+		s.Metrics = append(s.Metrics, snapshotMetric{
+			Name:  sketch.Name,
+			Type:  "distribution",
+			Tags:  sortedTags(sketch.Tags.UnsafeToReadOnlySliceString()),
+			Value: basic.Sum,
+			Count: basic.Cnt,
+		})
+	}
+
+	for _, sc := range agg.GetServiceChecks() {
+		s.ServiceChecks = append(s.ServiceChecks, snapshotServiceCheck{
+			Name:    sc.CheckName,
+			Status:  sc.Status.String(),
+			Tags:    sortedTags(sc.Tags),
+			Message: sc.Message,
+		})
+	}
+
+	for _, e := range agg.GetEvents() {
+		s.Events = append(s.Events, snapshotEvent{
+			Title:          e.Title,
+			AlertType:      string(e.AlertType),
+			SourceTypeName: e.SourceTypeName,
+			Tags:           sortedTags(e.Tags),
+			Text:           e.Text,
+		})
+	}
+
+	for eventType, messages := range agg.GetEventPlatformEvents() {
+		for _, m := range messages {
+			s.EventPlatformEvents = append(s.EventPlatformEvents, snapshotEventPlatformEvent{
+				EventType: eventType,
+				Payload:   string(m.GetContent()),
+			})
+		}
+	}
+}
+
+// write saves the snapshot to a file
+func (s *snapshot) write(path string) error {
+	s.sort()
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// readSnapshot loads a snapshot saved by write
+func readSnapshot(path string) (*snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &snapshot{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid snapshot %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// sort orders the outputs so that the snapshots of identical runs are identical
+func (s *snapshot) sort() {
+	sort.SliceStable(s.Metrics, func(i, j int) bool {
+		return metricKey(s.Metrics[i].Type, s.Metrics[i].Name, s.Metrics[i].Tags) < metricKey(s.Metrics[j].Type, s.Metrics[j].Name, s.Metrics[j].Tags)
+	})
+	sort.SliceStable(s.ServiceChecks, func(i, j int) bool {
+		return metricKey("", s.ServiceChecks[i].Name, s.ServiceChecks[i].Tags) < metricKey("", s.ServiceChecks[j].Name, s.ServiceChecks[j].Tags)
+	})
+	sort.SliceStable(s.Events, func(i, j int) bool {
+		return metricKey("", s.Events[i].Title, s.Events[i].Tags) < metricKey("", s.Events[j].Title, s.Events[j].Tags)
+	})
+	sort.SliceStable(s.EventPlatformEvents, func(i, j int) bool {
+		return s.EventPlatformEvents[i].EventType < s.EventPlatformEvents[j].EventType
+	})
+}
+
+// verify compares the output of a run to the snapshot and returns the differences
+func (s *snapshot) verify(run *snapshot) []string {
+	var diffs []string
+
+	expectedMetrics := map[string][]snapshotMetric{}
+	for _, m := range s.Metrics {
+		key := metricKey(m.Type, m.Name, s.Rules.filterTags(m.Tags))
+		expectedMetrics[key] = append(expectedMetrics[key], m)
+	}
+	actualMetrics := map[string][]snapshotMetric{}
+	for _, m := range run.Metrics {
+		key := metricKey(m.Type, m.Name, s.Rules.filterTags(m.Tags))
+		actualMetrics[key] = append(actualMetrics[key], m)
+	}
+	for _, key := range sortedKeys(expectedMetrics, actualMetrics) {
+		expected, actual := expectedMetrics[key], actualMetrics[key]
+		byValue := func(a, b snapshotMetric) int { return cmp.Compare(a.Value, b.Value) }
+		slices.SortStableFunc(expected, byValue)
+		slices.SortStableFunc(actual, byValue)
+		for i := 0; i < max(len(expected), len(actual)); i++ {
+			switch {
+			case i >= len(actual):
+				diffs = append(diffs, "missing metric "+key)
+			case i >= len(expected):
+				diffs = append(diffs, "unexpected metric "+key)
+			default:
+				rule := s.Rules.Metrics[expected[i].Name]
+				if rule.IgnoreValue {
+					continue
+				}
+				tolerance := s.Rules.Tolerance
+				if rule.Tolerance != nil {
+					tolerance = *rule.Tolerance
+				}
+				if !withinTolerance(expected[i].Value, actual[i].Value, tolerance) ||
+					!withinTolerance(float64(expected[i].Count), float64(actual[i].Count), tolerance) {
+					diffs = append(diffs, fmt.Sprintf("metric %s: expected %s, got %s", key, formatMetricValue(expected[i]), formatMetricValue(actual[i])))
+				}
+			}
+		}
+	}
+
+	expectedServiceChecks := map[string][]string{}
+	for _, sc := range s.ServiceChecks {
+		key := metricKey("", sc.Name, s.Rules.filterTags(sc.Tags))
+		expectedServiceChecks[key] = append(expectedServiceChecks[key], sc.Status)
+	}
+	actualServiceChecks := map[string][]string{}
+	for _, sc := range run.ServiceChecks {
+		key := metricKey("", sc.Name, s.Rules.filterTags(sc.Tags))
+		actualServiceChecks[key] = append(actualServiceChecks[key], sc.Status)
+	}
+	for _, key := range sortedKeys(expectedServiceChecks, actualServiceChecks) {
+		expected, actual := expectedServiceChecks[key], actualServiceChecks[key]
+		slices.Sort(expected)
+		slices.Sort(actual)
+		for i := 0; i < max(len(expected), len(actual)); i++ {
+			switch {
+			case i >= len(actual):
+				diffs = append(diffs, "missing service check "+key)
+			case i >= len(expected):
+				diffs = append(diffs, "unexpected service check "+key)
+			case expected[i] != actual[i]:
+				diffs = append(diffs, fmt.Sprintf("service check %s: expected status %s, got %s", key, expected[i], actual[i]))
+			}
+		}
+	}
+
+	expectedEvents := map[string]int{}
+	for _, e := range s.Events {
+		expectedEvents[metricKey(e.AlertType, e.Title, s.Rules.filterTags(e.Tags))]++
+	}
+	actualEvents := map[string]int{}
+	for _, e := range run.Events {
+		actualEvents[metricKey(e.AlertType, e.Title, s.Rules.filterTags(e.Tags))]++
+	}
+	diffs = append(diffs, diffCounts("events", expectedEvents, actualEvents)...)
+
+	expectedPayloads := map[string]int{}
+	for _, e := range s.EventPlatformEvents {
+		expectedPayloads[e.EventType]++
+	}
+	actualPayloads := map[string]int{}
+	for _, e := range run.EventPlatformEvents {
+		actualPayloads[e.EventType]++
+	}
+	diffs = append(diffs, diffCounts("event platform payloads", expectedPayloads, actualPayloads)...)
+
+	return diffs
+}
+
+// filterTags returns the sorted tags that are not ignored by the rules
+func (r snapshotRules) filterTags(tags []string) []string {
+	filtered := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if !slices.ContainsFunc(r.IgnoreTags, func(pattern string) bool { return tagMatches(pattern, tag) }) {
+			filtered = append(filtered, tag)
+		}
+	}
+	return sortedTags(filtered)
+}
+
+// tagMatches returns true if a tag matches an ignored tag pattern
+func tagMatches(pattern, tag string) bool {
+	if !strings.Contains(pattern, ":") {
+		key, _, _ := strings.Cut(tag, ":")
+		return key == pattern
+	}
+	if prefix, found := strings.CutSuffix(pattern, "*"); found {
+		return strings.HasPrefix(tag, prefix)
+	}
+	return tag == pattern
+}
+
+// withinTolerance returns true if the relative difference between two values is at most the tolerance
+func withinTolerance(expected, actual, tolerance float64) bool {
+	if expected == actual {
+		return true
+	}
+	return math.Abs(expected-actual) <= tolerance*math.Max(math.Abs(expected), math.Abs(actual))
+}
+
+func formatMetricValue(m snapshotMetric) string {
+	value := strconv.FormatFloat(m.Value, 'g', -1, 64)
+	if m.Type == "distribution" {
+		return fmt.Sprintf("sum %s over %d values", value, m.Count)
+	}
+	return value
+}
+
+// diffCounts reports the outputs whose number of occurrences differs
+func diffCounts(kind string, expected, actual map[string]int) []string {
+	var diffs []string
+	for _, key := range sortedKeys(expected, actual) {
+		if expected[key] != actual[key] {
+			diffs = append(diffs, fmt.Sprintf("%s %s: expected %d, got %d", kind, key, expected[key], actual[key]))
+		}
+	}
+	return diffs
+}
+
+func metricKey(kind, name string, tags []string) string {
+	key := name
+	if kind != "" {
+		key = kind + " " + key
+	}
+	return key + " [" + strings.Join(tags, ",") + "]"
+}
+
+func sortedTags(tags []string) []string {
+	sorted := slices.Clone(tags)
+	if sorted == nil {
+		sorted = []string{}
+	}
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+func sortedKeys[V any](maps ...map[string]V) []string {
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return slices.Compact(keys)
+}
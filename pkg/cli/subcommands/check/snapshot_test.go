@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package check
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSnapshot() *snapshot {
+	s := newSnapshot("test", 0, nil)
+	s.Metrics = []snapshotMetric{
+		{Name: "test.gauge", Type: "gauge", Tags: []string{"env:prod", "pid:1234"}, Value: 100},
+		{Name: "test.rate", Type: "rate", Tags: []string{"env:prod"}, Value: 2.5},
+		{Name: "test.distribution", Type: "distribution", Tags: []string{}, Value: 30, Count: 3},
+	}
+	s.ServiceChecks = []snapshotServiceCheck{{Name: "test.can_connect", Status: "OK", Tags: []string{"env:prod"}}}
+	s.Events = []snapshotEvent{{Title: "restarted", AlertType: "info", Tags: []string{"env:prod"}}}
+	s.EventPlatformEvents = []snapshotEventPlatformEvent{{EventType: "dbm-samples", Payload: "{}"}}
+	return s
+}
+
+func TestSnapshotWriteRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	s := testSnapshot()
+	require.NoError(t, s.write(path))
+
+	read, err := readSnapshot(path)
+	require.NoError(t, err)
+	assert.Equal(t, s, read)
+	assert.Equal(t, "test.distribution", read.Metrics[0].Name, "the metrics are sorted by type and name")
+	assert.Empty(t, read.verify(testSnapshot()))
+}
+
+func TestSnapshotVerify(t *testing.T) {
+	expected := testSnapshot()
+
+	run := testSnapshot()
+	run.Metrics[0].Value = 104
+	run.Metrics[0].Tags = []string{"env:prod", "pid:5678"}
+	run.Metrics = append(run.Metrics, snapshotMetric{Name: "test.new", Type: "gauge", Tags: []string{}, Value: 1})
+	run.ServiceChecks[0].Status = "CRITICAL"
+	run.Events = nil
+	run.EventPlatformEvents = append(run.EventPlatformEvents, run.EventPlatformEvents[0])
+
+	assert.Equal(t, []string{
+		"missing metric gauge test.gauge [env:prod,pid:1234]",
+		"unexpected metric gauge test.gauge [env:prod,pid:5678]",
+		"unexpected metric gauge test.new []",
+		"service check test.can_connect [env:prod]: expected status OK, got CRITICAL",
+		"events info restarted [env:prod]: expected 1, got 0",
+		"event platform payloads dbm-samples: expected 1, got 2",
+	}, expected.verify(run))
+
+	expected.Rules.IgnoreTags = []string{"pid"}
+	assert.Contains(t, expected.verify(run), "metric gauge test.gauge [env:prod]: expected 100, got 104")
+
+	expected.Rules.Tolerance = 0.05
+	assert.NotContains(t, expected.verify(run), "metric gauge test.gauge [env:prod]: expected 100, got 104")
+
+	tolerance := 0.01
+	expected.Rules.Metrics = map[string]snapshotMetricRule{"test.gauge": {Tolerance: &tolerance}}
+	assert.Contains(t, expected.verify(run), "metric gauge test.gauge [env:prod]: expected 100, got 104")
+
+	expected.Rules.Metrics = map[string]snapshotMetricRule{"test.gauge": {IgnoreValue: true}}
+	assert.NotContains(t, expected.verify(run), "metric gauge test.gauge [env:prod]: expected 100, got 104")
+}
+
+func TestSnapshotVerifyDistribution(t *testing.T) {
+	expected := testSnapshot()
+	run := testSnapshot()
+	run.Metrics[2].Count = 4
+
+	assert.Equal(t, []string{"metric distribution test.distribution []: expected sum 30 over 3 values, got sum 30 over 4 values"}, expected.verify(run))
+}
+
+func TestTagMatches(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		tag     string
+		matches bool
+	}{
+		{"pid", "pid:1234", true},
+		{"pid", "pid", true},
+		{"pid", "pidfile:/run/test.pid", false},
+		{"pod_name:web-*", "pod_name:web-5d8f", true},
+		{"pod_name:web-*", "pod_name:api-5d8f", false},
+		{"env:prod", "env:prod", true},
+		{"env:prod", "env:production", false},
+	} {
+		assert.Equal(t, tc.matches, tagMatches(tc.pattern, tc.tag), "%s %s", tc.pattern, tc.tag)
+	}
+}
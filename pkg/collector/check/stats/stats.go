@@ -106,7 +106,10 @@ type Stats struct {
 	// converted to a normal check
 	LongRunning              bool
 	Cancelling               bool
+	Hung                     bool  // a run exceeded the run timeout and has not returned yet
+	QuarantinedUntil         int64 // runs are skipped until this unix timestamp in seconds after a timeout, if any
 	TotalRuns                uint64
+	TotalSkippedRuns         uint64 // runs skipped because the check was still running or quarantined
 	TotalErrors              uint64
 	TotalWarnings            uint64
 	MetricSamples            int64
@@ -273,6 +276,38 @@ func (cs *Stats) SetStateCancelling() {
 	cs.Cancelling = true
 }
 
+// AddSkippedRun counts a run skipped because the check was still running or quarantined
+func (cs *Stats) AddSkippedRun() {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+	cs.TotalSkippedRuns++
+}
+
+// AverageExecutionDuration returns the average duration of the recent runs
+func (cs *Stats) AverageExecutionDuration() time.Duration {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+	return time.Duration(cs.AverageExecutionTime) * time.Millisecond
+}
+
+// SetStateHung sets whether a run of the check exceeded its run timeout and has not returned yet
+func (cs *Stats) SetStateHung(hung bool) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+	cs.Hung = hung
+}
+
+// SetQuarantinedUntil sets the date until which the runs of the check are skipped, the zero time clearing it
+func (cs *Stats) SetQuarantinedUntil(until time.Time) {
+	cs.m.Lock()
+	defer cs.m.Unlock()
+	if until.IsZero() {
+		cs.QuarantinedUntil = 0
+		return
+	}
+	cs.QuarantinedUntil = until.Unix()
+}
+
 type aggStats struct {
 	EventPlatformEvents       map[string]interface{}
 	EventPlatformEventsErrors map[string]interface{}
@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package dbm holds the helpers shared by the checks collecting the Database Monitoring data.
+package dbm
+
+import (
+	"strings"
+	"time"
+)
+
+// CheckIntervalExpired returns true and sets lastRun to the current time when the collection interval, in seconds,
+// elapsed since lastRun.
+func CheckIntervalExpired(lastRun *time.Time, collectionInterval int64) bool {
+	start := time.Now()
+	if lastRun.IsZero() || start.Sub(*lastRun).Milliseconds() >= collectionInterval*1000 {
+		*lastRun = start
+		return true
+	}
+	return false
+}
+
+// ResolveDBHostname returns the hostname the metrics of a database server are reported with: the reported hostname
+// if set, the hostname of the agent for the local servers, including the unix sockets, and the host otherwise.
+func ResolveDBHostname(reportedHostname string, host string, agentHostname string) string {
+	if reportedHostname != "" {
+		return reportedHostname
+	}
+	switch {
+	case host == "localhost", host == "127.0.0.1", host == "::1", strings.HasPrefix(host, "/"):
+		return agentHostname
+	default:
+		return host
+	}
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dbm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+)
+
+func TestCheckIntervalExpired(t *testing.T) {
+	var lastRun time.Time
+	assert.True(t, CheckIntervalExpired(&lastRun, 10))
+	assert.False(t, lastRun.IsZero())
+	assert.False(t, CheckIntervalExpired(&lastRun, 10))
+
+	lastRun = lastRun.Add(-10 * time.Second)
+	assert.True(t, CheckIntervalExpired(&lastRun, 10))
+}
+
+func TestResolveDBHostname(t *testing.T) {
+	assert.Equal(t, "reported", ResolveDBHostname("reported", "localhost", "agent"))
+	for _, host := range []string{"localhost", "127.0.0.1", "::1", "/var/run/mysqld/mysqld.sock"} {
+		assert.Equal(t, "agent", ResolveDBHostname("", host, "agent"), host)
+	}
+	assert.Equal(t, "db.example.com", ResolveDBHostname("", "db.example.com", "agent"))
+}
+
+func TestObfuscateStatement(t *testing.T) {
+	o := NewStatementObfuscator(obfuscate.Config{SQL: obfuscate.SQLConfig{
+		ObfuscationMode: obfuscate.ObfuscateAndNormalize,
+		CollectCommands: true,
+		TableNames:      true,
+	}}, false, "")
+
+	obfuscated, err := o.ObfuscateStatement("SELECT * FROM users WHERE id = 42")
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM users WHERE id = ?", obfuscated.Statement)
+	assert.Equal(t, GetQuerySignature(obfuscated.Statement), obfuscated.QuerySignature)
+	assert.Equal(t, []string{"SELECT"}, obfuscated.Commands)
+	assert.Equal(t, []string{"users"}, obfuscated.Tables)
+	assert.Same(t, o.Obfuscator(), o.Obfuscator())
+}
@@ -0,0 +1,50 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+// Package dbmtest provides the fixtures of the tests of the Database Monitoring checks.
+package dbmtest
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+)
+
+// ConfigureCheck configures the check with the instance and returns the mock sender the check submits to
+func ConfigureCheck(t *testing.T, c check.Check, instance string) *mocksender.MockSender {
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	require.NoError(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data(instance), nil, "test"))
+
+	sender := mocksender.NewMockSenderWithSenderManager(c.ID(), senderManager)
+	sender.SetupAcceptAll()
+	return sender
+}
+
+// NewMockDB returns a sqlmock stand-in of a database server, closed at the end of the test
+func NewMockDB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	db, dbMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return sqlx.NewDb(db, "sqlmock"), dbMock
+}
+
+// EventPlatformPayloads returns the payloads of the event type submitted to the event platform
+func EventPlatformPayloads(sender *mocksender.MockSender, eventType string) [][]byte {
+	var payloads [][]byte
+	for _, call := range sender.Calls {
+		if call.Method == "EventPlatformEvent" && call.Arguments.String(1) == eventType {
+			payloads = append(payloads, call.Arguments.Get(0).([]byte))
+		}
+	}
+	return payloads
+}
@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package dbm
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/twmb/murmur3"
+
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ObfuscatedStatement is a statement obfuscated and normalized with its metadata
+type ObfuscatedStatement struct {
+	Statement      string
+	QuerySignature string
+	Commands       []string
+	Tables         []string
+	Comments       []string
+}
+
+// GetQuerySignature returns the signature of an obfuscated statement
+func GetQuerySignature(statement string) string {
+	return strconv.FormatUint(murmur3.Sum64([]byte(statement)), 16)
+}
+
+// StatementObfuscator obfuscates the statements of a check. The obfuscator is created the first time it is used.
+type StatementObfuscator struct {
+	config                 obfuscate.Config
+	logUnobfuscatedQueries bool
+	logPrompt              string
+	obfuscator             *obfuscate.Obfuscator
+}
+
+// NewStatementObfuscator returns a StatementObfuscator using the obfuscation config. The statements which cannot be
+// obfuscated are logged, prefixed by logPrompt, when logUnobfuscatedQueries is set.
+func NewStatementObfuscator(config obfuscate.Config, logUnobfuscatedQueries bool, logPrompt string) *StatementObfuscator {
+	return &StatementObfuscator{
+		config:                 config,
+		logUnobfuscatedQueries: logUnobfuscatedQueries,
+		logPrompt:              logPrompt,
+	}
+}
+
+// Obfuscator returns the obfuscator, creating it the first time it is called
+func (s *StatementObfuscator) Obfuscator() *obfuscate.Obfuscator {
+	if s.obfuscator == nil {
+		s.obfuscator = obfuscate.NewObfuscator(s.config)
+	}
+	return s.obfuscator
+}
+
+// ObfuscateStatement obfuscates and normalizes a statement and computes its signature
+func (s *StatementObfuscator) ObfuscateStatement(statement string) (ObfuscatedStatement, error) {
+	obfuscated, err := s.Obfuscator().ObfuscateSQLString(statement)
+	if err != nil {
+		if s.logUnobfuscatedQueries {
+			log.Errorf("%s Obfuscation error for SQL: %s", s.logPrompt, statement)
+		}
+		return ObfuscatedStatement{}, err
+	}
+	var tables []string
+	if obfuscated.Metadata.TablesCSV != "" {
+		tables = strings.Split(obfuscated.Metadata.TablesCSV, ",")
+	}
+	return ObfuscatedStatement{
+		Statement:      obfuscated.Query,
+		QuerySignature: GetQuerySignature(obfuscated.Query),
+		Commands:       obfuscated.Metadata.Commands,
+		Tables:         tables,
+		Comments:       obfuscated.Metadata.Comments,
+	}, nil
+}
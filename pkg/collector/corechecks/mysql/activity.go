@@ -0,0 +1,152 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// activityRowDB is a thread of performance_schema.threads with its current statement
+type activityRowDB struct {
+	ThreadID      int64           `db:"thread_id"`
+	ProcesslistID sql.NullInt64   `db:"processlist_id"`
+	User          sql.NullString  `db:"processlist_user"`
+	Host          sql.NullString  `db:"processlist_host"`
+	DB            sql.NullString  `db:"processlist_db"`
+	Command       sql.NullString  `db:"processlist_command"`
+	State         sql.NullString  `db:"processlist_state"`
+	CurrentSchema sql.NullString  `db:"current_schema"`
+	SQLText       sql.NullString  `db:"sql_text"`
+	Digest        sql.NullString  `db:"digest"`
+	EventID       sql.NullInt64   `db:"event_id"`
+	EndEventID    sql.NullInt64   `db:"end_event_id"`
+	TimerWait     sql.NullFloat64 `db:"timer_wait"`
+	LockTime      sql.NullFloat64 `db:"lock_time"`
+	WaitEvent     sql.NullString  `db:"wait_event"`
+}
+
+// activityRow is a thread sampled from performance_schema
+type activityRow struct {
+	ThreadID      int64   `json:"thread_id"`
+	ProcesslistID int64   `json:"processlist_id,omitempty"`
+	User          string  `json:"processlist_user,omitempty"`
+	Host          string  `json:"processlist_host,omitempty"`
+	DB            string  `json:"processlist_db,omitempty"`
+	Command       string  `json:"processlist_command,omitempty"`
+	State         string  `json:"processlist_state,omitempty"`
+	CurrentSchema string  `json:"current_schema,omitempty"`
+	Digest        string  `json:"digest,omitempty"`
+	EventID       int64   `json:"event_id,omitempty"`
+	EndEventID    int64   `json:"end_event_id,omitempty"`
+	TimerWait     float64 `json:"event_timer_wait,omitempty"`
+	LockTime      float64 `json:"lock_time,omitempty"`
+	WaitEvent     string  `json:"wait_event,omitempty"`
+	Statement     string  `json:"sql_text,omitempty"`
+	queryRow
+}
+
+type activitySnapshot struct {
+	Timestamp          float64       `json:"timestamp,omitempty"`
+	Host               string        `json:"host,omitempty"` // Host is the database hostname, not the agent hostname
+	Source             string        `json:"ddsource"`
+	DBMType            string        `json:"dbm_type"`
+	AgentVersion       string        `json:"ddagentversion,omitempty"`
+	Tags               []string      `json:"ddtags,omitempty"`
+	CollectionInterval float64       `json:"collection_interval,omitempty"`
+	MySQLActivity      []activityRow `json:"mysql_activity"`
+}
+
+// activityQuery samples the foreground threads running a command, excluding the connection of the check. The wait
+// event is the current wait of the thread, or CPU when it is not waiting.
+const activityQuery = `SELECT t.thread_id, t.processlist_id, t.processlist_user, t.processlist_host, t.processlist_db,
+	t.processlist_command, t.processlist_state, s.current_schema, s.sql_text, s.digest, s.event_id, s.end_event_id,
+	s.timer_wait, s.lock_time,
+	IF(w.thread_id IS NULL OR w.end_event_id IS NOT NULL, 'CPU', w.event_name) AS wait_event
+FROM performance_schema.threads t
+LEFT JOIN performance_schema.events_statements_current s ON s.thread_id = t.thread_id
+LEFT JOIN performance_schema.events_waits_current w ON w.thread_id = t.thread_id
+WHERE t.type = 'FOREGROUND' AND t.processlist_id IS NOT NULL AND t.processlist_id != CONNECTION_ID()
+	AND t.processlist_command != 'Sleep'
+ORDER BY s.timer_wait DESC
+LIMIT ?`
+
+func nullString(s sql.NullString) string {
+	if s.Valid {
+		return s.String
+	}
+	return ""
+}
+
+// SampleActivity samples the threads running a command and submits them as an activity snapshot
+func (c *Check) SampleActivity() error {
+	var rows []activityRowDB
+	if err := c.db.Select(&rows, activityQuery, c.config.QueryActivity.DBRowsLimit); err != nil {
+		return fmt.Errorf("failed to query events_statements_current: %w", err)
+	}
+
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+
+	activityRows := make([]activityRow, 0, len(rows))
+	for _, row := range rows {
+		ar := activityRow{
+			ThreadID:      row.ThreadID,
+			ProcesslistID: row.ProcesslistID.Int64,
+			User:          nullString(row.User),
+			Host:          nullString(row.Host),
+			DB:            nullString(row.DB),
+			Command:       nullString(row.Command),
+			State:         nullString(row.State),
+			CurrentSchema: nullString(row.CurrentSchema),
+			Digest:        nullString(row.Digest),
+			EventID:       row.EventID.Int64,
+			EndEventID:    row.EndEventID.Int64,
+			TimerWait:     row.TimerWait.Float64,
+			LockTime:      row.LockTime.Float64,
+			WaitEvent:     nullString(row.WaitEvent),
+		}
+		if statement := nullString(row.SQLText); statement != "" {
+			obfuscated, err := c.obfuscator.ObfuscateStatement(statement)
+			if err != nil {
+				log.Debugf("%s failed to obfuscate the statement of thread %d: %s", c.logPrompt, row.ThreadID, err)
+			} else {
+				ar.Statement = obfuscated.Statement
+				ar.queryRow = queryRow{
+					QuerySignature: obfuscated.QuerySignature,
+					Tables:         obfuscated.Tables,
+					Commands:       obfuscated.Commands,
+					Comments:       obfuscated.Comments,
+				}
+			}
+		}
+		activityRows = append(activityRows, ar)
+	}
+
+	payload := activitySnapshot{
+		Timestamp:          float64(c.timeNow().UnixMilli()),
+		Host:               c.dbHostname,
+		Source:             IntegrationName,
+		DBMType:            "activity",
+		AgentVersion:       c.agentVersion,
+		Tags:               c.tags,
+		CollectionInterval: float64(c.config.QueryActivity.CollectionInterval),
+		MySQLActivity:      activityRows,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the activity payload: %w", err)
+	}
+	log.Debugf("%s Activity payload %s", c.logPrompt, string(payloadBytes))
+	sender.EventPlatformEvent(payloadBytes, "dbm-activity")
+	sender.Gauge("dd.mysql.activity.samples_count", float64(len(activityRows)), c.dbHostname, c.tags)
+	return nil
+}
@@ -0,0 +1,239 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package config contains the configuration of the mysql check.
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"gopkg.in/yaml.v3"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	defaultPort                = 3306
+	defaultConnectTimeout      = 10
+	defaultQueryTimeout        = 5000
+	defaultCollectionInterval  = 10
+	defaultStatementsRowsLimit = 10000
+	defaultActivityRowsLimit   = 3500
+)
+
+// InitConfig is used to deserialize integration init config.
+type InitConfig struct {
+	MinCollectionInterval int `yaml:"min_collection_interval"`
+}
+
+// QueryMetricsConfig configures the collection of the statement metrics from
+// performance_schema.events_statements_summary_by_digest
+type QueryMetricsConfig struct {
+	Enabled            bool  `yaml:"enabled"`
+	CollectionInterval int64 `yaml:"collection_interval"`
+	DBRowsLimit        int   `yaml:"db_rows_limit"`
+}
+
+// QueryActivityConfig configures the collection of the activity snapshots from
+// performance_schema.events_statements_current
+type QueryActivityConfig struct {
+	Enabled            bool  `yaml:"enabled"`
+	CollectionInterval int64 `yaml:"collection_interval"`
+	DBRowsLimit        int   `yaml:"payload_row_limit"`
+}
+
+// SSLConfig stores the TLS settings of the connection
+type SSLConfig struct {
+	CA                 string `yaml:"ca"`
+	Cert               string `yaml:"cert"`
+	Key                string `yaml:"key"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// Options holds the optional metric groups of the check
+type Options struct {
+	Replication          bool `yaml:"replication"`
+	DisableInnoDBMetrics bool `yaml:"disable_innodb_metrics"`
+}
+
+// ConnectionConfig stores the database connection information
+type ConnectionConfig struct {
+	Host           string     `yaml:"host"`
+	Port           int        `yaml:"port"`
+	Socket         string     `yaml:"sock"`
+	Username       string     `yaml:"username"`
+	Password       string     `yaml:"password"`
+	DBName         string     `yaml:"dbname"`
+	SSL            *SSLConfig `yaml:"ssl"`
+	ConnectTimeout int        `yaml:"connect_timeout"`
+	QueryTimeout   int        `yaml:"query_timeout"`
+}
+
+// InstanceConfig is used to deserialize integration instance config.
+type InstanceConfig struct {
+	ConnectionConfig       `yaml:",inline"`
+	User                   string              `yaml:"user"`
+	Pass                   string              `yaml:"pass"`
+	DBM                    bool                `yaml:"dbm"`
+	Tags                   []string            `yaml:"tags"`
+	ReportedHostname       string              `yaml:"reported_hostname"`
+	Options                Options             `yaml:"options"`
+	LogUnobfuscatedQueries bool                `yaml:"log_unobfuscated_queries"`
+	ObfuscatorOptions      obfuscate.SQLConfig `yaml:"obfuscator_options"`
+	QueryMetrics           QueryMetricsConfig  `yaml:"query_metrics"`
+	QueryActivity          QueryActivityConfig `yaml:"query_activity"`
+	Loader                 string              `yaml:"loader"`
+}
+
+// CheckConfig holds the config needed for an integration instance to run.
+type CheckConfig struct {
+	InitConfig
+	InstanceConfig
+}
+
+// String returns a string representation of the CheckConfig without sensitive information.
+func (c *CheckConfig) String() string {
+	return fmt.Sprintf(`CheckConfig:
+Host: '%s'
+Port: '%d'
+Socket: '%s'
+`, c.Host, c.Port, c.Socket)
+}
+
+// GetDefaultObfuscatorOptions return default obfuscator options
+func GetDefaultObfuscatorOptions() obfuscate.SQLConfig {
+	return obfuscate.SQLConfig{
+		DBMS:                          obfuscate.DBMSMySQL,
+		TableNames:                    true,
+		CollectCommands:               true,
+		CollectComments:               true,
+		ObfuscationMode:               obfuscate.ObfuscateAndNormalize,
+		RemoveSpaceBetweenParentheses: true,
+		KeepNull:                      true,
+		KeepTrailingSemicolon:         true,
+	}
+}
+
+// NewCheckConfig builds a new check config.
+func NewCheckConfig(rawInstance integration.Data, rawInitConfig integration.Data) (*CheckConfig, error) {
+	instance := InstanceConfig{}
+	initCfg := InitConfig{}
+
+	// Defaults begin
+	instance.Port = defaultPort
+	instance.ConnectTimeout = defaultConnectTimeout
+	instance.QueryTimeout = defaultQueryTimeout
+
+	instance.ObfuscatorOptions = GetDefaultObfuscatorOptions()
+
+	instance.QueryMetrics.Enabled = true
+	instance.QueryMetrics.CollectionInterval = defaultCollectionInterval
+	instance.QueryMetrics.DBRowsLimit = defaultStatementsRowsLimit
+
+	instance.QueryActivity.Enabled = true
+	instance.QueryActivity.CollectionInterval = defaultCollectionInterval
+	instance.QueryActivity.DBRowsLimit = defaultActivityRowsLimit
+	// Defaults end
+
+	if err := yaml.Unmarshal(rawInstance, &instance); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(rawInitConfig, &initCfg); err != nil {
+		return nil, err
+	}
+
+	if instance.Host == "" && instance.Socket == "" {
+		return nil, errors.New("`host` or `sock` must be configured")
+	}
+	if instance.Username == "" {
+		// For the backward compatibility with the Python integration
+		if instance.User == "" {
+			return nil, errors.New("`username` is not configured")
+		}
+		instance.Username = instance.User
+	}
+	if instance.Password == "" {
+		instance.Password = instance.Pass
+	}
+
+	c := &CheckConfig{
+		InstanceConfig: instance,
+		InitConfig:     initCfg,
+	}
+
+	log.Debugf("%s MySQL config: %s", GetLogPrompt(instance), c.String())
+
+	return c, nil
+}
+
+// TLSConfig builds the TLS configuration of the connection, or returns nil when ssl is not configured
+func (c *InstanceConfig) TLSConfig() (*tls.Config, error) {
+	if c.SSL == nil {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         c.Host,
+		InsecureSkipVerify: c.SSL.InsecureSkipVerify, //nolint:gosec // explicitly enabled by the user
+	}
+	if c.SSL.CA != "" {
+		pem, err := os.ReadFile(c.SSL.CA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the ssl ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", c.SSL.CA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.SSL.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(c.SSL.Cert, c.SSL.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the ssl certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// DriverConfig returns the connection configuration of the driver. tlsConfigName is the name the TLS configuration
+// is registered with, if any.
+func (c *InstanceConfig) DriverConfig(tlsConfigName string) *mysql.Config {
+	cfg := mysql.NewConfig()
+	cfg.User = c.Username
+	cfg.Passwd = c.Password
+	cfg.DBName = c.DBName
+	if c.Socket != "" {
+		cfg.Net = "unix"
+		cfg.Addr = c.Socket
+	} else {
+		cfg.Net = "tcp"
+		cfg.Addr = net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	}
+	cfg.Timeout = time.Duration(c.ConnectTimeout) * time.Second
+	if c.QueryTimeout > 0 {
+		cfg.ReadTimeout = time.Duration(c.QueryTimeout) * time.Millisecond
+	}
+	cfg.TLSConfig = tlsConfigName
+	return cfg
+}
+
+// GetLogPrompt returns a config based prompt
+func GetLogPrompt(c InstanceConfig) string {
+	if c.Socket != "" {
+		return c.Socket + ">"
+	}
+	return fmt.Sprintf("%s:%d>", c.Host, c.Port)
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCheckConfigDefaults(t *testing.T) {
+	c, err := NewCheckConfig([]byte("host: localhost\nusername: datadog\npassword: secret"), nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3306, c.Port)
+	assert.True(t, c.QueryMetrics.Enabled)
+	assert.Equal(t, 10000, c.QueryMetrics.DBRowsLimit)
+	assert.Equal(t, 3500, c.QueryActivity.DBRowsLimit)
+	assert.Equal(t, "mysql", c.ObfuscatorOptions.DBMS)
+	assert.NotContains(t, c.String(), "secret")
+}
+
+func TestNewCheckConfigErrors(t *testing.T) {
+	for instance, expectedErr := range map[string]string{
+		"username: datadog": "`host` or `sock` must be configured",
+		"host: localhost":   "`username` is not configured",
+	} {
+		_, err := NewCheckConfig([]byte(instance), nil)
+		assert.ErrorContains(t, err, expectedErr, instance)
+	}
+}
+
+func TestLegacyOptions(t *testing.T) {
+	c, err := NewCheckConfig([]byte("sock: /var/run/mysqld/mysqld.sock\nuser: datadog\npass: secret"), nil)
+	require.NoError(t, err)
+	assert.Equal(t, "datadog", c.Username)
+	assert.Equal(t, "secret", c.Password)
+	assert.Equal(t, "/var/run/mysqld/mysqld.sock>", GetLogPrompt(c.InstanceConfig))
+}
+
+func TestDriverConfig(t *testing.T) {
+	c, err := NewCheckConfig([]byte("host: db.example.com\nport: 3307\nusername: datadog\npassword: secret\ndbname: app"), nil)
+	require.NoError(t, err)
+	cfg := c.DriverConfig("")
+	assert.Equal(t, "tcp", cfg.Net)
+	assert.Equal(t, "db.example.com:3307", cfg.Addr)
+	assert.Equal(t, "app", cfg.DBName)
+	assert.Equal(t, 10*time.Second, cfg.Timeout)
+	assert.Equal(t, 5*time.Second, cfg.ReadTimeout)
+
+	tlsConfig, err := c.TLSConfig()
+	require.NoError(t, err)
+	assert.Nil(t, tlsConfig)
+
+	c, err = NewCheckConfig([]byte("sock: /var/run/mysqld/mysqld.sock\nusername: datadog\nssl:\n  insecure_skip_verify: true"), nil)
+	require.NoError(t, err)
+	cfg = c.DriverConfig("custom")
+	assert.Equal(t, "unix", cfg.Net)
+	assert.Equal(t, "/var/run/mysqld/mysqld.sock", cfg.Addr)
+	assert.Equal(t, "custom", cfg.TLSConfig)
+
+	tlsConfig, err = c.TLSConfig()
+	require.NoError(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+
+	c, err = NewCheckConfig([]byte("host: localhost\nusername: datadog\nssl:\n  ca: /does/not/exist"), nil)
+	require.NoError(t, err)
+	_, err = c.TLSConfig()
+	assert.ErrorContains(t, err, "failed to read the ssl ca")
+}
@@ -0,0 +1,257 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+type metricType int
+
+const (
+	gauge metricType = iota
+	rate
+	monotonicCount
+)
+
+// metric is a variable of SHOW GLOBAL STATUS or SHOW GLOBAL VARIABLES submitted as a metric
+type metric struct {
+	name  string
+	mtype metricType
+}
+
+// statusMetrics map the variables of SHOW GLOBAL STATUS to metrics
+var statusMetrics = map[string]metric{
+	"Connections":             {"mysql.net.connections", rate},
+	"Max_used_connections":    {"mysql.net.max_connections", gauge},
+	"Aborted_clients":         {"mysql.net.aborted_clients", rate},
+	"Aborted_connects":        {"mysql.net.aborted_connects", rate},
+	"Threads_connected":       {"mysql.performance.threads_connected", gauge},
+	"Threads_running":         {"mysql.performance.threads_running", gauge},
+	"Open_files":              {"mysql.performance.open_files", gauge},
+	"Open_tables":             {"mysql.performance.open_tables", gauge},
+	"Queries":                 {"mysql.performance.queries", rate},
+	"Questions":               {"mysql.performance.questions", rate},
+	"Com_select":              {"mysql.performance.com_select", rate},
+	"Com_insert":              {"mysql.performance.com_insert", rate},
+	"Com_update":              {"mysql.performance.com_update", rate},
+	"Com_delete":              {"mysql.performance.com_delete", rate},
+	"Com_replace":             {"mysql.performance.com_replace", rate},
+	"Com_load":                {"mysql.performance.com_load", rate},
+	"Slow_queries":            {"mysql.performance.slow_queries", rate},
+	"Created_tmp_tables":      {"mysql.performance.created_tmp_tables", rate},
+	"Created_tmp_disk_tables": {"mysql.performance.created_tmp_disk_tables", rate},
+	"Created_tmp_files":       {"mysql.performance.created_tmp_files", rate},
+	"Table_locks_waited":      {"mysql.performance.table_locks_waited", gauge},
+	"Bytes_sent":              {"mysql.performance.bytes_sent", rate},
+	"Bytes_received":          {"mysql.performance.bytes_received", rate},
+	"Key_reads":               {"mysql.myisam.key_reads", rate},
+	"Key_writes":              {"mysql.myisam.key_writes", rate},
+	"Key_read_requests":       {"mysql.myisam.key_read_requests", rate},
+	"Key_write_requests":      {"mysql.myisam.key_write_requests", rate},
+}
+
+// innodbMetrics map the InnoDB variables of SHOW GLOBAL STATUS to metrics
+var innodbMetrics = map[string]metric{
+	"Innodb_buffer_pool_pages_total":   {"mysql.innodb.buffer_pool_pages_total", gauge},
+	"Innodb_buffer_pool_pages_free":    {"mysql.innodb.buffer_pool_pages_free", gauge},
+	"Innodb_buffer_pool_pages_data":    {"mysql.innodb.buffer_pool_pages_data", gauge},
+	"Innodb_buffer_pool_pages_dirty":   {"mysql.innodb.buffer_pool_pages_dirty", gauge},
+	"Innodb_buffer_pool_pages_flushed": {"mysql.innodb.buffer_pool_pages_flushed", rate},
+	"Innodb_buffer_pool_read_requests": {"mysql.innodb.buffer_pool_read_requests", rate},
+	"Innodb_buffer_pool_reads":         {"mysql.innodb.buffer_pool_reads", rate},
+	"Innodb_buffer_pool_wait_free":     {"mysql.innodb.buffer_pool_wait_free", monotonicCount},
+	"Innodb_data_reads":                {"mysql.innodb.data_reads", rate},
+	"Innodb_data_writes":               {"mysql.innodb.data_writes", rate},
+	"Innodb_data_fsyncs":               {"mysql.innodb.data_fsyncs", rate},
+	"Innodb_os_log_fsyncs":             {"mysql.innodb.os_log_fsyncs", rate},
+	"Innodb_row_lock_waits":            {"mysql.innodb.row_lock_waits", rate},
+	"Innodb_row_lock_time":             {"mysql.innodb.row_lock_time", rate},
+	"Innodb_row_lock_current_waits":    {"mysql.innodb.row_lock_current_waits", gauge},
+	"Innodb_rows_read":                 {"mysql.innodb.rows_read", rate},
+	"Innodb_rows_inserted":             {"mysql.innodb.rows_inserted", rate},
+	"Innodb_rows_updated":              {"mysql.innodb.rows_updated", rate},
+	"Innodb_rows_deleted":              {"mysql.innodb.rows_deleted", rate},
+}
+
+// variableMetrics map the variables of SHOW GLOBAL VARIABLES to metrics
+var variableMetrics = map[string]metric{
+	"max_connections":         {"mysql.net.max_connections_available", gauge},
+	"table_open_cache":        {"mysql.performance.table_open_cache", gauge},
+	"thread_cache_size":       {"mysql.performance.thread_cache_size", gauge},
+	"key_buffer_size":         {"mysql.myisam.key_buffer_size", gauge},
+	"innodb_buffer_pool_size": {"mysql.innodb.buffer_pool_size", gauge},
+}
+
+func (c *Check) submit(sender sender.Sender, m metric, value float64, tags []string) {
+	switch m.mtype {
+	case gauge:
+		sender.Gauge(m.name, value, c.dbHostname, tags)
+	case rate:
+		sender.Rate(m.name, value, c.dbHostname, tags)
+	case monotonicCount:
+		sender.MonotonicCount(m.name, value, c.dbHostname, tags)
+	}
+}
+
+// showVariables returns the numeric values of the variables of a SHOW statement
+func (c *Check) showVariables(query string) (map[string]float64, error) {
+	rows, err := c.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := map[string]float64{}
+	for rows.Next() {
+		var name, value string
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		if f, ok := parseValue(value); ok {
+			values[name] = f
+		}
+	}
+	return values, rows.Err()
+}
+
+// parseValue converts the value of a variable, with ON and OFF converted to 1 and 0
+func parseValue(value string) (float64, bool) {
+	switch strings.ToUpper(value) {
+	case "ON", "YES":
+		return 1, true
+	case "OFF", "NO":
+		return 0, true
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	return f, err == nil
+}
+
+// collectMetrics submits the metrics of the global status and variables
+func (c *Check) collectMetrics(sender sender.Sender) error {
+	var allErrors error
+
+	status, err := c.showVariables("SHOW /*!50002 GLOBAL */ STATUS")
+	if err != nil {
+		allErrors = errors.Join(allErrors, fmt.Errorf("%s failed to collect status metrics: %w", c.logPrompt, err))
+	} else {
+		for variable, m := range statusMetrics {
+			if value, ok := status[variable]; ok {
+				c.submit(sender, m, value, c.tags)
+			}
+		}
+		if !c.config.Options.DisableInnoDBMetrics {
+			for variable, m := range innodbMetrics {
+				if value, ok := status[variable]; ok {
+					c.submit(sender, m, value, c.tags)
+				}
+			}
+			if total := status["Innodb_buffer_pool_pages_total"]; total > 0 {
+				free := status["Innodb_buffer_pool_pages_free"]
+				sender.Gauge("mysql.innodb.buffer_pool_utilization", (total-free)/total, c.dbHostname, c.tags)
+			}
+		}
+	}
+
+	variables, err := c.showVariables("SHOW GLOBAL VARIABLES")
+	if err != nil {
+		allErrors = errors.Join(allErrors, fmt.Errorf("%s failed to collect variable metrics: %w", c.logPrompt, err))
+	} else {
+		for variable, m := range variableMetrics {
+			if value, ok := variables[variable]; ok {
+				c.submit(sender, m, value, c.tags)
+			}
+		}
+		if maxConnections := variables["max_connections"]; maxConnections > 0 && status != nil {
+			if connected, ok := status["Threads_connected"]; ok {
+				sender.Gauge("mysql.net.connections_usage", connected/maxConnections, c.dbHostname, c.tags)
+			}
+		}
+	}
+	return allErrors
+}
+
+// replicaStatusQuery returns the statement listing the replication channels, renamed in MySQL 8.0.22
+func (c *Check) replicaStatusQuery() string {
+	if !c.mariaDB && c.versionNum >= 80022 {
+		return "SHOW REPLICA STATUS"
+	}
+	return "SHOW SLAVE STATUS"
+}
+
+// collectReplication submits the lag and the state of the replication channels of a replica
+func (c *Check) collectReplication(sender sender.Sender) error {
+	rows, err := c.db.Queryx(c.replicaStatusQuery())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	isReplica := false
+	for rows.Next() {
+		isReplica = true
+		columns := map[string]interface{}{}
+		if err := rows.MapScan(columns); err != nil {
+			return err
+		}
+		tags := c.tags
+		if channel := columnString(columns, "Channel_Name", "Connection_name"); channel != "" {
+			tags = append(append([]string{}, c.tags...), "channel:"+channel)
+		}
+
+		if lag, ok := parseValue(columnString(columns, "Seconds_Behind_Source", "Seconds_Behind_Master")); ok {
+			sender.Gauge("mysql.replication.seconds_behind_source", lag, c.dbHostname, tags)
+			sender.Gauge("mysql.replication.seconds_behind_master", lag, c.dbHostname, tags)
+		}
+
+		ioRunning := columnString(columns, "Replica_IO_Running", "Slave_IO_Running")
+		sqlRunning := columnString(columns, "Replica_SQL_Running", "Slave_SQL_Running")
+		running := 0.0
+		status := servicecheck.ServiceCheckOK
+		switch {
+		case ioRunning == "Yes" && sqlRunning == "Yes":
+			running = 1
+		case ioRunning == "Yes" || sqlRunning == "Yes":
+			status = servicecheck.ServiceCheckWarning
+		default:
+			status = servicecheck.ServiceCheckCritical
+		}
+		sender.Gauge("mysql.replication.replica_running", running, c.dbHostname, tags)
+		sender.Gauge("mysql.replication.slave_running", running, c.dbHostname, tags)
+		sender.ServiceCheck("mysql.replication.replica_running", status, c.dbHostname, tags, "")
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !isReplica {
+		log.Debugf("%s the server is not a replica", c.logPrompt)
+	}
+	return nil
+}
+
+// columnString returns the value of the first of the columns found in a row, and an empty string for NULL values
+func columnString(columns map[string]interface{}, names ...string) string {
+	for _, name := range names {
+		switch v := columns[name].(type) {
+		case nil:
+			continue
+		case []byte:
+			return string(v)
+		case string:
+			return v
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
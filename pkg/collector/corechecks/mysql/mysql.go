@@ -0,0 +1,264 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package mysql implements the MySQL check collecting the server metrics and, when dbm is enabled, the statement
+// metrics and activity samples of Database Monitoring from performance_schema.
+//
+// It is used in place of the Python check when the instances set `loader: core`.
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+	cache "github.com/patrickmn/go-cache"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/mysql/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	// CheckName is the name of the check
+	CheckName = "mysql"
+
+	// IntegrationName is the source of the DBM payloads
+	IntegrationName = "mysql"
+
+	serviceCheckName = "mysql.can_connect"
+	// a single connection is used so that its own statements can be excluded from the activity samples with
+	// CONNECTION_ID()
+	maxOpenConnections   = 1
+	defaultCheckInterval = 15 * time.Second
+)
+
+// Check collects the metrics of a MySQL server
+type Check struct {
+	core.CheckBase
+	config        *config.CheckConfig
+	db            *sqlx.DB
+	dbmEnabled    bool
+	agentVersion  string
+	agentHostname string
+	dbHostname    string
+	tags          []string
+	tagsString    string
+	logPrompt     string
+
+	// initialized is set once the version of the server is fetched
+	initialized bool
+	version     string
+	versionNum  int
+	mariaDB     bool
+
+	statementMetricsPrevious map[statementMetricsKey]statementMetricsCounters
+	statementsLastRun        time.Time
+	activityLastRun          time.Time
+
+	fqtEmitted *cache.Cache
+	obfuscator *dbm.StatementObfuscator
+
+	// for testing purpose
+	timeNow func() time.Time
+}
+
+// Factory creates a new check factory
+func Factory() option.Option[func() check.Check] {
+	return option.New(newCheck)
+}
+
+func newCheck() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBaseWithInterval(CheckName, defaultCheckInterval),
+		timeNow:   time.Now,
+	}
+}
+
+// Configure configures the MySQL check.
+func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigDigest uint64, rawInstance integration.Data, rawInitConfig integration.Data, source string) error {
+	var err error
+	c.config, err = config.NewCheckConfig(rawInstance, rawInitConfig)
+	if err != nil {
+		return fmt.Errorf("failed to build check config: %w", err)
+	}
+
+	// Must be called before c.CommonConfigure because this integration supports multiple instances
+	c.BuildID(integrationConfigDigest, rawInstance, rawInitConfig)
+
+	if err := c.CommonConfigure(senderManager, rawInitConfig, rawInstance, source); err != nil {
+		return fmt.Errorf("common configure failed: %s", err)
+	}
+
+	c.dbmEnabled = c.config.DBM
+	agentVersion, _ := version.Agent()
+	c.agentVersion = agentVersion.GetNumberAndPre()
+	c.logPrompt = config.GetLogPrompt(c.config.InstanceConfig)
+
+	agentHostname, err := hostname.Get(context.Background())
+	if err == nil {
+		c.agentHostname = agentHostname
+	} else {
+		log.Errorf("%s failed to retrieve agent hostname: %s", c.logPrompt, err)
+	}
+	host := c.config.Host
+	if c.config.Socket != "" {
+		host = c.config.Socket
+	}
+	c.dbHostname = dbm.ResolveDBHostname(c.config.ReportedHostname, host, c.agentHostname)
+
+	tags := make([]string, len(c.config.Tags))
+	copy(tags, c.config.Tags)
+	tags = append(tags, "dbms:"+IntegrationName, fmt.Sprintf("dbm:%t", c.dbmEnabled))
+	if c.config.Socket != "" {
+		tags = append(tags, "server:"+c.config.Socket)
+	} else {
+		tags = append(tags, "server:"+c.config.Host, fmt.Sprintf("port:%d", c.config.Port))
+	}
+	c.tags = tags
+	c.tagsString = strings.Join(tags, ",")
+
+	c.fqtEmitted = cache.New(time.Hour, 10*time.Minute)
+	c.obfuscator = dbm.NewStatementObfuscator(obfuscate.Config{SQL: c.config.ObfuscatorOptions}, c.config.LogUnobfuscatedQueries, c.logPrompt)
+	return nil
+}
+
+// Connect opens a connection pool to the database
+func (c *Check) Connect() (*sqlx.DB, error) {
+	tlsConfig, err := c.config.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	var tlsConfigName string
+	if tlsConfig != nil {
+		tlsConfigName = "datadog-" + string(c.ID())
+		if err := mysql.RegisterTLSConfig(tlsConfigName, tlsConfig); err != nil {
+			return nil, fmt.Errorf("failed to register the tls config: %w", err)
+		}
+	}
+	connector, err := mysql.NewConnector(c.config.DriverConfig(tlsConfigName))
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection config: %w", err)
+	}
+	db := sqlx.NewDb(sql.OpenDB(connector), "mysql")
+	db.SetMaxOpenConns(maxOpenConnections)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return db, nil
+}
+
+// parseVersion converts a version like 8.0.36-log or 10.11.6-MariaDB to a number like 80036
+func parseVersion(version string) (int, error) {
+	numbers := strings.SplitN(strings.SplitN(version, "-", 2)[0], ".", 3)
+	if len(numbers) < 2 {
+		return 0, fmt.Errorf("invalid server version %q", version)
+	}
+	var versionNum int
+	for i, multiplier := range []int{10000, 100, 1} {
+		if i >= len(numbers) {
+			break
+		}
+		n, err := strconv.Atoi(numbers[i])
+		if err != nil {
+			return 0, fmt.Errorf("invalid server version %q: %w", version, err)
+		}
+		versionNum += n * multiplier
+	}
+	return versionNum, nil
+}
+
+// init fetches the version of the server the collection depends on
+func (c *Check) init() error {
+	if err := c.db.Get(&c.version, "SELECT VERSION()"); err != nil {
+		return fmt.Errorf("failed to get the server version: %w", err)
+	}
+	var err error
+	if c.versionNum, err = parseVersion(c.version); err != nil {
+		return err
+	}
+	c.mariaDB = strings.Contains(strings.ToLower(c.version), "mariadb")
+	log.Debugf("%s connected to MySQL %s", c.logPrompt, c.version)
+	c.initialized = true
+	return nil
+}
+
+// Run executes the check.
+func (c *Check) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+	defer sender.Commit()
+
+	if c.db == nil {
+		db, err := c.Connect()
+		if err != nil {
+			sender.ServiceCheck(serviceCheckName, servicecheck.ServiceCheckCritical, c.dbHostname, c.tags, err.Error())
+			return fmt.Errorf("%s %w", c.logPrompt, err)
+		}
+		c.db = db
+	}
+	if !c.initialized {
+		if err := c.init(); err != nil {
+			sender.ServiceCheck(serviceCheckName, servicecheck.ServiceCheckCritical, c.dbHostname, c.tags, err.Error())
+			return fmt.Errorf("%s failed to initialize: %w", c.logPrompt, err)
+		}
+	}
+	sender.ServiceCheck(serviceCheckName, servicecheck.ServiceCheckOK, c.dbHostname, c.tags, "")
+
+	var allErrors error
+	if err := c.collectMetrics(sender); err != nil {
+		allErrors = errors.Join(allErrors, err)
+	}
+	if c.config.Options.Replication {
+		if err := c.collectReplication(sender); err != nil {
+			allErrors = errors.Join(allErrors, fmt.Errorf("%s failed to collect replication metrics: %w", c.logPrompt, err))
+		}
+	}
+
+	if c.dbmEnabled {
+		if c.config.QueryMetrics.Enabled && dbm.CheckIntervalExpired(&c.statementsLastRun, c.config.QueryMetrics.CollectionInterval) {
+			if err := c.StatementMetrics(); err != nil {
+				allErrors = errors.Join(allErrors, fmt.Errorf("%s failed to collect statement metrics: %w", c.logPrompt, err))
+			}
+		}
+		if c.config.QueryActivity.Enabled && dbm.CheckIntervalExpired(&c.activityLastRun, c.config.QueryActivity.CollectionInterval) {
+			if err := c.SampleActivity(); err != nil {
+				allErrors = errors.Join(allErrors, fmt.Errorf("%s failed to collect activity samples: %w", c.logPrompt, err))
+			}
+		}
+	}
+	return allErrors
+}
+
+// Teardown cleans up resources used throughout the check.
+func (c *Check) Teardown() {
+	if c.db != nil {
+		if err := c.db.Close(); err != nil {
+			log.Warnf("%s failed to close the connection: %s", c.logPrompt, err)
+		}
+		c.db = nil
+	}
+	if c.config != nil && c.config.SSL != nil {
+		mysql.DeregisterTLSConfig("datadog-" + string(c.ID()))
+	}
+}
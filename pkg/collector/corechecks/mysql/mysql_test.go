@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm/dbmtest"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+)
+
+var statementColumns = []string{
+	"schema_name", "digest", "digest_text", "count_star", "sum_timer_wait", "sum_lock_time", "sum_errors",
+	"sum_rows_affected", "sum_rows_sent", "sum_rows_examined", "sum_select_scan", "sum_select_full_join",
+	"sum_no_index_used", "sum_no_good_index_used",
+}
+
+var activityColumns = []string{
+	"thread_id", "processlist_id", "processlist_user", "processlist_host", "processlist_db", "processlist_command",
+	"processlist_state", "current_schema", "sql_text", "digest", "event_id", "end_event_id", "timer_wait",
+	"lock_time", "wait_event",
+}
+
+// newTestCheck returns a configured check whose connection is a sqlmock stand-in of the server
+func newTestCheck(t *testing.T, instance string) (*Check, sqlmock.Sqlmock, *mocksender.MockSender) {
+	c := newCheck().(*Check)
+	sender := dbmtest.ConfigureCheck(t, c, instance)
+
+	var dbMock sqlmock.Sqlmock
+	c.db, dbMock = dbmtest.NewMockDB(t)
+	c.initialized = true
+	c.version = "8.0.36"
+	c.versionNum = 80036
+	now := time.Unix(1700000000, 0)
+	c.timeNow = func() time.Time { return now }
+	return c, dbMock, sender
+}
+
+func TestParseVersion(t *testing.T) {
+	for version, expected := range map[string]int{
+		"8.0.36":          80036,
+		"8.0.36-log":      80036,
+		"5.7":             50700,
+		"10.11.6-MariaDB": 101106,
+	} {
+		versionNum, err := parseVersion(version)
+		require.NoError(t, err, version)
+		assert.Equal(t, expected, versionNum, version)
+	}
+	_, err := parseVersion("unknown")
+	assert.Error(t, err)
+}
+
+func TestConfigure(t *testing.T) {
+	c, _, _ := newTestCheck(t, "host: localhost\nusername: datadog\ndbm: true\ntags: [env:test]")
+	assert.Equal(t, []string{"env:test", "dbms:mysql", "dbm:true", "server:localhost", "port:3306"}, c.tags)
+	assert.Equal(t, c.agentHostname, c.dbHostname)
+
+	c, _, _ = newTestCheck(t, "host: db.example.com\nusername: datadog")
+	assert.Equal(t, "db.example.com", c.dbHostname)
+	assert.False(t, c.dbmEnabled)
+}
+
+func TestCollectMetrics(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\noptions:\n  replication: true")
+
+	dbMock.ExpectQuery("STATUS").WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+		AddRow("Threads_connected", "10").
+		AddRow("Questions", "1500").
+		AddRow("Innodb_buffer_pool_pages_total", "1000").
+		AddRow("Innodb_buffer_pool_pages_free", "250").
+		AddRow("Rpl_semi_sync_source_status", "OFF").
+		AddRow("Ssl_cipher", ""))
+	dbMock.ExpectQuery("SHOW GLOBAL VARIABLES").WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).
+		AddRow("max_connections", "100").
+		AddRow("version_comment", "MySQL Community Server - GPL"))
+	dbMock.ExpectQuery("SHOW REPLICA STATUS").WillReturnRows(sqlmock.NewRows(
+		[]string{"Replica_IO_Running", "Replica_SQL_Running", "Seconds_Behind_Source", "Channel_Name"}).
+		AddRow("Yes", "No", "12", ""))
+
+	require.NoError(t, c.Run())
+	require.NoError(t, dbMock.ExpectationsWereMet())
+
+	sender.AssertServiceCheck(t, "mysql.can_connect", servicecheck.ServiceCheckOK, "db.example.com", c.tags, "")
+	sender.AssertMetric(t, "Gauge", "mysql.performance.threads_connected", 10, "db.example.com", c.tags)
+	sender.AssertMetric(t, "Rate", "mysql.performance.questions", 1500, "db.example.com", c.tags)
+	sender.AssertMetric(t, "Gauge", "mysql.innodb.buffer_pool_utilization", 0.75, "db.example.com", c.tags)
+	sender.AssertMetric(t, "Gauge", "mysql.net.max_connections_available", 100, "db.example.com", c.tags)
+	sender.AssertMetric(t, "Gauge", "mysql.net.connections_usage", 0.1, "db.example.com", c.tags)
+	sender.AssertMetric(t, "Gauge", "mysql.replication.seconds_behind_source", 12, "db.example.com", c.tags)
+	sender.AssertMetric(t, "Gauge", "mysql.replication.replica_running", 0, "db.example.com", c.tags)
+	sender.AssertServiceCheck(t, "mysql.replication.replica_running", servicecheck.ServiceCheckWarning, "db.example.com", c.tags, "")
+}
+
+func TestReplicaStatusQuery(t *testing.T) {
+	c := &Check{versionNum: 80021}
+	assert.Equal(t, "SHOW SLAVE STATUS", c.replicaStatusQuery())
+	c.versionNum = 80022
+	assert.Equal(t, "SHOW REPLICA STATUS", c.replicaStatusQuery())
+	c.mariaDB = true
+	assert.Equal(t, "SHOW SLAVE STATUS", c.replicaStatusQuery())
+}
+
+func TestStatementMetrics(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\ndbm: true")
+
+	digestText := "SELECT * FROM orders WHERE id = ?"
+	dbMock.ExpectQuery("FROM performance_schema.events_statements_summary_by_digest").WithArgs(10000).
+		WillReturnRows(sqlmock.NewRows(statementColumns).
+			AddRow("shop", "abc123", digestText, 10, 1e9, 1e6, 0, 0, 10, 20, 0, 0, 0, 0))
+	require.NoError(t, c.StatementMetrics())
+	assert.Empty(t, dbmtest.EventPlatformPayloads(sender, "dbm-metrics"), "the first collection only records the counters")
+
+	dbMock.ExpectQuery("FROM performance_schema.events_statements_summary_by_digest").
+		WillReturnRows(sqlmock.NewRows(statementColumns).
+			AddRow("shop", "abc123", digestText, 14, 3e9, 2e6, 1, 0, 14, 28, 0, 0, 0, 0).
+			AddRow("shop", "def456", "SELECT ?", 5, 1e6, 0, 0, 0, 5, 0, 0, 0, 0, 0))
+	require.NoError(t, c.StatementMetrics())
+	require.NoError(t, dbMock.ExpectationsWereMet())
+
+	payloads := dbmtest.EventPlatformPayloads(sender, "dbm-metrics")
+	require.Len(t, payloads, 1)
+	var payload metricsPayload
+	require.NoError(t, json.Unmarshal(payloads[0], &payload))
+	require.Len(t, payload.MySQLRows, 1, "the new digests are reported from the next collection")
+	row := payload.MySQLRows[0]
+	assert.Equal(t, "abc123", row.Digest)
+	assert.Equal(t, "shop", row.SchemaName)
+	assert.Equal(t, []string{"orders"}, row.Tables)
+	assert.Equal(t, dbm.GetQuerySignature(row.DigestText), row.QuerySignature)
+	assert.Equal(t, 4.0, row.CountStar)
+	assert.Equal(t, 2e9, row.SumTimerWait)
+	assert.Equal(t, 1.0, row.SumErrors)
+	assert.Equal(t, 8.0, row.SumRowsExamined)
+	assert.Equal(t, "MySQL", payload.MySQLFlavor)
+
+	assert.Len(t, dbmtest.EventPlatformPayloads(sender, "dbm-samples"), 1, "the full query text is sent once")
+}
+
+func TestStatementMetricsTruncated(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\ndbm: true")
+
+	for _, count := range []int{10, 2} {
+		dbMock.ExpectQuery("FROM performance_schema.events_statements_summary_by_digest").
+			WillReturnRows(sqlmock.NewRows(statementColumns).
+				AddRow(nil, "abc123", "SELECT ?", count, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0))
+		require.NoError(t, c.StatementMetrics())
+	}
+
+	payloads := dbmtest.EventPlatformPayloads(sender, "dbm-metrics")
+	require.Len(t, payloads, 1)
+	var payload metricsPayload
+	require.NoError(t, json.Unmarshal(payloads[0], &payload))
+	assert.Empty(t, payload.MySQLRows)
+}
+
+func TestSampleActivity(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\ndbm: true")
+
+	dbMock.ExpectQuery("FROM performance_schema.threads").WithArgs(3500).WillReturnRows(sqlmock.NewRows(activityColumns).
+		AddRow(52, 12, "web", "10.0.0.3:51234", "shop", "Query", "executing", "shop",
+			"UPDATE orders SET state = 'paid' WHERE id = 1", "def789", 7, nil, 2e9, 1e6, "wait/io/table/sql/handler").
+		AddRow(53, 13, "web", "10.0.0.3:51235", nil, "Connect", nil, nil, nil, nil, nil, nil, nil, nil, "CPU"))
+	require.NoError(t, c.SampleActivity())
+	require.NoError(t, dbMock.ExpectationsWereMet())
+
+	payloads := dbmtest.EventPlatformPayloads(sender, "dbm-activity")
+	require.Len(t, payloads, 1)
+	var snapshot activitySnapshot
+	require.NoError(t, json.Unmarshal(payloads[0], &snapshot))
+	assert.Equal(t, "activity", snapshot.DBMType)
+	require.Len(t, snapshot.MySQLActivity, 2)
+
+	row := snapshot.MySQLActivity[0]
+	assert.Equal(t, "UPDATE orders SET state = ? WHERE id = ?", row.Statement)
+	assert.Equal(t, []string{"orders"}, row.Tables)
+	assert.Equal(t, "wait/io/table/sql/handler", row.WaitEvent)
+	assert.Equal(t, int64(12), row.ProcesslistID)
+	assert.Equal(t, 2e9, row.TimerWait)
+
+	assert.Empty(t, snapshot.MySQLActivity[1].Statement)
+	assert.Equal(t, "CPU", snapshot.MySQLActivity[1].WaitEvent)
+}
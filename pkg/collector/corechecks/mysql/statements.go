@@ -0,0 +1,266 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package mysql
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	cache "github.com/patrickmn/go-cache"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// statementMetricsKey identifies a row of events_statements_summary_by_digest
+type statementMetricsKey struct {
+	SchemaName string
+	Digest     string
+}
+
+// statementMetricsCounters are the cumulative counters of a row of events_statements_summary_by_digest. The timers
+// are in picoseconds.
+type statementMetricsCounters struct {
+	CountStar          float64 `db:"count_star" json:"count_star"`
+	SumTimerWait       float64 `db:"sum_timer_wait" json:"sum_timer_wait"`
+	SumLockTime        float64 `db:"sum_lock_time" json:"sum_lock_time"`
+	SumErrors          float64 `db:"sum_errors" json:"sum_errors"`
+	SumRowsAffected    float64 `db:"sum_rows_affected" json:"sum_rows_affected"`
+	SumRowsSent        float64 `db:"sum_rows_sent" json:"sum_rows_sent"`
+	SumRowsExamined    float64 `db:"sum_rows_examined" json:"sum_rows_examined"`
+	SumSelectScan      float64 `db:"sum_select_scan" json:"sum_select_scan"`
+	SumSelectFullJoin  float64 `db:"sum_select_full_join" json:"sum_select_full_join"`
+	SumNoIndexUsed     float64 `db:"sum_no_index_used" json:"sum_no_index_used"`
+	SumNoGoodIndexUsed float64 `db:"sum_no_good_index_used" json:"sum_no_good_index_used"`
+}
+
+// statementMetricsRowDB is a row of events_statements_summary_by_digest
+type statementMetricsRowDB struct {
+	SchemaName sql.NullString `db:"schema_name"`
+	Digest     string         `db:"digest"`
+	DigestText string         `db:"digest_text"`
+	statementMetricsCounters
+}
+
+// queryRow holds the metadata of an obfuscated statement
+type queryRow struct {
+	QuerySignature string   `json:"query_signature,omitempty"`
+	Tables         []string `json:"dd_tables,omitempty"`
+	Commands       []string `json:"dd_commands,omitempty"`
+	Comments       []string `json:"dd_comments,omitempty"`
+}
+
+// mysqlRow contains the metrics of a statement digest over the last collection interval
+type mysqlRow struct {
+	queryRow
+	DigestText string `json:"digest_text"`
+	Digest     string `json:"digest"`
+	SchemaName string `json:"schema_name,omitempty"`
+	statementMetricsCounters
+}
+
+type metricsPayload struct {
+	Host                  string   `json:"host,omitempty"` // Host is the database hostname, not the agent hostname
+	Timestamp             float64  `json:"timestamp,omitempty"`
+	MinCollectionInterval float64  `json:"min_collection_interval,omitempty"`
+	Tags                  []string `json:"tags,omitempty"`
+	AgentVersion          string   `json:"ddagentversion,omitempty"`
+	AgentHostname         string   `json:"ddagenthostname,omitempty"`
+
+	MySQLRows    []mysqlRow `json:"mysql_rows"`
+	MySQLVersion string     `json:"mysql_version,omitempty"`
+	MySQLFlavor  string     `json:"mysql_flavor,omitempty"`
+}
+
+type fqtDBMetadata struct {
+	Tables   []string `json:"tables"`
+	Commands []string `json:"commands"`
+	Comments []string `json:"comments"`
+}
+
+type fqtDB struct {
+	Instance       string        `json:"instance"`
+	QuerySignature string        `json:"query_signature"`
+	Statement      string        `json:"statement"`
+	Metadata       fqtDBMetadata `json:"metadata"`
+}
+
+type fqtMySQL struct {
+	SchemaName string `json:"schema,omitempty"`
+}
+
+type fqtPayload struct {
+	Timestamp    float64  `json:"timestamp,omitempty"`
+	Host         string   `json:"host,omitempty"` // Host is the database hostname, not the agent hostname
+	AgentVersion string   `json:"ddagentversion,omitempty"`
+	Source       string   `json:"ddsource"`
+	Tags         string   `json:"ddtags,omitempty"`
+	DBMType      string   `json:"dbm_type"`
+	DB           fqtDB    `json:"db"`
+	MySQL        fqtMySQL `json:"mysql"`
+}
+
+const statementMetricsQuery = `SELECT schema_name, digest, digest_text, count_star, sum_timer_wait, sum_lock_time,
+	sum_errors, sum_rows_affected, sum_rows_sent, sum_rows_examined, sum_select_scan, sum_select_full_join,
+	sum_no_index_used, sum_no_good_index_used
+FROM performance_schema.events_statements_summary_by_digest
+WHERE digest IS NOT NULL AND digest_text IS NOT NULL AND digest_text NOT LIKE 'EXPLAIN %'
+ORDER BY count_star DESC
+LIMIT ?`
+
+// subtractCounters returns the difference between the current and the previous counters, and false if a counter
+// decreased because the digests were truncated
+func subtractCounters(current, previous statementMetricsCounters) (statementMetricsCounters, bool) {
+	diff := statementMetricsCounters{
+		CountStar:          current.CountStar - previous.CountStar,
+		SumTimerWait:       current.SumTimerWait - previous.SumTimerWait,
+		SumLockTime:        current.SumLockTime - previous.SumLockTime,
+		SumErrors:          current.SumErrors - previous.SumErrors,
+		SumRowsAffected:    current.SumRowsAffected - previous.SumRowsAffected,
+		SumRowsSent:        current.SumRowsSent - previous.SumRowsSent,
+		SumRowsExamined:    current.SumRowsExamined - previous.SumRowsExamined,
+		SumSelectScan:      current.SumSelectScan - previous.SumSelectScan,
+		SumSelectFullJoin:  current.SumSelectFullJoin - previous.SumSelectFullJoin,
+		SumNoIndexUsed:     current.SumNoIndexUsed - previous.SumNoIndexUsed,
+		SumNoGoodIndexUsed: current.SumNoGoodIndexUsed - previous.SumNoGoodIndexUsed,
+	}
+	for _, v := range []float64{
+		diff.CountStar, diff.SumTimerWait, diff.SumLockTime, diff.SumErrors, diff.SumRowsAffected, diff.SumRowsSent,
+		diff.SumRowsExamined, diff.SumSelectScan, diff.SumSelectFullJoin, diff.SumNoIndexUsed, diff.SumNoGoodIndexUsed,
+	} {
+		if v < 0 {
+			return diff, false
+		}
+	}
+	return diff, true
+}
+
+func (c *Check) flavor() string {
+	if c.mariaDB {
+		return "MariaDB"
+	}
+	return "MySQL"
+}
+
+// StatementMetrics collects the deltas of the statement digest counters since the previous collection, and submits
+// them with the obfuscated digest texts. The first collection only records the counters.
+func (c *Check) StatementMetrics() error {
+	start := c.timeNow()
+
+	var rows []statementMetricsRowDB
+	if err := c.db.Select(&rows, statementMetricsQuery, c.config.QueryMetrics.DBRowsLimit); err != nil {
+		return fmt.Errorf("failed to query events_statements_summary_by_digest, make sure performance_schema is enabled: %w", err)
+	}
+
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+
+	current := make(map[statementMetricsKey]statementMetricsCounters, len(rows))
+	mysqlRows := []mysqlRow{}
+	for _, row := range rows {
+		key := statementMetricsKey{SchemaName: row.SchemaName.String, Digest: row.Digest}
+		current[key] = row.statementMetricsCounters
+
+		if c.statementMetricsPrevious == nil {
+			continue
+		}
+		previous, found := c.statementMetricsPrevious[key]
+		if !found {
+			continue
+		}
+		diff, ok := subtractCounters(row.statementMetricsCounters, previous)
+		if !ok || diff.CountStar == 0 {
+			continue
+		}
+
+		obfuscated, err := c.obfuscator.ObfuscateStatement(row.DigestText)
+		if err != nil {
+			log.Debugf("%s failed to obfuscate the digest %s: %s", c.logPrompt, row.Digest, err)
+			continue
+		}
+		qr := queryRow{
+			QuerySignature: obfuscated.QuerySignature,
+			Tables:         obfuscated.Tables,
+			Commands:       obfuscated.Commands,
+			Comments:       obfuscated.Comments,
+		}
+		mysqlRows = append(mysqlRows, mysqlRow{
+			queryRow:                 qr,
+			DigestText:               obfuscated.Statement,
+			Digest:                   row.Digest,
+			SchemaName:               row.SchemaName.String,
+			statementMetricsCounters: diff,
+		})
+
+		if _, found := c.fqtEmitted.Get(qr.QuerySignature); !found {
+			c.sendFQT(obfuscated, row.SchemaName.String)
+			c.fqtEmitted.Set(qr.QuerySignature, "1", cache.DefaultExpiration)
+		}
+	}
+	firstRun := c.statementMetricsPrevious == nil
+	c.statementMetricsPrevious = current
+	if firstRun {
+		return nil
+	}
+
+	payload := metricsPayload{
+		Host:                  c.dbHostname,
+		Timestamp:             float64(c.timeNow().UnixMilli()),
+		MinCollectionInterval: float64(c.config.QueryMetrics.CollectionInterval),
+		Tags:                  c.tags,
+		AgentVersion:          c.agentVersion,
+		AgentHostname:         c.agentHostname,
+		MySQLRows:             mysqlRows,
+		MySQLVersion:          c.version,
+		MySQLFlavor:           c.flavor(),
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the query metrics payload: %w", err)
+	}
+	log.Debugf("%s Query metrics payload %s", c.logPrompt, string(payloadBytes))
+	sender.EventPlatformEvent(payloadBytes, "dbm-metrics")
+	sender.Gauge("dd.mysql.statement_metrics.time_ms", float64(c.timeNow().Sub(start).Milliseconds()), c.dbHostname, c.tags)
+	sender.Gauge("dd.mysql.statement_metrics.rows", float64(len(mysqlRows)), c.dbHostname, c.tags)
+	return nil
+}
+
+// sendFQT submits the full query text of a statement seen for the first time
+func (c *Check) sendFQT(statement dbm.ObfuscatedStatement, schemaName string) {
+	sender, err := c.GetSender()
+	if err != nil {
+		log.Errorf("%s failed to get the sender: %s", c.logPrompt, err)
+		return
+	}
+	payload := fqtPayload{
+		Timestamp:    float64(c.timeNow().UnixMilli()),
+		Host:         c.dbHostname,
+		AgentVersion: c.agentVersion,
+		Source:       IntegrationName,
+		Tags:         c.tagsString,
+		DBMType:      "fqt",
+		DB: fqtDB{
+			Instance:       schemaName,
+			QuerySignature: statement.QuerySignature,
+			Statement:      statement.Statement,
+			Metadata: fqtDBMetadata{
+				Tables:   statement.Tables,
+				Commands: statement.Commands,
+				Comments: statement.Comments,
+			},
+		},
+		MySQL: fqtMySQL{SchemaName: schemaName},
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		log.Errorf("%s failed to marshal the fqt payload: %s", c.logPrompt, err)
+		return
+	}
+	sender.EventPlatformEvent(payloadBytes, "dbm-samples")
+}
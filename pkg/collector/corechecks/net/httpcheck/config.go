@@ -0,0 +1,139 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package httpcheck
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+const (
+	defaultTimeout           = 10
+	defaultStatusCodePattern = `(1|2|3)\d\d`
+	defaultDaysWarning       = 14
+	defaultDaysCritical      = 7
+	secondsPerDay            = 24 * 60 * 60
+	maxIncludedContentLength = 200
+	maxResponseContentLength = 10 * 1024 * 1024
+)
+
+// instanceConfig is the configuration of an instance, following the options of the http_check integration
+type instanceConfig struct {
+	Name    string            `yaml:"name"`
+	URL     string            `yaml:"url"`
+	Method  string            `yaml:"method"`
+	Data    string            `yaml:"data"`
+	Headers map[string]string `yaml:"headers"`
+	Timeout float64           `yaml:"timeout"`
+
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	ResponseStatusCode  string `yaml:"http_response_status_code"`
+	ContentMatch        string `yaml:"content_match"`
+	ReverseContentMatch bool   `yaml:"reverse_content_match"`
+	IncludeContent      bool   `yaml:"include_content"`
+	AllowRedirects      *bool  `yaml:"allow_redirects"`
+	CollectResponseTime *bool  `yaml:"collect_response_time"`
+
+	CheckCertificateExpiration *bool   `yaml:"check_certificate_expiration"`
+	DaysWarning                float64 `yaml:"days_warning"`
+	DaysCritical               float64 `yaml:"days_critical"`
+	SecondsWarning             float64 `yaml:"seconds_warning"`
+	SecondsCritical            float64 `yaml:"seconds_critical"`
+
+	httputils.TLSInstanceConfig `yaml:",inline"`
+
+	Tags []string `yaml:"tags"`
+}
+
+// config is the parsed configuration of an instance
+type config struct {
+	instanceConfig
+	url *url.URL
+
+	statusCodeRegex   *regexp.Regexp
+	contentMatchRegex *regexp.Regexp
+}
+
+func parseConfig(data integration.Data) (*config, error) {
+	c := &config{}
+	if err := yaml.Unmarshal(data, &c.instanceConfig); err != nil {
+		return nil, err
+	}
+
+	if c.URL == "" {
+		return nil, errors.New("url must be set")
+	}
+	var err error
+	if c.url, err = url.Parse(c.URL); err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if c.url.Scheme != "http" && c.url.Scheme != "https" {
+		return nil, fmt.Errorf("invalid url %s: the scheme must be http or https", c.URL)
+	}
+	if c.Name == "" {
+		c.Name = c.URL
+	}
+	if c.Method == "" {
+		c.Method = http.MethodGet
+	}
+	c.Method = strings.ToUpper(c.Method)
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+
+	if c.ResponseStatusCode == "" {
+		c.ResponseStatusCode = defaultStatusCodePattern
+	}
+	// the status code only has to start with a match, like in the http_check integration
+	if c.statusCodeRegex, err = regexp.Compile("^(?:" + c.ResponseStatusCode + ")"); err != nil {
+		return nil, fmt.Errorf("invalid http_response_status_code: %w", err)
+	}
+	if c.ContentMatch != "" {
+		if c.contentMatchRegex, err = regexp.Compile(c.ContentMatch); err != nil {
+			return nil, fmt.Errorf("invalid content_match: %w", err)
+		}
+	}
+
+	if c.DaysWarning <= 0 {
+		c.DaysWarning = defaultDaysWarning
+	}
+	if c.DaysCritical <= 0 {
+		c.DaysCritical = defaultDaysCritical
+	}
+	if c.SecondsWarning <= 0 {
+		c.SecondsWarning = c.DaysWarning * secondsPerDay
+	}
+	if c.SecondsCritical <= 0 {
+		c.SecondsCritical = c.DaysCritical * secondsPerDay
+	}
+	return c, nil
+}
+
+// allowRedirects returns whether the redirects are followed, which they are by default
+func (c *config) allowRedirects() bool {
+	return c.AllowRedirects == nil || *c.AllowRedirects
+}
+
+// collectResponseTime returns whether the response time is submitted, which it is by default
+func (c *config) collectResponseTime() bool {
+	return c.CollectResponseTime == nil || *c.CollectResponseTime
+}
+
+// checkCertificateExpiration returns whether the certificate of HTTPS urls is checked, which it is by default
+func (c *config) checkCertificateExpiration() bool {
+	return c.url.Scheme == "https" && (c.CheckCertificateExpiration == nil || *c.CheckCertificateExpiration)
+}
@@ -0,0 +1,315 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package httpcheck implements a check monitoring the availability and the certificate of HTTP endpoints.
+//
+// It submits the metrics and service checks of the http_check integration and is used in place of the
+// Python check when the instances set `loader: core`.
+package httpcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+const (
+	// CheckName is the name of the check
+	CheckName = "http_check"
+
+	serviceCheckCanConnect = "http.can_connect"
+	serviceCheckSSLCert    = "http.ssl_cert"
+)
+
+// for testing purpose
+var timeNow = time.Now
+
+// Check sends a request to an HTTP endpoint and validates its response
+type Check struct {
+	core.CheckBase
+	config *config
+	client *http.Client
+	tags   []string
+}
+
+// Factory creates a new check factory
+func Factory() option.Option[func() check.Check] {
+	return option.New(newCheck)
+}
+
+func newCheck() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBase(CheckName),
+	}
+}
+
+// Configure parses the check configuration and initializes the check
+func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigDigest uint64, data integration.Data, initConfig integration.Data, source string) error {
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+
+	c.BuildID(integrationConfigDigest, data, initConfig)
+	if err := c.CommonConfigure(senderManager, initConfig, data, source); err != nil {
+		return err
+	}
+
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		return err
+	}
+	c.config = cfg
+	c.tags = append([]string{"url:" + cfg.URL, "instance:" + cfg.Name}, cfg.Tags...)
+	c.client = &http.Client{
+		Timeout: time.Duration(cfg.Timeout * float64(time.Second)),
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+			// each run opens a new connection for its timings to include the connection and the handshake
+			DisableKeepAlives: true,
+		},
+	}
+	if !cfg.allowRedirects() {
+		c.client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	return nil
+}
+
+// timings are the durations of the phases of a request
+type timings struct {
+	sync.Mutex
+	dnsStart, connectStart, tlsStart time.Time
+	dns, connect, tls, ttfb          time.Duration
+}
+
+func (t *timings) trace(start time.Time) *httptrace.ClientTrace {
+	// the callbacks can be called concurrently when dialing several addresses
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.Lock()
+			defer t.Unlock()
+			t.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.Lock()
+			defer t.Unlock()
+			t.dns = time.Since(t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.Lock()
+			defer t.Unlock()
+			t.connectStart = time.Now()
+		},
+		ConnectDone: func(_, _ string, err error) {
+			t.Lock()
+			defer t.Unlock()
+			if err == nil {
+				t.connect = time.Since(t.connectStart)
+			}
+		},
+		TLSHandshakeStart: func() {
+			t.Lock()
+			defer t.Unlock()
+			t.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.Lock()
+			defer t.Unlock()
+			t.tls = time.Since(t.tlsStart)
+		},
+		GotFirstResponseByte: func() {
+			t.Lock()
+			defer t.Unlock()
+			t.ttfb = time.Since(start)
+		},
+	}
+}
+
+// response is the result of a request to the endpoint
+type response struct {
+	statusCode   int
+	content      []byte
+	tlsState     *tls.ConnectionState
+	responseTime time.Duration
+	timings      *timings
+}
+
+// Run sends a request to the endpoint and submits its status
+func (c *Check) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+	defer sender.Commit()
+
+	resp, err := c.request()
+	var status servicecheck.ServiceCheckStatus
+	var message string
+	if err != nil {
+		status, message = servicecheck.ServiceCheckCritical, err.Error()
+	} else {
+		if c.config.collectResponseTime() {
+			c.submitTimings(sender, resp)
+		}
+		status, message = c.validate(resp)
+	}
+	sender.ServiceCheck(serviceCheckCanConnect, status, "", c.tags, message)
+
+	canConnect := 0.0
+	if status == servicecheck.ServiceCheckOK {
+		canConnect = 1
+	}
+	sender.Gauge("network.http.can_connect", canConnect, "", c.tags)
+	sender.Gauge("network.http.cant_connect", 1-canConnect, "", c.tags)
+
+	if c.config.checkCertificateExpiration() {
+		var tlsState *tls.ConnectionState
+		if resp != nil {
+			tlsState = resp.tlsState
+		}
+		c.checkCertificate(sender, tlsState, err)
+	}
+	return nil
+}
+
+// request sends the request to the endpoint and reads its response
+func (c *Check) request() (*response, error) {
+	start := time.Now()
+	t := &timings{}
+	ctx := httptrace.WithClientTrace(context.Background(), t.trace(start))
+
+	var body io.Reader
+	if c.config.Data != "" {
+		body = strings.NewReader(c.config.Data)
+	}
+	req, err := http.NewRequestWithContext(ctx, c.config.Method, c.config.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range c.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseContentLength))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the response of %s: %w", c.config.URL, err)
+	}
+
+	return &response{
+		statusCode:   resp.StatusCode,
+		content:      content,
+		tlsState:     resp.TLS,
+		responseTime: time.Since(start),
+		timings:      t,
+	}, nil
+}
+
+func (c *Check) submitTimings(sender sender.Sender, resp *response) {
+	sender.Gauge("network.http.response_time", resp.responseTime.Seconds(), "", c.tags)
+
+	resp.timings.Lock()
+	defer resp.timings.Unlock()
+	sender.Gauge("network.http.timing.dns", resp.timings.dns.Seconds(), "", c.tags)
+	sender.Gauge("network.http.timing.connect", resp.timings.connect.Seconds(), "", c.tags)
+	if resp.tlsState != nil {
+		sender.Gauge("network.http.timing.tls", resp.timings.tls.Seconds(), "", c.tags)
+	}
+	sender.Gauge("network.http.timing.ttfb", resp.timings.ttfb.Seconds(), "", c.tags)
+}
+
+// validate returns the status of the response according to the expected status code and content
+func (c *Check) validate(resp *response) (servicecheck.ServiceCheckStatus, string) {
+	statusCode := fmt.Sprint(resp.statusCode)
+	if !c.config.statusCodeRegex.MatchString(statusCode) {
+		message := fmt.Sprintf("Incorrect HTTP return code for url %s. Expected %s, got %s.", c.config.URL, c.config.ResponseStatusCode, statusCode)
+		return servicecheck.ServiceCheckCritical, c.withContent(message, resp.content)
+	}
+
+	if c.config.contentMatchRegex != nil {
+		found := c.config.contentMatchRegex.Match(resp.content)
+		if found && c.config.ReverseContentMatch {
+			message := fmt.Sprintf("Content %q found in response with reverse_content_match", c.config.ContentMatch)
+			return servicecheck.ServiceCheckCritical, c.withContent(message, resp.content)
+		}
+		if !found && !c.config.ReverseContentMatch {
+			message := fmt.Sprintf("Content %q not found in response.", c.config.ContentMatch)
+			return servicecheck.ServiceCheckCritical, c.withContent(message, resp.content)
+		}
+	}
+	return servicecheck.ServiceCheckOK, ""
+}
+
+// withContent appends the beginning of the content of the response to the message when include_content is set
+func (c *Check) withContent(message string, content []byte) string {
+	if !c.config.IncludeContent {
+		return message
+	}
+	if len(content) > maxIncludedContentLength {
+		content = content[:maxIncludedContentLength]
+	}
+	return message + "\nContent: " + string(content)
+}
+
+// checkCertificate submits the time left before the expiration of the certificate of the endpoint
+func (c *Check) checkCertificate(sender sender.Sender, tlsState *tls.ConnectionState, requestErr error) {
+	var verificationErr *tls.CertificateVerificationError
+	if errors.As(requestErr, &verificationErr) {
+		sender.ServiceCheck(serviceCheckSSLCert, servicecheck.ServiceCheckCritical, "", c.tags, verificationErr.Error())
+		return
+	}
+	if tlsState == nil || len(tlsState.PeerCertificates) == 0 {
+		message := "Could not get the certificate of " + c.config.URL
+		if requestErr != nil {
+			message += ": " + requestErr.Error()
+		}
+		sender.ServiceCheck(serviceCheckSSLCert, servicecheck.ServiceCheckUnknown, "", c.tags, message)
+		return
+	}
+
+	// the first certificate is the one of the endpoint, the chain was validated during the handshake
+	certificate := tlsState.PeerCertificates[0]
+	secondsLeft := certificate.NotAfter.Sub(timeNow()).Seconds()
+	daysLeft := secondsLeft / secondsPerDay
+	sender.Gauge("http.ssl.days_left", daysLeft, "", c.tags)
+	sender.Gauge("http.ssl.seconds_left", secondsLeft, "", c.tags)
+
+	status, message := servicecheck.ServiceCheckOK, fmt.Sprintf("Days left: %d", int(daysLeft))
+	switch {
+	case secondsLeft <= 0:
+		status, message = servicecheck.ServiceCheckCritical, fmt.Sprintf("Certificate has expired on %s", certificate.NotAfter.UTC().Format(time.RFC3339))
+	case secondsLeft < c.config.SecondsCritical:
+		status, message = servicecheck.ServiceCheckCritical, fmt.Sprintf("This cert TTL is critical: only %d days before it expires", int(daysLeft))
+	case secondsLeft < c.config.SecondsWarning:
+		status, message = servicecheck.ServiceCheckWarning, fmt.Sprintf("This cert is almost expired, only %d days left", int(daysLeft))
+	}
+	log.Debugf("The certificate of %s expires in %.0f seconds", c.config.URL, secondsLeft)
+	sender.ServiceCheck(serviceCheckSSLCert, status, "", c.tags, message)
+}
@@ -0,0 +1,203 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package httpcheck
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+)
+
+func newTestHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("status: healthy"))
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte("maintenance"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+	return mux
+}
+
+func runCheck(t *testing.T, instance string) *mocksender.MockSender {
+	c := newCheck().(*Check)
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	require.NoError(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data(instance), nil, "test"))
+
+	mockSender := mocksender.NewMockSenderWithSenderManager(c.ID(), senderManager)
+	mockSender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+	return mockSender
+}
+
+// writeCACert writes the certificate of the TLS server to a file and returns its path
+func writeCACert(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestConfigure(t *testing.T) {
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	for instance, expectedErr := range map[string]string{
+		`name: test`:           "url must be set",
+		`url: ftp://localhost`: "scheme must be http or https",
+		"url: http://localhost\ncontent_match: '('":             "invalid content_match",
+		"url: http://localhost\nhttp_response_status_code: '('": "invalid http_response_status_code",
+		"url: http://localhost\ntls_cert: /does/not/exist":      "could not load tls_cert",
+	} {
+		assert.ErrorContains(t, newCheck().Configure(senderManager, integration.FakeConfigHash, integration.Data(instance), nil, "test"), expectedErr)
+	}
+}
+
+func TestRunOK(t *testing.T) {
+	server := httptest.NewServer(newTestHandler())
+	defer server.Close()
+
+	sender := runCheck(t, `
+name: test
+url: `+server.URL+`
+content_match: "status: \\w+"
+tags: ["team:core"]
+`)
+	tags := []string{"url:" + server.URL, "instance:test", "team:core"}
+	sender.AssertServiceCheck(t, "http.can_connect", servicecheck.ServiceCheckOK, "", tags, "")
+	sender.AssertMetric(t, "Gauge", "network.http.can_connect", 1, "", tags)
+	sender.AssertMetric(t, "Gauge", "network.http.cant_connect", 0, "", tags)
+	sender.AssertMetricInRange(t, "Gauge", "network.http.response_time", 0, 10, "", tags)
+	sender.AssertMetricInRange(t, "Gauge", "network.http.timing.connect", 0, 10, "", tags)
+	sender.AssertMetricInRange(t, "Gauge", "network.http.timing.ttfb", 0, 10, "", tags)
+	sender.AssertNotCalled(t, "Gauge", "network.http.timing.tls", mock.Anything, "", tags)
+	// the certificate is only checked for the HTTPS urls
+	sender.AssertNotCalled(t, "ServiceCheck", "http.ssl_cert", mock.Anything, "", tags, mock.Anything)
+}
+
+func TestRunValidation(t *testing.T) {
+	server := httptest.NewServer(newTestHandler())
+	defer server.Close()
+
+	for _, tc := range []struct {
+		name            string
+		instance        string
+		expectedStatus  servicecheck.ServiceCheckStatus
+		expectedMessage string
+	}{
+		{
+			name:            "unexpected status code",
+			instance:        "url: " + server.URL + "/error\ninclude_content: true",
+			expectedStatus:  servicecheck.ServiceCheckCritical,
+			expectedMessage: "Incorrect HTTP return code for url " + server.URL + "/error. Expected (1|2|3)\\d\\d, got 503.\nContent: maintenance",
+		},
+		{
+			name:           "expected status code",
+			instance:       "url: " + server.URL + "/error\nhttp_response_status_code: '503'",
+			expectedStatus: servicecheck.ServiceCheckOK,
+		},
+		{
+			name:            "content not found",
+			instance:        "url: " + server.URL + "\ncontent_match: unhealthy",
+			expectedStatus:  servicecheck.ServiceCheckCritical,
+			expectedMessage: `Content "unhealthy" not found in response.`,
+		},
+		{
+			name:            "reverse content match",
+			instance:        "url: " + server.URL + "\ncontent_match: healthy\nreverse_content_match: true",
+			expectedStatus:  servicecheck.ServiceCheckCritical,
+			expectedMessage: `Content "healthy" found in response with reverse_content_match`,
+		},
+		{
+			name:           "redirect followed",
+			instance:       "url: " + server.URL + "/redirect\ncontent_match: healthy",
+			expectedStatus: servicecheck.ServiceCheckOK,
+		},
+		{
+			name:           "redirect not followed",
+			instance:       "url: " + server.URL + "/redirect\nallow_redirects: false\nhttp_response_status_code: '302'",
+			expectedStatus: servicecheck.ServiceCheckOK,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := runCheck(t, tc.instance)
+			sender.AssertServiceCheck(t, "http.can_connect", tc.expectedStatus, "", nil, tc.expectedMessage)
+		})
+	}
+}
+
+func TestRunConnectionError(t *testing.T) {
+	server := httptest.NewServer(newTestHandler())
+	url := server.URL
+	server.Close()
+
+	sender := runCheck(t, "url: "+url)
+	sender.AssertCalled(t, "ServiceCheck", "http.can_connect", servicecheck.ServiceCheckCritical, "", mocksender.MatchTagsContains([]string{"url:" + url}), mock.MatchedBy(func(message string) bool {
+		return message != ""
+	}))
+	sender.AssertMetric(t, "Gauge", "network.http.cant_connect", 1, "", nil)
+	sender.AssertNotCalled(t, "Gauge", "network.http.response_time", mock.Anything, "", mock.Anything)
+}
+
+func TestRunCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(newTestHandler())
+	defer server.Close()
+	caCert := writeCACert(t, server)
+	notAfter := server.Certificate().NotAfter
+
+	t.Run("untrusted", func(t *testing.T) {
+		sender := runCheck(t, "url: "+server.URL)
+		sender.AssertCalled(t, "ServiceCheck", "http.can_connect", servicecheck.ServiceCheckCritical, "", mock.Anything, mock.Anything)
+		sender.AssertCalled(t, "ServiceCheck", "http.ssl_cert", servicecheck.ServiceCheckCritical, "", mock.Anything, mock.MatchedBy(func(message string) bool {
+			return strings.Contains(message, "certificate signed by unknown authority")
+		}))
+	})
+
+	t.Run("not verified", func(t *testing.T) {
+		sender := runCheck(t, "url: "+server.URL+"\ntls_verify: false")
+		sender.AssertServiceCheck(t, "http.can_connect", servicecheck.ServiceCheckOK, "", nil, "")
+		sender.AssertCalled(t, "ServiceCheck", "http.ssl_cert", servicecheck.ServiceCheckOK, "", mock.Anything, mock.MatchedBy(func(message string) bool {
+			return strings.HasPrefix(message, "Days left: ")
+		}))
+	})
+
+	for _, tc := range []struct {
+		name            string
+		now             time.Time
+		expectedStatus  servicecheck.ServiceCheckStatus
+		expectedMessage string
+	}{
+		{"valid", notAfter.Add(-30 * 24 * time.Hour), servicecheck.ServiceCheckOK, "Days left: 30"},
+		{"warning", notAfter.Add(-10 * 24 * time.Hour), servicecheck.ServiceCheckWarning, "This cert is almost expired, only 10 days left"},
+		{"critical", notAfter.Add(-3 * 24 * time.Hour), servicecheck.ServiceCheckCritical, "This cert TTL is critical: only 3 days before it expires"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			timeNow = func() time.Time { return tc.now }
+			defer func() { timeNow = time.Now }()
+
+			sender := runCheck(t, "url: "+server.URL+"\ntls_ca_cert: "+caCert)
+			sender.AssertServiceCheck(t, "http.can_connect", servicecheck.ServiceCheckOK, "", nil, "")
+			sender.AssertServiceCheck(t, "http.ssl_cert", tc.expectedStatus, "", nil, tc.expectedMessage)
+			sender.AssertMetricInRange(t, "Gauge", "http.ssl.days_left", 0, 31, "", nil)
+			sender.AssertMetricInRange(t, "Gauge", "network.http.timing.tls", 0, 10, "", nil)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package tcpcheck implements a check monitoring whether TCP connections can be opened to a host.
+//
+// It submits the metrics and service checks of the tcp_check integration and is used in place of the
+// Python check when the instances set `loader: core`.
+package tcpcheck
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+const (
+	// CheckName is the name of the check
+	CheckName = "tcp_check"
+
+	defaultTimeout = 10
+)
+
+type instanceConfig struct {
+	Name                string   `yaml:"name"`
+	Host                string   `yaml:"host"`
+	Port                int      `yaml:"port"`
+	Timeout             float64  `yaml:"timeout"`
+	CollectResponseTime bool     `yaml:"collect_response_time"`
+	Tags                []string `yaml:"tags"`
+}
+
+func (c *instanceConfig) parse(data []byte) error {
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return err
+	}
+	if c.Host == "" {
+		return errors.New("host must be set")
+	}
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("invalid port %d", c.Port)
+	}
+	if c.Name == "" {
+		c.Name = c.address()
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	return nil
+}
+
+func (c *instanceConfig) address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// Check opens a TCP connection to a host
+type Check struct {
+	core.CheckBase
+	config instanceConfig
+	tags   []string
+}
+
+// Factory creates a new check factory
+func Factory() option.Option[func() check.Check] {
+	return option.New(newCheck)
+}
+
+func newCheck() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBase(CheckName),
+	}
+}
+
+// Configure parses the check configuration and initializes the check
+func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigDigest uint64, data integration.Data, initConfig integration.Data, source string) error {
+	if err := c.config.parse(data); err != nil {
+		return err
+	}
+
+	c.BuildID(integrationConfigDigest, data, initConfig)
+	if err := c.CommonConfigure(senderManager, initConfig, data, source); err != nil {
+		return err
+	}
+
+	c.tags = append([]string{
+		"url:" + c.config.address(),
+		"instance:" + c.config.Name,
+		"target_host:" + c.config.Host,
+		"port:" + strconv.Itoa(c.config.Port),
+	}, c.config.Tags...)
+	return nil
+}
+
+// Run opens a connection to the host and submits whether it succeeded
+func (c *Check) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+	defer sender.Commit()
+
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", c.config.address(), time.Duration(c.config.Timeout*float64(time.Second)))
+	if err != nil {
+		log.Debugf("Could not connect to %s: %s", c.config.address(), err)
+		sender.ServiceCheck("tcp.can_connect", servicecheck.ServiceCheckCritical, "", c.tags, err.Error())
+		sender.Gauge("network.tcp.can_connect", 0, "", c.tags)
+		return nil
+	}
+	responseTime := time.Since(start)
+	conn.Close()
+
+	sender.ServiceCheck("tcp.can_connect", servicecheck.ServiceCheckOK, "", c.tags, "")
+	sender.Gauge("network.tcp.can_connect", 1, "", c.tags)
+	if c.config.CollectResponseTime {
+		sender.Gauge("network.tcp.response_time", responseTime.Seconds(), "", c.tags)
+	}
+	return nil
+}
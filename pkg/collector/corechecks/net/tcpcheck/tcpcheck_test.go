@@ -0,0 +1,71 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package tcpcheck
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+)
+
+func runCheck(t *testing.T, instance string) *mocksender.MockSender {
+	c := newCheck().(*Check)
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	require.NoError(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data(instance), nil, "test"))
+
+	mockSender := mocksender.NewMockSenderWithSenderManager(c.ID(), senderManager)
+	mockSender.SetupAcceptAll()
+	require.NoError(t, c.Run())
+	return mockSender
+}
+
+func TestConfigure(t *testing.T) {
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	assert.ErrorContains(t, newCheck().Configure(senderManager, integration.FakeConfigHash, integration.Data("port: 80"), nil, "test"), "host must be set")
+	assert.ErrorContains(t, newCheck().Configure(senderManager, integration.FakeConfigHash, integration.Data("host: localhost"), nil, "test"), "invalid port 0")
+}
+
+func TestRunCanConnect(t *testing.T) {
+	server := httptest.NewServer(nil)
+	defer server.Close()
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	sender := runCheck(t, `
+name: test
+host: `+host+`
+port: `+port+`
+collect_response_time: true
+tags: ["team:core"]
+`)
+	tags := []string{"url:" + server.Listener.Addr().String(), "instance:test", "target_host:" + host, "port:" + port, "team:core"}
+	sender.AssertServiceCheck(t, "tcp.can_connect", servicecheck.ServiceCheckOK, "", tags, "")
+	sender.AssertMetric(t, "Gauge", "network.tcp.can_connect", 1, "", tags)
+	sender.AssertMetricInRange(t, "Gauge", "network.tcp.response_time", 0, 10, "", tags)
+}
+
+func TestRunCannotConnect(t *testing.T) {
+	server := httptest.NewServer(nil)
+	host, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	server.Close()
+
+	sender := runCheck(t, "host: "+host+"\nport: "+port+"\ntimeout: 1")
+	sender.AssertCalled(t, "ServiceCheck", "tcp.can_connect", servicecheck.ServiceCheckCritical, "", mocksender.MatchTagsContains([]string{"instance:" + server.Listener.Addr().String()}), mock.MatchedBy(func(message string) bool {
+		return message != ""
+	}))
+	sender.AssertMetric(t, "Gauge", "network.tcp.can_connect", 0, "", nil)
+	// the response time is only submitted when it is collected
+	sender.AssertNotCalled(t, "Gauge", "network.tcp.response_time", mock.Anything, "", mock.Anything)
+}
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+const (
+	defaultTimeout = 10
+
+	// overrideTypeGauge and overrideTypeCounter are the types a metric can be submitted as with the
+	// `type` of its entry in `metrics`
+	overrideTypeGauge   = "gauge"
+	overrideTypeCounter = "counter"
+)
+
+// shareLabelsConfig selects the labels of a metric shared with the other metrics
+type shareLabelsConfig struct {
+	// Labels are the labels shared, all of them when empty
+	Labels []string `yaml:"labels"`
+	// Match are the labels whose values must be the same on both metrics, the labels are
+	// shared with all the metrics when empty
+	Match []string `yaml:"match"`
+	// Values are the values of the samples whose labels are shared, all of them when empty
+	Values []float64 `yaml:"values"`
+}
+
+// instanceConfig is the configuration of an instance, following the options of the openmetrics v2 integration
+type instanceConfig struct {
+	OpenMetricsEndpoint string `yaml:"openmetrics_endpoint"`
+	// PrometheusURL is the endpoint of the openmetrics v1 integration, used when OpenMetricsEndpoint is not set
+	PrometheusURL string `yaml:"prometheus_url"`
+	Namespace     string `yaml:"namespace"`
+	RawPrefix     string `yaml:"raw_metric_prefix"`
+
+	// Metrics are regular expressions matching the names of the metrics to collect, or maps from the
+	// names of the metrics to their new name or to `{name: <new name>, type: gauge|counter}`
+	Metrics                []interface{}          `yaml:"metrics"`
+	ExcludeMetrics         []string               `yaml:"exclude_metrics"`
+	ExcludeMetricsByLabels map[string]interface{} `yaml:"exclude_metrics_by_labels"`
+
+	RenameLabels  map[string]string            `yaml:"rename_labels"`
+	ExcludeLabels []string                     `yaml:"exclude_labels"`
+	IncludeLabels []string                     `yaml:"include_labels"`
+	ShareLabels   map[string]shareLabelsConfig `yaml:"share_labels"`
+	TargetInfo    bool                         `yaml:"target_info"`
+
+	CollectHistogramBuckets          *bool `yaml:"collect_histogram_buckets"`
+	NonCumulativeHistogramBuckets    bool  `yaml:"non_cumulative_histogram_buckets"`
+	HistogramBucketsAsDistributions  bool  `yaml:"histogram_buckets_as_distributions"`
+	CollectCountersWithDistributions bool  `yaml:"collect_counters_with_distributions"`
+
+	RawLineFilters    []string `yaml:"raw_line_filters"`
+	EnableHealthCheck *bool    `yaml:"enable_health_service_check"`
+	TagByEndpoint     *bool    `yaml:"tag_by_endpoint"`
+	Tags              []string `yaml:"tags"`
+
+	Headers         map[string]string `yaml:"headers"`
+	Username        string            `yaml:"username"`
+	Password        string            `yaml:"password"`
+	BearerTokenAuth bool              `yaml:"bearer_token_auth"`
+	BearerTokenPath string            `yaml:"bearer_token_path"`
+	Timeout         float64           `yaml:"timeout"`
+
+	httputils.TLSInstanceConfig `yaml:",inline"`
+}
+
+// metricOverride is the name and type a metric is submitted with
+type metricOverride struct {
+	name  string
+	mtype string
+}
+
+// config is the parsed configuration of an instance
+type config struct {
+	instanceConfig
+	endpoint string
+
+	// metrics maps the names of the metrics to their name and type overrides
+	metrics        map[string]metricOverride
+	metricsRegex   *regexp.Regexp
+	excludeRegex   *regexp.Regexp
+	excludeByLabel map[string][]string
+	excludeLabels  map[string]bool
+	includeLabels  map[string]bool
+}
+
+func parseConfig(data integration.Data) (*config, error) {
+	c := &config{}
+	if err := yaml.Unmarshal(data, &c.instanceConfig); err != nil {
+		return nil, err
+	}
+
+	c.endpoint = c.OpenMetricsEndpoint
+	if c.endpoint == "" {
+		c.endpoint = c.PrometheusURL
+	}
+	if c.endpoint == "" {
+		return nil, errors.New("openmetrics_endpoint must be set")
+	}
+	if len(c.Metrics) == 0 {
+		return nil, errors.New("metrics must be set, use `.*` to collect all the metrics")
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	c.Namespace = strings.TrimSuffix(c.Namespace, ".")
+	if c.TargetInfo {
+		if _, found := c.ShareLabels["target_info"]; !found {
+			if c.ShareLabels == nil {
+				c.ShareLabels = map[string]shareLabelsConfig{}
+			}
+			c.ShareLabels["target_info"] = shareLabelsConfig{}
+		}
+	}
+
+	c.metrics = map[string]metricOverride{}
+	var patterns []string
+	for _, entry := range c.Metrics {
+		switch entry := entry.(type) {
+		case string:
+			patterns = append(patterns, entry)
+		case map[interface{}]interface{}:
+			for name, override := range entry {
+				parsed, err := parseMetricOverride(fmt.Sprint(name), override)
+				if err != nil {
+					return nil, err
+				}
+				c.metrics[fmt.Sprint(name)] = parsed
+			}
+		default:
+			return nil, fmt.Errorf("invalid metrics entry %v: expected a regular expression or a map", entry)
+		}
+	}
+
+	var err error
+	if c.metricsRegex, err = compilePatterns(patterns); err != nil {
+		return nil, fmt.Errorf("invalid metrics: %w", err)
+	}
+	if c.excludeRegex, err = compilePatterns(c.ExcludeMetrics); err != nil {
+		return nil, fmt.Errorf("invalid exclude_metrics: %w", err)
+	}
+
+	c.excludeByLabel = map[string][]string{}
+	for label, values := range c.ExcludeMetricsByLabels {
+		switch values := values.(type) {
+		case bool:
+			if values {
+				c.excludeByLabel[label] = nil
+			}
+		case []interface{}:
+			for _, value := range values {
+				c.excludeByLabel[label] = append(c.excludeByLabel[label], fmt.Sprint(value))
+			}
+		default:
+			return nil, fmt.Errorf("invalid exclude_metrics_by_labels entry for %s: expected true or a list of values", label)
+		}
+	}
+
+	c.excludeLabels = toSet(c.ExcludeLabels)
+	c.includeLabels = toSet(c.IncludeLabels)
+	return c, nil
+}
+
+func parseMetricOverride(name string, override interface{}) (metricOverride, error) {
+	switch override := override.(type) {
+	case string:
+		return metricOverride{name: override}, nil
+	case map[interface{}]interface{}:
+		parsed := metricOverride{name: name}
+		if newName, ok := override["name"].(string); ok {
+			parsed.name = newName
+		}
+		if mtype, ok := override["type"].(string); ok {
+			if mtype != overrideTypeGauge && mtype != overrideTypeCounter {
+				return metricOverride{}, fmt.Errorf("invalid type %q for the metric %s: expected gauge or counter", mtype, name)
+			}
+			parsed.mtype = mtype
+		}
+		return parsed, nil
+	default:
+		return metricOverride{}, fmt.Errorf("invalid entry for the metric %s: expected a name or a map", name)
+	}
+}
+
+// compilePatterns returns a regular expression matching the whole names matched by any of the patterns,
+// or nil when there are no patterns. The `*` pattern of the openmetrics v1 integration matches all the names.
+func compilePatterns(patterns []string) (*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	alternatives := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern == "*" {
+			pattern = ".*"
+		}
+		alternatives = append(alternatives, "(?:"+pattern+")")
+	}
+	return regexp.Compile("^(?:" + strings.Join(alternatives, "|") + ")$")
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// isHealthCheckEnabled returns whether the health service check is sent, which it is by default
+func (c *config) isHealthCheckEnabled() bool {
+	return c.EnableHealthCheck == nil || *c.EnableHealthCheck
+}
+
+// collectHistogramBuckets returns whether the histogram buckets are collected, which they are by default
+func (c *config) collectHistogramBuckets() bool {
+	return c.CollectHistogramBuckets == nil || *c.CollectHistogramBuckets
+}
+
+// tagByEndpoint returns whether the metrics are tagged with the endpoint, which they are by default
+func (c *config) tagByEndpoint() bool {
+	return c.TagByEndpoint == nil || *c.TagByEndpoint
+}
@@ -0,0 +1,166 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package openmetrics implements a check collecting the metrics exposed by OpenMetrics and Prometheus endpoints.
+//
+// It supports the main options of the openmetrics v2 integration and is used in place of the Python check
+// when the instances set `loader: core`.
+package openmetrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+	"github.com/DataDog/datadog-agent/pkg/util/prometheus"
+)
+
+const (
+	// CheckName is the name of the check
+	CheckName = "openmetrics"
+
+	// defaultBearerTokenPath is the token of the service account in Kubernetes
+	defaultBearerTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// maxResponseSize is the maximum size of the payloads read from the endpoints
+	maxResponseSize = 256 * 1024 * 1024
+)
+
+// Check scrapes an OpenMetrics or Prometheus endpoint
+type Check struct {
+	core.CheckBase
+	config *config
+	client *http.Client
+}
+
+// Factory creates a new check factory
+func Factory() option.Option[func() check.Check] {
+	return option.New(newCheck)
+}
+
+func newCheck() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBase(CheckName),
+	}
+}
+
+// Configure parses the check configuration and initializes the check
+func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigDigest uint64, data integration.Data, initConfig integration.Data, source string) error {
+	cfg, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+
+	// Several instances can scrape the same endpoint with different options
+	c.BuildID(integrationConfigDigest, data, initConfig)
+	if err := c.CommonConfigure(senderManager, initConfig, data, source); err != nil {
+		return err
+	}
+
+	tlsConfig, err := cfg.TLSConfig()
+	if err != nil {
+		return err
+	}
+	c.config = cfg
+	c.client = &http.Client{
+		Timeout: time.Duration(cfg.Timeout * float64(time.Second)),
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     tlsConfig,
+			MaxIdleConnsPerHost: 1,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+	return nil
+}
+
+// Run scrapes the endpoint and submits its metrics
+func (c *Check) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+	defer sender.Commit()
+
+	tags := append([]string{}, c.config.Tags...)
+	if c.config.tagByEndpoint() {
+		tags = append(tags, "endpoint:"+c.config.endpoint)
+	}
+
+	families, err := c.scrape()
+	if err != nil {
+		c.submitHealth(sender, servicecheck.ServiceCheckCritical, tags, err.Error())
+		return err
+	}
+
+	newScrape(c.config, families).submit(sender, tags)
+	c.submitHealth(sender, servicecheck.ServiceCheckOK, tags, "")
+	return nil
+}
+
+func (c *Check) submitHealth(sender sender.Sender, status servicecheck.ServiceCheckStatus, tags []string, message string) {
+	if !c.config.isHealthCheckEnabled() {
+		return
+	}
+	sender.ServiceCheck(c.config.metricName("openmetrics.health"), status, "", tags, message)
+}
+
+// scrape returns the metric families exposed by the endpoint
+func (c *Check) scrape() ([]*prometheus.MetricFamily, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, c.config.endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", prometheus.AcceptHeader)
+	for name, value := range c.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if c.config.Username != "" {
+		req.SetBasicAuth(c.config.Username, c.config.Password)
+	}
+	if c.config.BearerTokenAuth || c.config.BearerTokenPath != "" {
+		// the token is read at each run as it can be rotated
+		path := c.config.BearerTokenPath
+		if path == "" {
+			path = defaultBearerTokenPath
+		}
+		token, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the bearer token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not scrape %s: %w", c.config.endpoint, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not scrape %s: unexpected response status %q", c.config.endpoint, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the response of %s: %w", c.config.endpoint, err)
+	}
+	format := prometheus.FormatFromContentType(resp.Header.Get("Content-Type"))
+	families, err := prometheus.ParseMetricsWithFormat(data, format, c.config.RawLineFilters)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse the metrics of %s: %w", c.config.endpoint, err)
+	}
+	log.Debugf("Scraped %d metric families from %s", len(families), c.config.endpoint)
+	return families, nil
+}
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+)
+
+const testPayload = `# HELP http_requests_total Requests received.
+# TYPE http_requests_total counter
+http_requests_total{code="200",method="get"} 1027
+http_requests_total{code="500",method="get"} 3
+# TYPE go_goroutines gauge
+go_goroutines 42
+# TYPE process_start_time_seconds gauge
+process_start_time_seconds 1.7e+09
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{handler="/",le="0.1"} 10
+request_duration_seconds_bucket{handler="/",le="1"} 15
+request_duration_seconds_bucket{handler="/",le="+Inf"} 16
+request_duration_seconds_sum{handler="/"} 5.5
+request_duration_seconds_count{handler="/"} 16
+# TYPE rpc_duration_seconds summary
+rpc_duration_seconds{quantile="0.5"} 0.05
+rpc_duration_seconds_sum 17
+rpc_duration_seconds_count 100
+# TYPE target_info gauge
+target_info{service_name="api",service_version="1.2"} 1
+`
+
+func newTestServer(t *testing.T, contentType string, payload string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(payload))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func runCheck(t *testing.T, instance string) (*mocksender.MockSender, error) {
+	c := newCheck().(*Check)
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	require.NoError(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data(instance), nil, "test"))
+
+	mockSender := mocksender.NewMockSenderWithSenderManager(c.ID(), senderManager)
+	mockSender.SetupAcceptAll()
+	return mockSender, c.Run()
+}
+
+func TestConfigure(t *testing.T) {
+	c := newCheck()
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	assert.ErrorContains(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data(`metrics: [".*"]`), nil, "test"), "openmetrics_endpoint")
+	assert.ErrorContains(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data(`openmetrics_endpoint: http://localhost`), nil, "test"), "metrics must be set")
+	assert.ErrorContains(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data("openmetrics_endpoint: http://localhost\nmetrics: [\"(\"]"), nil, "test"), "invalid metrics")
+	assert.ErrorContains(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data("openmetrics_endpoint: http://localhost\nmetrics: [{foo: {type: histogram}}]"), nil, "test"), "invalid type")
+	// the wildcard of the openmetrics v1 integration is supported
+	assert.NoError(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data("prometheus_url: http://localhost\nmetrics: [\"*\"]"), nil, "test"))
+}
+
+func TestRun(t *testing.T) {
+	server := newTestServer(t, "text/plain; version=0.0.4", testPayload)
+	sender, err := runCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+namespace: app
+metrics:
+  - http_requests
+  - go_.*
+  - request_duration_seconds
+  - rpc_duration_seconds
+  - process_start_time_seconds: {name: start_time, type: gauge}
+exclude_metrics_by_labels:
+  code: ["500"]
+rename_labels:
+  method: http_method
+headers:
+  Authorization: Bearer token
+tags:
+  - team:core
+`)
+	require.NoError(t, err)
+
+	endpoint := "endpoint:" + server.URL
+	sender.AssertMetric(t, "MonotonicCount", "app.http_requests.count", 1027, "", []string{"team:core", endpoint, "code:200", "http_method:get"})
+	sender.AssertNotCalled(t, "MonotonicCount", "app.http_requests.count", float64(3), "", mocksender.MatchTagsContains([]string{"code:500"}))
+	sender.AssertMetric(t, "Gauge", "app.go_goroutines", 42, "", []string{endpoint})
+	sender.AssertMetric(t, "Gauge", "app.start_time", 1.7e9, "", []string{endpoint})
+
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.sum", 5.5, "", []string{"handler:/"})
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.count", 16, "", []string{"handler:/"})
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.bucket", 10, "", []string{"handler:/", "upper_bound:0.1"})
+	sender.AssertMetric(t, "MonotonicCount", "app.request_duration_seconds.bucket", 16, "", []string{"handler:/", "upper_bound:inf"})
+
+	sender.AssertMetric(t, "Gauge", "app.rpc_duration_seconds.quantile", 0.05, "", []string{"quantile:0.5"})
+	sender.AssertMetric(t, "MonotonicCount", "app.rpc_duration_seconds.sum", 17, "", nil)
+	sender.AssertMetric(t, "MonotonicCount", "app.rpc_duration_seconds.count", 100, "", nil)
+
+	sender.AssertNotCalled(t, "Gauge", "app.target_info", float64(1), "", mocksender.MatchTagsContains(nil))
+	sender.AssertServiceCheck(t, "app.openmetrics.health", servicecheck.ServiceCheckOK, "", []string{endpoint}, "")
+}
+
+func TestRunHistogramAsDistributions(t *testing.T) {
+	server := newTestServer(t, "text/plain", testPayload)
+	sender, err := runCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+metrics: [request_duration_seconds]
+histogram_buckets_as_distributions: true
+tag_by_endpoint: false
+headers:
+  Authorization: Bearer token
+`)
+	require.NoError(t, err)
+
+	tags := []string{"handler:/"}
+	sender.AssertHistogramBucket(t, "HistogramBucket", "request_duration_seconds", 10, 0, 0.1, true, "", append(tags, "upper_bound:0.1", "lower_bound:0"), false)
+	sender.AssertHistogramBucket(t, "HistogramBucket", "request_duration_seconds", 5, 0.1, 1, true, "", append(tags, "upper_bound:1", "lower_bound:0.1"), false)
+	sender.AssertNotCalled(t, "MonotonicCount", "request_duration_seconds.count", float64(16), "", tags)
+}
+
+func TestRunTargetInfoAndShareLabels(t *testing.T) {
+	payload := `# TYPE target info
+target_info{service_name="api"} 1
+# TYPE kube_pod_info gauge
+kube_pod_info{pod="a",node="n1"} 1
+kube_pod_info{pod="b",node="n2"} 1
+# TYPE kube_pod_restarts counter
+kube_pod_restarts_total{pod="a"} 2
+kube_pod_restarts_total{pod="b"} 4
+# EOF
+`
+	server := newTestServer(t, "application/openmetrics-text; version=1.0.0", payload)
+	sender, err := runCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+metrics: [kube_pod_restarts]
+target_info: true
+share_labels:
+  kube_pod_info:
+    match: [pod]
+    labels: [node]
+exclude_labels: [pod]
+tag_by_endpoint: false
+enable_health_service_check: false
+headers:
+  Authorization: Bearer token
+`)
+	require.NoError(t, err)
+
+	sender.AssertMetric(t, "MonotonicCount", "kube_pod_restarts.count", 2, "", []string{"node:n1", "service_name:api"})
+	sender.AssertMetric(t, "MonotonicCount", "kube_pod_restarts.count", 4, "", []string{"node:n2", "service_name:api"})
+	sender.AssertNotCalled(t, "MonotonicCount", "kube_pod_restarts.count", float64(2), "", mocksender.MatchTagsContains([]string{"pod:a"}))
+	sender.AssertNotCalled(t, "ServiceCheck", "openmetrics.health", servicecheck.ServiceCheckOK, "", nil, "")
+}
+
+func TestRunError(t *testing.T) {
+	server := newTestServer(t, "text/plain", testPayload)
+	sender, err := runCheck(t, `
+openmetrics_endpoint: `+server.URL+`
+metrics: [".*"]
+`)
+	assert.ErrorContains(t, err, "401")
+	sender.AssertServiceCheck(t, "openmetrics.health", servicecheck.ServiceCheckCritical, "", []string{"endpoint:" + server.URL}, err.Error())
+}
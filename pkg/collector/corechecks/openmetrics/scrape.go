@@ -0,0 +1,304 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package openmetrics
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/prometheus"
+)
+
+const (
+	metricTypeCounter   = "COUNTER"
+	metricTypeGauge     = "GAUGE"
+	metricTypeSummary   = "SUMMARY"
+	metricTypeHistogram = "HISTOGRAM"
+)
+
+// sharedLabels are the labels shared by the samples of a metric listed in `share_labels`
+type sharedLabels struct {
+	match []string
+	// labels are the labels to add to the samples, by the values of their `match` labels
+	labels map[string]model.LabelSet
+}
+
+// scrape submits the metric families of one scrape of the endpoint
+type scrape struct {
+	config   *config
+	families []*prometheus.MetricFamily
+	shared   []sharedLabels
+}
+
+func newScrape(config *config, families []*prometheus.MetricFamily) *scrape {
+	s := &scrape{config: config, families: families}
+	for _, family := range families {
+		if shareConfig, found := config.ShareLabels[family.Name]; found {
+			s.shared = append(s.shared, collectSharedLabels(family, shareConfig))
+		}
+	}
+	return s
+}
+
+func collectSharedLabels(family *prometheus.MetricFamily, shareConfig shareLabelsConfig) sharedLabels {
+	shared := sharedLabels{match: shareConfig.Match, labels: map[string]model.LabelSet{}}
+	for _, sample := range family.Samples {
+		if len(shareConfig.Values) > 0 && !slices.Contains(shareConfig.Values, float64(sample.Value)) {
+			continue
+		}
+		labels := model.LabelSet{}
+		for name, value := range sample.Metric {
+			if name == model.MetricNameLabel || slices.Contains(shareConfig.Match, string(name)) {
+				continue
+			}
+			if len(shareConfig.Labels) == 0 || slices.Contains(shareConfig.Labels, string(name)) {
+				labels[name] = value
+			}
+		}
+		key := matchKey(sample.Metric, shareConfig.Match)
+		shared.labels[key] = shared.labels[key].Merge(labels)
+	}
+	return shared
+}
+
+// matchKey returns the values of the match labels, identifying the samples sharing labels
+func matchKey(metric model.Metric, match []string) string {
+	values := make([]string, 0, len(match))
+	for _, label := range match {
+		values = append(values, string(metric[model.LabelName(label)]))
+	}
+	return strings.Join(values, "\x00")
+}
+
+// metricName returns the name of a metric in the namespace of the instance
+func (c *config) metricName(name string) string {
+	if c.Namespace == "" {
+		return name
+	}
+	return c.Namespace + "." + name
+}
+
+// resolve returns the name and type override of a metric family, and false when it is not collected
+func (c *config) resolve(family *prometheus.MetricFamily) (string, metricOverride, bool) {
+	name := strings.TrimPrefix(family.Name, c.RawPrefix)
+	if family.Type == metricTypeCounter {
+		// the counters are named after their `_total` samples in the Prometheus formats
+		name = strings.TrimSuffix(name, "_total")
+	}
+	if c.excludeRegex != nil && c.excludeRegex.MatchString(name) {
+		return "", metricOverride{}, false
+	}
+	if override, found := c.metrics[name]; found {
+		return name, override, true
+	}
+	if c.metricsRegex != nil && c.metricsRegex.MatchString(name) {
+		return name, metricOverride{name: name}, true
+	}
+	return "", metricOverride{}, false
+}
+
+func (s *scrape) submit(sender sender.Sender, tags []string) {
+	for _, family := range s.families {
+		rawName, override, collected := s.config.resolve(family)
+		if !collected {
+			continue
+		}
+		name := s.config.metricName(override.name)
+
+		mtype := family.Type
+		switch override.mtype {
+		case overrideTypeGauge:
+			mtype = metricTypeGauge
+		case overrideTypeCounter:
+			mtype = metricTypeCounter
+		}
+
+		switch mtype {
+		case metricTypeCounter:
+			for _, sample := range s.samples(family) {
+				sender.MonotonicCount(name+".count", float64(sample.Value), "", s.tags(sample.Metric, tags))
+			}
+		case metricTypeHistogram:
+			s.submitHistogram(sender, name, family, tags)
+		case metricTypeSummary:
+			s.submitSummary(sender, name, family, tags)
+		default:
+			for _, sample := range s.samples(family) {
+				sender.Gauge(name, float64(sample.Value), "", s.tags(sample.Metric, tags))
+			}
+		}
+		log.Tracef("Submitted the metric %s as %s", rawName, name)
+	}
+}
+
+// samples returns the samples of the family which are not excluded by their labels or their value
+func (s *scrape) samples(family *prometheus.MetricFamily) model.Vector {
+	samples := make(model.Vector, 0, len(family.Samples))
+	for _, sample := range family.Samples {
+		if math.IsNaN(float64(sample.Value)) || s.isExcludedByLabels(sample.Metric) {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+func (s *scrape) isExcludedByLabels(metric model.Metric) bool {
+	for label, values := range s.config.excludeByLabel {
+		value, found := metric[model.LabelName(label)]
+		if found && (len(values) == 0 || slices.Contains(values, string(value))) {
+			return true
+		}
+	}
+	return false
+}
+
+// tags returns the tags of a sample: its labels, the labels shared by other metrics and the tags of the instance
+func (s *scrape) tags(metric model.Metric, instanceTags []string) []string {
+	labels := model.LabelSet(metric).Clone()
+	for _, shared := range s.shared {
+		for name, value := range shared.labels[matchKey(metric, shared.match)] {
+			if _, found := labels[name]; !found {
+				labels[name] = value
+			}
+		}
+	}
+
+	tags := make([]string, 0, len(labels)+len(instanceTags))
+	tags = append(tags, instanceTags...)
+	for name, value := range labels {
+		if name == model.MetricNameLabel || s.config.excludeLabels[string(name)] {
+			continue
+		}
+		if len(s.config.includeLabels) > 0 && !s.config.includeLabels[string(name)] {
+			continue
+		}
+		tagName := string(name)
+		if renamed, found := s.config.RenameLabels[tagName]; found {
+			tagName = renamed
+		}
+		tags = append(tags, tagName+":"+string(value))
+	}
+	// the labels are in a map, sort them for the tags to be stable
+	slices.Sort(tags[len(instanceTags):])
+	return tags
+}
+
+func (s *scrape) submitSummary(sender sender.Sender, name string, family *prometheus.MetricFamily, instanceTags []string) {
+	for _, sample := range s.samples(family) {
+		sampleName := string(sample.Metric[model.MetricNameLabel])
+		tags := s.tags(sample.Metric, instanceTags)
+		switch {
+		case strings.HasSuffix(sampleName, "_sum"):
+			sender.MonotonicCount(name+".sum", float64(sample.Value), "", tags)
+		case strings.HasSuffix(sampleName, "_count"):
+			sender.MonotonicCount(name+".count", float64(sample.Value), "", tags)
+		default:
+			sender.Gauge(name+".quantile", float64(sample.Value), "", tags)
+		}
+	}
+}
+
+// histogramBucket is the cumulative count of a bucket of a histogram
+type histogramBucket struct {
+	upperBound float64
+	count      float64
+}
+
+// histogramSeries holds the buckets of one series of a histogram
+type histogramSeries struct {
+	metric  model.Metric
+	buckets []histogramBucket
+}
+
+func (s *scrape) submitHistogram(sender sender.Sender, name string, family *prometheus.MetricFamily, instanceTags []string) {
+	asDistributions := s.config.HistogramBucketsAsDistributions
+	submitCounters := !asDistributions || s.config.CollectCountersWithDistributions
+
+	series := map[model.Fingerprint]*histogramSeries{}
+	var fingerprints []model.Fingerprint
+	for _, sample := range s.samples(family) {
+		sampleName := string(sample.Metric[model.MetricNameLabel])
+		switch {
+		case strings.HasSuffix(sampleName, "_sum"):
+			if submitCounters {
+				sender.MonotonicCount(name+".sum", float64(sample.Value), "", s.tags(sample.Metric, instanceTags))
+			}
+		case strings.HasSuffix(sampleName, "_count"):
+			if submitCounters {
+				sender.MonotonicCount(name+".count", float64(sample.Value), "", s.tags(sample.Metric, instanceTags))
+			}
+		case strings.HasSuffix(sampleName, "_bucket"):
+			upperBound, err := strconv.ParseFloat(string(sample.Metric[model.BucketLabel]), 64)
+			if err != nil {
+				log.Debugf("Ignoring the bucket of %s with the invalid upper bound %q", sampleName, sample.Metric[model.BucketLabel])
+				continue
+			}
+			metric := sample.Metric.Clone()
+			delete(metric, model.BucketLabel)
+			fingerprint := metric.Fingerprint()
+			if _, found := series[fingerprint]; !found {
+				series[fingerprint] = &histogramSeries{metric: metric}
+				fingerprints = append(fingerprints, fingerprint)
+			}
+			series[fingerprint].buckets = append(series[fingerprint].buckets, histogramBucket{upperBound: upperBound, count: float64(sample.Value)})
+		}
+	}
+
+	if !asDistributions && !s.config.collectHistogramBuckets() {
+		return
+	}
+	for _, fingerprint := range fingerprints {
+		hs := series[fingerprint]
+		sort.Slice(hs.buckets, func(i, j int) bool { return hs.buckets[i].upperBound < hs.buckets[j].upperBound })
+		tags := s.tags(hs.metric, instanceTags)
+
+		lowerBound := math.Inf(-1)
+		previousCount := 0.0
+		for _, bucket := range hs.buckets {
+			count := bucket.count
+			if asDistributions || s.config.NonCumulativeHistogramBuckets {
+				count -= previousCount
+			}
+			bucketTags := append(slices.Clip(tags), "upper_bound:"+formatBound(bucket.upperBound))
+
+			if asDistributions {
+				// the first bucket starts at 0 for the positive values
+				if math.IsInf(lowerBound, -1) && bucket.upperBound > 0 {
+					lowerBound = 0
+				}
+				bucketTags = append(bucketTags, "lower_bound:"+formatBound(lowerBound))
+				sender.HistogramBucket(name, int64(count), lowerBound, bucket.upperBound, true, "", bucketTags, false)
+			} else {
+				if s.config.NonCumulativeHistogramBuckets {
+					bucketTags = append(bucketTags, "lower_bound:"+formatBound(lowerBound))
+				}
+				sender.MonotonicCount(name+".bucket", count, "", bucketTags)
+			}
+			lowerBound = bucket.upperBound
+			previousCount = bucket.count
+		}
+	}
+}
+
+// formatBound formats a bucket bound like the openmetrics integration
+func formatBound(bound float64) string {
+	switch {
+	case math.IsInf(bound, 1):
+		return "inf"
+	case math.IsInf(bound, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(bound, 'f', -1, 64)
+	}
+}
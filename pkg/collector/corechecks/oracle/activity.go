@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/oracle/common"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -334,7 +335,7 @@ AND status = 'ACTIVE'`)
 			}
 		} else {
 			sessionRow.Statement = statement
-			sessionRow.QuerySignature = common.GetQuerySignature(statement)
+			sessionRow.QuerySignature = dbm.GetQuerySignature(statement)
 		}
 
 		if sample.PdbName.Valid {
@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/oracle/common"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/oracle/config"
 	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
@@ -142,15 +143,6 @@ func handleServiceCheck(c *Check, err error) {
 	sender.Commit()
 }
 
-func checkIntervalExpired(lastRun *time.Time, collectionInterval int64) bool {
-	start := time.Now()
-	if lastRun.IsZero() || start.Sub(*lastRun).Milliseconds() >= collectionInterval*1000 {
-		*lastRun = start
-		return true
-	}
-	return false
-}
-
 // Run executes the check.
 func (c *Check) Run() error {
 	var allErrors error
@@ -169,7 +161,7 @@ func (c *Check) Run() error {
 		c.db = db
 	}
 
-	metricIntervalExpired := checkIntervalExpired(&c.metricLastRun, c.config.MetricCollectionInterval)
+	metricIntervalExpired := dbm.CheckIntervalExpired(&c.metricLastRun, c.config.MetricCollectionInterval)
 
 	if !c.initialized {
 		err := c.init()
@@ -186,7 +178,7 @@ func (c *Check) Run() error {
 		c.connection = conn
 	}
 
-	dbInstanceIntervalExpired := checkIntervalExpired(&c.dbInstanceLastRun, c.config.DatabaseInstanceCollectionInterval)
+	dbInstanceIntervalExpired := dbm.CheckIntervalExpired(&c.dbInstanceLastRun, c.config.DatabaseInstanceCollectionInterval)
 
 	if dbInstanceIntervalExpired && !c.legacyIntegrationCompatibilityMode && !c.config.OnlyCustomQueries {
 		err := sendDbInstanceMetadata(c)
@@ -265,7 +257,7 @@ func (c *Check) Run() error {
 		}
 	}
 
-	tablespaceIntervalExpired := checkIntervalExpired(&c.tablespaceLastRun, c.config.Tablespaces.CollectionInterval)
+	tablespaceIntervalExpired := dbm.CheckIntervalExpired(&c.tablespaceLastRun, c.config.Tablespaces.CollectionInterval)
 	if c.config.Tablespaces.Enabled && tablespaceIntervalExpired {
 		err := c.Tablespaces()
 		if err != nil {
@@ -433,12 +425,12 @@ func newCheck() check.Check {
 }
 
 //nolint:revive // TODO(DBM) Fix revive linter
-func (c *Check) GetObfuscatedStatement(o *obfuscate.Obfuscator, statement string) (common.ObfuscatedStatement, error) {
+func (c *Check) GetObfuscatedStatement(o *obfuscate.Obfuscator, statement string) (dbm.ObfuscatedStatement, error) {
 	obfuscatedStatement, err := o.ObfuscateSQLString(statement)
 	if err == nil {
-		return common.ObfuscatedStatement{
+		return dbm.ObfuscatedStatement{
 			Statement:      obfuscatedStatement.Query,
-			QuerySignature: common.GetQuerySignature(obfuscatedStatement.Query),
+			QuerySignature: dbm.GetQuerySignature(obfuscatedStatement.Query),
 			Commands:       obfuscatedStatement.Metadata.Commands,
 			Tables:         strings.Split(obfuscatedStatement.Metadata.TablesCSV, ","),
 			Comments:       obfuscatedStatement.Metadata.Comments,
@@ -447,7 +439,7 @@ func (c *Check) GetObfuscatedStatement(o *obfuscate.Obfuscator, statement string
 		if c.config.InstanceConfig.LogUnobfuscatedQueries {
 			log.Errorf("%s Obfuscation error for SQL: %s", c.logPrompt, statement)
 		}
-		return common.ObfuscatedStatement{Statement: statement}, err
+		return dbm.ObfuscatedStatement{Statement: statement}, err
 	}
 }
 
@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/oracle/common"
 	"github.com/DataDog/datadog-agent/pkg/obfuscate"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -354,7 +355,7 @@ func handlePredicate(predicateType string, dbValue sql.NullString, payloadValue
 
 //nolint:revive // TODO(DBM) Fix revive linter
 func (c *Check) StatementMetrics() (int, error) {
-	if !checkIntervalExpired(&c.statementsLastRun, c.config.QueryMetrics.CollectionInterval) {
+	if !dbm.CheckIntervalExpired(&c.statementsLastRun, c.config.QueryMetrics.CollectionInterval) {
 		return 0, nil
 	}
 	start := c.statementsLastRun
@@ -0,0 +1,306 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	cache "github.com/patrickmn/go-cache"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/postgres/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// activityRowDB is a row of pg_stat_activity
+type activityRowDB struct {
+	Now             string         `db:"now"`
+	PID             int64          `db:"pid"`
+	DBName          sql.NullString `db:"datname"`
+	UserName        sql.NullString `db:"usename"`
+	ApplicationName sql.NullString `db:"application_name"`
+	ClientAddr      sql.NullString `db:"client_addr"`
+	BackendType     sql.NullString `db:"backend_type"`
+	State           sql.NullString `db:"state"`
+	WaitEventType   sql.NullString `db:"wait_event_type"`
+	WaitEvent       sql.NullString `db:"wait_event"`
+	BackendStart    sql.NullString `db:"backend_start"`
+	XactStart       sql.NullString `db:"xact_start"`
+	QueryStart      sql.NullString `db:"query_start"`
+	StateChange     sql.NullString `db:"state_change"`
+	BlockingPIDs    sql.NullString `db:"blocking_pids"`
+	Query           sql.NullString `db:"query"`
+}
+
+// activityRow is a session sampled from pg_stat_activity
+type activityRow struct {
+	Now             string `json:"now"`
+	PID             int64  `json:"pid"`
+	DBName          string `json:"datname,omitempty"`
+	UserName        string `json:"usename,omitempty"`
+	ApplicationName string `json:"application_name,omitempty"`
+	ClientAddr      string `json:"client_addr,omitempty"`
+	BackendType     string `json:"backend_type,omitempty"`
+	State           string `json:"state,omitempty"`
+	WaitEventType   string `json:"wait_event_type,omitempty"`
+	WaitEvent       string `json:"wait_event,omitempty"`
+	BackendStart    string `json:"backend_start,omitempty"`
+	XactStart       string `json:"xact_start,omitempty"`
+	QueryStart      string `json:"query_start,omitempty"`
+	StateChange     string `json:"state_change,omitempty"`
+	BlockingPIDs    string `json:"blocking_pids,omitempty"`
+	Statement       string `json:"query,omitempty"`
+	QueryTruncated  string `json:"query_truncated,omitempty"`
+	queryRow
+}
+
+type activitySnapshot struct {
+	Timestamp          float64       `json:"timestamp,omitempty"`
+	Host               string        `json:"host,omitempty"` // Host is the database hostname, not the agent hostname
+	Source             string        `json:"ddsource"`
+	DBMType            string        `json:"dbm_type"`
+	AgentVersion       string        `json:"ddagentversion,omitempty"`
+	Tags               []string      `json:"ddtags,omitempty"`
+	CollectionInterval float64       `json:"collection_interval,omitempty"`
+	PostgresActivity   []activityRow `json:"postgres_activity"`
+}
+
+type planDefinition struct {
+	Definition string `json:"definition"`
+	Signature  string `json:"signature"`
+}
+
+type planDB struct {
+	Instance       string                `json:"instance,omitempty"`
+	Plan           planDefinition        `json:"plan"`
+	QuerySignature string                `json:"query_signature,omitempty"`
+	Statement      string                `json:"statement,omitempty"`
+	Metadata       planStatementMetadata `json:"metadata"`
+}
+
+type planStatementMetadata struct {
+	Tables   []string `json:"tables"`
+	Commands []string `json:"commands"`
+	Comments []string `json:"comments"`
+}
+
+type planPostgres struct {
+	DBName      string `json:"datname,omitempty"`
+	UserName    string `json:"usename,omitempty"`
+	PID         int64  `json:"pid"`
+	QueryStart  string `json:"query_start,omitempty"`
+	ClientAddr  string `json:"client_addr,omitempty"`
+	BackendType string `json:"backend_type,omitempty"`
+}
+
+type planPayload struct {
+	Timestamp    float64      `json:"timestamp,omitempty"`
+	Host         string       `json:"host,omitempty"` // Host is the database hostname, not the agent hostname
+	AgentVersion string       `json:"ddagentversion,omitempty"`
+	Source       string       `json:"ddsource"`
+	Tags         string       `json:"ddtags,omitempty"`
+	DBMType      string       `json:"dbm_type"`
+	DB           planDB       `json:"db"`
+	Postgres     planPostgres `json:"postgres"`
+}
+
+// activityQuery samples the sessions of pg_stat_activity, excluding the idle sessions and the sessions of the agent
+const activityQuery = `SELECT now()::text AS now, pid, datname, usename, application_name, client_addr::text AS client_addr,
+	backend_type, state, wait_event_type, wait_event, backend_start::text AS backend_start,
+	xact_start::text AS xact_start, query_start::text AS query_start, state_change::text AS state_change,
+	array_to_string(pg_blocking_pids(pid), ',') AS blocking_pids, query
+FROM pg_stat_activity
+WHERE coalesce(state, '') NOT IN ('', 'idle') AND pid != pg_backend_pid()
+	AND coalesce(application_name, '') != $1
+ORDER BY query_start NULLS LAST
+LIMIT $2`
+
+// explainableCommands are the commands the explain function is run on
+var explainableCommands = map[string]bool{
+	"SELECT": true,
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+}
+
+func nullString(s sql.NullString) string {
+	if s.Valid {
+		return s.String
+	}
+	return ""
+}
+
+// isTruncated returns whether a statement was truncated to track_activity_query_size
+func (c *Check) isTruncated(statement string) bool {
+	// track_activity_query_size includes the null terminator
+	return c.trackActivityQuerySize > 0 && len(statement) >= c.trackActivityQuerySize-1
+}
+
+// SampleActivity samples the active sessions of pg_stat_activity. The samples are submitted as an activity snapshot
+// when collectActivity is set, and the statements of the samples are explained when collectPlans is set.
+func (c *Check) SampleActivity(collectActivity bool, collectPlans bool) error {
+	var rows []activityRowDB
+	if err := c.db.Select(&rows, activityQuery, config.ApplicationName, c.config.QueryActivity.DBRowsLimit); err != nil {
+		return fmt.Errorf("failed to query pg_stat_activity: %w", err)
+	}
+
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+
+	activityRows := make([]activityRow, 0, len(rows))
+	var planErrors int
+	for _, row := range rows {
+		ar := activityRow{
+			Now:             row.Now,
+			PID:             row.PID,
+			DBName:          nullString(row.DBName),
+			UserName:        nullString(row.UserName),
+			ApplicationName: nullString(row.ApplicationName),
+			ClientAddr:      nullString(row.ClientAddr),
+			BackendType:     nullString(row.BackendType),
+			State:           nullString(row.State),
+			WaitEventType:   nullString(row.WaitEventType),
+			WaitEvent:       nullString(row.WaitEvent),
+			BackendStart:    nullString(row.BackendStart),
+			XactStart:       nullString(row.XactStart),
+			QueryStart:      nullString(row.QueryStart),
+			StateChange:     nullString(row.StateChange),
+			BlockingPIDs:    nullString(row.BlockingPIDs),
+		}
+		statement := nullString(row.Query)
+		truncated := c.isTruncated(statement)
+		if truncated {
+			ar.QueryTruncated = "truncated"
+		} else {
+			ar.QueryTruncated = "not_truncated"
+		}
+		if statement != "" {
+			obfuscated, err := c.obfuscator.ObfuscateStatement(statement)
+			if err != nil {
+				log.Debugf("%s failed to obfuscate the statement of pid %d: %s", c.logPrompt, row.PID, err)
+			} else {
+				ar.Statement = obfuscated.Statement
+				ar.queryRow = queryRow{
+					QuerySignature: obfuscated.QuerySignature,
+					Tables:         obfuscated.Tables,
+					Commands:       obfuscated.Commands,
+					Comments:       obfuscated.Comments,
+				}
+				if collectPlans && ar.State == "active" && !truncated {
+					if err := c.explainSample(statement, obfuscated, ar); err != nil {
+						planErrors++
+						log.Debugf("%s failed to explain the statement of pid %d: %s", c.logPrompt, row.PID, err)
+					}
+				}
+			}
+		}
+		activityRows = append(activityRows, ar)
+	}
+
+	if collectPlans {
+		sender.Gauge("dd.postgres.plan_errors.count", float64(planErrors), c.dbHostname, c.tags)
+	}
+	if !collectActivity {
+		return nil
+	}
+
+	payload := activitySnapshot{
+		Timestamp:          float64(c.timeNow().UnixMilli()),
+		Host:               c.dbHostname,
+		Source:             IntegrationName,
+		DBMType:            "activity",
+		AgentVersion:       c.agentVersion,
+		Tags:               c.tags,
+		CollectionInterval: float64(c.config.QueryActivity.CollectionInterval),
+		PostgresActivity:   activityRows,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the activity payload: %w", err)
+	}
+	log.Debugf("%s Activity payload %s", c.logPrompt, string(payloadBytes))
+	sender.EventPlatformEvent(payloadBytes, "dbm-activity")
+	sender.Gauge("dd.postgres.activity.samples_count", float64(len(activityRows)), c.dbHostname, c.tags)
+	return nil
+}
+
+// explainSample collects the explain plan of a sampled statement with the explain function, at most
+// explained_queries_per_hour_per_query times an hour per query signature
+func (c *Check) explainSample(statement string, obfuscated dbm.ObfuscatedStatement, row activityRow) error {
+	if len(obfuscated.Commands) == 0 || !explainableCommands[strings.ToUpper(obfuscated.Commands[0])] {
+		return nil
+	}
+	if _, found := c.planEmitted.Get(obfuscated.QuerySignature); found {
+		return nil
+	}
+
+	var plan sql.NullString
+	if err := c.db.Get(&plan, fmt.Sprintf("SELECT %s($1)", c.config.QuerySamples.ExplainFunction), statement); err != nil {
+		return err
+	}
+	if !plan.Valid || plan.String == "" {
+		return nil
+	}
+	// the plan is cached even if it cannot be obfuscated to avoid explaining the statement again
+	c.planEmitted.Set(obfuscated.QuerySignature, "1", cache.DefaultExpiration)
+
+	o := c.obfuscator.Obfuscator()
+	obfuscatedPlan, err := o.ObfuscateSQLExecPlan(plan.String, false)
+	if err != nil {
+		if c.config.LogUnobfuscatedPlans {
+			log.Errorf("%s Obfuscation error for plan: %s", c.logPrompt, plan.String)
+		}
+		return fmt.Errorf("failed to obfuscate the plan: %w", err)
+	}
+	normalizedPlan, err := o.ObfuscateSQLExecPlan(plan.String, true)
+	if err != nil {
+		return fmt.Errorf("failed to normalize the plan: %w", err)
+	}
+
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+	payload := planPayload{
+		Timestamp:    float64(c.timeNow().UnixMilli()),
+		Host:         c.dbHostname,
+		AgentVersion: c.agentVersion,
+		Source:       IntegrationName,
+		Tags:         c.tagsString + ",db:" + row.DBName,
+		DBMType:      "plan",
+		DB: planDB{
+			Instance:       row.DBName,
+			Plan:           planDefinition{Definition: obfuscatedPlan, Signature: dbm.GetQuerySignature(normalizedPlan)},
+			QuerySignature: obfuscated.QuerySignature,
+			Statement:      obfuscated.Statement,
+			Metadata: planStatementMetadata{
+				Tables:   obfuscated.Tables,
+				Commands: obfuscated.Commands,
+				Comments: obfuscated.Comments,
+			},
+		},
+		Postgres: planPostgres{
+			DBName:      row.DBName,
+			UserName:    row.UserName,
+			PID:         row.PID,
+			QueryStart:  row.QueryStart,
+			ClientAddr:  row.ClientAddr,
+			BackendType: row.BackendType,
+		},
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the plan payload: %w", err)
+	}
+	log.Debugf("%s Plan payload %s", c.logPrompt, string(payloadBytes))
+	sender.EventPlatformEvent(payloadBytes, "dbm-samples")
+	return nil
+}
@@ -0,0 +1,228 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package config contains the configuration of the postgres check.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	defaultPort                    = 5432
+	defaultDBName                  = "postgres"
+	defaultQueryTimeout            = 5000
+	defaultConnectTimeout          = 5
+	defaultCollectionInterval      = 10
+	defaultExplainFunction         = "datadog.explain_statement"
+	defaultStatementsRowsLimit     = 10000
+	defaultActivityRowsLimit       = 3500
+	defaultExplainedQueriesPerHour = 60
+
+	// ApplicationName is the application name of the connections of the check, used to exclude them from the samples
+	ApplicationName = "datadog-agent"
+)
+
+// InitConfig is used to deserialize integration init config.
+type InitConfig struct {
+	MinCollectionInterval int `yaml:"min_collection_interval"`
+}
+
+// QueryMetricsConfig configures the collection of the statement metrics from pg_stat_statements
+type QueryMetricsConfig struct {
+	Enabled            bool  `yaml:"enabled"`
+	CollectionInterval int64 `yaml:"collection_interval"`
+	DBRowsLimit        int   `yaml:"db_rows_limit"`
+}
+
+// QuerySamplesConfig configures the collection of the explain plans of the statements sampled from pg_stat_activity
+type QuerySamplesConfig struct {
+	Enabled                 bool   `yaml:"enabled"`
+	CollectionInterval      int64  `yaml:"collection_interval"`
+	ExplainFunction         string `yaml:"explain_function"`
+	ExplainedQueriesPerHour int    `yaml:"explained_queries_per_hour_per_query"`
+}
+
+// QueryActivityConfig configures the collection of the activity snapshots from pg_stat_activity
+type QueryActivityConfig struct {
+	Enabled            bool  `yaml:"enabled"`
+	CollectionInterval int64 `yaml:"collection_interval"`
+	DBRowsLimit        int   `yaml:"payload_row_limit"`
+}
+
+// ConnectionConfig stores the database connection information
+type ConnectionConfig struct {
+	Host           string `yaml:"host"`
+	Port           int    `yaml:"port"`
+	Username       string `yaml:"username"`
+	Password       string `yaml:"password"`
+	DBName         string `yaml:"dbname"`
+	SSLMode        string `yaml:"ssl"`
+	SSLRootCert    string `yaml:"ssl_root_cert"`
+	SSLCert        string `yaml:"ssl_cert"`
+	SSLKey         string `yaml:"ssl_key"`
+	ConnectTimeout int    `yaml:"connect_timeout"`
+	QueryTimeout   int    `yaml:"query_timeout"`
+}
+
+// InstanceConfig is used to deserialize integration instance config.
+type InstanceConfig struct {
+	ConnectionConfig       `yaml:",inline"`
+	User                   string              `yaml:"user"`
+	DBM                    bool                `yaml:"dbm"`
+	Tags                   []string            `yaml:"tags"`
+	ReportedHostname       string              `yaml:"reported_hostname"`
+	CollectDatabaseSize    bool                `yaml:"collect_database_size_metrics"`
+	CollectBgwriterMetrics bool                `yaml:"collect_bgwriter_metrics"`
+	LogUnobfuscatedQueries bool                `yaml:"log_unobfuscated_queries"`
+	LogUnobfuscatedPlans   bool                `yaml:"log_unobfuscated_plans"`
+	ObfuscatorOptions      obfuscate.SQLConfig `yaml:"obfuscator_options"`
+	QueryMetrics           QueryMetricsConfig  `yaml:"query_metrics"`
+	QuerySamples           QuerySamplesConfig  `yaml:"query_samples"`
+	QueryActivity          QueryActivityConfig `yaml:"query_activity"`
+	Loader                 string              `yaml:"loader"`
+}
+
+// CheckConfig holds the config needed for an integration instance to run.
+type CheckConfig struct {
+	InitConfig
+	InstanceConfig
+}
+
+// String returns a string representation of the CheckConfig without sensitive information.
+func (c *CheckConfig) String() string {
+	return fmt.Sprintf(`CheckConfig:
+Host: '%s'
+Port: '%d'
+DBName: '%s'
+`, c.Host, c.Port, c.DBName)
+}
+
+// GetDefaultObfuscatorOptions return default obfuscator options
+func GetDefaultObfuscatorOptions() obfuscate.SQLConfig {
+	return obfuscate.SQLConfig{
+		DBMS:                          obfuscate.DBMSPostgres,
+		TableNames:                    true,
+		CollectCommands:               true,
+		CollectComments:               true,
+		ObfuscationMode:               obfuscate.ObfuscateAndNormalize,
+		RemoveSpaceBetweenParentheses: true,
+		KeepNull:                      true,
+		KeepTrailingSemicolon:         true,
+	}
+}
+
+// NewCheckConfig builds a new check config.
+func NewCheckConfig(rawInstance integration.Data, rawInitConfig integration.Data) (*CheckConfig, error) {
+	instance := InstanceConfig{}
+	initCfg := InitConfig{}
+
+	// Defaults begin
+	instance.Port = defaultPort
+	instance.DBName = defaultDBName
+	instance.SSLMode = "allow"
+	instance.ConnectTimeout = defaultConnectTimeout
+	instance.QueryTimeout = defaultQueryTimeout
+	instance.CollectDatabaseSize = true
+	instance.CollectBgwriterMetrics = true
+
+	instance.ObfuscatorOptions = GetDefaultObfuscatorOptions()
+
+	instance.QueryMetrics.Enabled = true
+	instance.QueryMetrics.CollectionInterval = defaultCollectionInterval
+	instance.QueryMetrics.DBRowsLimit = defaultStatementsRowsLimit
+
+	instance.QuerySamples.Enabled = true
+	instance.QuerySamples.CollectionInterval = defaultCollectionInterval
+	instance.QuerySamples.ExplainFunction = defaultExplainFunction
+	instance.QuerySamples.ExplainedQueriesPerHour = defaultExplainedQueriesPerHour
+
+	instance.QueryActivity.Enabled = true
+	instance.QueryActivity.CollectionInterval = defaultCollectionInterval
+	instance.QueryActivity.DBRowsLimit = defaultActivityRowsLimit
+	// Defaults end
+
+	if err := yaml.Unmarshal(rawInstance, &instance); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(rawInitConfig, &initCfg); err != nil {
+		return nil, err
+	}
+
+	if instance.Host == "" {
+		return nil, errors.New("`host` is not configured")
+	}
+	if instance.Username == "" {
+		// For the backward compatibility with the Python integration
+		if instance.User == "" {
+			return nil, errors.New("`username` is not configured")
+		}
+		instance.Username = instance.User
+	}
+	switch instance.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	case "true":
+		instance.SSLMode = "require"
+	case "false":
+		instance.SSLMode = "disable"
+	default:
+		return nil, fmt.Errorf("invalid ssl mode %q", instance.SSLMode)
+	}
+
+	c := &CheckConfig{
+		InstanceConfig: instance,
+		InitConfig:     initCfg,
+	}
+
+	log.Debugf("%s Postgres config: %s", GetLogPrompt(instance), c.String())
+
+	return c, nil
+}
+
+// DataSourceName returns the connection string of the database
+func (c *InstanceConfig) DataSourceName() string {
+	query := url.Values{}
+	query.Set("sslmode", c.SSLMode)
+	query.Set("application_name", ApplicationName)
+	query.Set("connect_timeout", strconv.Itoa(c.ConnectTimeout))
+	if c.QueryTimeout > 0 {
+		query.Set("statement_timeout", strconv.Itoa(c.QueryTimeout))
+	}
+	for key, value := range map[string]string{"sslrootcert": c.SSLRootCert, "sslcert": c.SSLCert, "sslkey": c.SSLKey} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.Username, c.Password),
+		Host:     net.JoinHostPort(c.Host, strconv.Itoa(c.Port)),
+		Path:     "/" + c.DBName,
+		RawQuery: query.Encode(),
+	}
+	if len(c.Host) > 0 && c.Host[0] == '/' {
+		// unix sockets are set with the host parameter
+		query.Set("host", c.Host)
+		dsn.Host = ""
+		dsn.RawQuery = query.Encode()
+	}
+	return dsn.String()
+}
+
+// GetLogPrompt returns a config based prompt
+func GetLogPrompt(c InstanceConfig) string {
+	return fmt.Sprintf("%s:%d/%s>", c.Host, c.Port, c.DBName)
+}
@@ -0,0 +1,257 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+type metricType int
+
+const (
+	gauge metricType = iota
+	rate
+	monotonicCount
+)
+
+// metricColumn is a column of a query submitted as a metric
+type metricColumn struct {
+	name  string
+	mtype metricType
+}
+
+// metricsQuery is a query whose columns are submitted as metrics, tagged with its tag columns
+type metricsQuery struct {
+	name  string
+	query string
+	// tags map the columns used as tags to the names of the tags
+	tags    map[string]string
+	metrics map[string]metricColumn
+	// minVersion is the minimum server_version_num the query is run on
+	minVersion int
+	// maxVersion is the server_version_num from which the query is not run anymore, when set
+	maxVersion int
+	// enabled returns whether the query is enabled in the configuration, when set
+	enabled func(c *Check) bool
+}
+
+var databaseQuery = metricsQuery{
+	name: "database",
+	query: `SELECT datname, numbackends, xact_commit, xact_rollback, blks_read, blks_hit,
+	tup_returned, tup_fetched, tup_inserted, tup_updated, tup_deleted, conflicts,
+	temp_files, temp_bytes, deadlocks
+FROM pg_stat_database
+WHERE datname IS NOT NULL AND datname NOT IN ('template0', 'template1')`,
+	tags: map[string]string{"datname": "db"},
+	metrics: map[string]metricColumn{
+		"numbackends":   {"postgresql.connections", gauge},
+		"xact_commit":   {"postgresql.commits", rate},
+		"xact_rollback": {"postgresql.rollbacks", rate},
+		"blks_read":     {"postgresql.disk_read", rate},
+		"blks_hit":      {"postgresql.buffer_hit", rate},
+		"tup_returned":  {"postgresql.rows_returned", rate},
+		"tup_fetched":   {"postgresql.rows_fetched", rate},
+		"tup_inserted":  {"postgresql.rows_inserted", rate},
+		"tup_updated":   {"postgresql.rows_updated", rate},
+		"tup_deleted":   {"postgresql.rows_deleted", rate},
+		"conflicts":     {"postgresql.conflicts", rate},
+		"temp_files":    {"postgresql.temp_files", rate},
+		"temp_bytes":    {"postgresql.temp_bytes", rate},
+		"deadlocks":     {"postgresql.deadlocks.count", monotonicCount},
+	},
+}
+
+var databaseSizeQuery = metricsQuery{
+	name: "database size",
+	query: `SELECT datname, pg_database_size(datname) AS database_size
+FROM pg_database
+WHERE datallowconn AND NOT datistemplate`,
+	tags:    map[string]string{"datname": "db"},
+	metrics: map[string]metricColumn{"database_size": {"postgresql.database_size", gauge}},
+	enabled: func(c *Check) bool { return c.config.CollectDatabaseSize },
+}
+
+var connectionsQuery = metricsQuery{
+	name: "connections",
+	query: `SELECT current_setting('max_connections')::int AS max_connections,
+	(SELECT count(*) FROM pg_stat_activity)::float / current_setting('max_connections')::int AS percent_usage_connections`,
+	metrics: map[string]metricColumn{
+		"max_connections":           {"postgresql.max_connections", gauge},
+		"percent_usage_connections": {"postgresql.percent_usage_connections", gauge},
+	},
+}
+
+var bgwriterMetrics = map[string]metricColumn{
+	"checkpoints_timed":     {"postgresql.bgwriter.checkpoints_timed", monotonicCount},
+	"checkpoints_req":       {"postgresql.bgwriter.checkpoints_requested", monotonicCount},
+	"buffers_checkpoint":    {"postgresql.bgwriter.buffers_checkpoint", monotonicCount},
+	"buffers_clean":         {"postgresql.bgwriter.buffers_clean", monotonicCount},
+	"maxwritten_clean":      {"postgresql.bgwriter.maxwritten_clean", monotonicCount},
+	"buffers_backend":       {"postgresql.bgwriter.buffers_backend", monotonicCount},
+	"buffers_alloc":         {"postgresql.bgwriter.buffers_alloc", monotonicCount},
+	"checkpoint_write_time": {"postgresql.bgwriter.write_time", monotonicCount},
+	"checkpoint_sync_time":  {"postgresql.bgwriter.sync_time", monotonicCount},
+}
+
+var bgwriterQuery = metricsQuery{
+	name: "bgwriter",
+	query: `SELECT checkpoints_timed, checkpoints_req, buffers_checkpoint, buffers_clean, maxwritten_clean,
+	buffers_backend, buffers_alloc, checkpoint_write_time, checkpoint_sync_time
+FROM pg_stat_bgwriter`,
+	metrics:    bgwriterMetrics,
+	maxVersion: 170000,
+	enabled:    func(c *Check) bool { return c.config.CollectBgwriterMetrics },
+}
+
+// bgwriterCheckpointerQuery collects the bgwriter metrics from PostgreSQL 17, where the checkpoints moved to
+// pg_stat_checkpointer
+var bgwriterCheckpointerQuery = metricsQuery{
+	name: "bgwriter",
+	query: `SELECT c.num_timed AS checkpoints_timed, c.num_requested AS checkpoints_req,
+	c.buffers_written AS buffers_checkpoint, b.buffers_clean, b.maxwritten_clean, b.buffers_alloc,
+	c.write_time AS checkpoint_write_time, c.sync_time AS checkpoint_sync_time
+FROM pg_stat_bgwriter b, pg_stat_checkpointer c`,
+	metrics:    bgwriterMetrics,
+	minVersion: 170000,
+	enabled:    func(c *Check) bool { return c.config.CollectBgwriterMetrics },
+}
+
+var replicationDelayQuery = metricsQuery{
+	name: "replication delay",
+	query: `SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE GREATEST(0, EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())) END AS replication_delay
+WHERE pg_is_in_recovery()`,
+	metrics:    map[string]metricColumn{"replication_delay": {"postgresql.replication_delay", gauge}},
+	minVersion: 100000,
+}
+
+var replicationStatsQuery = metricsQuery{
+	name: "replication stats",
+	query: `SELECT application_name, state, client_addr::text AS client_addr,
+	EXTRACT(EPOCH FROM write_lag) AS write_lag, EXTRACT(EPOCH FROM flush_lag) AS flush_lag,
+	EXTRACT(EPOCH FROM replay_lag) AS replay_lag
+FROM pg_stat_replication`,
+	tags: map[string]string{"application_name": "wal_app_name", "state": "wal_state", "client_addr": "wal_client_addr"},
+	metrics: map[string]metricColumn{
+		"write_lag":  {"postgresql.replication.wal_write_lag", gauge},
+		"flush_lag":  {"postgresql.replication.wal_flush_lag", gauge},
+		"replay_lag": {"postgresql.replication.wal_replay_lag", gauge},
+	},
+	minVersion: 100000,
+}
+
+var metricsQueries = []metricsQuery{
+	databaseQuery,
+	databaseSizeQuery,
+	connectionsQuery,
+	bgwriterQuery,
+	bgwriterCheckpointerQuery,
+	replicationDelayQuery,
+	replicationStatsQuery,
+}
+
+// collectMetrics runs the metrics queries supported by the server
+func (c *Check) collectMetrics(sender sender.Sender) error {
+	var allErrors error
+	for _, query := range metricsQueries {
+		if query.enabled != nil && !query.enabled(c) {
+			continue
+		}
+		if c.versionNum < query.minVersion || (query.maxVersion != 0 && c.versionNum >= query.maxVersion) {
+			continue
+		}
+		if err := c.runMetricsQuery(sender, query); err != nil {
+			allErrors = errors.Join(allErrors, fmt.Errorf("%s failed to collect %s metrics: %w", c.logPrompt, query.name, err))
+		}
+	}
+	return allErrors
+}
+
+func (c *Check) runMetricsQuery(sender sender.Sender, query metricsQuery) error {
+	rows, err := c.db.Queryx(query.query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		columns := map[string]interface{}{}
+		if err := rows.MapScan(columns); err != nil {
+			return err
+		}
+
+		tags := make([]string, len(c.tags), len(c.tags)+len(query.tags))
+		copy(tags, c.tags)
+		for column, tag := range query.tags {
+			if value := columnString(columns[column]); value != "" {
+				tags = append(tags, tag+":"+value)
+			}
+		}
+
+		for column, metric := range query.metrics {
+			value, ok := columnFloat(columns[column])
+			if !ok {
+				continue
+			}
+			switch metric.mtype {
+			case gauge:
+				sender.Gauge(metric.name, value, c.dbHostname, tags)
+			case rate:
+				sender.Rate(metric.name, value, c.dbHostname, tags)
+			case monotonicCount:
+				sender.MonotonicCount(metric.name, value, c.dbHostname, tags)
+			}
+		}
+	}
+	return rows.Err()
+}
+
+// columnFloat converts the value of a numeric column, and returns false for NULL values
+func columnFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case []byte:
+		return parseFloat(string(v))
+	case string:
+		return parseFloat(v)
+	case nil:
+		return 0, false
+	default:
+		log.Debugf("unexpected type %T of a metric column", value)
+		return 0, false
+	}
+}
+
+func parseFloat(value string) (float64, bool) {
+	f, err := strconv.ParseFloat(value, 64)
+	return f, err == nil
+}
+
+// columnString converts the value of a tag column, and returns an empty string for NULL values
+func columnString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,235 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package postgres implements the PostgreSQL check collecting the database metrics and, when dbm is enabled, the
+// statement metrics, activity samples and explain plans of Database Monitoring.
+//
+// It is used in place of the Python check when the instances set `loader: core`.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the pgx driver
+	"github.com/jmoiron/sqlx"
+	cache "github.com/patrickmn/go-cache"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/postgres/config"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/obfuscate"
+	"github.com/DataDog/datadog-agent/pkg/util/hostname"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+const (
+	// CheckName is the name of the check
+	CheckName = "postgres"
+
+	// IntegrationName is the source of the DBM payloads
+	IntegrationName = "postgres"
+
+	serviceCheckName     = "postgres.can_connect"
+	maxOpenConnections   = 5
+	defaultCheckInterval = 10 * time.Second
+)
+
+// Check collects the metrics of a PostgreSQL server
+type Check struct {
+	core.CheckBase
+	config        *config.CheckConfig
+	db            *sqlx.DB
+	dbmEnabled    bool
+	agentVersion  string
+	agentHostname string
+	dbHostname    string
+	tags          []string
+	tagsString    string
+	logPrompt     string
+
+	// initialized is set once the version and the settings of the server are fetched
+	initialized            bool
+	version                string
+	versionNum             int
+	trackActivityQuerySize int
+
+	statementMetricsPrevious map[statementMetricsKey]statementMetricsCounters
+	statementsLastRun        time.Time
+	activityLastRun          time.Time
+	samplesLastRun           time.Time
+
+	fqtEmitted  *cache.Cache
+	planEmitted *cache.Cache
+	obfuscator  *dbm.StatementObfuscator
+
+	// for testing purpose
+	timeNow func() time.Time
+}
+
+// Factory creates a new check factory
+func Factory() option.Option[func() check.Check] {
+	return option.New(newCheck)
+}
+
+func newCheck() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBaseWithInterval(CheckName, defaultCheckInterval),
+		timeNow:   time.Now,
+	}
+}
+
+// Configure configures the Postgres check.
+func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigDigest uint64, rawInstance integration.Data, rawInitConfig integration.Data, source string) error {
+	var err error
+	c.config, err = config.NewCheckConfig(rawInstance, rawInitConfig)
+	if err != nil {
+		return fmt.Errorf("failed to build check config: %w", err)
+	}
+
+	// Must be called before c.CommonConfigure because this integration supports multiple instances
+	c.BuildID(integrationConfigDigest, rawInstance, rawInitConfig)
+
+	if err := c.CommonConfigure(senderManager, rawInitConfig, rawInstance, source); err != nil {
+		return fmt.Errorf("common configure failed: %s", err)
+	}
+
+	c.dbmEnabled = c.config.DBM
+	agentVersion, _ := version.Agent()
+	c.agentVersion = agentVersion.GetNumberAndPre()
+	c.logPrompt = config.GetLogPrompt(c.config.InstanceConfig)
+
+	agentHostname, err := hostname.Get(context.Background())
+	if err == nil {
+		c.agentHostname = agentHostname
+	} else {
+		log.Errorf("%s failed to retrieve agent hostname: %s", c.logPrompt, err)
+	}
+	c.dbHostname = dbm.ResolveDBHostname(c.config.ReportedHostname, c.config.Host, c.agentHostname)
+
+	tags := make([]string, len(c.config.Tags))
+	copy(tags, c.config.Tags)
+	tags = append(tags,
+		"dbms:"+IntegrationName,
+		fmt.Sprintf("dbm:%t", c.dbmEnabled),
+		"server:"+c.config.Host,
+		fmt.Sprintf("port:%d", c.config.Port),
+	)
+	c.tags = tags
+	c.tagsString = strings.Join(tags, ",")
+
+	c.fqtEmitted = cache.New(time.Hour, 10*time.Minute)
+	c.planEmitted = cache.New(time.Hour/time.Duration(max(c.config.QuerySamples.ExplainedQueriesPerHour, 1)), 10*time.Minute)
+	c.obfuscator = dbm.NewStatementObfuscator(obfuscate.Config{
+		SQL:                  c.config.ObfuscatorOptions,
+		SQLExecPlan:          obfuscate.DefaultSQLExecPlanConfig,
+		SQLExecPlanNormalize: obfuscate.DefaultSQLExecPlanNormalizeConfig,
+	}, c.config.LogUnobfuscatedQueries, c.logPrompt)
+	return nil
+}
+
+// Connect opens a connection pool to the database
+func (c *Check) Connect() (*sqlx.DB, error) {
+	db, err := sqlx.Open("pgx", c.config.DataSourceName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the connection: %w", err)
+	}
+	db.SetMaxOpenConns(maxOpenConnections)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return db, nil
+}
+
+// init fetches the version and the settings of the server the collection depends on
+func (c *Check) init() error {
+	if err := c.db.Get(&c.version, "SHOW server_version"); err != nil {
+		return fmt.Errorf("failed to get the server version: %w", err)
+	}
+	var versionNum string
+	if err := c.db.Get(&versionNum, "SHOW server_version_num"); err != nil {
+		return fmt.Errorf("failed to get the server version number: %w", err)
+	}
+	var err error
+	if c.versionNum, err = strconv.Atoi(versionNum); err != nil {
+		return fmt.Errorf("invalid server version number %q: %w", versionNum, err)
+	}
+	var querySize string
+	if err := c.db.Get(&querySize, "SHOW track_activity_query_size"); err != nil {
+		log.Warnf("%s failed to get track_activity_query_size, the truncation of the samples will not be detected: %s", c.logPrompt, err)
+	} else if c.trackActivityQuerySize, err = strconv.Atoi(querySize); err != nil {
+		log.Warnf("%s invalid track_activity_query_size %q: %s", c.logPrompt, querySize, err)
+	}
+	log.Debugf("%s connected to PostgreSQL %s", c.logPrompt, c.version)
+	c.initialized = true
+	return nil
+}
+
+// Run executes the check.
+func (c *Check) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+	defer sender.Commit()
+
+	if c.db == nil {
+		db, err := c.Connect()
+		if err != nil {
+			sender.ServiceCheck(serviceCheckName, servicecheck.ServiceCheckCritical, c.dbHostname, c.tags, err.Error())
+			return fmt.Errorf("%s %w", c.logPrompt, err)
+		}
+		c.db = db
+	}
+	if !c.initialized {
+		if err := c.init(); err != nil {
+			sender.ServiceCheck(serviceCheckName, servicecheck.ServiceCheckCritical, c.dbHostname, c.tags, err.Error())
+			return fmt.Errorf("%s failed to initialize: %w", c.logPrompt, err)
+		}
+	}
+	sender.ServiceCheck(serviceCheckName, servicecheck.ServiceCheckOK, c.dbHostname, c.tags, "")
+
+	var allErrors error
+	if err := c.collectMetrics(sender); err != nil {
+		allErrors = errors.Join(allErrors, err)
+	}
+
+	if c.dbmEnabled {
+		if c.config.QueryMetrics.Enabled && dbm.CheckIntervalExpired(&c.statementsLastRun, c.config.QueryMetrics.CollectionInterval) {
+			if err := c.StatementMetrics(); err != nil {
+				allErrors = errors.Join(allErrors, fmt.Errorf("%s failed to collect statement metrics: %w", c.logPrompt, err))
+			}
+		}
+		collectActivity := c.config.QueryActivity.Enabled && dbm.CheckIntervalExpired(&c.activityLastRun, c.config.QueryActivity.CollectionInterval)
+		collectPlans := c.config.QuerySamples.Enabled && dbm.CheckIntervalExpired(&c.samplesLastRun, c.config.QuerySamples.CollectionInterval)
+		if collectActivity || collectPlans {
+			if err := c.SampleActivity(collectActivity, collectPlans); err != nil {
+				allErrors = errors.Join(allErrors, fmt.Errorf("%s failed to collect activity samples: %w", c.logPrompt, err))
+			}
+		}
+	}
+	return allErrors
+}
+
+// Teardown cleans up resources used throughout the check.
+func (c *Check) Teardown() {
+	if c.db != nil {
+		if err := c.db.Close(); err != nil {
+			log.Warnf("%s failed to close the connection: %s", c.logPrompt, err)
+		}
+		c.db = nil
+	}
+}
@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm/dbmtest"
+	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+)
+
+var statementColumns = []string{
+	"queryid", "datname", "rolname", "query", "calls", "total_time", "rows",
+	"shared_blks_hit", "shared_blks_read", "shared_blks_dirtied", "shared_blks_written",
+	"local_blks_hit", "local_blks_read", "local_blks_dirtied", "local_blks_written",
+	"temp_blks_read", "temp_blks_written", "blk_read_time", "blk_write_time",
+}
+
+var activityColumns = []string{
+	"now", "pid", "datname", "usename", "application_name", "client_addr", "backend_type", "state",
+	"wait_event_type", "wait_event", "backend_start", "xact_start", "query_start", "state_change",
+	"blocking_pids", "query",
+}
+
+// newTestCheck returns a configured check whose connection is a sqlmock stand-in of the server
+func newTestCheck(t *testing.T, instance string) (*Check, sqlmock.Sqlmock, *mocksender.MockSender) {
+	c := newCheck().(*Check)
+	sender := dbmtest.ConfigureCheck(t, c, instance)
+
+	var dbMock sqlmock.Sqlmock
+	c.db, dbMock = dbmtest.NewMockDB(t)
+	c.initialized = true
+	c.version = "16.2"
+	c.versionNum = 160002
+	c.trackActivityQuerySize = 1024
+	now := time.Unix(1700000000, 0)
+	c.timeNow = func() time.Time { return now }
+	return c, dbMock, sender
+}
+
+func TestConfigure(t *testing.T) {
+	c, _, _ := newTestCheck(t, "host: localhost\nusername: datadog\ndbm: true\ntags: [env:test]")
+	assert.Equal(t, []string{"env:test", "dbms:postgres", "dbm:true", "server:localhost", "port:5432"}, c.tags)
+	assert.Equal(t, c.agentHostname, c.dbHostname)
+
+	c, _, _ = newTestCheck(t, "host: db.example.com\nusername: datadog")
+	assert.Equal(t, "db.example.com", c.dbHostname)
+	assert.False(t, c.dbmEnabled)
+}
+
+func TestCollectMetrics(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\ncollect_database_size_metrics: false")
+
+	dbMock.ExpectQuery("FROM pg_stat_database").WillReturnRows(sqlmock.NewRows([]string{"datname", "numbackends", "xact_commit", "deadlocks"}).
+		AddRow("app", 3, 120, 1))
+	dbMock.ExpectQuery("max_connections").WillReturnRows(sqlmock.NewRows([]string{"max_connections", "percent_usage_connections"}).
+		AddRow(100, 0.03))
+	dbMock.ExpectQuery("FROM pg_stat_bgwriter").WillReturnRows(sqlmock.NewRows([]string{"checkpoints_timed", "buffers_alloc"}).
+		AddRow(4, 1000))
+	dbMock.ExpectQuery("pg_last_wal_receive_lsn").WillReturnRows(sqlmock.NewRows([]string{"replication_delay"}))
+	dbMock.ExpectQuery("FROM pg_stat_replication").WillReturnRows(sqlmock.NewRows([]string{"application_name", "state", "client_addr", "write_lag"}).
+		AddRow("replica", "streaming", "10.0.0.2", nil))
+
+	require.NoError(t, c.Run())
+	require.NoError(t, dbMock.ExpectationsWereMet())
+
+	dbTags := append(append([]string{}, c.tags...), "db:app")
+	sender.AssertServiceCheck(t, "postgres.can_connect", servicecheck.ServiceCheckOK, "db.example.com", c.tags, "")
+	sender.AssertMetric(t, "Gauge", "postgresql.connections", 3, "db.example.com", dbTags)
+	sender.AssertMetric(t, "Rate", "postgresql.commits", 120, "db.example.com", dbTags)
+	sender.AssertMetric(t, "MonotonicCount", "postgresql.deadlocks.count", 1, "db.example.com", dbTags)
+	sender.AssertMetric(t, "Gauge", "postgresql.max_connections", 100, "db.example.com", c.tags)
+	sender.AssertMetric(t, "MonotonicCount", "postgresql.bgwriter.buffers_alloc", 1000, "db.example.com", c.tags)
+	sender.AssertNotCalled(t, "Gauge", "postgresql.replication.wal_write_lag", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestStatementMetrics(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\ndbm: true")
+
+	statement := "SELECT * FROM orders WHERE id = 1"
+	dbMock.ExpectQuery("FROM pg_stat_statements").WillReturnRows(sqlmock.NewRows(statementColumns).
+		AddRow(42, "app", "web", statement, 10, 100.5, 10, 5, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0))
+	require.NoError(t, c.StatementMetrics())
+	assert.Empty(t, dbmtest.EventPlatformPayloads(sender, "dbm-metrics"), "the first collection only records the counters")
+
+	dbMock.ExpectQuery("FROM pg_stat_statements").WillReturnRows(sqlmock.NewRows(statementColumns).
+		AddRow(42, "app", "web", statement, 15, 150.5, 16, 8, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0))
+	require.NoError(t, c.StatementMetrics())
+	require.NoError(t, dbMock.ExpectationsWereMet())
+
+	payloads := dbmtest.EventPlatformPayloads(sender, "dbm-metrics")
+	require.Len(t, payloads, 1)
+	var payload metricsPayload
+	require.NoError(t, json.Unmarshal(payloads[0], &payload))
+	require.Len(t, payload.PostgresRows, 1)
+	row := payload.PostgresRows[0]
+	assert.Equal(t, "SELECT * FROM orders WHERE id = ?", row.Query)
+	assert.Equal(t, "42", row.QueryID)
+	assert.Equal(t, []string{"orders"}, row.Tables)
+	assert.Equal(t, dbm.GetQuerySignature(row.Query), row.QuerySignature)
+	assert.Equal(t, 5.0, row.Calls)
+	assert.Equal(t, 50.0, row.TotalTime)
+	assert.Equal(t, 6.0, row.Rows)
+	assert.Equal(t, 3.0, row.SharedBlksHit)
+	assert.Equal(t, "16.2", payload.PostgresVersion)
+
+	assert.Len(t, dbmtest.EventPlatformPayloads(sender, "dbm-samples"), 1, "the full query text is sent once")
+}
+
+func TestStatementMetricsReset(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\ndbm: true")
+
+	for _, calls := range []int{10, 2} {
+		dbMock.ExpectQuery("FROM pg_stat_statements").WillReturnRows(sqlmock.NewRows(statementColumns).
+			AddRow(42, "app", "web", "SELECT 1", calls, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0))
+		require.NoError(t, c.StatementMetrics())
+	}
+
+	payloads := dbmtest.EventPlatformPayloads(sender, "dbm-metrics")
+	require.Len(t, payloads, 1)
+	var payload metricsPayload
+	require.NoError(t, json.Unmarshal(payloads[0], &payload))
+	assert.Empty(t, payload.PostgresRows)
+}
+
+func TestStatementMetricsQueryVersions(t *testing.T) {
+	assert.Contains(t, statementMetricsQuery(120000, 10), "s.total_time AS total_time")
+	assert.Contains(t, statementMetricsQuery(160000, 10), "s.total_exec_time AS total_time")
+	assert.Contains(t, statementMetricsQuery(160000, 10), "s.blk_read_time AS blk_read_time")
+	assert.Contains(t, statementMetricsQuery(170000, 10), "s.shared_blk_read_time AS blk_read_time")
+	assert.Contains(t, statementMetricsQuery(170000, 10), "LIMIT 10")
+}
+
+func TestSampleActivity(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\ndbm: true")
+
+	plan := `[{"Plan": {"Node Type": "Index Scan", "Index Cond": "(id = 1)", "Total Cost": 8.3, "Plan Rows": 1}}]`
+	activityRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows(activityColumns).
+			AddRow("2024-01-01 00:00:00+00", 101, "app", "web", "rails", "10.0.0.3", "client backend", "active",
+				"Lock", "relation", nil, nil, "2024-01-01 00:00:00+00", nil, "102", "SELECT * FROM orders WHERE id = 1").
+			AddRow("2024-01-01 00:00:00+00", 102, "app", "web", "rails", "10.0.0.3", "client backend", "idle in transaction",
+				nil, nil, nil, nil, nil, nil, "", "UPDATE orders SET state = 'paid' WHERE id = 1")
+	}
+	dbMock.ExpectQuery("FROM pg_stat_activity").WithArgs("datadog-agent", 3500).WillReturnRows(activityRows())
+	dbMock.ExpectQuery(`SELECT datadog\.explain_statement`).WithArgs("SELECT * FROM orders WHERE id = 1").
+		WillReturnRows(sqlmock.NewRows([]string{"explain_statement"}).AddRow(plan))
+	require.NoError(t, c.SampleActivity(true, true))
+
+	// the plan of the query signature is not collected again
+	dbMock.ExpectQuery("FROM pg_stat_activity").WillReturnRows(activityRows())
+	require.NoError(t, c.SampleActivity(false, true))
+	require.NoError(t, dbMock.ExpectationsWereMet())
+
+	activityPayloads := dbmtest.EventPlatformPayloads(sender, "dbm-activity")
+	require.Len(t, activityPayloads, 1)
+	var snapshot activitySnapshot
+	require.NoError(t, json.Unmarshal(activityPayloads[0], &snapshot))
+	require.Len(t, snapshot.PostgresActivity, 2)
+	assert.Equal(t, "SELECT * FROM orders WHERE id = ?", snapshot.PostgresActivity[0].Statement)
+	assert.Equal(t, "102", snapshot.PostgresActivity[0].BlockingPIDs)
+	assert.Equal(t, "not_truncated", snapshot.PostgresActivity[0].QueryTruncated)
+	assert.Equal(t, "UPDATE orders SET state = ? WHERE id = ?", snapshot.PostgresActivity[1].Statement)
+
+	planPayloads := dbmtest.EventPlatformPayloads(sender, "dbm-samples")
+	require.Len(t, planPayloads, 1)
+	var payload planPayload
+	require.NoError(t, json.Unmarshal(planPayloads[0], &payload))
+	assert.Equal(t, "plan", payload.DBMType)
+	assert.Equal(t, snapshot.PostgresActivity[0].QuerySignature, payload.DB.QuerySignature)
+	var definition []map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(payload.DB.Plan.Definition), &definition))
+	require.Len(t, definition, 1)
+	assert.NotContains(t, definition[0]["Plan"]["Index Cond"], "1", "the plan values are obfuscated")
+	assert.Equal(t, 8.3, definition[0]["Plan"]["Total Cost"], "the plan costs are kept")
+	assert.NotEmpty(t, payload.DB.Plan.Signature)
+	assert.Equal(t, int64(101), payload.Postgres.PID)
+}
+
+func TestSampleActivityTruncated(t *testing.T) {
+	c, dbMock, sender := newTestCheck(t, "host: db.example.com\nusername: datadog\ndbm: true")
+	c.trackActivityQuerySize = 20
+
+	dbMock.ExpectQuery("FROM pg_stat_activity").WillReturnRows(sqlmock.NewRows(activityColumns).
+		AddRow("2024-01-01 00:00:00+00", 101, "app", "web", "rails", nil, "client backend", "active",
+			nil, nil, nil, nil, nil, nil, "", "SELECT * FROM orders"))
+	require.NoError(t, c.SampleActivity(true, true))
+	require.NoError(t, dbMock.ExpectationsWereMet())
+
+	assert.Empty(t, dbmtest.EventPlatformPayloads(sender, "dbm-samples"), "truncated statements are not explained")
+	var snapshot activitySnapshot
+	require.NoError(t, json.Unmarshal(dbmtest.EventPlatformPayloads(sender, "dbm-activity")[0], &snapshot))
+	assert.Equal(t, "truncated", snapshot.PostgresActivity[0].QueryTruncated)
+}
@@ -0,0 +1,284 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	cache "github.com/patrickmn/go-cache"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/dbm"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// statementMetricsKey identifies a row of pg_stat_statements
+type statementMetricsKey struct {
+	QueryID  int64
+	DBName   string
+	UserName string
+}
+
+// statementMetricsCounters are the cumulative counters of a row of pg_stat_statements
+type statementMetricsCounters struct {
+	Calls             float64 `db:"calls" json:"calls"`
+	TotalTime         float64 `db:"total_time" json:"total_time"`
+	Rows              float64 `db:"rows" json:"rows"`
+	SharedBlksHit     float64 `db:"shared_blks_hit" json:"shared_blks_hit"`
+	SharedBlksRead    float64 `db:"shared_blks_read" json:"shared_blks_read"`
+	SharedBlksDirtied float64 `db:"shared_blks_dirtied" json:"shared_blks_dirtied"`
+	SharedBlksWritten float64 `db:"shared_blks_written" json:"shared_blks_written"`
+	LocalBlksHit      float64 `db:"local_blks_hit" json:"local_blks_hit"`
+	LocalBlksRead     float64 `db:"local_blks_read" json:"local_blks_read"`
+	LocalBlksDirtied  float64 `db:"local_blks_dirtied" json:"local_blks_dirtied"`
+	LocalBlksWritten  float64 `db:"local_blks_written" json:"local_blks_written"`
+	TempBlksRead      float64 `db:"temp_blks_read" json:"temp_blks_read"`
+	TempBlksWritten   float64 `db:"temp_blks_written" json:"temp_blks_written"`
+	BlkReadTime       float64 `db:"blk_read_time" json:"blk_read_time"`
+	BlkWriteTime      float64 `db:"blk_write_time" json:"blk_write_time"`
+}
+
+// statementMetricsRowDB is a row of pg_stat_statements
+type statementMetricsRowDB struct {
+	QueryID  int64  `db:"queryid"`
+	DBName   string `db:"datname"`
+	UserName string `db:"rolname"`
+	Query    string `db:"query"`
+	statementMetricsCounters
+}
+
+// queryRow holds the metadata of an obfuscated statement
+type queryRow struct {
+	QuerySignature string   `json:"query_signature,omitempty"`
+	Tables         []string `json:"dd_tables,omitempty"`
+	Commands       []string `json:"dd_commands,omitempty"`
+	Comments       []string `json:"dd_comments,omitempty"`
+}
+
+// postgresRow contains the metrics of a statement over the last collection interval
+type postgresRow struct {
+	queryRow
+	Query    string `json:"query"`
+	QueryID  string `json:"queryid"`
+	DBName   string `json:"datname"`
+	UserName string `json:"rolname"`
+	statementMetricsCounters
+}
+
+type metricsPayload struct {
+	Host                  string   `json:"host,omitempty"` // Host is the database hostname, not the agent hostname
+	Timestamp             float64  `json:"timestamp,omitempty"`
+	MinCollectionInterval float64  `json:"min_collection_interval,omitempty"`
+	Tags                  []string `json:"tags,omitempty"`
+	AgentVersion          string   `json:"ddagentversion,omitempty"`
+	AgentHostname         string   `json:"ddagenthostname,omitempty"`
+
+	PostgresRows    []postgresRow `json:"postgres_rows"`
+	PostgresVersion string        `json:"postgres_version,omitempty"`
+}
+
+type fqtDBMetadata struct {
+	Tables   []string `json:"tables"`
+	Commands []string `json:"commands"`
+	Comments []string `json:"comments"`
+}
+
+type fqtDB struct {
+	Instance       string        `json:"instance"`
+	QuerySignature string        `json:"query_signature"`
+	Statement      string        `json:"statement"`
+	Metadata       fqtDBMetadata `json:"metadata"`
+}
+
+type fqtPostgres struct {
+	DBName   string `json:"datname"`
+	UserName string `json:"rolname"`
+}
+
+type fqtPayload struct {
+	Timestamp    float64     `json:"timestamp,omitempty"`
+	Host         string      `json:"host,omitempty"` // Host is the database hostname, not the agent hostname
+	AgentVersion string      `json:"ddagentversion,omitempty"`
+	Source       string      `json:"ddsource"`
+	Tags         string      `json:"ddtags,omitempty"`
+	DBMType      string      `json:"dbm_type"`
+	DB           fqtDB       `json:"db"`
+	Postgres     fqtPostgres `json:"postgres"`
+}
+
+// statementMetricsQuery returns the pg_stat_statements query matching the columns of the server version
+func statementMetricsQuery(versionNum int, rowsLimit int) string {
+	totalTime := "total_exec_time"
+	if versionNum < 130000 {
+		totalTime = "total_time"
+	}
+	blkReadTime, blkWriteTime := "blk_read_time", "blk_write_time"
+	if versionNum >= 170000 {
+		blkReadTime, blkWriteTime = "shared_blk_read_time", "shared_blk_write_time"
+	}
+	return fmt.Sprintf(`SELECT s.queryid, d.datname, r.rolname, s.query, s.calls, s.%s AS total_time, s.rows,
+	s.shared_blks_hit, s.shared_blks_read, s.shared_blks_dirtied, s.shared_blks_written,
+	s.local_blks_hit, s.local_blks_read, s.local_blks_dirtied, s.local_blks_written,
+	s.temp_blks_read, s.temp_blks_written, s.%s AS blk_read_time, s.%s AS blk_write_time
+FROM pg_stat_statements s
+JOIN pg_roles r ON r.oid = s.userid
+JOIN pg_database d ON d.oid = s.dbid
+WHERE s.queryid IS NOT NULL AND s.query NOT LIKE 'EXPLAIN %%'
+ORDER BY s.calls DESC
+LIMIT %d`, totalTime, blkReadTime, blkWriteTime, rowsLimit)
+}
+
+// subtractCounters returns the difference between the current and the previous counters, and false if a counter
+// decreased because the statistics were reset
+func subtractCounters(current, previous statementMetricsCounters) (statementMetricsCounters, bool) {
+	diff := statementMetricsCounters{
+		Calls:             current.Calls - previous.Calls,
+		TotalTime:         current.TotalTime - previous.TotalTime,
+		Rows:              current.Rows - previous.Rows,
+		SharedBlksHit:     current.SharedBlksHit - previous.SharedBlksHit,
+		SharedBlksRead:    current.SharedBlksRead - previous.SharedBlksRead,
+		SharedBlksDirtied: current.SharedBlksDirtied - previous.SharedBlksDirtied,
+		SharedBlksWritten: current.SharedBlksWritten - previous.SharedBlksWritten,
+		LocalBlksHit:      current.LocalBlksHit - previous.LocalBlksHit,
+		LocalBlksRead:     current.LocalBlksRead - previous.LocalBlksRead,
+		LocalBlksDirtied:  current.LocalBlksDirtied - previous.LocalBlksDirtied,
+		LocalBlksWritten:  current.LocalBlksWritten - previous.LocalBlksWritten,
+		TempBlksRead:      current.TempBlksRead - previous.TempBlksRead,
+		TempBlksWritten:   current.TempBlksWritten - previous.TempBlksWritten,
+		BlkReadTime:       current.BlkReadTime - previous.BlkReadTime,
+		BlkWriteTime:      current.BlkWriteTime - previous.BlkWriteTime,
+	}
+	for _, v := range []float64{
+		diff.Calls, diff.TotalTime, diff.Rows, diff.SharedBlksHit, diff.SharedBlksRead, diff.SharedBlksDirtied,
+		diff.SharedBlksWritten, diff.LocalBlksHit, diff.LocalBlksRead, diff.LocalBlksDirtied, diff.LocalBlksWritten,
+		diff.TempBlksRead, diff.TempBlksWritten, diff.BlkReadTime, diff.BlkWriteTime,
+	} {
+		if v < 0 {
+			return diff, false
+		}
+	}
+	return diff, true
+}
+
+// StatementMetrics collects the deltas of the pg_stat_statements counters since the previous collection, and submits
+// them with the obfuscated statements. The first collection only records the counters.
+func (c *Check) StatementMetrics() error {
+	start := c.timeNow()
+
+	var rows []statementMetricsRowDB
+	if err := c.db.Select(&rows, statementMetricsQuery(c.versionNum, c.config.QueryMetrics.DBRowsLimit)); err != nil {
+		return fmt.Errorf("failed to query pg_stat_statements, make sure the extension is created: %w", err)
+	}
+
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+
+	current := make(map[statementMetricsKey]statementMetricsCounters, len(rows))
+	postgresRows := []postgresRow{}
+	for _, row := range rows {
+		key := statementMetricsKey{QueryID: row.QueryID, DBName: row.DBName, UserName: row.UserName}
+		current[key] = row.statementMetricsCounters
+
+		if c.statementMetricsPrevious == nil {
+			continue
+		}
+		previous, found := c.statementMetricsPrevious[key]
+		if !found {
+			continue
+		}
+		diff, ok := subtractCounters(row.statementMetricsCounters, previous)
+		if !ok || diff.Calls == 0 {
+			continue
+		}
+
+		obfuscated, err := c.obfuscator.ObfuscateStatement(row.Query)
+		if err != nil {
+			log.Debugf("%s failed to obfuscate the statement of queryid %d: %s", c.logPrompt, row.QueryID, err)
+			continue
+		}
+		qr := queryRow{
+			QuerySignature: obfuscated.QuerySignature,
+			Tables:         obfuscated.Tables,
+			Commands:       obfuscated.Commands,
+			Comments:       obfuscated.Comments,
+		}
+		postgresRows = append(postgresRows, postgresRow{
+			queryRow:                 qr,
+			Query:                    obfuscated.Statement,
+			QueryID:                  strconv.FormatInt(row.QueryID, 10),
+			DBName:                   row.DBName,
+			UserName:                 row.UserName,
+			statementMetricsCounters: diff,
+		})
+
+		if _, found := c.fqtEmitted.Get(qr.QuerySignature); !found {
+			c.sendFQT(obfuscated, row.DBName, row.UserName)
+			c.fqtEmitted.Set(qr.QuerySignature, "1", cache.DefaultExpiration)
+		}
+	}
+	firstRun := c.statementMetricsPrevious == nil
+	c.statementMetricsPrevious = current
+	if firstRun {
+		return nil
+	}
+
+	payload := metricsPayload{
+		Host:                  c.dbHostname,
+		Timestamp:             float64(c.timeNow().UnixMilli()),
+		MinCollectionInterval: float64(c.config.QueryMetrics.CollectionInterval),
+		Tags:                  c.tags,
+		AgentVersion:          c.agentVersion,
+		AgentHostname:         c.agentHostname,
+		PostgresRows:          postgresRows,
+		PostgresVersion:       c.version,
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the query metrics payload: %w", err)
+	}
+	log.Debugf("%s Query metrics payload %s", c.logPrompt, string(payloadBytes))
+	sender.EventPlatformEvent(payloadBytes, "dbm-metrics")
+	sender.Gauge("dd.postgres.statement_metrics.time_ms", float64(c.timeNow().Sub(start).Milliseconds()), c.dbHostname, c.tags)
+	sender.Gauge("dd.postgres.statement_metrics.rows", float64(len(postgresRows)), c.dbHostname, c.tags)
+	return nil
+}
+
+// sendFQT submits the full query text of a statement seen for the first time
+func (c *Check) sendFQT(statement dbm.ObfuscatedStatement, dbName string, userName string) {
+	sender, err := c.GetSender()
+	if err != nil {
+		log.Errorf("%s failed to get the sender: %s", c.logPrompt, err)
+		return
+	}
+	payload := fqtPayload{
+		Timestamp:    float64(c.timeNow().UnixMilli()),
+		Host:         c.dbHostname,
+		AgentVersion: c.agentVersion,
+		Source:       IntegrationName,
+		Tags:         c.tagsString,
+		DBMType:      "fqt",
+		DB: fqtDB{
+			Instance:       dbName,
+			QuerySignature: statement.QuerySignature,
+			Statement:      statement.Statement,
+			Metadata: fqtDBMetadata{
+				Tables:   statement.Tables,
+				Commands: statement.Commands,
+				Comments: statement.Comments,
+			},
+		},
+		Postgres: fqtPostgres{DBName: dbName, UserName: userName},
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		log.Errorf("%s failed to marshal the fqt payload: %s", c.logPrompt, err)
+		return
+	}
+	sender.EventPlatformEvent(payloadBytes, "dbm-samples")
+}
@@ -0,0 +1,247 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package directory implements the directory check, which reports the number, the size and the age of the files
+// of a directory tree.
+package directory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+const (
+	// CheckName is the name of the check
+	CheckName = "directory"
+
+	defaultWorkers           = 4
+	defaultMaxFileGaugeCount = 20
+)
+
+type instanceConfig struct {
+	Directory   string `yaml:"directory"`
+	Name        string `yaml:"name"`
+	DirTagName  string `yaml:"dirtagname"`
+	FileTagName string `yaml:"filetagname"`
+	// Pattern is a glob the file names must match to be counted
+	Pattern string `yaml:"pattern"`
+	// FileRegex is a regular expression the slash-separated relative paths of the files must match to be counted
+	FileRegex string `yaml:"file_regex"`
+	// ExcludeDirs are regular expressions matched against the slash-separated relative paths of the directories
+	// not to walk
+	ExcludeDirs []string `yaml:"exclude_dirs"`
+	// Patterns count the files matching globs, by name
+	Patterns  map[string]string `yaml:"patterns"`
+	Recursive bool              `yaml:"recursive"`
+	// MaxDepth is the number of levels of subdirectories walked by a recursive walk, 0 means no limit
+	MaxDepth          int  `yaml:"max_depth"`
+	CountOnly         bool `yaml:"countonly"`
+	FileGauges        bool `yaml:"filegauges"`
+	MaxFileGaugeCount int  `yaml:"max_filegauge_count"`
+	IgnoreMissing     bool `yaml:"ignore_missing"`
+	// Workers is the number of directories read concurrently
+	Workers int `yaml:"workers"`
+	// TimeBudget is the maximum duration of a walk, in seconds. It defaults to the collection interval.
+	TimeBudget float64 `yaml:"time_budget"`
+}
+
+// Check reports the number, the size and the age of the files of a directory tree
+type Check struct {
+	core.CheckBase
+	config      instanceConfig
+	fileRegex   *regexp.Regexp
+	excludeDirs []*regexp.Regexp
+	tags        []string
+
+	// for testing purpose
+	timeNow func() time.Time
+}
+
+// Factory creates a new check factory
+func Factory() option.Option[func() check.Check] {
+	return option.New(newCheck)
+}
+
+func newCheck() check.Check {
+	return &Check{
+		CheckBase: core.NewCheckBase(CheckName),
+		timeNow:   time.Now,
+	}
+}
+
+// Configure parses the check configuration and init the check
+func (c *Check) Configure(senderManager sender.SenderManager, integrationConfigDigest uint64, rawInstance integration.Data, rawInitConfig integration.Data, source string) error {
+	c.BuildID(integrationConfigDigest, rawInstance, rawInitConfig)
+
+	if err := c.CommonConfigure(senderManager, rawInitConfig, rawInstance, source); err != nil {
+		return err
+	}
+
+	c.config = instanceConfig{
+		DirTagName:        "name",
+		FileTagName:       "filename",
+		MaxFileGaugeCount: defaultMaxFileGaugeCount,
+		Workers:           defaultWorkers,
+	}
+	if err := yaml.Unmarshal(rawInstance, &c.config); err != nil {
+		return err
+	}
+	if c.config.Directory == "" {
+		return errors.New("instance config `directory` must not be empty")
+	}
+	c.config.Directory = filepath.Clean(c.config.Directory)
+	if c.config.Name == "" {
+		c.config.Name = c.config.Directory
+	}
+	if c.config.Workers < 1 {
+		c.config.Workers = 1
+	}
+
+	if c.config.Pattern != "" {
+		if _, err := filepath.Match(c.config.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", c.config.Pattern, err)
+		}
+	}
+	for name, pattern := range c.config.Patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q of %s: %w", pattern, name, err)
+		}
+	}
+	if c.config.FileRegex != "" {
+		re, err := regexp.Compile(c.config.FileRegex)
+		if err != nil {
+			return fmt.Errorf("invalid file_regex: %w", err)
+		}
+		c.fileRegex = re
+	}
+	c.excludeDirs = nil
+	for _, exclude := range c.config.ExcludeDirs {
+		re, err := regexp.Compile(exclude)
+		if err != nil {
+			return fmt.Errorf("invalid exclude_dirs: %w", err)
+		}
+		c.excludeDirs = append(c.excludeDirs, re)
+	}
+
+	c.tags = []string{c.config.DirTagName + ":" + c.config.Name}
+	return nil
+}
+
+// timeBudget returns the maximum duration of a walk
+func (c *Check) timeBudget() time.Duration {
+	if c.config.TimeBudget > 0 {
+		return time.Duration(c.config.TimeBudget * float64(time.Second))
+	}
+	return c.Interval()
+}
+
+// Run executes the check
+func (c *Check) Run() error {
+	sender, err := c.GetSender()
+	if err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(c.config.Directory); err != nil || !info.IsDir() {
+		if c.config.IgnoreMissing {
+			return nil
+		}
+		if err == nil {
+			err = fmt.Errorf("%s is not a directory", c.config.Directory)
+		}
+		return fmt.Errorf("unable to access %s: %w", c.config.Directory, err)
+	}
+
+	ctx := context.Background()
+	if budget := c.timeBudget(); budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	result := c.walk(ctx)
+	now := c.timeNow()
+
+	sender.Gauge("system.disk.directory.files", float64(result.files), "", c.tags)
+	truncated := 0.0
+	if result.truncated {
+		truncated = 1
+		c.Warnf("The walk of %s exceeded its time budget of %s, the metrics only cover part of the directory", c.config.Directory, c.timeBudget())
+	}
+	sender.Gauge("system.disk.directory.walk_truncated", truncated, "", c.tags)
+	for name, count := range result.patternFiles {
+		sender.Gauge("system.disk.directory.pattern.files", float64(count), "", append(c.copyTags(), "pattern:"+name))
+	}
+
+	if !c.config.CountOnly {
+		sender.Gauge("system.disk.directory.folders", float64(result.folders), "", c.tags)
+		sender.Gauge("system.disk.directory.bytes", float64(result.bytes), "", c.tags)
+		if result.files > 0 {
+			sender.Gauge("system.disk.directory.oldest_file_age", now.Sub(result.oldest).Seconds(), "", c.tags)
+			sender.Gauge("system.disk.directory.newest_file_age", now.Sub(result.newest).Seconds(), "", c.tags)
+		}
+		for _, file := range result.fileGauges {
+			tags := append(c.copyTags(), c.config.FileTagName+":"+file.path)
+			sender.Gauge("system.disk.directory.file.bytes", float64(file.size), "", tags)
+			sender.Gauge("system.disk.directory.file.modified_sec_ago", now.Sub(file.modTime).Seconds(), "", tags)
+		}
+		if result.droppedFileGauges > 0 {
+			c.Warnf("%d files of %s were not reported by the per-file metrics, which are limited to %d files by `max_filegauge_count`", result.droppedFileGauges, c.config.Directory, c.config.MaxFileGaugeCount)
+		}
+	}
+
+	for _, walkErr := range result.errors {
+		c.Warnf("%s", walkErr)
+	}
+
+	sender.Commit()
+	return nil
+}
+
+func (c *Check) copyTags() []string {
+	return append(make([]string, 0, len(c.tags)+1), c.tags...)
+}
+
+// excluded returns true if a directory must not be walked
+func (c *Check) excluded(relPath string) bool {
+	for _, re := range c.excludeDirs {
+		if re.MatchString(relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches returns true if a file is counted
+func (c *Check) matches(relPath string) bool {
+	if c.config.Pattern != "" {
+		if matched, _ := filepath.Match(c.config.Pattern, filepath.Base(relPath)); !matched {
+			return false
+		}
+	}
+	return c.fileRegex == nil || c.fileRegex.MatchString(relPath)
+}
+
+// statFile returns the information of a file, following its symbolic links
+func statFile(path string, entry fs.DirEntry) (fs.FileInfo, error) {
+	if entry.Type()&fs.ModeSymlink != 0 {
+		return os.Stat(path)
+	}
+	return entry.Info()
+}
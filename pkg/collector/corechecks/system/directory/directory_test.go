@@ -0,0 +1,148 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package directory
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+)
+
+var now = time.Unix(1700000000, 0)
+
+// createTree creates files of the given sizes and ages, by path relative to the returned directory
+func createTree(t *testing.T, files map[string]int) string {
+	dir := t.TempDir()
+	for path, age := range files {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, make([]byte, age), 0644))
+		modTime := now.Add(-time.Duration(age) * time.Second)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	return dir
+}
+
+func newTestCheck(t *testing.T, instance string) (*Check, *mocksender.MockSender) {
+	c := newCheck().(*Check)
+	c.timeNow = func() time.Time { return now }
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	require.NoError(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data(instance), nil, "test"))
+
+	sender := mocksender.NewMockSenderWithSenderManager(c.ID(), senderManager)
+	sender.SetupAcceptAll()
+	return c, sender
+}
+
+func TestConfigure(t *testing.T) {
+	c := newCheck().(*Check)
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	assert.Error(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data("name: spool"), nil, "test"))
+	assert.Error(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data("directory: /tmp\nfile_regex: '['"), nil, "test"))
+	assert.Error(t, c.Configure(senderManager, integration.FakeConfigHash, integration.Data("directory: /tmp\npattern: '['"), nil, "test"))
+
+	c, _ = newTestCheck(t, "directory: /var/spool/\nworkers: 0")
+	assert.Equal(t, "/var/spool", c.config.Directory)
+	assert.Equal(t, []string{"name:/var/spool"}, c.tags)
+	assert.Equal(t, 1, c.config.Workers)
+	assert.Equal(t, 15*time.Second, c.timeBudget())
+}
+
+func TestRun(t *testing.T) {
+	dir := createTree(t, map[string]int{
+		"a.log":         10,
+		"b.txt":         20,
+		"sub/c.log":     30,
+		"sub/deep/d.gz": 40,
+		"tmp/e.log":     50,
+	})
+	c, sender := newTestCheck(t, "directory: "+dir+"\nname: spool\nrecursive: true\nexclude_dirs: ['^tmp$']\npatterns:\n  logs: '*.log'")
+
+	require.NoError(t, c.Run())
+
+	tags := []string{"name:spool"}
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.files", 4, "", tags)
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.folders", 2, "", tags)
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.bytes", 100, "", tags)
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.oldest_file_age", 40, "", tags)
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.newest_file_age", 10, "", tags)
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.pattern.files", 2, "", []string{"name:spool", "pattern:logs"})
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.walk_truncated", 0, "", tags)
+	sender.AssertNotCalled(t, "Gauge", "system.disk.directory.file.bytes", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunFilters(t *testing.T) {
+	dir := createTree(t, map[string]int{
+		"a.log":             10,
+		"b.txt":             20,
+		"sub/c.log":         30,
+		"sub/deep/d.log":    40,
+		"sub/deep/e.log.gz": 50,
+	})
+
+	c, sender := newTestCheck(t, "directory: "+dir+"\nrecursive: true\nmax_depth: 1\npattern: '*.log'")
+	require.NoError(t, c.Run())
+	tags := []string{"name:" + dir}
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.files", 2, "", tags)
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.folders", 2, "", tags)
+
+	c, sender = newTestCheck(t, "directory: "+dir+"\nrecursive: true\nfile_regex: '^sub/.*\\.gz$'\ncountonly: true")
+	require.NoError(t, c.Run())
+	sender.AssertMetric(t, "Gauge", "system.disk.directory.files", 1, "", tags)
+	sender.AssertNotCalled(t, "Gauge", "system.disk.directory.bytes", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRunFileGauges(t *testing.T) {
+	dir := createTree(t, map[string]int{"a.log": 10, "b.log": 20, "c.log": 30})
+	c, sender := newTestCheck(t, "directory: "+dir+"\nfilegauges: true\nmax_filegauge_count: 2\ndirtagname: dir\nfiletagname: file")
+
+	require.NoError(t, c.Run())
+
+	var files []string
+	for _, call := range sender.Calls {
+		if call.Method == "Gauge" && call.Arguments.String(0) == "system.disk.directory.file.bytes" {
+			tags := call.Arguments.Get(3).([]string)
+			require.Len(t, tags, 2)
+			assert.Equal(t, "dir:"+dir, tags[0])
+			files = append(files, tags[1])
+			sender.AssertMetric(t, "Gauge", "system.disk.directory.file.modified_sec_ago", call.Arguments.Get(1).(float64), "", tags)
+		}
+	}
+	// The files of the smallest paths are reported
+	assert.Equal(t, []string{"file:" + filepath.Join(dir, "a.log"), "file:" + filepath.Join(dir, "b.log")}, files)
+	assert.Len(t, c.GetWarnings(), 1)
+}
+
+func TestRunMissing(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+
+	c, _ := newTestCheck(t, "directory: "+dir)
+	assert.Error(t, c.Run())
+
+	c, sender := newTestCheck(t, "directory: "+dir+"\nignore_missing: true")
+	assert.NoError(t, c.Run())
+	sender.AssertNotCalled(t, "Gauge", "system.disk.directory.files", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestWalkTimeBudget(t *testing.T) {
+	dir := createTree(t, map[string]int{"a.log": 10, "sub/b.log": 20})
+	c, _ := newTestCheck(t, "directory: "+dir+"\nrecursive: true")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result := c.walk(ctx)
+	assert.True(t, result.truncated)
+	assert.Zero(t, result.files)
+}
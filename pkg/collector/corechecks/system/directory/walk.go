@@ -0,0 +1,243 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package directory
+
+import (
+	"container/heap"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxWalkErrors limits the number of errors reported by a walk
+const maxWalkErrors = 10
+
+// fileGauge is a file reported by the per-file metrics
+type fileGauge struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// fileGaugeHeap is a max-heap of files by path
+type fileGaugeHeap []fileGauge
+
+func (h fileGaugeHeap) Len() int           { return len(h) }
+func (h fileGaugeHeap) Less(i, j int) bool { return h[i].path > h[j].path }
+func (h fileGaugeHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *fileGaugeHeap) Push(x any) {
+	*h = append(*h, x.(fileGauge))
+}
+
+func (h *fileGaugeHeap) Pop() any {
+	old := *h
+	file := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return file
+}
+
+// walkResult is the aggregated content of a directory tree
+type walkResult struct {
+	sync.Mutex
+	files             int
+	folders           int
+	bytes             int64
+	oldest            time.Time
+	newest            time.Time
+	patternFiles      map[string]int
+	fileGauges        fileGaugeHeap
+	droppedFileGauges int
+	truncated         bool
+	errors            []error
+}
+
+func (r *walkResult) addError(err error) {
+	r.Lock()
+	defer r.Unlock()
+	if len(r.errors) < maxWalkErrors {
+		r.errors = append(r.errors, err)
+	}
+}
+
+// addFileGauge keeps the file if its path is one of the `maxCount` smallest paths of the files seen so far. The result
+// must be locked.
+func (r *walkResult) addFileGauge(file fileGauge, maxCount int) {
+	if len(r.fileGauges) < maxCount {
+		heap.Push(&r.fileGauges, file)
+		return
+	}
+	r.droppedFileGauges++
+	if maxCount > 0 && file.path < r.fileGauges[0].path {
+		r.fileGauges[0] = file
+		heap.Fix(&r.fileGauges, 0)
+	}
+}
+
+// walkDir is a directory to read by a walk
+type walkDir struct {
+	path  string
+	depth int
+}
+
+// walkQueue holds the directories left to read by the workers of a walk
+type walkQueue struct {
+	sync.Mutex
+	cond *sync.Cond
+	dirs []walkDir
+	// pending is the number of directories queued or being read
+	pending int
+}
+
+func newWalkQueue(root walkDir) *walkQueue {
+	q := &walkQueue{dirs: []walkDir{root}, pending: 1}
+	q.cond = sync.NewCond(q)
+	return q
+}
+
+// pop returns the next directory to read, waiting for the directories being read when the queue is empty. It
+// returns false once all the directories are read.
+func (q *walkQueue) pop() (walkDir, bool) {
+	q.Lock()
+	defer q.Unlock()
+	for len(q.dirs) == 0 && q.pending > 0 {
+		q.cond.Wait()
+	}
+	if len(q.dirs) == 0 {
+		return walkDir{}, false
+	}
+	dir := q.dirs[0]
+	q.dirs = q.dirs[1:]
+	return dir, true
+}
+
+// done queues the subdirectories of a directory read
+func (q *walkQueue) done(subdirs []walkDir) {
+	q.Lock()
+	defer q.Unlock()
+	q.dirs = append(q.dirs, subdirs...)
+	q.pending += len(subdirs) - 1
+	q.cond.Broadcast()
+}
+
+// walk reads the directory tree with `workers` goroutines reading the directories of a queue, until the context is
+// done
+func (c *Check) walk(ctx context.Context) *walkResult {
+	result := &walkResult{patternFiles: make(map[string]int, len(c.config.Patterns))}
+	for name := range c.config.Patterns {
+		result.patternFiles[name] = 0
+	}
+
+	queue := newWalkQueue(walkDir{path: c.config.Directory})
+	var wg sync.WaitGroup
+	for i := 0; i < c.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				dir, ok := queue.pop()
+				if !ok {
+					return
+				}
+				if ctx.Err() != nil {
+					result.Lock()
+					result.truncated = true
+					result.Unlock()
+					queue.done(nil)
+					continue
+				}
+				queue.done(c.readDir(ctx, result, dir.path, dir.depth))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Report the files of the smallest paths, whatever the order the directories were read in
+	slices.SortFunc(result.fileGauges, func(a, b fileGauge) int { return strings.Compare(a.path, b.path) })
+	return result
+}
+
+// readDir adds the files of a directory to the result, and returns the subdirectories to walk
+func (c *Check) readDir(ctx context.Context, result *walkResult, path string, depth int) []walkDir {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		result.addError(err)
+		return nil
+	}
+
+	var subdirs []walkDir
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			result.Lock()
+			result.truncated = true
+			result.Unlock()
+			return nil
+		}
+
+		entryPath := filepath.Join(path, entry.Name())
+		relPath, err := filepath.Rel(c.config.Directory, entryPath)
+		if err != nil {
+			relPath = entryPath
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		if entry.IsDir() {
+			if c.excluded(relPath) {
+				continue
+			}
+			result.Lock()
+			result.folders++
+			result.Unlock()
+			if c.config.Recursive && (c.config.MaxDepth == 0 || depth < c.config.MaxDepth) {
+				subdirs = append(subdirs, walkDir{path: entryPath, depth: depth + 1})
+			}
+			continue
+		}
+
+		if !c.matches(relPath) {
+			continue
+		}
+		var info os.FileInfo
+		if !c.config.CountOnly {
+			info, err = statFile(entryPath, entry)
+			if err != nil {
+				result.addError(err)
+				continue
+			}
+			if info.IsDir() {
+				// symbolic links to directories are not followed
+				continue
+			}
+		}
+
+		result.Lock()
+		result.files++
+		for name, pattern := range c.config.Patterns {
+			if matched, _ := filepath.Match(pattern, entry.Name()); matched {
+				result.patternFiles[name]++
+			}
+		}
+		if info != nil {
+			result.bytes += info.Size()
+			modTime := info.ModTime()
+			if result.oldest.IsZero() || modTime.Before(result.oldest) {
+				result.oldest = modTime
+			}
+			if modTime.After(result.newest) {
+				result.newest = modTime
+			}
+			if c.config.FileGauges {
+				result.addFileGauge(fileGauge{path: entryPath, size: info.Size(), modTime: modTime}, c.config.MaxFileGaugeCount)
+			}
+		}
+		result.Unlock()
+	}
+	return subdirs
+}
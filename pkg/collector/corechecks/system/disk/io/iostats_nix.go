@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/system/pressure"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -26,6 +27,7 @@ import (
 var (
 	ioCounters = disk.IOCounters
 	swapMemory = mem.SwapMemory
+	procfsPath = pressure.ProcfsPath
 
 	// for test purpose
 	nowNano = func() int64 { return time.Now().UnixNano() }
@@ -185,6 +187,10 @@ func (c *IOCheck) nixIO() error {
 		log.Errorf("system.IOCheck: could not retrieve I/O block stats: %s", errSwap)
 	}
 
+	if err := pressure.Submit(sender, procfsPath(), pressure.IO, "system.io"); err != nil {
+		log.Debugf("system.IOCheck: could not retrieve io pressure stall information: %s", err)
+	}
+
 	c.stats = iomap
 	c.ts = now
 	return nil
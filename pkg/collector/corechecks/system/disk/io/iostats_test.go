@@ -136,6 +136,7 @@ func TestIOCheck(t *testing.T) {
 
 	ioCounters = ioSampler
 	swapMemory = SwapMemory
+	procfsPath = t.TempDir
 	ioCheck := new(IOCheck)
 	mock := mocksender.NewMockSender(ioCheck.ID())
 	ioCheck.Configure(mock.GetSenderManager(), integration.FakeConfigHash, nil, nil, "test")
@@ -203,6 +204,7 @@ func TestIOCheck(t *testing.T) {
 func TestIOCheckBlacklist(t *testing.T) {
 	ioCounters = ioSampler
 	swapMemory = SwapMemory
+	procfsPath = t.TempDir
 	ioCheck := new(IOCheck)
 	mock := mocksender.NewMockSender(ioCheck.ID())
 	ioCheck.Configure(mock.GetSenderManager(), integration.FakeConfigHash, nil, nil, "test")
@@ -13,6 +13,8 @@ import (
 
 	"github.com/shirou/gopsutil/v4/mem"
 
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/system/pressure"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
@@ -22,6 +24,7 @@ import (
 var virtualMemory = mem.VirtualMemory
 var swapMemory = mem.SwapMemory
 var runtimeOS = runtime.GOOS
+var procfsPath = pressure.ProcfsPath
 
 // Check doesn't need additional fields
 type Check struct {
@@ -77,6 +80,10 @@ func (c *Check) Run() error {
 		return fmt.Errorf("failed to gather any memory information")
 	}
 
+	if runtimeOS == "linux" {
+		linuxSpecificSaturationCheck(sender)
+	}
+
 	sender.Commit()
 	return nil
 }
@@ -99,6 +106,18 @@ func (c *Check) linuxSpecificVirtualMemoryCheck(v *mem.VirtualMemoryStat) error
 	return nil
 }
 
+// linuxSpecificSaturationCheck submits the memory pressure stall information and the counters of /proc/vmstat. They
+// are not available on all kernels, so the errors are not reported.
+func linuxSpecificSaturationCheck(sender sender.Sender) {
+	path := procfsPath()
+	if err := pressure.Submit(sender, path, pressure.Memory, "system.mem"); err != nil {
+		log.Debugf("memory.Check: could not retrieve memory pressure stall information: %s", err)
+	}
+	if err := submitVMStat(sender, path); err != nil {
+		log.Debugf("memory.Check: could not retrieve vmstat counters: %s", err)
+	}
+}
+
 func (c *Check) freebsdSpecificVirtualMemoryCheck(v *mem.VirtualMemoryStat) error {
 	sender, err := c.GetSender()
 	if err != nil {
@@ -64,6 +64,7 @@ func TestMemoryCheckLinux(t *testing.T) {
 	mock := mocksender.NewMockSender(memCheck.ID())
 
 	runtimeOS = "linux"
+	procfsPath = t.TempDir
 
 	mock.On("Gauge", "system.mem.free", 11554304000.0/mbSize, "", []string(nil)).Return().Times(1)
 	mock.On("Gauge", "system.mem.usable", 234567890.0/mbSize, "", []string(nil)).Return().Times(1)
@@ -171,6 +172,7 @@ func TestMemoryError(t *testing.T) {
 	mock := mocksender.NewMockSender(memCheck.ID())
 
 	runtimeOS = "linux"
+	procfsPath = t.TempDir
 	mock.On("FinalizeCheckServiceTag").Return().Times(1)
 	memCheck.Configure(mock.GetSenderManager(), 0, nil, nil, "")
 	err := memCheck.Run()
@@ -189,6 +191,7 @@ func TestSwapMemoryError(t *testing.T) {
 	mock := mocksender.NewMockSender(memCheck.ID())
 
 	runtimeOS = "linux"
+	procfsPath = t.TempDir
 
 	mock.On("Gauge", "system.mem.total", 12345667890.0/mbSize, "", []string(nil)).Return().Times(1)
 	mock.On("Gauge", "system.mem.free", 11554304000.0/mbSize, "", []string(nil)).Return().Times(1)
@@ -224,6 +227,7 @@ func TestVirtualMemoryError(t *testing.T) {
 	mock := mocksender.NewMockSender(memCheck.ID())
 
 	runtimeOS = "linux"
+	procfsPath = t.TempDir
 
 	mock.On("Gauge", "system.swap.total", 100000.0/mbSize, "", []string(nil)).Return().Times(1)
 	mock.On("Gauge", "system.swap.free", 60000.0/mbSize, "", []string(nil)).Return().Times(1)
@@ -243,3 +247,32 @@ func TestVirtualMemoryError(t *testing.T) {
 	mock.AssertNumberOfCalls(t, "Rate", 2)
 	mock.AssertNumberOfCalls(t, "Commit", 1)
 }
+
+func TestMemoryCheckLinuxSaturation(t *testing.T) {
+	virtualMemory = VirtualMemory
+	swapMemory = SwapMemory
+	memCheck := new(Check)
+
+	mock := mocksender.NewMockSender(memCheck.ID())
+	mock.SetupAcceptAll()
+
+	runtimeOS = "linux"
+	procfsPath = func() string { return "../testfiles/procfs" }
+	memCheck.Configure(mock.GetSenderManager(), 0, nil, nil, "")
+	err := memCheck.Run()
+	require.Nil(t, err)
+
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.page_faults", 1341848, "", nil)
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.page_major_faults", 232, "", nil)
+	mock.AssertMetric(t, "MonotonicCount", "system.swap.pages_in", 120, "", nil)
+	mock.AssertMetric(t, "MonotonicCount", "system.swap.pages_out", 340, "", nil)
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.oom_kills", 2, "", nil)
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.compaction.stalls", 15, "", nil)
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.compaction.failures", 4, "", nil)
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.compaction.successes", 11, "", nil)
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.pages_scanned", 5000, "", []string{"reclaimer:kswapd"})
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.pages_scanned", 800, "", []string{"reclaimer:direct"})
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.pages_reclaimed", 4000, "", []string{"reclaimer:kswapd"})
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.pages_reclaimed", 500, "", []string{"reclaimer:direct"})
+	mock.AssertMetric(t, "MonotonicCount", "system.mem.alloc_stalls", 10, "", nil)
+}
@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !windows
+
+package memory
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+)
+
+// vmstatCounter is a counter of /proc/vmstat submitted as a monotonic count
+type vmstatCounter struct {
+	name string
+	tags []string
+}
+
+// vmstatCounters map the counters of /proc/vmstat to metrics. The allocation stalls are reported per zone since
+// Linux 4.8 and summed in a single metric.
+var vmstatCounters = map[string]vmstatCounter{
+	"pgfault":            {"system.mem.page_faults", nil},
+	"pgmajfault":         {"system.mem.page_major_faults", nil},
+	"pswpin":             {"system.swap.pages_in", nil},
+	"pswpout":            {"system.swap.pages_out", nil},
+	"oom_kill":           {"system.mem.oom_kills", nil},
+	"compact_stall":      {"system.mem.compaction.stalls", nil},
+	"compact_fail":       {"system.mem.compaction.failures", nil},
+	"compact_success":    {"system.mem.compaction.successes", nil},
+	"pgscan_kswapd":      {"system.mem.pages_scanned", []string{"reclaimer:kswapd"}},
+	"pgscan_direct":      {"system.mem.pages_scanned", []string{"reclaimer:direct"}},
+	"pgsteal_kswapd":     {"system.mem.pages_reclaimed", []string{"reclaimer:kswapd"}},
+	"pgsteal_direct":     {"system.mem.pages_reclaimed", []string{"reclaimer:direct"}},
+	"allocstall":         {"system.mem.alloc_stalls", nil},
+	"allocstall_dma":     {"system.mem.alloc_stalls", nil},
+	"allocstall_dma32":   {"system.mem.alloc_stalls", nil},
+	"allocstall_normal":  {"system.mem.alloc_stalls", nil},
+	"allocstall_movable": {"system.mem.alloc_stalls", nil},
+	"allocstall_device":  {"system.mem.alloc_stalls", nil},
+}
+
+// readVMStat returns the counters of <procfsPath>/vmstat
+func readVMStat(procfsPath string) (map[string]uint64, error) {
+	path := filepath.Join(procfsPath, "vmstat")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	counters := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for i := 0; scanner.Scan(); i++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s in '%s' at line %d", err, path, i)
+		}
+		counters[fields[0]] = value
+	}
+	return counters, scanner.Err()
+}
+
+// submitVMStat submits the page fault, swap, OOM kill, compaction and reclaim counters of /proc/vmstat
+func submitVMStat(sender sender.Sender, procfsPath string) error {
+	counters, err := readVMStat(procfsPath)
+	if err != nil {
+		return err
+	}
+
+	type metricKey struct {
+		name string
+		tags string
+	}
+	values := make(map[metricKey]float64)
+	tags := make(map[metricKey][]string)
+	for counter, value := range counters {
+		m, ok := vmstatCounters[counter]
+		if !ok {
+			continue
+		}
+		key := metricKey{m.name, strings.Join(m.tags, ",")}
+		values[key] += float64(value)
+		tags[key] = m.tags
+	}
+	for key, value := range values {
+		sender.MonotonicCount(key.name, value, "", tags[key])
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package pressure submits the host-wide pressure stall information (PSI) of /proc/pressure, which the cpu, memory
+// and io checks report to alert on saturation rather than utilization.
+package pressure
+
+import (
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+)
+
+// Resources with pressure stall information
+const (
+	CPU    = "cpu"
+	Memory = "memory"
+	IO     = "io"
+)
+
+// ProcfsPath returns the path of procfs, which differs from /proc when the agent runs in a container
+func ProcfsPath() string {
+	if pkgconfigsetup.Datadog().IsSet("procfs_path") {
+		return pkgconfigsetup.Datadog().GetString("procfs_path")
+	}
+	return "/proc"
+}
@@ -0,0 +1,40 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package pressure
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
+)
+
+// Submit reads <procfsPath>/pressure/<resource> and submits the stall averages as gauges, in percent, and the total
+// stall times as rates, converted from microseconds to nanoseconds like the container stall metrics. The full metrics
+// are only submitted when the kernel reports them, which is not the case of the cpu before Linux 5.13.
+func Submit(sender sender.Sender, procfsPath, resource, prefix string) error {
+	var some, full cgroups.PSIStats
+	if err := cgroups.ParsePSIFile(filepath.Join(procfsPath, "pressure", resource), &some, &full); err != nil {
+		return err
+	}
+	submitStats(sender, prefix+".pressure.some", prefix+".partial_stall", &some)
+	submitStats(sender, prefix+".pressure.full", prefix+".full_stall", &full)
+	return nil
+}
+
+func submitStats(sender sender.Sender, avgPrefix, stallName string, stats *cgroups.PSIStats) {
+	for suffix, avg := range map[string]*float64{".avg10": stats.Avg10, ".avg60": stats.Avg60, ".avg300": stats.Avg300} {
+		if avg != nil {
+			sender.Gauge(avgPrefix+suffix, *avg, "", nil)
+		}
+	}
+	if stats.Total != nil {
+		sender.Rate(stallName, float64(*stats.Total)*float64(time.Microsecond), "", nil)
+	}
+}
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package pressure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+)
+
+const testProcfsPath = "../testfiles/procfs"
+
+func TestSubmitCPU(t *testing.T) {
+	mock := mocksender.NewMockSender("pressure")
+	mock.SetupAcceptAll()
+
+	require.NoError(t, Submit(mock, testProcfsPath, CPU, "system.cpu"))
+
+	mock.AssertMetric(t, "Gauge", "system.cpu.pressure.some.avg10", 1.5, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.cpu.pressure.some.avg60", 0.75, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.cpu.pressure.some.avg300", 0.25, "", nil)
+	mock.AssertMetric(t, "Rate", "system.cpu.partial_stall", 123456789000, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.cpu.pressure.full.avg10", 0, "", nil)
+	mock.AssertMetric(t, "Rate", "system.cpu.full_stall", 0, "", nil)
+	mock.AssertNumberOfCalls(t, "Gauge", 6)
+	mock.AssertNumberOfCalls(t, "Rate", 2)
+}
+
+func TestSubmitIO(t *testing.T) {
+	mock := mocksender.NewMockSender("pressure")
+	mock.SetupAcceptAll()
+
+	require.NoError(t, Submit(mock, testProcfsPath, IO, "system.io"))
+
+	mock.AssertMetric(t, "Gauge", "system.io.pressure.some.avg10", 10.25, "", nil)
+	mock.AssertMetric(t, "Gauge", "system.io.pressure.full.avg300", 2.3, "", nil)
+	mock.AssertMetric(t, "Rate", "system.io.partial_stall", 987654321000, "", nil)
+	mock.AssertMetric(t, "Rate", "system.io.full_stall", 456789012000, "", nil)
+}
+
+func TestSubmitMissingFile(t *testing.T) {
+	mock := mocksender.NewMockSender("pressure")
+	mock.SetupAcceptAll()
+
+	assert.Error(t, Submit(mock, t.TempDir(), Memory, "system.mem"))
+	mock.AssertNumberOfCalls(t, "Gauge", 0)
+}
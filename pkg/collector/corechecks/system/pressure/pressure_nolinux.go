@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !linux
+
+package pressure
+
+import (
+	"errors"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+)
+
+// Submit is only supported on Linux
+func Submit(_ sender.Sender, _, _, _ string) error {
+	return errors.New("pressure stall information is only available on Linux")
+}
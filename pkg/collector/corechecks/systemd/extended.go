@@ -0,0 +1,255 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build systemd
+
+package systemd
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-systemd/v22/dbus"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
+	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	unitFailedState = "failed"
+
+	// cgroupCacheValidity is how long the list of cgroups is reused by the instances of the check
+	cgroupCacheValidity = 5 * time.Second
+)
+
+// unitState is the state of a unit with extended metrics, kept between runs
+type unitState struct {
+	activeState string
+	// nRestarts is the number of automatic restarts of a service, when hasNRestarts is set
+	nRestarts    uint64
+	hasNRestarts bool
+}
+
+// hasExtendedMetrics returns true if a unit matches a pattern of `extended_metrics`
+func (c *SystemdCheck) hasExtendedMetrics(unitName string) bool {
+	for _, pattern := range c.config.instance.ExtendedMetrics {
+		if matched, _ := path.Match(pattern, unitName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// submitExtendedMetrics submits the opt-in metrics and events of a unit, given the properties of the unit and of its
+// type which were already retrieved, or nil
+func (c *SystemdCheck) submitExtendedMetrics(sender sender.Sender, conn *dbus.Conn, unit dbus.UnitStatus, unitProperties map[string]interface{}, typeProperties map[string]interface{}, tags []string) {
+	if unitProperties != nil {
+		if controlGroup, err := getPropertyString(unitProperties, "ControlGroup"); err == nil && controlGroup != "" {
+			c.submitCgroupMetrics(sender, unit.Name, controlGroup, tags)
+		}
+	}
+	c.submitTransitions(sender, unit, typeProperties, tags)
+
+	switch {
+	case strings.HasSuffix(unit.Name, "."+typeService):
+		c.submitExitStatus(sender, unit, typeProperties, tags)
+	case strings.HasSuffix(unit.Name, "."+typeTimer):
+		c.submitTimerMetrics(sender, conn, unit, tags)
+	}
+}
+
+// submitTransitions compares the state of a unit to the previous run: the automatic restarts of a service since the
+// previous run are counted from its `NRestarts` property, and entering the failed state submits an event
+func (c *SystemdCheck) submitTransitions(sender sender.Sender, unit dbus.UnitStatus, typeProperties map[string]interface{}, tags []string) {
+	if c.unitStates == nil {
+		c.unitStates = make(map[string]unitState)
+	}
+	previous, found := c.unitStates[unit.Name]
+	state := unitState{activeState: unit.ActiveState}
+
+	// only present for the services from systemd v235
+	if nRestarts, err := getPropertyUint64(typeProperties, "NRestarts"); err == nil {
+		state.nRestarts, state.hasNRestarts = nRestarts, true
+		if found && previous.hasNRestarts {
+			restarts := nRestarts
+			if nRestarts >= previous.nRestarts {
+				restarts -= previous.nRestarts
+			} // otherwise the counter was reset since the previous run
+			sender.Count("systemd.unit.restarts", float64(restarts), "", tags)
+		}
+	}
+	c.unitStates[unit.Name] = state
+
+	if found && previous.activeState != unitFailedState && unit.ActiveState == unitFailedState {
+		sender.Event(event.Event{
+			Title:          fmt.Sprintf("systemd unit %s failed", unit.Name),
+			Text:           fmt.Sprintf("The unit %s went from the %s state to the failed state (%s).", unit.Name, previous.activeState, unit.SubState),
+			Ts:             c.stats.UnixNow(),
+			Priority:       event.PriorityNormal,
+			AlertType:      event.AlertTypeError,
+			SourceTypeName: CheckName,
+			EventType:      CheckName,
+			AggregationKey: unit.Name,
+			Tags:           tags,
+		})
+	}
+}
+
+// submitCgroupMetrics submits the resources used by the cgroup of a unit
+func (c *SystemdCheck) submitCgroupMetrics(sender sender.Sender, unitName string, controlGroup string, tags []string) {
+	stats, err := c.stats.GetUnitCgroupStats(controlGroup)
+	if err != nil {
+		log.Debugf("Unable to get the cgroup stats of unit %s: %v", unitName, err)
+		return
+	}
+
+	if stats.CPU != nil {
+		submitRate(sender, "systemd.unit.cpu.usage", stats.CPU.Total, tags)
+		submitRate(sender, "systemd.unit.cpu.user", stats.CPU.User, tags)
+		submitRate(sender, "systemd.unit.cpu.system", stats.CPU.System, tags)
+	}
+	if stats.Memory != nil {
+		submitGauge(sender, "systemd.unit.memory.usage", stats.Memory.UsageTotal, tags)
+		submitGauge(sender, "systemd.unit.memory.rss", stats.Memory.RSS, tags)
+		submitGauge(sender, "systemd.unit.memory.cache", stats.Memory.Cache, tags)
+	}
+	if stats.IO != nil {
+		submitRate(sender, "systemd.unit.io.read_bytes", stats.IO.ReadBytes, tags)
+		submitRate(sender, "systemd.unit.io.write_bytes", stats.IO.WriteBytes, tags)
+		submitRate(sender, "systemd.unit.io.read_operations", stats.IO.ReadOperations, tags)
+		submitRate(sender, "systemd.unit.io.write_operations", stats.IO.WriteOperations, tags)
+	}
+	if stats.PID != nil {
+		submitGauge(sender, "systemd.unit.tasks", stats.PID.HierarchicalThreadCount, tags)
+	}
+}
+
+// submitExitStatus submits the exit status of the last run of the main process of a service, given its service
+// properties
+func (c *SystemdCheck) submitExitStatus(sender sender.Sender, unit dbus.UnitStatus, serviceProperties map[string]interface{}, tags []string) {
+	if serviceProperties == nil {
+		return
+	}
+	// ExecMainCode is 0 until the main process of the service exits
+	code, err := getPropertyInt64(serviceProperties, "ExecMainCode")
+	if err != nil || code == 0 {
+		return
+	}
+	status, err := getPropertyInt64(serviceProperties, "ExecMainStatus")
+	if err != nil {
+		log.Debugf("Error getting the exit status of unit %s: %v", unit.Name, err)
+		return
+	}
+	statusTags := tags
+	if result, err := getPropertyString(serviceProperties, "Result"); err == nil && result != "" {
+		statusTags = append(append([]string{}, tags...), "result:"+result)
+	}
+	sender.Gauge("systemd.service.last_exit_status", float64(status), "", statusTags)
+}
+
+// submitTimerMetrics submits when a timer triggers next and when it last triggered, in seconds
+func (c *SystemdCheck) submitTimerMetrics(sender sender.Sender, conn *dbus.Conn, unit dbus.UnitStatus, tags []string) {
+	timerProperties, err := c.stats.GetUnitTypeProperties(conn, unit.Name, dbusTypeMap[typeTimer])
+	if err != nil {
+		log.Debugf("Error getting timer properties for unit %s: %v", unit.Name, err)
+		return
+	}
+	now := c.stats.UnixNow()
+
+	// the timestamps are in microseconds, 0 or MaxUint64 when not set
+	if next, err := getPropertyUint64(timerProperties, "NextElapseUSecRealtime"); err == nil && next != 0 && next != math.MaxUint64 {
+		sender.Gauge("systemd.timer.next_trigger_in", float64(int64(next/1000000)-now), "", tags)
+	}
+	if last, err := getPropertyUint64(timerProperties, "LastTriggerUSec"); err == nil && last != 0 && last != math.MaxUint64 {
+		sender.Gauge("systemd.timer.last_trigger_age", float64(now-int64(last/1000000)), "", tags)
+	}
+}
+
+func submitGauge(sender sender.Sender, metricName string, value *uint64, tags []string) {
+	if value != nil {
+		sender.Gauge(metricName, float64(*value), "", tags)
+	}
+}
+
+func submitRate(sender sender.Sender, metricName string, value *uint64, tags []string) {
+	if value != nil {
+		sender.Rate(metricName, float64(*value), "", tags)
+	}
+}
+
+func getPropertyInt64(properties map[string]interface{}, propertyName string) (int64, error) {
+	prop, ok := properties[propertyName]
+	if !ok {
+		return 0, fmt.Errorf("property %s not found", propertyName)
+	}
+	switch typedProp := prop.(type) {
+	case int:
+		return int64(typedProp), nil
+	case int32:
+		return int64(typedProp), nil
+	case int64:
+		return typedProp, nil
+	}
+	return 0, fmt.Errorf("property %s (%T) cannot be converted to int64", propertyName, prop)
+}
+
+// newUnitCgroupReader returns a reader of the cgroups of the units of the system manager, identified by their name
+func newUnitCgroupReader() (*cgroups.Reader, error) {
+	procPath := pkgconfigsetup.Datadog().GetString("container_proc_root")
+	hostPrefix := ""
+	if strings.HasPrefix(procPath, "/host") {
+		hostPrefix = "/host"
+	}
+	return cgroups.NewReader(
+		cgroups.WithProcPath(procPath),
+		cgroups.WithHostPrefix(hostPrefix),
+		cgroups.WithReaderFilter(unitCgroupFilter),
+	)
+}
+
+// unitCgroupFilter selects the cgroups of the units of the system manager. Their parents are all slices, while the
+// units of the user managers and of the containers are nested in a service or a scope.
+func unitCgroupFilter(fullPath, name string) (string, error) {
+	if !isUnitName(name) {
+		return "", nil
+	}
+	for _, parent := range strings.Split(path.Dir(fullPath), "/") {
+		if strings.HasSuffix(parent, ".service") || strings.HasSuffix(parent, ".scope") {
+			return "", nil
+		}
+	}
+	return name, nil
+}
+
+func isUnitName(name string) bool {
+	for _, suffix := range []string{".service", ".scope", ".slice", ".socket", ".mount", ".swap"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// readUnitCgroupStats returns the stats of the cgroup of a unit, given its `ControlGroup` property
+func readUnitCgroupStats(reader *cgroups.Reader, controlGroup string) (*cgroups.Stats, error) {
+	if err := reader.RefreshCgroups(cgroupCacheValidity); err != nil {
+		return nil, err
+	}
+	cgroup := reader.GetCgroup(path.Base(controlGroup))
+	if cgroup == nil {
+		return nil, fmt.Errorf("cgroup %s not found", controlGroup)
+	}
+	stats := &cgroups.Stats{}
+	if allFailed, errs := cgroups.GetStats(cgroup, stats); allFailed {
+		return nil, fmt.Errorf("unable to read cgroup %s: %v", controlGroup, errs)
+	}
+	return stats, nil
+}
@@ -11,7 +11,9 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/coreos/go-systemd/v22/dbus"
@@ -23,6 +25,7 @@ import (
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
 	"github.com/DataDog/datadog-agent/pkg/config/env"
 	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/option"
 )
@@ -37,6 +40,7 @@ const (
 	typeUnit    = "unit"
 	typeService = "service"
 	typeSocket  = "socket"
+	typeTimer   = "timer"
 
 	canConnectServiceCheck   = "systemd.can_connect"
 	systemStateServiceCheck  = "systemd.system.state"
@@ -48,6 +52,7 @@ var dbusTypeMap = map[string]string{
 	typeUnit:    "Unit",
 	typeService: "Service",
 	typeSocket:  "Socket",
+	typeTimer:   "Timer",
 }
 
 // metricConfigItem map a metric to a systemd unit property.
@@ -142,6 +147,9 @@ type SystemdCheck struct {
 	core.CheckBase
 	stats  systemdStats
 	config systemdConfig
+
+	// unitStates are the states of the units with extended metrics at the previous run
+	unitStates map[string]unitState
 }
 type unitSubstateMapping = map[string]string
 
@@ -149,6 +157,9 @@ type systemdInstanceConfig struct {
 	PrivateSocket         string                         `yaml:"private_socket"`
 	UnitNames             []string                       `yaml:"unit_names"`
 	SubstateStatusMapping map[string]unitSubstateMapping `yaml:"substate_status_mapping"`
+	// ExtendedMetrics are glob patterns matching the monitored units whose cgroup resources, restarts, last exit
+	// status and timer triggers are reported, and whose failures submit an event
+	ExtendedMetrics []string `yaml:"extended_metrics"`
 }
 
 type systemdInitConfig struct{}
@@ -169,12 +180,17 @@ type systemdStats interface {
 	ListUnits(c *dbus.Conn) ([]dbus.UnitStatus, error)
 	GetUnitTypeProperties(c *dbus.Conn, unitName string, unitType string) (map[string]interface{}, error)
 	GetVersion(c *dbus.Conn) (string, error)
+	GetUnitCgroupStats(controlGroup string) (*cgroups.Stats, error)
 
 	// Misc
 	UnixNow() int64
 }
 
-type defaultSystemdStats struct{}
+type defaultSystemdStats struct {
+	cgroupReaderOnce sync.Once
+	cgroupReader     *cgroups.Reader
+	cgroupReaderErr  error
+}
 
 func (s *defaultSystemdStats) PrivateSocketConnection(privateSocket string) (*dbus.Conn, error) {
 	return NewSystemdConnection(privateSocket)
@@ -204,6 +220,16 @@ func (s *defaultSystemdStats) GetVersion(c *dbus.Conn) (string, error) {
 	return c.GetManagerProperty("Version")
 }
 
+func (s *defaultSystemdStats) GetUnitCgroupStats(controlGroup string) (*cgroups.Stats, error) {
+	s.cgroupReaderOnce.Do(func() {
+		s.cgroupReader, s.cgroupReaderErr = newUnitCgroupReader()
+	})
+	if s.cgroupReaderErr != nil {
+		return nil, s.cgroupReaderErr
+	}
+	return readUnitCgroupStats(s.cgroupReader, controlGroup)
+}
+
 func (s *defaultSystemdStats) UnixNow() int64 {
 	return time.Now().Unix()
 }
@@ -322,6 +348,7 @@ func (c *SystemdCheck) submitMetrics(sender sender.Sender, conn *dbus.Conn) erro
 
 	loadedCount := 0
 	monitoredCount := 0
+	extendedUnits := make(map[string]struct{})
 	for _, unit := range units {
 		if unit.LoadState == unitLoadedState {
 			loadedCount++
@@ -342,8 +369,18 @@ func (c *SystemdCheck) submitMetrics(sender sender.Sender, conn *dbus.Conn) erro
 			sender.ServiceCheck(unitSubStateServiceCheck, getServiceCheckStatus(unit.SubState, subStateMapping), "", tags, "")
 		}
 
-		c.submitBasicUnitMetrics(sender, conn, unit, tags)
-		c.submitPropertyMetricsAsGauge(sender, conn, unit, tags)
+		unitProperties := c.submitBasicUnitMetrics(sender, conn, unit, tags)
+		typeProperties := c.submitPropertyMetricsAsGauge(sender, conn, unit, tags)
+		if c.hasExtendedMetrics(unit.Name) {
+			extendedUnits[unit.Name] = struct{}{}
+			c.submitExtendedMetrics(sender, conn, unit, unitProperties, typeProperties, tags)
+		}
+	}
+	// forget the units which are gone
+	for unitName := range c.unitStates {
+		if _, found := extendedUnits[unitName]; !found {
+			delete(c.unitStates, unitName)
+		}
 	}
 
 	sender.Gauge("systemd.units_total", float64(len(units)), "", nil)
@@ -352,7 +389,9 @@ func (c *SystemdCheck) submitMetrics(sender sender.Sender, conn *dbus.Conn) erro
 	return nil
 }
 
-func (c *SystemdCheck) submitBasicUnitMetrics(sender sender.Sender, conn *dbus.Conn, unit dbus.UnitStatus, tags []string) {
+// submitBasicUnitMetrics submits the state and the uptime of a unit, and returns its properties or nil if they could
+// not be retrieved
+func (c *SystemdCheck) submitBasicUnitMetrics(sender sender.Sender, conn *dbus.Conn, unit dbus.UnitStatus, tags []string) map[string]interface{} {
 	active := 0
 	if unit.ActiveState == unitActiveState {
 		active = 1
@@ -368,14 +407,15 @@ func (c *SystemdCheck) submitBasicUnitMetrics(sender sender.Sender, conn *dbus.C
 	unitProperties, err := c.stats.GetUnitTypeProperties(conn, unit.Name, dbusTypeMap[typeUnit])
 	if err != nil {
 		log.Warnf("Error getting unit unitProperties: %s: %v", unit.Name, err)
-		return
+		return nil
 	}
 	activeEnterTimestamp, err := getPropertyUint64(unitProperties, "ActiveEnterTimestamp")
 	if err != nil {
 		log.Warnf("Error getting property ActiveEnterTimestamp: %v", err)
-		return
+		return unitProperties
 	}
 	sender.Gauge("systemd.unit.uptime", float64(computeUptime(unit.ActiveState, activeEnterTimestamp, c.stats.UnixNow())), "", tags)
+	return unitProperties
 }
 
 func (c *SystemdCheck) submitCountMetrics(sender sender.Sender, units []dbus.UnitStatus) {
@@ -395,7 +435,9 @@ func (c *SystemdCheck) submitCountMetrics(sender sender.Sender, units []dbus.Uni
 	}
 }
 
-func (c *SystemdCheck) submitPropertyMetricsAsGauge(sender sender.Sender, conn *dbus.Conn, unit dbus.UnitStatus, tags []string) {
+// submitPropertyMetricsAsGauge submits the metrics of the properties of the unit type, and returns these properties
+// or nil if the unit type has no metrics or they could not be retrieved
+func (c *SystemdCheck) submitPropertyMetricsAsGauge(sender sender.Sender, conn *dbus.Conn, unit dbus.UnitStatus, tags []string) map[string]interface{} {
 	for unitType := range metricConfigs {
 		if !strings.HasSuffix(unit.Name, "."+unitType) {
 			continue
@@ -403,7 +445,7 @@ func (c *SystemdCheck) submitPropertyMetricsAsGauge(sender sender.Sender, conn *
 		serviceProperties, err := c.stats.GetUnitTypeProperties(conn, unit.Name, dbusTypeMap[unitType])
 		if err != nil {
 			log.Warnf("Error getting detailed properties for unit %s", unit.Name)
-			return
+			return nil
 		}
 		for _, service := range metricConfigs[unitType] {
 			err := sendServicePropertyAsGauge(sender, serviceProperties, service, tags)
@@ -416,7 +458,9 @@ func (c *SystemdCheck) submitPropertyMetricsAsGauge(sender sender.Sender, conn *
 				}
 			}
 		}
+		return serviceProperties
 	}
+	return nil
 }
 
 func sendServicePropertyAsGauge(sender sender.Sender, properties map[string]interface{}, service metricConfigItem, tags []string) error {
@@ -556,6 +600,12 @@ func (c *SystemdCheck) Configure(senderManager sender.SenderManager, integration
 		}
 	}
 
+	for _, pattern := range c.config.instance.ExtendedMetrics {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s' in 'extended_metrics': %v", pattern, err)
+		}
+	}
+
 	for unitName, unitMapping := range c.config.instance.SubstateStatusMapping {
 		for _, serviceCheckStatus := range unitMapping {
 			if !isValidServiceCheckStatus(serviceCheckStatus) {
@@ -10,6 +10,7 @@ package systemd
 import (
 	"fmt"
 	"math"
+	"path"
 	"testing"
 	"time"
 
@@ -27,7 +28,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	checkid "github.com/DataDog/datadog-agent/pkg/collector/check/id"
 	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	"github.com/DataDog/datadog-agent/pkg/metrics/event"
 	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
+	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
+	"github.com/DataDog/datadog-agent/pkg/util/pointer"
 )
 
 const systemdVersion = "241"
@@ -83,6 +87,11 @@ func (s *mockSystemdStats) GetUnitTypeProperties(conn *dbus.Conn, unitName strin
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
+func (s *mockSystemdStats) GetUnitCgroupStats(controlGroup string) (*cgroups.Stats, error) {
+	args := s.Mock.Called(controlGroup)
+	return args.Get(0).(*cgroups.Stats), args.Error(1)
+}
+
 func getCreatePropertieWithDefaults(props map[string]interface{}) map[string]interface{} {
 	defaultProps := map[string]interface{}{
 		"CPUAccounting":    true,
@@ -1113,3 +1122,139 @@ unit_names:
 	assert.Equal(t, checkid.ID("systemd:b1fb7cdd591e17a1"), check2.ID())
 	assert.NotEqual(t, check1.ID(), check2.ID())
 }
+
+func TestInvalidExtendedMetricsPattern(t *testing.T) {
+	check := SystemdCheck{}
+	rawInstanceConfig := []byte(`
+unit_names:
+- foo.service
+extended_metrics:
+- "[foo"
+`)
+	err := check.Configure(aggregator.NewNoOpSenderManager(), integration.FakeConfigHash, rawInstanceConfig, []byte(``), "test")
+
+	assert.ErrorContains(t, err, "invalid pattern '[foo' in 'extended_metrics'")
+}
+
+func TestExtendedMetrics(t *testing.T) {
+	rawInstanceConfig := []byte(`
+unit_names:
+ - app.service
+ - backup.timer
+ - other.service
+extended_metrics:
+ - "app*.service"
+ - "*.timer"
+`)
+	units := []dbus.UnitStatus{
+		{Name: "app.service", ActiveState: "active", SubState: "running", LoadState: "loaded"},
+		{Name: "backup.timer", ActiveState: "active", SubState: "waiting", LoadState: "loaded"},
+		{Name: "other.service", ActiveState: "active", SubState: "running", LoadState: "loaded"},
+	}
+	stats := createDefaultMockSystemdStats()
+	stats.On("ListUnits", mock.Anything).Return(units, nil).Once()
+	stats.On("UnixNow").Return(int64(1000))
+	stats.On("GetVersion", mock.Anything).Return(systemdVersion)
+	stats.On("GetUnitTypeProperties", mock.Anything, "app.service", dbusTypeMap[typeUnit]).Return(map[string]interface{}{
+		"ActiveEnterTimestamp": uint64(100 * 1000 * 1000),
+		"ControlGroup":         "/system.slice/app.service",
+	}, nil)
+	for _, unitName := range []string{"backup.timer", "other.service"} {
+		stats.On("GetUnitTypeProperties", mock.Anything, unitName, dbusTypeMap[typeUnit]).Return(map[string]interface{}{
+			"ActiveEnterTimestamp": uint64(100 * 1000 * 1000),
+		}, nil)
+	}
+	serviceProperties := getCreatePropertieWithDefaults(map[string]interface{}{
+		"NRestarts":      uint32(2),
+		"ExecMainCode":   int32(1),
+		"ExecMainStatus": int32(3),
+		"Result":         "exit-code",
+	})
+	// the service properties are retrieved once per run
+	stats.On("GetUnitTypeProperties", mock.Anything, "app.service", dbusTypeMap[typeService]).Return(serviceProperties, nil).Once()
+	stats.On("GetUnitTypeProperties", mock.Anything, "other.service", dbusTypeMap[typeService]).Return(serviceProperties, nil)
+	stats.On("GetUnitTypeProperties", mock.Anything, "backup.timer", dbusTypeMap[typeTimer]).Return(map[string]interface{}{
+		"NextElapseUSecRealtime": uint64(1600 * 1000 * 1000),
+		"LastTriggerUSec":        uint64(400 * 1000 * 1000),
+	}, nil)
+	stats.On("GetUnitCgroupStats", "/system.slice/app.service").Return(&cgroups.Stats{
+		CPU:    &cgroups.CPUStats{Total: pointer.Ptr(uint64(3000)), User: pointer.Ptr(uint64(2000))},
+		Memory: &cgroups.MemoryStats{UsageTotal: pointer.Ptr(uint64(4096))},
+		IO:     &cgroups.IOStats{ReadBytes: pointer.Ptr(uint64(512))},
+		PID:    &cgroups.PIDStats{HierarchicalThreadCount: pointer.Ptr(uint64(12))},
+	}, nil)
+
+	check := SystemdCheck{stats: stats}
+	senderManager := mocksender.CreateDefaultDemultiplexer()
+	require.NoError(t, check.Configure(senderManager, integration.FakeConfigHash, rawInstanceConfig, nil, "test"))
+
+	mockSender := mocksender.NewMockSenderWithSenderManager(check.ID(), senderManager)
+	mockSender.SetupAcceptAll()
+
+	require.NoError(t, check.Run())
+
+	appTags := []string{"unit:app.service"}
+	mockSender.AssertMetric(t, "Rate", "systemd.unit.cpu.usage", 3000, "", appTags)
+	mockSender.AssertMetric(t, "Rate", "systemd.unit.cpu.user", 2000, "", appTags)
+	mockSender.AssertMetric(t, "Gauge", "systemd.unit.memory.usage", 4096, "", appTags)
+	mockSender.AssertMetric(t, "Rate", "systemd.unit.io.read_bytes", 512, "", appTags)
+	mockSender.AssertMetric(t, "Gauge", "systemd.unit.tasks", 12, "", appTags)
+	mockSender.AssertNotCalled(t, "Count", "systemd.unit.restarts", mock.Anything, mock.Anything, appTags)
+	mockSender.AssertMetric(t, "Gauge", "systemd.service.last_exit_status", 3, "", []string{"unit:app.service", "result:exit-code"})
+
+	timerTags := []string{"unit:backup.timer"}
+	mockSender.AssertMetric(t, "Gauge", "systemd.timer.next_trigger_in", 600, "", timerTags)
+	mockSender.AssertMetric(t, "Gauge", "systemd.timer.last_trigger_age", 600, "", timerTags)
+
+	otherTags := []string{"unit:other.service"}
+	mockSender.AssertNotCalled(t, "Count", "systemd.unit.restarts", mock.Anything, mock.Anything, otherTags)
+	mockSender.AssertNotCalled(t, "Gauge", "systemd.service.last_exit_status", mock.Anything, mock.Anything, append(otherTags, "result:exit-code"))
+	stats.AssertNotCalled(t, "GetUnitCgroupStats", "/system.slice/other.service")
+	mockSender.AssertNotCalled(t, "Event", mock.Anything)
+
+	// the service restarted 3 times and then failed
+	units[0].ActiveState = "failed"
+	units[0].SubState = "failed"
+	stats.On("ListUnits", mock.Anything).Return(units, nil).Once()
+	stats.On("GetUnitTypeProperties", mock.Anything, "app.service", dbusTypeMap[typeService]).Return(getCreatePropertieWithDefaults(map[string]interface{}{
+		"NRestarts": uint32(5),
+	}), nil).Once()
+	mockSender.ResetCalls()
+
+	require.NoError(t, check.Run())
+
+	mockSender.AssertMetric(t, "Count", "systemd.unit.restarts", 3, "", appTags)
+	mockSender.AssertEvent(t, event.Event{
+		Title:          "systemd unit app.service failed",
+		Text:           "The unit app.service went from the active state to the failed state (failed).",
+		Ts:             1000,
+		Priority:       event.PriorityNormal,
+		AlertType:      event.AlertTypeError,
+		SourceTypeName: CheckName,
+		EventType:      CheckName,
+		AggregationKey: "app.service",
+		Tags:           appTags,
+	}, 0)
+	assert.Contains(t, check.unitStates, "app.service")
+
+	// the state of the units which are gone is forgotten
+	stats.On("ListUnits", mock.Anything).Return(units[1:], nil)
+	require.NoError(t, check.Run())
+	assert.NotContains(t, check.unitStates, "app.service")
+	assert.Contains(t, check.unitStates, "backup.timer")
+}
+
+func TestUnitCgroupFilter(t *testing.T) {
+	for fullPath, expected := range map[string]string{
+		"/sys/fs/cgroup/system.slice/nginx.service": "nginx.service",
+		"/sys/fs/cgroup/system.slice":               "system.slice",
+		"/sys/fs/cgroup/init.scope":                 "init.scope",
+		"/sys/fs/cgroup/user.slice/user-1000.slice/user@1000.service/app.slice/dbus.service": "",
+		"/sys/fs/cgroup/system.slice/docker-1234.scope/system.slice/nginx.service":           "",
+		"/sys/fs/cgroup/system.slice/nginx.service/worker":                                   "",
+	} {
+		id, err := unitCgroupFilter(fullPath, path.Base(fullPath))
+		assert.NoError(t, err)
+		assert.Equal(t, expected, id, fullPath)
+	}
+}
@@ -144,8 +144,8 @@ func testObfuscaterConfig(t *testing.T) {
 			ObfuscateSQLValues: []string{},
 		},
 		Mongo:                defaultMongoObfuscateSettings,
-		SQLExecPlan:          defaultSQLPlanObfuscateSettings,
-		SQLExecPlanNormalize: defaultSQLPlanNormalizeSettings,
+		SQLExecPlan:          obfuscate.DefaultSQLExecPlanConfig,
+		SQLExecPlanNormalize: obfuscate.DefaultSQLExecPlanNormalizeConfig,
 		HTTP: obfuscate.HTTPConfig{
 			RemoveQueryString: false,
 			RemovePathDigits:  false,
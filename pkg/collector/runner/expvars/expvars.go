@@ -30,6 +30,7 @@ const (
 	runningChecksExpvarKey = "RunningChecks"
 	runsExpvarKey          = "Runs"
 	runningExpvarKey       = "Running"
+	skippedRunsExpvarKey   = "SkippedRuns"
 	warningsExpvarKey      = "Warnings"
 )
 
@@ -85,6 +86,7 @@ func Reset() {
 		errorsExpvarKey,
 		runsExpvarKey,
 		runningChecksExpvarKey,
+		skippedRunsExpvarKey,
 		warningsExpvarKey,
 	} {
 		runnerStats.Delete(key)
@@ -230,6 +232,24 @@ func GetRunsCount() int64 {
 	return count.(*expvar.Int).Value()
 }
 
+// AddSkippedRun is used to count a run of a check skipped because it was still running or quarantined, in the
+// 'SkippedRuns' expvar and in the stats of the check
+func AddSkippedRun(id checkid.ID) {
+	runnerStats.Add(skippedRunsExpvarKey, 1)
+	if stats, found := CheckStats(id); found {
+		stats.AddSkippedRun()
+	}
+}
+
+// GetSkippedRunsCount is used to get the value of 'SkippedRuns' expvar
+func GetSkippedRunsCount() int64 {
+	count := runnerStats.Get(skippedRunsExpvarKey)
+	if count == nil {
+		return 0
+	}
+	return count.(*expvar.Int).Value()
+}
+
 // AddWarningsCount is used to increment the 'Warnings' expvar
 func AddWarningsCount(amount int) {
 	runnerStats.Add(warningsExpvarKey, int64(amount))
@@ -30,6 +30,8 @@ const (
 	stopCheckTimeout time.Duration = 500 * time.Millisecond
 	// Time to wait for all checks to stop
 	stopAllChecksTimeout time.Duration = 2 * time.Second
+	// How often the queue latency is compared to `check_runners_max_queue_latency`
+	queueLatencyPollingInterval time.Duration = 15 * time.Second
 )
 
 var (
@@ -74,9 +76,50 @@ func NewRunner(senderManager sender.SenderManager, haAgent haagent.Component) *R
 
 	r.ensureMinWorkers(numWorkers)
 
+	if maxQueueLatency := pkgconfigsetup.Datadog().GetDuration("check_runners_max_queue_latency"); !r.isStaticWorkerCount && maxQueueLatency > 0 {
+		go r.scaleWithQueueLatency(maxQueueLatency)
+	}
+
 	return r
 }
 
+// scaleWithQueueLatency periodically adds workers while the checks are picked up by the workers later than
+// `maxQueueLatency` after they are due, until the runner stops
+func (r *Runner) scaleWithQueueLatency(maxQueueLatency time.Duration) {
+	ticker := time.NewTicker(queueLatencyPollingInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !r.isRunning.Load() {
+			return
+		}
+		if sc := r.getScheduler(); sc != nil {
+			r.scaleWorkers(sc.EnqueueLatency(), maxQueueLatency)
+		}
+	}
+}
+
+// scaleWorkers adds a worker, up to `MaxNumWorkers`, if the queue latency exceeds `maxQueueLatency`. It returns true
+// if a worker was added.
+func (r *Runner) scaleWorkers(queueLatency, maxQueueLatency time.Duration) bool {
+	if queueLatency <= maxQueueLatency {
+		return false
+	}
+
+	r.workersLock.Lock()
+	numWorkers := len(r.workers)
+	r.workersLock.Unlock()
+
+	if numWorkers >= pkgconfigsetup.MaxNumWorkers {
+		log.Debugf("Runner %d: checks were picked up %v late but the runner already has %d workers", r.id, queueLatency, numWorkers)
+		return false
+	}
+
+	log.Infof("Runner %d: checks were picked up %v late, adding a worker", r.id, queueLatency)
+	r.ensureMinWorkers(numWorkers + 1)
+	return true
+}
+
 // EnsureMinWorkers increases the number of workers to match the
 // `desiredNumWorkers` parameter
 func (r *Runner) ensureMinWorkers(desiredNumWorkers int) {
@@ -136,6 +179,11 @@ func (r *Runner) newWorker() (*worker.Worker, error) {
 		defer r.removeWorker(worker.ID)
 
 		worker.Run()
+
+		// The worker gave up on a hung check, start another one in its place
+		if worker.Replaced() && r.isRunning.Load() {
+			r.AddWorker()
+		}
 	}()
 
 	return worker, nil
@@ -453,3 +453,23 @@ func TestRunnerShouldAddCheckStats(t *testing.T) {
 	// If there's a scheduler with scheduled check, add the stats
 	require.True(t, r.ShouldAddCheckStats(testCheck.ID()))
 }
+
+func TestRunnerScaleWorkers(t *testing.T) {
+	testSetUp(t)
+	pkgconfigsetup.Datadog().SetWithoutSource("check_runners", "0")
+
+	r := NewRunner(aggregator.NewNoOpSenderManager(), haagentmock.NewMockHaAgent())
+	require.NotNil(t, r)
+	defer r.Stop()
+	assertAsyncWorkerCount(t, pkgconfigsetup.DefaultNumWorkers)
+
+	assert.False(t, r.scaleWorkers(time.Second, 2*time.Second))
+	assertAsyncWorkerCount(t, pkgconfigsetup.DefaultNumWorkers)
+
+	assert.True(t, r.scaleWorkers(3*time.Second, 2*time.Second))
+	assertAsyncWorkerCount(t, pkgconfigsetup.DefaultNumWorkers+1)
+
+	r.ensureMinWorkers(pkgconfigsetup.MaxNumWorkers)
+	assert.False(t, r.scaleWorkers(3*time.Second, 2*time.Second), "the workers are capped")
+	assertAsyncWorkerCount(t, pkgconfigsetup.MaxNumWorkers)
+}
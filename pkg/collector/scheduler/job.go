@@ -8,11 +8,13 @@ package scheduler
 
 import (
 	"fmt"
+	"hash/fnv"
 	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	checkid "github.com/DataDog/datadog-agent/pkg/collector/check/id"
+	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
 	"github.com/DataDog/datadog-agent/pkg/status/health"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -36,6 +38,18 @@ func (jb *jobBucket) addJob(c check.Check) {
 	jb.jobs = append(jb.jobs, c)
 }
 
+// cost returns the sum of the average execution times of the checks of the bucket
+func (jb *jobBucket) cost() time.Duration {
+	jb.mu.RLock()
+	defer jb.mu.RUnlock()
+
+	var total time.Duration
+	for _, c := range jb.jobs {
+		total += checkCost(c.ID())
+	}
+	return total
+}
+
 // removeJob removes the check from the bucket, and returns
 // whether the check was indeed in the bucket (and therefore actually removed)
 func (jb *jobBucket) removeJob(id checkid.ID) bool {
@@ -69,18 +83,33 @@ type jobQueue struct {
 	currentBucketIdx    uint
 	schedulingBucketIdx uint
 	running             bool
+	jitter              bool         // place the checks in the bucket derived from their ID
+	rebalanceTicker     *time.Ticker // nil when the buckets are not rebalanced
 	health              *health.Handle
 	mu                  sync.RWMutex // to protect critical sections in struct's fields
 }
 
+// checkCost returns the average execution time of a check, which the buckets are rebalanced with. Overridden in tests.
+var checkCost = func(id checkid.ID) time.Duration {
+	stats, found := expvars.CheckStats(id)
+	if !found {
+		return 0
+	}
+	return stats.AverageExecutionDuration()
+}
+
 // newJobQueue creates a new jobQueue instance
-func newJobQueue(interval time.Duration) *jobQueue {
+func newJobQueue(interval time.Duration, jitter bool, rebalanceInterval time.Duration) *jobQueue {
 	jq := &jobQueue{
 		interval:     interval,
 		stop:         make(chan bool),
 		stopped:      make(chan bool),
 		health:       health.RegisterLiveness(fmt.Sprintf("collector-queue-%vs", interval.Seconds())),
 		bucketTicker: time.NewTicker(time.Second),
+		jitter:       jitter,
+	}
+	if rebalanceInterval > 0 {
+		jq.rebalanceTicker = time.NewTicker(rebalanceInterval)
 	}
 
 	var nb int
@@ -120,11 +149,83 @@ func (jq *jobQueue) addJob(c check.Check) {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
+	if jq.jitter {
+		// The bucket derived from the ID of the check is stable across restarts and spreads the checks uniformly
+		// over the interval, whatever the order they are scheduled in
+		jq.buckets[jitterBucketIdx(c.ID(), len(jq.buckets))].addJob(c)
+		return
+	}
+
 	// Checks scheduled to buckets scheduled with sparse round-robin
 	jq.buckets[jq.schedulingBucketIdx].addJob(c)
 	jq.schedulingBucketIdx = (jq.schedulingBucketIdx + jq.sparseStep) % uint(len(jq.buckets))
 }
 
+// jitterBucketIdx returns the bucket of a check from the hash of its ID
+func jitterBucketIdx(id checkid.ID, nbBuckets int) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % uint32(nbBuckets))
+}
+
+// rebalance moves checks from the buckets with the largest cost, which is the sum of the average execution times of
+// their checks, to the buckets with the smallest, as long as it reduces the largest cost. It returns the number of
+// checks moved.
+func (jq *jobQueue) rebalance() int {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+
+	if len(jq.buckets) < 2 {
+		return 0
+	}
+
+	costs := make([]time.Duration, len(jq.buckets))
+	for i, bucket := range jq.buckets {
+		costs[i] = bucket.cost()
+	}
+
+	moved := 0
+	// each move reduces the largest cost, bounding the moves keeps a rebalance cheap
+	for moved < len(jq.buckets) {
+		heaviest, lightest := 0, 0
+		for i, cost := range costs {
+			if cost > costs[heaviest] {
+				heaviest = i
+			}
+			if cost < costs[lightest] {
+				lightest = i
+			}
+		}
+
+		// the lightest check of the heaviest bucket whose move doesn't make the lightest bucket the heaviest
+		from := jq.buckets[heaviest]
+		from.mu.RLock()
+		var candidate check.Check
+		var candidateCost time.Duration
+		for _, c := range from.jobs {
+			cost := checkCost(c.ID())
+			if cost > 0 && costs[lightest]+cost < costs[heaviest] && (candidate == nil || cost < candidateCost) {
+				candidate, candidateCost = c, cost
+			}
+		}
+		from.mu.RUnlock()
+		if candidate == nil {
+			break
+		}
+
+		from.removeJob(candidate.ID())
+		jq.buckets[lightest].addJob(candidate)
+		costs[heaviest] -= candidateCost
+		costs[lightest] += candidateCost
+		moved++
+	}
+
+	if moved > 0 {
+		log.Debugf("Rebalanced %d checks of the %v job queue", moved, jq.interval)
+	}
+	return moved
+}
+
 func (jq *jobQueue) removeJob(id checkid.ID) error {
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
@@ -158,7 +259,8 @@ func (jq *jobQueue) stats() map[string]interface{} {
 
 // run schedules the checks in the queue by posting them to the
 // execution pipeline.
-// Not blocking, runs in a new goroutine.
+// Not blocking, runs in a new goroutine. The tickers of the queue are
+// stopped once it is stopped.
 func (jq *jobQueue) run(s *Scheduler) {
 
 	go func() {
@@ -166,10 +268,19 @@ func (jq *jobQueue) run(s *Scheduler) {
 		for jq.process(s) {
 			// empty
 		}
+		jq.stopTickers()
 		jq.stopped <- true
 	}()
 }
 
+// stopTickers stops the bucket and rebalance tickers
+func (jq *jobQueue) stopTickers() {
+	jq.bucketTicker.Stop()
+	if jq.rebalanceTicker != nil {
+		jq.rebalanceTicker.Stop()
+	}
+}
+
 // process  enqueues the checks at a tick, and returns whether the queue
 // should listen to the following tick (or stop)
 func (jq *jobQueue) process(s *Scheduler) bool {
@@ -205,6 +316,7 @@ func (jq *jobQueue) process(s *Scheduler) bool {
 			select {
 			// blocking, we'll be here as long as it takes
 			case s.checksPipe <- check:
+				s.recordEnqueueLatency(time.Since(t))
 			case <-jq.stop:
 				jq.health.Deregister() //nolint:errcheck
 				return false
@@ -219,9 +331,20 @@ func (jq *jobQueue) process(s *Scheduler) bool {
 		jq.mu.Lock()
 		jq.currentBucketIdx = (jq.currentBucketIdx + 1) % uint(len(jq.buckets))
 		jq.mu.Unlock()
+	case <-jq.rebalanceTickerC():
+		jq.rebalance()
 	case <-jq.health.C:
 		// nothing
 	}
 
 	return true
 }
+
+// rebalanceTickerC returns the channel of the rebalance ticker, nil and therefore never ready when the buckets are
+// not rebalanced
+func (jq *jobQueue) rebalanceTickerC() <-chan time.Time {
+	if jq.rebalanceTicker == nil {
+		return nil
+	}
+	return jq.rebalanceTicker.C
+}
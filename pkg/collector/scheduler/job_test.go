@@ -6,10 +6,12 @@
 package scheduler
 
 import (
+	"fmt"
 	"runtime"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	checkid "github.com/DataDog/datadog-agent/pkg/collector/check/id"
@@ -66,3 +68,60 @@ func TestBucket_RemoveJob(t *testing.T) {
 	// use the bucket, just to keep it alive during the earlier GC run
 	bucket.addJob(&TestJobCheck{id: "here so the GC doesn't GC the entire bucket"})
 }
+
+func TestJobQueueJitter(t *testing.T) {
+	jq := newJobQueue(20*time.Second, true, 0)
+	defer jq.health.Deregister() //nolint:errcheck
+
+	for i := 0; i < 200; i++ {
+		jq.addJob(&TestJobCheck{id: fmt.Sprintf("check:%d", i)})
+	}
+
+	for i, bucket := range jq.buckets {
+		assert.NotZero(t, bucket.size(), "bucket %d is empty", i)
+		for _, c := range bucket.jobs {
+			assert.Equal(t, i, jitterBucketIdx(c.ID(), len(jq.buckets)), "the bucket of a check only depends on its ID")
+		}
+	}
+}
+
+func TestJobQueueRebalance(t *testing.T) {
+	costs := map[checkid.ID]time.Duration{"a": 3 * time.Second, "b": time.Second, "c": time.Second, "d": time.Second}
+	defer func(original func(checkid.ID) time.Duration) { checkCost = original }(checkCost)
+	checkCost = func(id checkid.ID) time.Duration { return costs[id] }
+
+	jq := newJobQueue(3*time.Second, false, 0)
+	defer jq.health.Deregister() //nolint:errcheck
+	for _, id := range []string{"a", "b", "c", "d"} {
+		jq.buckets[0].addJob(&TestJobCheck{id: id})
+	}
+
+	assert.Equal(t, 3, jq.rebalance())
+	assert.Equal(t, 3*time.Second, jq.buckets[0].cost())
+	assert.Equal(t, 2*time.Second, jq.buckets[1].cost())
+	assert.Equal(t, time.Second, jq.buckets[2].cost())
+
+	assert.Equal(t, 0, jq.rebalance(), "the buckets are balanced")
+}
+
+func TestJobQueueStopTickers(t *testing.T) {
+	jq := newJobQueue(3*time.Second, false, 10*time.Millisecond)
+	jq.run(&Scheduler{})
+
+	// let the queue rebalance its buckets at least once
+	time.Sleep(50 * time.Millisecond)
+	jq.stop <- true
+	<-jq.stopped
+
+	// a tick may have been sent before the tickers were stopped
+	select {
+	case <-jq.rebalanceTicker.C:
+	default:
+	}
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-jq.rebalanceTicker.C:
+		assert.Fail(t, "the rebalance ticker is not stopped")
+	default:
+	}
+}
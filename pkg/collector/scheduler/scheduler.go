@@ -13,6 +13,7 @@ import (
 
 	"go.uber.org/atomic"
 
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/telemetry"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
@@ -59,6 +60,10 @@ type Scheduler struct {
 
 	cancelOneTime chan bool      // Used to internally communicate a cancel signal to one-time schedule goroutines
 	wgOneTime     sync.WaitGroup // WaitGroup to track the exit of one-time schedule goroutines
+
+	jitter            bool             // Place the checks in the bucket derived from their ID
+	rebalanceInterval time.Duration    // How often the buckets are rebalanced with the execution times, 0 to disable
+	enqueueLatency    *atomic.Duration // Largest delay between a bucket tick and the pickup of one of its checks
 }
 
 // NewScheduler create a Scheduler and returns a pointer to it.
@@ -74,6 +79,10 @@ func NewScheduler(checksPipe chan<- check.Check) *Scheduler {
 		running:          atomic.NewBool(false),
 		cancelOneTime:    make(chan bool),
 		wgOneTime:        sync.WaitGroup{},
+
+		jitter:            pkgconfigsetup.Datadog().GetBool("check_scheduler_jitter"),
+		rebalanceInterval: pkgconfigsetup.Datadog().GetDuration("check_scheduler_rebalance_interval"),
+		enqueueLatency:    atomic.NewDuration(0),
 	}
 }
 
@@ -97,7 +106,7 @@ func (s *Scheduler) Enter(check check.Check) error {
 	defer s.mu.Unlock()
 
 	if _, ok := s.jobQueues[check.Interval()]; !ok {
-		s.jobQueues[check.Interval()] = newJobQueue(check.Interval())
+		s.jobQueues[check.Interval()] = newJobQueue(check.Interval(), s.jitter, s.rebalanceInterval)
 		s.startQueue(s.jobQueues[check.Interval()])
 		if check.IsTelemetryEnabled() {
 			tlmQueuesCount.Inc()
@@ -218,6 +227,21 @@ func (s *Scheduler) IsCheckScheduled(id checkid.ID) bool {
 	return found
 }
 
+// EnqueueLatency returns the largest delay between the time a check was due and the time a worker picked it up since
+// the previous call. A growing latency means that the workers can't keep up with the schedule.
+func (s *Scheduler) EnqueueLatency() time.Duration {
+	return s.enqueueLatency.Swap(0)
+}
+
+func (s *Scheduler) recordEnqueueLatency(latency time.Duration) {
+	for {
+		current := s.enqueueLatency.Load()
+		if latency <= current || s.enqueueLatency.CompareAndSwap(current, latency) {
+			return
+		}
+	}
+}
+
 // stopQueues shuts down the timers for each active queue
 // Blocks until all the queues have fully stopped
 func (s *Scheduler) stopQueues() {
@@ -199,3 +199,12 @@ func TestStopOneTimeSchedule(t *testing.T) {
 	// sleep to make the runtime schedule the hanging goroutines, if there are any
 	time.Sleep(time.Millisecond)
 }
+
+func TestEnqueueLatency(t *testing.T) {
+	s := getScheduler()
+
+	s.recordEnqueueLatency(2 * time.Second)
+	s.recordEnqueueLatency(time.Second)
+	assert.Equal(t, 2*time.Second, s.EnqueueLatency(), "the largest latency is reported")
+	assert.Zero(t, s.EnqueueLatency(), "the latency is reset once read")
+}
@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package worker
+
+import (
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/DataDog/datadog-agent/comp/core/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	checkid "github.com/DataDog/datadog-agent/pkg/collector/check/id"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	runTimeoutConfigKey           = "check_run_timeout"
+	quarantineConfigKey           = "check_run_timeout_quarantine"
+	quarantineMaxBackoffConfigKey = "check_run_timeout_quarantine_max_backoff"
+)
+
+// quarantines holds the checks whose runs timed out, shared by all the workers since any of them may pick the next
+// run of a check
+var quarantines = newQuarantineStore()
+
+// runTimeouts holds the run timeouts of the scheduled checks, resolved once when they are scheduled
+var runTimeouts sync.Map // map[checkid.ID]time.Duration
+
+// RegisterCheck resolves the run timeout of a check, it must be called when the check is scheduled
+func RegisterCheck(c check.Check) {
+	runTimeouts.Store(c.ID(), runTimeout(c))
+}
+
+// UnregisterCheck forgets the run timeout and the quarantine of a check, it must be called when the check is
+// unscheduled
+func UnregisterCheck(id checkid.ID) {
+	runTimeouts.Delete(id)
+	quarantines.reset(id)
+}
+
+// registeredRunTimeout returns the run timeout resolved when the check was scheduled, the checks that were not
+// registered are never timed out
+func registeredRunTimeout(id checkid.ID) time.Duration {
+	if timeout, found := runTimeouts.Load(id); found {
+		return timeout.(time.Duration)
+	}
+	return 0
+}
+
+// quarantine is the state of a check whose runs timed out
+type quarantine struct {
+	consecutiveTimeouts int
+	until               time.Time
+}
+
+type quarantineStore struct {
+	sync.Mutex
+	checks map[checkid.ID]*quarantine
+}
+
+func newQuarantineStore() *quarantineStore {
+	return &quarantineStore{checks: make(map[checkid.ID]*quarantine)}
+}
+
+// quarantinedUntil returns the date until which the runs of a check are skipped, if it is quarantined
+func (q *quarantineStore) quarantinedUntil(id checkid.ID, now time.Time) (time.Time, bool) {
+	q.Lock()
+	defer q.Unlock()
+
+	state, found := q.checks[id]
+	if !found || !now.Before(state.until) {
+		return time.Time{}, false
+	}
+	return state.until, true
+}
+
+// timedOut records a timeout of a check and returns the date until which it is quarantined. The backoff starts at
+// `base` and doubles on every consecutive timeout, up to `maxBackoff`. The zero time is returned when the
+// quarantine is disabled.
+func (q *quarantineStore) timedOut(id checkid.ID, now time.Time, base, maxBackoff time.Duration, enabled bool) time.Time {
+	q.Lock()
+	defer q.Unlock()
+
+	state, found := q.checks[id]
+	if !found {
+		state = &quarantine{}
+		q.checks[id] = state
+	}
+	state.consecutiveTimeouts++
+	if !enabled {
+		return time.Time{}
+	}
+
+	backoff := base
+	for i := 1; i < state.consecutiveTimeouts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, maxBackoff)
+	state.until = now.Add(backoff)
+	return state.until
+}
+
+// reset forgets the timeouts of a check after a run completed within its timeout or when it is unscheduled, and
+// returns true if it had timed out
+func (q *quarantineStore) reset(id checkid.ID) bool {
+	q.Lock()
+	defer q.Unlock()
+
+	_, found := q.checks[id]
+	delete(q.checks, id)
+	return found
+}
+
+// runTimeout returns the maximum duration of a run of a check: the `run_timeout` of its instance, in seconds, or
+// `check_run_timeout`. Long running checks are never timed out.
+func runTimeout(c check.Check) time.Duration {
+	if c.Interval() == 0 {
+		return 0
+	}
+
+	commonOptions := integration.CommonInstanceConfig{}
+	if instance := c.InstanceConfig(); instance != "" {
+		if err := yaml.Unmarshal([]byte(instance), &commonOptions); err != nil {
+			log.Debugf("Unable to parse the run timeout of check %s: %s", c.ID(), err)
+		}
+	}
+	if commonOptions.RunTimeout > 0 {
+		return time.Duration(commonOptions.RunTimeout) * time.Second
+	}
+	return pkgconfigsetup.Datadog().GetDuration(runTimeoutConfigKey)
+}
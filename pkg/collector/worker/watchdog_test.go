@@ -0,0 +1,81 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/collector/check/stub"
+	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
+)
+
+type instanceCheck struct {
+	stub.StubCheck
+	instance string
+	interval time.Duration
+}
+
+func (c *instanceCheck) InstanceConfig() string  { return c.instance }
+func (c *instanceCheck) Interval() time.Duration { return c.interval }
+
+func TestQuarantineBackoff(t *testing.T) {
+	q := newQuarantineStore()
+	now := time.Now()
+
+	for i, expected := range []time.Duration{15 * time.Second, 30 * time.Second, time.Minute, time.Minute} {
+		until := q.timedOut("check:123", now, 15*time.Second, time.Minute, true)
+		assert.Equal(t, now.Add(expected), until, "timeout %d", i+1)
+	}
+
+	until, quarantined := q.quarantinedUntil("check:123", now)
+	assert.True(t, quarantined)
+	assert.Equal(t, now.Add(time.Minute), until)
+	_, quarantined = q.quarantinedUntil("check:123", now.Add(time.Minute))
+	assert.False(t, quarantined)
+
+	assert.True(t, q.reset("check:123"))
+	assert.False(t, q.reset("check:123"))
+	assert.Equal(t, now.Add(15*time.Second), q.timedOut("check:123", now, 15*time.Second, time.Minute, true))
+}
+
+func TestQuarantineDisabled(t *testing.T) {
+	q := newQuarantineStore()
+	now := time.Now()
+
+	assert.True(t, q.timedOut("check:123", now, 15*time.Second, time.Minute, false).IsZero())
+	_, quarantined := q.quarantinedUntil("check:123", now)
+	assert.False(t, quarantined)
+}
+
+func TestRunTimeout(t *testing.T) {
+	pkgconfigsetup.Datadog().SetWithoutSource("check_run_timeout", 30*time.Second)
+	defer pkgconfigsetup.Datadog().SetWithoutSource("check_run_timeout", 0)
+
+	assert.Equal(t, 30*time.Second, runTimeout(&instanceCheck{interval: 15 * time.Second}))
+	assert.Equal(t, 5*time.Second, runTimeout(&instanceCheck{instance: "run_timeout: 5", interval: 15 * time.Second}))
+	assert.Equal(t, 30*time.Second, runTimeout(&instanceCheck{instance: "run_timeout: [invalid]", interval: 15 * time.Second}))
+	assert.Zero(t, runTimeout(&instanceCheck{instance: "run_timeout: 5"}), "long running checks are never timed out")
+}
+
+func TestRegisterCheck(t *testing.T) {
+	pkgconfigsetup.Datadog().SetWithoutSource("check_run_timeout", 30*time.Second)
+	defer pkgconfigsetup.Datadog().SetWithoutSource("check_run_timeout", 0)
+
+	c := &instanceCheck{instance: "run_timeout: 5", interval: 15 * time.Second}
+	assert.Zero(t, registeredRunTimeout(c.ID()), "unregistered checks are never timed out")
+
+	RegisterCheck(c)
+	assert.Equal(t, 5*time.Second, registeredRunTimeout(c.ID()))
+	quarantines.timedOut(c.ID(), time.Now(), 15*time.Second, time.Minute, true)
+
+	UnregisterCheck(c.ID())
+	assert.Zero(t, registeredRunTimeout(c.ID()))
+	_, quarantined := quarantines.quarantinedUntil(c.ID(), time.Now())
+	assert.False(t, quarantined, "the quarantine must be cleared when the check is unscheduled")
+}
@@ -8,12 +8,14 @@ package worker
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	haagent "github.com/DataDog/datadog-agent/comp/haagent/def"
 	"github.com/DataDog/datadog-agent/pkg/aggregator/sender"
 	"github.com/DataDog/datadog-agent/pkg/collector/check"
 	checkid "github.com/DataDog/datadog-agent/pkg/collector/check/id"
+	"github.com/DataDog/datadog-agent/pkg/collector/check/stats"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/expvars"
 	"github.com/DataDog/datadog-agent/pkg/collector/runner/tracker"
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
@@ -55,6 +57,7 @@ type Worker struct {
 	shouldAddCheckStatsFunc func(id checkid.ID) bool
 	utilizationTickInterval time.Duration
 	haAgent                 haagent.Component
+	replaced                atomic.Bool
 }
 
 // NewWorker returns an instance of a `Worker` after parameter sanity checks are passed
@@ -139,16 +142,22 @@ func (w *Worker) Run() {
 
 	for check := range w.pendingChecksChan {
 		checkLogger := CheckLogger{Check: check}
-		longRunning := check.Interval() == 0
 
 		if w.haAgent.Enabled() && check.IsHASupported() && !w.haAgent.IsActive() {
 			checkLogger.Debug("Check is an HA integration and current agent is not leader, skipping execution...")
 			continue
 		}
 
+		if until, quarantined := quarantines.quarantinedUntil(check.ID(), time.Now()); quarantined {
+			checkLogger.Debug(fmt.Sprintf("Check is quarantined until %s after timing out, skipping execution...", until.Format(time.RFC3339)))
+			expvars.AddSkippedRun(check.ID())
+			continue
+		}
+
 		// Add check to tracker if it's not already running
 		if !w.checksTracker.AddCheck(check) {
 			checkLogger.Debug("Check is already running, skipping execution...")
+			expvars.AddSkippedRun(check.ID())
 			continue
 		}
 
@@ -162,65 +171,166 @@ func (w *Worker) Run() {
 		utilizationTracker.Started()
 
 		// Run the check
-		checkErr := check.Run()
+		timeout := registeredRunTimeout(check.ID())
+		if timeout <= 0 {
+			checkErr := check.Run()
+			utilizationTracker.Finished()
+			w.checkFinished(check, &checkLogger, checkStartTime, checkErr)
+			continue
+		}
 
-		utilizationTracker.Finished()
+		// The check runs in its own goroutine so that the worker can give up on it when it exceeds its timeout
+		done := make(chan error, 1)
+		go func() {
+			done <- check.Run()
+		}()
+
+		timer := time.NewTimer(timeout)
+		select {
+		case checkErr := <-done:
+			timer.Stop()
+			utilizationTracker.Finished()
+			w.checkFinished(check, &checkLogger, checkStartTime, checkErr)
+			if quarantines.reset(check.ID()) {
+				if checkStats, found := expvars.CheckStats(check.ID()); found {
+					checkStats.SetQuarantinedUntil(time.Time{})
+				}
+			}
+		case <-timer.C:
+			utilizationTracker.Finished()
+			w.checkTimedOut(check, &checkLogger, checkStartTime, timeout, done)
+
+			// The worker stops there and leaves the hung run behind, the runner starts a replacement so that the
+			// other checks keep their intervals
+			log.Warnf("Runner %d, worker %d: Check %s is hung, the worker is replaced", w.runnerID, w.ID, check.ID())
+			w.replaced.Store(true)
+			return
+		}
+	}
 
-		expvars.DeleteRunningStats(check.ID())
+	log.Debugf("Runner %d, worker %d: Finished processing checks.", w.runnerID, w.ID)
+}
 
-		checkWarnings := check.GetWarnings()
+// Replaced returns true if the worker stopped processing checks because a run exceeded its timeout, in which case the
+// runner starts another worker in its place
+func (w *Worker) Replaced() bool {
+	return w.replaced.Load()
+}
 
-		// Use the default sender for the service checks
-		sender, err := w.getDefaultSenderFunc()
-		if err != nil {
-			log.Errorf("Error getting default sender: %v. Not sending status check for %s", err, check)
-		}
-		serviceCheckTags := []string{fmt.Sprintf("check:%s", check.String()), "dd_enable_check_intake:true"}
-		serviceCheckStatus := servicecheck.ServiceCheckOK
+// checkFinished submits the status and the stats of a check run that returned
+func (w *Worker) checkFinished(check check.Check, checkLogger *CheckLogger, checkStartTime time.Time, checkErr error) {
+	longRunning := check.Interval() == 0
 
-		hname, _ := hostname.Get(context.TODO())
+	expvars.DeleteRunningStats(check.ID())
 
-		if len(checkWarnings) != 0 {
-			expvars.AddWarningsCount(len(checkWarnings))
-			serviceCheckStatus = servicecheck.ServiceCheckWarning
-		}
+	checkWarnings := check.GetWarnings()
+
+	serviceCheckStatus := servicecheck.ServiceCheckOK
 
-		if checkErr != nil {
-			checkLogger.Error(checkErr)
-			expvars.AddErrorsCount(1)
-			serviceCheckStatus = servicecheck.ServiceCheckCritical
+	if len(checkWarnings) != 0 {
+		expvars.AddWarningsCount(len(checkWarnings))
+		serviceCheckStatus = servicecheck.ServiceCheckWarning
+	}
+
+	if checkErr != nil {
+		checkLogger.Error(checkErr)
+		expvars.AddErrorsCount(1)
+		serviceCheckStatus = servicecheck.ServiceCheckCritical
+	}
+
+	if !longRunning {
+		w.sendServiceCheck(check, serviceCheckStatus)
+	}
+
+	// Remove the check from the running list
+	w.checksTracker.DeleteCheck(check.ID())
+
+	// Publish statistics about this run
+	expvars.AddRunningCheckCount(-1)
+	expvars.AddRunsCount(1)
+
+	if !longRunning || len(checkWarnings) != 0 || checkErr != nil {
+		// If the scheduler isn't assigned (it should), just add stats
+		// otherwise only do so if the check is in the scheduler
+		if w.shouldAddCheckStatsFunc(check.ID()) {
+			sStats, _ := check.GetSenderStats()
+			expvars.AddCheckStats(check, time.Since(checkStartTime), checkErr, checkWarnings, sStats, w.haAgent)
 		}
+	}
 
-		if sender != nil && !longRunning {
-			if pkgconfigsetup.Datadog().GetBool("integration_check_status_enabled") {
-				sender.ServiceCheck(serviceCheckStatusKey, serviceCheckStatus, hname, serviceCheckTags, "")
-			}
-			// FIXME(remy): this `Commit()` should be part of the `if` above, we keep
-			// it here for now to make sure it's not breaking any historical behavior
-			// with the shared default sender.
-			sender.Commit()
+	checkLogger.CheckFinished()
+}
+
+// checkTimedOut reports a run that exceeded its timeout as an error, marks the check as hung, quarantines it if
+// enabled, and stops and cancels it so that the hung run can be interrupted. The check stays in the running list,
+// so that it is not run again, until the hung run returns.
+func (w *Worker) checkTimedOut(check check.Check, checkLogger *CheckLogger, checkStartTime time.Time, timeout time.Duration, done <-chan error) {
+	timeoutErr := fmt.Errorf("check run timed out after %s", timeout)
+	checkLogger.Error(timeoutErr)
+	expvars.AddErrorsCount(1)
+	w.sendServiceCheck(check, servicecheck.ServiceCheckCritical)
+
+	if w.shouldAddCheckStatsFunc(check.ID()) {
+		expvars.AddCheckStats(check, timeout, timeoutErr, nil, stats.SenderStats{}, w.haAgent)
+	}
+
+	until := quarantines.timedOut(
+		check.ID(),
+		time.Now(),
+		max(check.Interval(), timeout),
+		pkgconfigsetup.Datadog().GetDuration(quarantineMaxBackoffConfigKey),
+		pkgconfigsetup.Datadog().GetBool(quarantineConfigKey),
+	)
+	if checkStats, found := expvars.CheckStats(check.ID()); found {
+		checkStats.SetStateHung(true)
+		checkStats.SetQuarantinedUntil(until)
+	}
+	if !until.IsZero() {
+		log.Warnf("Check %s is quarantined until %s", check.ID(), until.Format(time.RFC3339))
+	}
+
+	// Cancelling a check may wait for the hung run, e.g. for the GIL of a Python check
+	go func() {
+		check.Stop()
+		check.Cancel()
+	}()
+
+	go func() {
+		checkErr := <-done
+		log.Infof("Hung run of check %s returned after %s: %v", check.ID(), time.Since(checkStartTime), checkErr)
+
+		// The check is not hung anymore once it can be run again
+		if checkStats, found := expvars.CheckStats(check.ID()); found {
+			checkStats.SetStateHung(false)
 		}
 
-		// Remove the check from the running list
+		expvars.DeleteRunningStats(check.ID())
 		w.checksTracker.DeleteCheck(check.ID())
-
-		// Publish statistics about this run
 		expvars.AddRunningCheckCount(-1)
 		expvars.AddRunsCount(1)
+	}()
+}
 
-		if !longRunning || len(checkWarnings) != 0 || checkErr != nil {
-			// If the scheduler isn't assigned (it should), just add stats
-			// otherwise only do so if the check is in the scheduler
-			if w.shouldAddCheckStatsFunc(check.ID()) {
-				sStats, _ := check.GetSenderStats()
-				expvars.AddCheckStats(check, time.Since(checkStartTime), checkErr, checkWarnings, sStats, w.haAgent)
-			}
-		}
-
-		checkLogger.CheckFinished()
+// sendServiceCheck submits the status of a check run with the default sender
+func (w *Worker) sendServiceCheck(check check.Check, status servicecheck.ServiceCheckStatus) {
+	// Use the default sender for the service checks
+	sender, err := w.getDefaultSenderFunc()
+	if err != nil {
+		log.Errorf("Error getting default sender: %v. Not sending status check for %s", err, check)
+	}
+	if sender == nil {
+		return
 	}
 
-	log.Debugf("Runner %d, worker %d: Finished processing checks.", w.runnerID, w.ID)
+	if pkgconfigsetup.Datadog().GetBool("integration_check_status_enabled") {
+		hname, _ := hostname.Get(context.TODO())
+		serviceCheckTags := []string{fmt.Sprintf("check:%s", check.String()), "dd_enable_check_intake:true"}
+		sender.ServiceCheck(serviceCheckStatusKey, status, hname, serviceCheckTags, "")
+	}
+	// FIXME(remy): this `Commit()` should be part of the `if` above, we keep
+	// it here for now to make sure it's not breaking any historical behavior
+	// with the shared default sender.
+	sender.Commit()
 }
 
 func startUtilizationUpdater(name string, ut *utilizationtracker.UtilizationTracker) {
@@ -83,6 +83,28 @@ func (c *testCheck) Run() error {
 	return nil
 }
 
+// stopRecordingCheck records the calls to Stop and Cancel
+type stopRecordingCheck struct {
+	*testCheck
+	callsMutex sync.Mutex
+	calls      []string
+}
+
+func (c *stopRecordingCheck) Stop()   { c.record("Stop") }
+func (c *stopRecordingCheck) Cancel() { c.record("Cancel") }
+
+func (c *stopRecordingCheck) record(call string) {
+	c.callsMutex.Lock()
+	defer c.callsMutex.Unlock()
+	c.calls = append(c.calls, call)
+}
+
+func (c *stopRecordingCheck) Calls() []string {
+	c.callsMutex.Lock()
+	defer c.callsMutex.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
 // Helpers
 
 // AssertAsyncWorkerCount returns the expvar count of the currently-running
@@ -770,3 +792,60 @@ func getWorkerUtilizationExpvar(t *testing.T, name string) float64 {
 
 	return workerStats.Utilization
 }
+
+func TestWorkerRunTimeout(t *testing.T) {
+	expvars.Reset()
+	pkgconfigsetup.Datadog().SetWithoutSource("hostname", "myhost")
+	pkgconfigsetup.Datadog().SetWithoutSource("check_run_timeout", 100*time.Millisecond)
+	pkgconfigsetup.Datadog().SetWithoutSource("check_run_timeout_quarantine", true)
+	defer pkgconfigsetup.Datadog().SetWithoutSource("check_run_timeout", 0)
+	defer pkgconfigsetup.Datadog().SetWithoutSource("check_run_timeout_quarantine", false)
+
+	checksTracker := tracker.NewRunningChecksTracker()
+	pendingChecksChan := make(chan check.Check, 10)
+	mockShouldAddStatsFunc := func(checkid.ID) bool { return true }
+
+	unblock := make(chan struct{})
+	hungCheck := &stopRecordingCheck{testCheck: newCheck(t, "hung:123", false, func(checkid.ID) { <-unblock })}
+	otherCheck := newCheck(t, "other:123", false, nil)
+	RegisterCheck(hungCheck)
+	RegisterCheck(otherCheck)
+	defer UnregisterCheck(hungCheck.ID())
+	defer UnregisterCheck(otherCheck.ID())
+	pendingChecksChan <- hungCheck
+	pendingChecksChan <- otherCheck
+
+	worker, err := NewWorker(aggregator.NewNoOpSenderManager(), haagentmock.NewMockHaAgent(), 100, 200, pendingChecksChan, checksTracker, mockShouldAddStatsFunc)
+	require.Nil(t, err)
+
+	// The worker gives up on the hung check and stops, leaving the other check to its replacement
+	worker.Run()
+	assert.True(t, worker.Replaced())
+	assert.Equal(t, 0, otherCheck.RunCount())
+
+	_, running := checksTracker.Check(hungCheck.ID())
+	assert.True(t, running, "the hung check must not be run again until it returns")
+	stats, found := expvars.CheckStats(hungCheck.ID())
+	require.True(t, found)
+	assert.True(t, stats.Hung)
+	assert.NotZero(t, stats.QuarantinedUntil)
+	assert.Contains(t, stats.LastError, "timed out")
+	require.Eventually(t, func() bool { return len(hungCheck.Calls()) == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"Stop", "Cancel"}, hungCheck.Calls())
+
+	close(unblock)
+	require.Eventually(t, func() bool {
+		_, running := checksTracker.Check(hungCheck.ID())
+		return !running
+	}, 2*time.Second, 10*time.Millisecond)
+	stats, _ = expvars.CheckStats(hungCheck.ID())
+	assert.False(t, stats.Hung)
+	assert.Equal(t, 1, hungCheck.RunCount())
+
+	close(pendingChecksChan)
+	replacement, err := NewWorker(aggregator.NewNoOpSenderManager(), haagentmock.NewMockHaAgent(), 100, 201, pendingChecksChan, checksTracker, mockShouldAddStatsFunc)
+	require.Nil(t, err)
+	replacement.Run()
+	assert.False(t, replacement.Replaced())
+	assert.Equal(t, 1, otherCheck.RunCount())
+}
@@ -83,6 +83,7 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("apm_config.peer_service_aggregation", true, "DD_APM_PEER_SERVICE_AGGREGATION")                               //nolint:errcheck
 	config.BindEnvAndSetDefault("apm_config.peer_tags_aggregation", true, "DD_APM_PEER_TAGS_AGGREGATION")                                     //nolint:errcheck
 	config.BindEnvAndSetDefault("apm_config.compute_stats_by_span_kind", true, "DD_APM_COMPUTE_STATS_BY_SPAN_KIND")                           //nolint:errcheck
+	config.BindEnvAndSetDefault("apm_config.compute_stats_exception_span_events", false, "DD_APM_COMPUTE_STATS_EXCEPTION_SPAN_EVENTS")        //nolint:errcheck
 	config.BindEnvAndSetDefault("apm_config.instrumentation.enabled", false, "DD_APM_INSTRUMENTATION_ENABLED")
 	config.BindEnvAndSetDefault("apm_config.instrumentation.enabled_namespaces", []string{}, "DD_APM_INSTRUMENTATION_ENABLED_NAMESPACES")
 	config.ParseEnvAsStringSlice("apm_config.instrumentation.enabled_namespaces", func(in string) []string {
@@ -130,6 +131,8 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnv("apm_config.max_traces_per_second", "DD_APM_MAX_TPS", "DD_MAX_TPS") // deprecated
 	config.BindEnv("apm_config.target_traces_per_second", "DD_APM_TARGET_TPS")
 	config.BindEnv("apm_config.errors_per_second", "DD_APM_ERROR_TPS")
+	config.BindEnv("apm_config.priority_sampler.budgets", "DD_APM_PRIORITY_SAMPLER_BUDGETS")
+	config.BindEnv("apm_config.priority_sampler.min_rate", "DD_APM_PRIORITY_SAMPLER_MIN_RATE")
 	config.BindEnv("apm_config.enable_rare_sampler", "DD_APM_ENABLE_RARE_SAMPLER")
 	config.BindEnv("apm_config.disable_rare_sampler", "DD_APM_DISABLE_RARE_SAMPLER") // Deprecated
 	config.BindEnv("apm_config.max_remote_traces_per_second", "DD_APM_MAX_REMOTE_TPS")
@@ -153,6 +156,13 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnv("apm_config.analyzed_spans", "DD_APM_ANALYZED_SPANS")
 	config.BindEnv("apm_config.ignore_resources", "DD_APM_IGNORE_RESOURCES", "DD_IGNORE_RESOURCE")
 	config.BindEnv("apm_config.instrumentation.targets", "DD_APM_INSTRUMENTATION_TARGETS")
+	config.ParseEnvAsSlice("apm_config.priority_sampler.budgets", func(in string) []interface{} {
+		var budgets []interface{}
+		if err := json.Unmarshal([]byte(in), &budgets); err != nil {
+			log.Errorf(`"apm_config.priority_sampler.budgets" can not be parsed: %v`, err)
+		}
+		return budgets
+	})
 	config.ParseEnvAsSlice("apm_config.instrumentation.targets", func(in string) []interface{} {
 		var mappings []interface{}
 		if err := json.Unmarshal([]byte(in), &mappings); err != nil {
@@ -188,6 +198,8 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("apm_config.obfuscation.credit_cards.keep_values", []string{}, "DD_APM_OBFUSCATION_CREDIT_CARDS_KEEP_VALUES")
 	config.BindEnvAndSetDefault("apm_config.sql_obfuscation_mode", "", "DD_APM_SQL_OBFUSCATION_MODE")
 	config.BindEnvAndSetDefault("apm_config.debug.port", 5012, "DD_APM_DEBUG_PORT")
+	config.BindEnvAndSetDefault("apm_config.dry_run.enabled", false, "DD_APM_DRY_RUN_ENABLED")
+	config.BindEnvAndSetDefault("apm_config.dry_run.buffer_size", 100, "DD_APM_DRY_RUN_BUFFER_SIZE")
 	config.BindEnv("apm_config.features", "DD_APM_FEATURES")
 	config.ParseEnvAsStringSlice("apm_config.features", func(s string) []string {
 		// Either commas or spaces can be used as separators.
@@ -80,10 +80,10 @@ const (
 	// DefaultRuntimePoliciesDir is the default policies directory used by the runtime security module
 	DefaultRuntimePoliciesDir = "/etc/datadog-agent/runtime-security.d"
 
-	// DefaultCompressorKind is the default compressor. Options available are 'zlib' and 'zstd'
+	// DefaultCompressorKind is the default compressor. Options available are 'zlib', 'zstd', 'gzip', 'lz4' and 'snappy'
 	DefaultCompressorKind = "zstd"
 
-	// DefaultLogCompressionKind is the default log compressor. Options available are 'zstd' and 'gzip'
+	// DefaultLogCompressionKind is the default log compressor. Options available are 'zstd', 'gzip', 'lz4' and 'snappy'
 	DefaultLogCompressionKind = "gzip"
 
 	// DefaultZstdCompressionLevel is the default compression level for `zstd`.
@@ -363,6 +363,8 @@ func InitConfig(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("secret_backend_command_allow_group_exec_perm", false)
 	config.BindEnvAndSetDefault("secret_backend_skip_checks", false)
 	config.BindEnvAndSetDefault("secret_backend_remove_trailing_line_break", false)
+	config.BindEnvAndSetDefault("secret_backend_type", "")
+	config.BindEnvAndSetDefault("secret_backend_config", map[string]interface{}{})
 	config.BindEnvAndSetDefault("secret_refresh_interval", 0)
 	config.BindEnvAndSetDefault("secret_refresh_scatter", true)
 	config.SetDefault("secret_audit_file_max_size", 0)
@@ -419,6 +421,7 @@ func InitConfig(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("prometheus_scrape.service_endpoints", false) // Enables Service Endpoints checks in the prometheus config provider
 	config.BindEnv("prometheus_scrape.checks")                                // Defines any extra prometheus/openmetrics check configurations to be handled by the prometheus config provider
 	config.BindEnvAndSetDefault("prometheus_scrape.version", 1)               // Version of the openmetrics check to be scheduled by the Prometheus auto-discovery
+	config.BindEnvAndSetDefault("prometheus_scrape.use_core_check", false)    // Schedules the Go implementation of the openmetrics check instead of the Python one
 
 	// Network Devices Monitoring
 	bindEnvAndSetLogsConfigKeys(config, "network_devices.metadata.")
@@ -1142,7 +1145,16 @@ func agent(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("enable_signing_metadata_collection", true)
 	config.BindEnvAndSetDefault("metadata_provider_stop_timeout", 30*time.Second)
 	config.BindEnvAndSetDefault("check_runners", int64(4))
+	// Adds a worker, when check_runners is 0, every time the checks are picked up later than this after they are due
+	config.BindEnvAndSetDefault("check_runners_max_queue_latency", 0*time.Second)
+	config.BindEnvAndSetDefault("check_scheduler_jitter", false)
+	config.BindEnvAndSetDefault("check_scheduler_rebalance_interval", 0*time.Second)
 	config.BindEnvAndSetDefault("check_cancel_timeout", 500*time.Millisecond)
+	// Check runs exceeding the timeout (0 to disable) are abandoned by their worker, optionally with the check
+	// quarantined with an exponential backoff
+	config.BindEnvAndSetDefault("check_run_timeout", 0*time.Second)
+	config.BindEnvAndSetDefault("check_run_timeout_quarantine", false)
+	config.BindEnvAndSetDefault("check_run_timeout_quarantine_max_backoff", time.Hour)
 	config.BindEnvAndSetDefault("auth_token_file_path", "")
 	// used to override the path where the IPC cert/key files are stored/retrieved
 	config.BindEnvAndSetDefault("ipc_cert_file_path", "")
@@ -1368,6 +1380,14 @@ func serializer(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("enable_payloads.service_checks", true)
 	config.BindEnvAndSetDefault("enable_payloads.sketches", true)
 	config.BindEnvAndSetDefault("enable_payloads.json_to_v1_intake", true)
+
+	// Serializer: export series and sketches to an OTLP/HTTP endpoint in addition to the Datadog intake
+	config.BindEnvAndSetDefault("otlp_metrics_export.enabled", false)
+	config.BindEnvAndSetDefault("otlp_metrics_export.endpoint", "http://localhost:4318/v1/metrics")
+	config.BindEnvAndSetDefault("otlp_metrics_export.headers", map[string]string{})
+	config.BindEnvAndSetDefault("otlp_metrics_export.timeout", 10) // in seconds
+	config.BindEnvAndSetDefault("otlp_metrics_export.max_metrics_per_payload", 1000)
+	config.BindEnvAndSetDefault("otlp_metrics_export.queue_size", 100)
 }
 
 func aggregator(config pkgconfigmodel.Setup) {
@@ -1377,6 +1397,20 @@ func aggregator(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("basic_telemetry_add_container_tags", false) // configure adding the agent container tags to the basic agent telemetry metrics (e.g. `datadog.agent.running`)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_chan_size", 200)
 	config.BindEnvAndSetDefault("aggregator_flush_metrics_and_serialize_in_parallel_buffer_size", 4000)
+	// Recording rules rolling up metrics at flush time
+	config.BindEnv("aggregator_recording_rules")
+	config.ParseEnvAsSlice("aggregator_recording_rules", func(in string) []interface{} {
+		var rules []interface{}
+		if err := json.Unmarshal([]byte(in), &rules); err != nil {
+			log.Errorf(`"aggregator_recording_rules" can not be parsed: %v`, err)
+		}
+		return rules
+	})
+	// OpenMetrics exporter mirroring the flushed series and sketches
+	config.BindEnvAndSetDefault("aggregator_openmetrics_exporter.enabled", false)
+	config.BindEnvAndSetDefault("aggregator_openmetrics_exporter.listen_address", "localhost:5015")
+	config.BindEnvAndSetDefault("aggregator_openmetrics_exporter.staleness_timeout", 300)
+	config.BindEnvAndSetDefault("aggregator_openmetrics_exporter.sketch_quantiles", []float64{0.5, 0.75, 0.9, 0.95, 0.99})
 }
 
 func serverless(config pkgconfigmodel.Setup) {
@@ -1394,6 +1428,7 @@ func serverless(config pkgconfigmodel.Setup) {
 func forwarder(config pkgconfigmodel.Setup) {
 	// Forwarder
 	config.BindEnvAndSetDefault("additional_endpoints", map[string][]string{})
+	config.SetKnown("forwarder_routes")
 	config.BindEnvAndSetDefault("forwarder_timeout", 20)
 	config.BindEnv("forwarder_retry_queue_max_size")                                                     // Deprecated in favor of `forwarder_retry_queue_payloads_max_size`
 	config.BindEnv("forwarder_retry_queue_payloads_max_size")                                            // Default value is defined inside `NewOptions` in pkg/forwarder/forwarder.go
@@ -1413,6 +1448,9 @@ func forwarder(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("forwarder_storage_path", "")
 	config.BindEnvAndSetDefault("forwarder_outdated_file_in_days", 10)
 	config.BindEnvAndSetDefault("forwarder_flush_to_disk_mem_ratio", 0.5)
+	config.BindEnvAndSetDefault("forwarder_storage_compression_kind", "none")
+	config.BindEnvAndSetDefault("forwarder_storage_flush_on_shutdown", true)
+	config.BindEnvAndSetDefault("forwarder_storage_journal", false)
 	config.BindEnvAndSetDefault("forwarder_storage_max_size_in_bytes", 0)                // 0 means disabled. This is a BETA feature.
 	config.BindEnvAndSetDefault("forwarder_storage_max_disk_ratio", 0.80)                // Do not store transactions on disk when the disk usage exceeds 80% of the disk capacity. Use 80% as some applications do not behave well when the disk space is very small.
 	config.BindEnvAndSetDefault("forwarder_retry_queue_capacity_time_interval_sec", 900) // 15 mins
@@ -1422,6 +1460,10 @@ func forwarder(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("forwarder_low_prio_buffer_size", 100)
 	config.BindEnvAndSetDefault("forwarder_requeue_buffer_size", 100)
 	config.BindEnvAndSetDefault("forwarder_http_protocol", "auto")
+
+	// Forwarder authentication with a gateway in front of the intake
+	bindEnvAndSetAuthenticationKeys(config, "forwarder_authentication.")
+	config.BindEnvAndSetDefault("forwarder_authentication.domains", []string{})
 }
 
 func dogstatsd(config pkgconfigmodel.Setup) {
@@ -1461,6 +1503,11 @@ func dogstatsd(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("dogstatsd_expiry_seconds", 300)
 	// Control how long we keep dogstatsd contexts in memory.
 	config.BindEnvAndSetDefault("dogstatsd_context_expiry_seconds", 20)
+	// Control how many dogstatsd contexts each time sampler keeps in memory, 0 means no limit.
+	config.BindEnvAndSetDefault("dogstatsd_context_limits.global", 0)
+	config.BindEnvAndSetDefault("dogstatsd_context_limits.per_metric", 0)
+	config.BindEnvAndSetDefault("dogstatsd_context_limits.metric_limits", map[string]string{})
+	config.BindEnvAndSetDefault("dogstatsd_context_limits.policy", "drop")
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection", false) // Only supported for socket traffic
 	config.BindEnvAndSetDefault("dogstatsd_origin_detection_client", false)
 	config.BindEnvAndSetDefault("dogstatsd_origin_optout_enabled", true)
@@ -1482,6 +1529,12 @@ func dogstatsd(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("dogstatsd_no_aggregation_pipeline", true)
 	// How many metrics maximum in payloads sent by the no-aggregation pipeline to the intake.
 	config.BindEnvAndSetDefault("dogstatsd_no_aggregation_pipeline_batch_size", 2048)
+	// Downsampling of the no-aggregation pipeline samples, in seconds. 0 disables the downsampling.
+	config.BindEnvAndSetDefault("dogstatsd_no_aggregation_pipeline_downsampling.interval", 0)
+	// How the gauge samples of an interval are combined: last, sum, min, max or count.
+	config.BindEnvAndSetDefault("dogstatsd_no_aggregation_pipeline_downsampling.gauge_aggregation", "last")
+	// How long after the end of an interval its samples are still accepted, in seconds.
+	config.BindEnvAndSetDefault("dogstatsd_no_aggregation_pipeline_downsampling.max_delay", 10)
 	// Force the amount of dogstatsd workers (mainly used for benchmarks or some very specific use-case)
 	config.BindEnvAndSetDefault("dogstatsd_workers_count", 0)
 
@@ -1586,6 +1639,8 @@ func logsagent(config pkgconfigmodel.Setup) {
 
 	// Transport protocol for log payloads
 	config.BindEnvAndSetDefault("logs_config.http_protocol", "auto")
+	// Authentication of the logs HTTP requests with a gateway in front of the intake
+	bindEnvAndSetAuthenticationKeys(config, "logs_config.authentication.")
 
 	bindEnvAndSetLogsConfigKeys(config, "logs_config.")
 	bindEnvAndSetLogsConfigKeys(config, "database_monitoring.samples.")
@@ -2308,9 +2363,11 @@ func ResolveSecrets(config pkgconfigmodel.Config, secretResolver secrets.Compone
 		RemoveLinebreak:        config.GetBool("secret_backend_remove_trailing_line_break"),
 		RunPath:                config.GetString("run_path"),
 		AuditFileMaxSize:       config.GetInt("secret_audit_file_max_size"),
+		Type:                   config.GetString("secret_backend_type"),
+		Config:                 config.GetStringMap("secret_backend_config"),
 	})
 
-	if config.GetString("secret_backend_command") != "" {
+	if config.GetString("secret_backend_command") != "" || config.GetString("secret_backend_type") != "" {
 		// Viper doesn't expose the final location of the file it
 		// loads. Since we are searching for 'datadog.yaml' in multiple
 		// locations we let viper determine the one to use before
@@ -2498,6 +2555,19 @@ func toggleDefaultPayloads(config pkgconfigmodel.Config) {
 	}
 }
 
+// bindEnvAndSetAuthenticationKeys binds the keys of an `authentication` section, see pkg/util/http.NewAuthenticator
+func bindEnvAndSetAuthenticationKeys(config pkgconfigmodel.Setup, prefix string) {
+	config.BindEnvAndSetDefault(prefix+"type", "")
+	config.BindEnvAndSetDefault(prefix+"headers", map[string]string{})
+	config.BindEnvAndSetDefault(prefix+"oauth2.token_url", "")
+	config.BindEnvAndSetDefault(prefix+"oauth2.client_id", "")
+	config.BindEnvAndSetDefault(prefix+"oauth2.client_secret", "")
+	config.BindEnvAndSetDefault(prefix+"oauth2.scopes", []string{})
+	config.BindEnvAndSetDefault(prefix+"hmac.key_id", "")
+	config.BindEnvAndSetDefault(prefix+"hmac.secret", "")
+	config.BindEnvAndSetDefault(prefix+"hmac.header", "Authorization")
+}
+
 func bindEnvAndSetLogsConfigKeys(config pkgconfigmodel.Setup, prefix string) {
 	config.BindEnv(prefix + "logs_dd_url") // Send the logs to a proxy. Must respect format '<HOST>:<PORT>' and '<PORT>' to be an integer
 	config.BindEnv(prefix + "dd_url")
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package utils
+
+import (
+	"fmt"
+	"slices"
+
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+	"github.com/DataDog/datadog-agent/pkg/config/structure"
+)
+
+// Payload types a forwarder route can select
+const (
+	RoutePayloadSeries        = "series"
+	RoutePayloadSketches      = "sketches"
+	RoutePayloadServiceChecks = "service_checks"
+	RoutePayloadEvents        = "events"
+	RoutePayloadMetadata      = "metadata"
+	RoutePayloadProcess       = "process"
+)
+
+var routePayloadTypes = []string{
+	RoutePayloadSeries,
+	RoutePayloadSketches,
+	RoutePayloadServiceChecks,
+	RoutePayloadEvents,
+	RoutePayloadMetadata,
+	RoutePayloadProcess,
+}
+
+// ForwarderRoute helps unmarshalling `forwarder_routes` config param. A route restricts the
+// payloads sent to one of the configured domains.
+type ForwarderRoute struct {
+	// Domain is the endpoint the route applies to, as it appears in `dd_url` or `additional_endpoints`
+	Domain string `mapstructure:"domain"`
+	// PayloadTypes lists the payload types sent to the domain, all of them when empty
+	PayloadTypes []string `mapstructure:"payload_types"`
+	// MetricPrefixes restricts series and sketches to the metrics whose name starts with one of the prefixes
+	MetricPrefixes []string `mapstructure:"metric_prefixes"`
+	// Tags restricts series and sketches to the metrics carrying at least one of the tags
+	Tags []string `mapstructure:"tags"`
+}
+
+// AcceptsPayloadType returns whether the route sends payloads of the given type to its domain
+func (r ForwarderRoute) AcceptsPayloadType(payloadType string) bool {
+	return len(r.PayloadTypes) == 0 || slices.Contains(r.PayloadTypes, payloadType)
+}
+
+// HasMetricPredicates returns whether the route filters series and sketches on their name or tags
+func (r ForwarderRoute) HasMetricPredicates() bool {
+	return len(r.MetricPrefixes) > 0 || len(r.Tags) > 0
+}
+
+// GetForwarderRoutes returns the "forwarder_routes" set in the configuration
+func GetForwarderRoutes(c pkgconfigmodel.Reader) ([]ForwarderRoute, error) {
+	var routes []ForwarderRoute
+	if err := structure.UnmarshalKey(c, "forwarder_routes", &routes); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(routes))
+	for _, route := range routes {
+		if route.Domain == "" {
+			return nil, fmt.Errorf("forwarder route without a domain")
+		}
+		if _, ok := seen[route.Domain]; ok {
+			return nil, fmt.Errorf("several forwarder routes for domain %q", route.Domain)
+		}
+		seen[route.Domain] = struct{}{}
+
+		for _, payloadType := range route.PayloadTypes {
+			if !slices.Contains(routePayloadTypes, payloadType) {
+				return nil, fmt.Errorf("unknown payload type %q in the forwarder route for domain %q", payloadType, route.Domain)
+			}
+		}
+	}
+	return routes, nil
+}
@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/config/mock"
+)
+
+func TestGetForwarderRoutes(t *testing.T) {
+	datadogYaml := `
+forwarder_routes:
+  - domain: https://app.datadoghq.eu
+    payload_types: [series, sketches]
+    metric_prefixes: ["system.", "kubernetes."]
+  - domain: https://partner.example.com
+    payload_types: [service_checks]
+`
+	routes, err := GetForwarderRoutes(mock.NewFromYAML(t, datadogYaml))
+	require.NoError(t, err)
+	require.Len(t, routes, 2)
+
+	assert.Equal(t, ForwarderRoute{
+		Domain:         "https://app.datadoghq.eu",
+		PayloadTypes:   []string{RoutePayloadSeries, RoutePayloadSketches},
+		MetricPrefixes: []string{"system.", "kubernetes."},
+	}, routes[0])
+	assert.True(t, routes[0].AcceptsPayloadType(RoutePayloadSeries))
+	assert.False(t, routes[0].AcceptsPayloadType(RoutePayloadEvents))
+	assert.True(t, routes[0].HasMetricPredicates())
+
+	assert.True(t, routes[1].AcceptsPayloadType(RoutePayloadServiceChecks))
+	assert.False(t, routes[1].AcceptsPayloadType(RoutePayloadSeries))
+	assert.False(t, routes[1].HasMetricPredicates())
+}
+
+func TestGetForwarderRoutesDefault(t *testing.T) {
+	routes, err := GetForwarderRoutes(mock.New(t))
+	require.NoError(t, err)
+	assert.Empty(t, routes)
+}
+
+func TestGetForwarderRoutesInvalid(t *testing.T) {
+	for name, datadogYaml := range map[string]string{
+		"no domain": `
+forwarder_routes:
+  - payload_types: [series]
+`,
+		"duplicate domain": `
+forwarder_routes:
+  - domain: https://app.datadoghq.eu
+  - domain: https://app.datadoghq.eu
+`,
+		"unknown payload type": `
+forwarder_routes:
+  - domain: https://app.datadoghq.eu
+    payload_types: [logs]
+`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := GetForwarderRoutes(mock.NewFromYAML(t, datadogYaml))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -420,11 +420,16 @@ func httpClientFactory(timeout time.Duration, cfg pkgconfigmodel.Reader) func()
 		transport = httputils.CreateHTTPTransport(cfg, httputils.WithHTTP2())
 	}
 
+	authenticator, err := httputils.NewAuthenticator(cfg, "logs_config.authentication")
+	if err != nil {
+		log.Errorf("Misconfiguration of the logs authentication, the requests will not be authenticated: %v", err)
+	}
+
 	return func() *http.Client {
 		client := &http.Client{
 			Timeout: timeout,
 			// reusing core agent HTTP transport to benefit from proxy settings.
-			Transport: transport,
+			Transport: httputils.WithAuthentication(transport, authenticator),
 		}
 
 		return client
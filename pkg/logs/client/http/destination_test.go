@@ -8,6 +8,7 @@ package http
 import (
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"regexp"
 	"strconv"
 	"testing"
@@ -562,3 +563,24 @@ func TestTransportProtocol_HTTP1FallBackWhenUsingProxy(t *testing.T) {
 	// Assert that the server chose HTTP/1.1 because a proxy was configured
 	assert.Equal(t, "HTTP/1.1", resp.Proto)
 }
+
+func TestHTTPClientFactoryAuthentication(t *testing.T) {
+	c := configmock.New(t)
+	c.SetWithoutSource("logs_config.authentication.type", "static_headers")
+	c.SetWithoutSource("logs_config.authentication.headers", map[string]string{"X-Gateway-Key": "secret"})
+
+	var received http.Header
+	s := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer s.Close()
+
+	client := httpClientFactory(5*time.Second, c)()
+	resp, err := client.Post(s.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("Failed to make request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	assert.Equal(t, "secret", received.Get("X-Gateway-Key"))
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build zlib && zstd && test
+
+package serializer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	metricscompression "github.com/DataDog/datadog-agent/comp/serializer/metricscompression/impl"
+	"github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer/internal/stream"
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+)
+
+// BenchmarkJSONPayloadBuilderCompression compares the compression kinds on the workloads of
+// json_payload_builder_throughput_benchmark_test.go.
+func BenchmarkJSONPayloadBuilderCompression(b *testing.B) {
+	workloads := []struct{ points, items, tags int }{
+		{0, 21000, 1},
+		{1, 20000, 1},
+		{10, 10000, 1},
+		{200, 1000, 1},
+		{1, 15000, 10},
+		{1, 2000, 100},
+		{1, 20, 10000},
+	}
+	kinds := []string{compression.ZlibKind, compression.ZstdKind, compression.GzipKind, compression.Lz4Kind, compression.SnappyKind}
+
+	build := func(b *testing.B, payloadBuilder *stream.JSONPayloadBuilder, series metrics.Series) transaction.BytesPayloads {
+		iterableSeries := metricsserializer.CreateIterableSeries(metricsserializer.CreateSerieSource(series))
+		payloads, err := payloadBuilder.BuildWithOnErrItemTooBigPolicy(iterableSeries, stream.DropItemOnErrItemTooBig)
+		require.NoError(b, err)
+		return payloads
+	}
+
+	for _, w := range workloads {
+		series := generateData(w.points, w.items, w.tags)
+		b.Run(fmt.Sprintf("%03d-points-%05d-items-%05d-tags", w.points, w.items, w.tags), func(b *testing.B) {
+			for _, kind := range kinds {
+				b.Run(kind, func(b *testing.B) {
+					mockConfig := mock.New(b)
+					mockConfig.SetWithoutSource("serializer_compressor_kind", kind)
+					compressor := metricscompression.NewCompressorReq(metricscompression.Requires{Cfg: mockConfig}).Comp
+					payloadBuilder := stream.NewJSONPayloadBuilder(true, mockConfig, compressor, logmock.New(b))
+
+					var uncompressedSize, compressedSize int
+					for _, pl := range build(b, payloadBuilder, series) {
+						content, err := compressor.Decompress(pl.GetContent())
+						require.NoError(b, err)
+						uncompressedSize += len(content)
+						compressedSize += pl.Len()
+					}
+
+					b.ResetTimer()
+					b.ReportAllocs()
+
+					var payloadCount int
+					for n := 0; n < b.N; n++ {
+						payloadCount += len(build(b, payloadBuilder, series))
+					}
+					b.ReportMetric(float64(payloadCount)/float64(b.N), "payloads")
+					b.ReportMetric(float64(compressedSize), "compressed-payload-bytes")
+					b.ReportMetric(float64(uncompressedSize)/float64(compressedSize), "ratio")
+				})
+			}
+		})
+	}
+}
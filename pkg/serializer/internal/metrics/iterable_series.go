@@ -144,18 +144,29 @@ func (series *IterableSeries) MarshalSplitCompress(bufferContext *marshaler.Buff
 // The third contains only those that pass the provided autoscaling local failover filter function.
 // This function exists because we need a way to build both payloads in a single pass over the input data, which cannot be iterated over twice.
 func (series *IterableSeries) MarshalSplitCompressMultiple(config config.Component, strategy compression.Component, filterFuncForMRF func(s *metrics.Serie) bool, filterFuncForAutoscaling func(s *metrics.Serie) bool) (transaction.BytesPayloads, transaction.BytesPayloads, transaction.BytesPayloads, error) {
-	pbs := make([]*PayloadsBuilder, 3) // 0: all, 1: MRF, 2: autoscaling
+	payloads, err := series.MarshalSplitCompressFiltered(config, strategy, filterFuncForMRF, filterFuncForAutoscaling)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return payloads[0], payloads[1], payloads[2], nil
+}
+
+// MarshalSplitCompressFiltered uses the stream compressor to marshal and compress one series into several sets of payloads.
+// The first set of payloads contains all metrics, the following ones contain only those that pass the
+// filter function of the same rank.
+func (series *IterableSeries) MarshalSplitCompressFiltered(config config.Component, strategy compression.Component, filterFuncs ...func(s *metrics.Serie) bool) ([]transaction.BytesPayloads, error) {
+	pbs := make([]*PayloadsBuilder, len(filterFuncs)+1) // 0: all, then one per filter
 	for i := range pbs {
 		bufferContext := marshaler.NewBufferContext()
 		pb, err := series.NewPayloadsBuilder(bufferContext, config, strategy)
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, err
 		}
 		pbs[i] = &pb
 
 		err = pbs[i].startPayload()
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, err
 		}
 	}
 	// Use series.source.MoveNext() instead of series.MoveNext() because this function supports
@@ -163,33 +174,30 @@ func (series *IterableSeries) MarshalSplitCompressMultiple(config config.Compone
 	for series.source.MoveNext() {
 		err := pbs[0].writeSerie(series.source.Current())
 		if err != nil {
-			return nil, nil, nil, err
-		}
-
-		if filterFuncForMRF(series.source.Current()) {
-			err = pbs[1].writeSerie(series.source.Current())
-			if err != nil {
-				return nil, nil, nil, err
-			}
+			return nil, err
 		}
 
-		if filterFuncForAutoscaling(series.source.Current()) {
-			err = pbs[2].writeSerie(series.source.Current())
-			if err != nil {
-				return nil, nil, nil, err
+		for i, filterFunc := range filterFuncs {
+			if filterFunc(series.source.Current()) {
+				err = pbs[i+1].writeSerie(series.source.Current())
+				if err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
 	// if the last payload has any data, flush it
+	payloads := make([]transaction.BytesPayloads, 0, len(pbs))
 	for i := range pbs {
 		err := pbs[i].finishPayload()
 		if err != nil {
-			return nil, nil, nil, err
+			return nil, err
 		}
+		payloads = append(payloads, pbs[i].payloads)
 	}
 
-	return pbs[0].payloads, pbs[1].payloads, pbs[2].payloads, nil
+	return payloads, nil
 }
 
 // NewPayloadsBuilder initializes a new PayloadsBuilder to be used for serializing series into a set of output payloads.
@@ -86,51 +86,54 @@ func (sl SketchSeriesList) MarshalSplitCompress(bufferContext *marshaler.BufferC
 // build both payloads in a single pass over the input data, which cannot be
 // iterated over twice.
 func (sl SketchSeriesList) MarshalSplitCompressMultiple(config config.Component, strategy compression.Component, filterFunc func(ss *metrics.SketchSeries) bool, logger log.Component) (transaction.BytesPayloads, transaction.BytesPayloads, error) {
-	var err error
-
-	bufferContext := marshaler.NewBufferContext()
-	bufferContext2 := marshaler.NewBufferContext()
-
-	pb := newPayloadsBuilder(bufferContext, config, strategy, logger)
-	pb2 := newPayloadsBuilder(bufferContext2, config, strategy, logger)
-
-	// start things off
-	err = pb.startPayload()
+	payloads, err := sl.MarshalSplitCompressFiltered(config, strategy, logger, filterFunc)
 	if err != nil {
 		return nil, nil, err
 	}
-	err = pb2.startPayload()
-	if err != nil {
-		return nil, nil, err
+	return payloads[0], payloads[1], nil
+}
+
+// MarshalSplitCompressFiltered is like MarshalSplitCompressMultiple, with one set of payloads per filter function
+// following the set containing all sketches.
+func (sl SketchSeriesList) MarshalSplitCompressFiltered(config config.Component, strategy compression.Component, logger log.Component, filterFuncs ...func(ss *metrics.SketchSeries) bool) ([]transaction.BytesPayloads, error) {
+	pbs := make([]payloadsBuilder, len(filterFuncs)+1)
+	for i := range pbs {
+		pbs[i] = newPayloadsBuilder(marshaler.NewBufferContext(), config, strategy, logger)
+
+		// start things off
+		err := pbs[i].startPayload()
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	for sl.MoveNext() {
 		ss := sl.Current()
-		err = pb.marshal(ss)
+		err := pbs[0].marshal(ss)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
-		if filterFunc(ss) {
-			err = pb2.marshal(ss)
-			if err != nil {
-				return nil, nil, err
+		for i, filterFunc := range filterFuncs {
+			if filterFunc(ss) {
+				err = pbs[i+1].marshal(ss)
+				if err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
-	err = pb.finishPayload()
-	if err != nil {
-		logger.Debugf("Failed to finish payload with err %v", err)
-		return nil, nil, err
-	}
-
-	err = pb2.finishPayload()
-	if err != nil {
-		logger.Debugf("Failed to finish payload with err %v", err)
-		return nil, nil, err
+	payloads := make([]transaction.BytesPayloads, 0, len(pbs))
+	for i := range pbs {
+		err := pbs[i].finishPayload()
+		if err != nil {
+			logger.Debugf("Failed to finish payload with err %v", err)
+			return nil, err
+		}
+		payloads = append(payloads, pbs[i].payloads)
 	}
 
-	return pb.payloads, pb2.payloads, nil
+	return payloads, nil
 }
 
 func newPayloadsBuilder(bufferContext *marshaler.BufferContext, config config.Component, strategy compression.Component, logger log.Component) payloadsBuilder {
@@ -0,0 +1,224 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package otlp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/comp/core/config"
+	log "github.com/DataDog/datadog-agent/comp/core/log/def"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	httputils "github.com/DataDog/datadog-agent/pkg/util/http"
+)
+
+var (
+	expvars                = expvar.NewMap("otlp_metrics_export")
+	expvarsPayloadsSent    = expvar.Int{}
+	expvarsPayloadErrors   = expvar.Int{}
+	expvarsPayloadsDropped = expvar.Int{}
+	expvarsMetricsExported = expvar.Int{}
+)
+
+func init() {
+	expvars.Set("PayloadsSent", &expvarsPayloadsSent)
+	expvars.Set("PayloadErrors", &expvarsPayloadErrors)
+	expvars.Set("PayloadsDropped", &expvarsPayloadsDropped)
+	expvars.Set("MetricsExported", &expvarsMetricsExported)
+}
+
+// payload is a marshalled OTLP request waiting to be sent
+type payload struct {
+	data        []byte
+	metricCount int
+}
+
+// Exporter sends series and sketches to an OTLP/HTTP endpoint, in addition to the Datadog intake.
+// The metrics are marshalled by batches of maxMetricsPerPayload while the flush is serialized, and
+// the payloads are sent by a background goroutine so that a slow endpoint does not delay the
+// flushes: payloads are dropped when too many are waiting to be sent.
+type Exporter struct {
+	log                  log.Component
+	client               *http.Client
+	endpoint             string
+	headers              http.Header
+	maxMetricsPerPayload int
+
+	// queueMutex protects the queue from being closed while a payload is enqueued
+	queueMutex sync.RWMutex
+	queue      chan payload
+	stopped    bool
+	wg         sync.WaitGroup
+}
+
+// IsEnabled returns whether the metrics must be exported to an OTLP endpoint
+func IsEnabled(config config.Component) bool {
+	return config.GetBool("otlp_metrics_export.enabled")
+}
+
+// NewExporter returns a started Exporter configured from the `otlp_metrics_export` section
+func NewExporter(config config.Component, logger log.Component) *Exporter {
+	e := newExporter(config, logger)
+	e.wg.Add(1)
+	go e.run()
+
+	logger.Infof("Exporting metrics to the OTLP endpoint %s", e.endpoint)
+	return e
+}
+
+func newExporter(config config.Component, logger log.Component) *Exporter {
+	headers := make(http.Header)
+	for name, value := range config.GetStringMapString("otlp_metrics_export.headers") {
+		headers.Set(name, value)
+	}
+	headers.Set("Content-Type", "application/x-protobuf")
+	headers.Set("Content-Encoding", "gzip")
+
+	maxMetricsPerPayload := config.GetInt("otlp_metrics_export.max_metrics_per_payload")
+	if maxMetricsPerPayload <= 0 {
+		maxMetricsPerPayload = 1000
+	}
+	queueSize := config.GetInt("otlp_metrics_export.queue_size")
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	return &Exporter{
+		log: logger,
+		client: &http.Client{
+			Timeout: time.Duration(config.GetInt("otlp_metrics_export.timeout")) * time.Second,
+			// reusing the core agent HTTP transport to benefit from the proxy and TLS settings
+			Transport: httputils.CreateHTTPTransport(config),
+		},
+		endpoint:             config.GetString("otlp_metrics_export.endpoint"),
+		headers:              headers,
+		maxMetricsPerPayload: maxMetricsPerPayload,
+		queue:                make(chan payload, queueSize),
+	}
+}
+
+// Batcher marshals the metrics added to it into OTLP payloads of at most maxMetricsPerPayload
+// metrics, so that only one batch of the flush is kept in memory.
+type Batcher[T any] struct {
+	exporter *Exporter
+	marshal  func([]T) ([]byte, error)
+	items    []T
+}
+
+// NewSeriesBatcher returns a Batcher of the series of a flush
+func (e *Exporter) NewSeriesBatcher() *Batcher[*metrics.Serie] {
+	return &Batcher[*metrics.Serie]{exporter: e, marshal: MarshalSeries}
+}
+
+// NewSketchesBatcher returns a Batcher of the sketches of a flush
+func (e *Exporter) NewSketchesBatcher() *Batcher[*metrics.SketchSeries] {
+	return &Batcher[*metrics.SketchSeries]{exporter: e, marshal: MarshalSketches}
+}
+
+// Add adds a metric to the batch, which is queued once full
+func (b *Batcher[T]) Add(item T) {
+	b.items = append(b.items, item)
+	if len(b.items) >= b.exporter.maxMetricsPerPayload {
+		b.Flush()
+	}
+}
+
+// Flush queues the metrics of the batch
+func (b *Batcher[T]) Flush() {
+	if len(b.items) == 0 {
+		return
+	}
+	data, err := b.marshal(b.items)
+	if err != nil {
+		expvarsPayloadErrors.Add(1)
+		b.exporter.log.Errorf("Could not marshal %d metrics for the OTLP endpoint %s: %v", len(b.items), b.exporter.endpoint, err)
+	} else {
+		b.exporter.enqueue(payload{data: data, metricCount: len(b.items)})
+	}
+	// release the metrics of the batch
+	clear(b.items)
+	b.items = b.items[:0]
+}
+
+// Stop sends the queued payloads and stops the exporter, the payloads queued afterwards are dropped
+func (e *Exporter) Stop() {
+	e.queueMutex.Lock()
+	if !e.stopped {
+		e.stopped = true
+		close(e.queue)
+	}
+	e.queueMutex.Unlock()
+	e.wg.Wait()
+}
+
+func (e *Exporter) enqueue(p payload) {
+	e.queueMutex.RLock()
+	defer e.queueMutex.RUnlock()
+	if e.stopped {
+		expvarsPayloadsDropped.Add(1)
+		return
+	}
+	select {
+	case e.queue <- p:
+	default:
+		expvarsPayloadsDropped.Add(1)
+		e.log.Warnf("Dropping %d metrics: too many payloads waiting to be sent to the OTLP endpoint %s", p.metricCount, e.endpoint)
+	}
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+	for p := range e.queue {
+		e.post(p)
+	}
+}
+
+func (e *Exporter) post(p payload) {
+	if err := e.postPayload(p.data); err != nil {
+		expvarsPayloadErrors.Add(1)
+		e.log.Errorf("Could not send %d metrics to the OTLP endpoint %s: %v", p.metricCount, e.endpoint, err)
+		return
+	}
+	expvarsPayloadsSent.Add(1)
+	expvarsMetricsExported.Add(int64(p.metricCount))
+}
+
+func (e *Exporter) postPayload(payload []byte) error {
+	var body bytes.Buffer
+	writer := gzip.NewWriter(&body)
+	if _, err := writer.Write(payload); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, e.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header = e.headers.Clone()
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Read the body to reuse the connection
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package otlp
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+type otlpServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	headers  []http.Header
+	payloads [][]byte
+}
+
+func newOTLPServer(t *testing.T, status int) *otlpServer {
+	s := &otlpServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reader, err := gzip.NewReader(r.Body)
+		require.NoError(t, err)
+		payload, err := io.ReadAll(reader)
+		require.NoError(t, err)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.headers = append(s.headers, r.Header)
+		s.payloads = append(s.payloads, payload)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestExporter(t *testing.T) {
+	server := newOTLPServer(t, http.StatusOK)
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("otlp_metrics_export.endpoint", server.URL+"/v1/metrics")
+	cfg.SetWithoutSource("otlp_metrics_export.headers", map[string]string{"Authorization": "Bearer token"})
+	cfg.SetWithoutSource("otlp_metrics_export.max_metrics_per_payload", 2)
+
+	series := []*metrics.Serie{
+		{Name: "a", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 10, Value: 1}}},
+		{Name: "b", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 10, Value: 2}}},
+		{Name: "c", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 10, Value: 3}}},
+	}
+	exporter := NewExporter(cfg, logmock.New(t))
+	batcher := exporter.NewSeriesBatcher()
+	for _, serie := range series {
+		batcher.Add(serie)
+	}
+	// only the metrics of the batch being built are kept
+	assert.Len(t, batcher.items, 1)
+	batcher.Flush()
+	exporter.NewSketchesBatcher().Flush()
+	exporter.Stop()
+
+	require.Len(t, server.payloads, 2)
+	for _, headers := range server.headers {
+		assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+		assert.Equal(t, "application/x-protobuf", headers.Get("Content-Type"))
+	}
+	expected, err := MarshalSeries(series[:2])
+	require.NoError(t, err)
+	assert.Equal(t, expected, server.payloads[0])
+	expected, err = MarshalSeries(series[2:])
+	require.NoError(t, err)
+	assert.Equal(t, expected, server.payloads[1])
+}
+
+func TestExporterErrors(t *testing.T) {
+	server := newOTLPServer(t, http.StatusBadRequest)
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("otlp_metrics_export.endpoint", server.URL)
+	cfg.SetWithoutSource("otlp_metrics_export.queue_size", 1)
+
+	errors := expvarsPayloadErrors.Value()
+	dropped := expvarsPayloadsDropped.Value()
+
+	// Fill the queue before starting the sender goroutine
+	exporter := newExporter(cfg, logmock.New(t))
+	batcher := exporter.NewSeriesBatcher()
+	batcher.Add(&metrics.Serie{Name: "queued", MType: metrics.APIGaugeType})
+	batcher.Flush()
+	batcher.Add(&metrics.Serie{Name: "dropped", MType: metrics.APIGaugeType})
+	batcher.Flush()
+	exporter.wg.Add(1)
+	go exporter.run()
+	exporter.Stop()
+
+	require.Len(t, server.payloads, 1)
+
+	assert.Equal(t, errors+1, expvarsPayloadErrors.Value())
+	assert.Equal(t, dropped+1, expvarsPayloadsDropped.Value())
+
+	// the payloads queued once the exporter is stopped are dropped
+	batcher.Add(&metrics.Serie{Name: "stopped", MType: metrics.APIGaugeType})
+	batcher.Flush()
+	exporter.Stop()
+	require.Len(t, server.payloads, 1)
+	assert.Equal(t, dropped+2, expvarsPayloadsDropped.Value())
+}
+
+func TestExporterTransport(t *testing.T) {
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("skip_ssl_validation", true)
+	cfg.SetWithoutSource("otlp_metrics_export.timeout", 3)
+
+	exporter := newExporter(cfg, logmock.New(t))
+	assert.Equal(t, 3*time.Second, exporter.client.Timeout)
+	transport, ok := exporter.client.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.True(t, transport.TLSClientConfig.InsecureSkipVerify)
+}
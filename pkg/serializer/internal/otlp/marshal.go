@@ -0,0 +1,400 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package otlp converts the series and sketches of the Agent to OTLP metrics and exports them
+// to an OTLP/HTTP endpoint.
+package otlp
+
+import (
+	"bytes"
+	"math"
+	"strings"
+
+	"github.com/richardartoul/molecule"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+// Field numbers of the messages we write, taken from
+// https://github.com/open-telemetry/opentelemetry-proto/blob/main/opentelemetry/proto/metrics/v1/metrics.proto
+const (
+	requestResourceMetrics = 1 // ExportMetricsServiceRequest
+
+	resourceMetricsResource     = 1 // ResourceMetrics
+	resourceMetricsScopeMetrics = 2
+
+	resourceAttributes = 1 // Resource
+
+	scopeMetricsScope   = 1 // ScopeMetrics
+	scopeMetricsMetrics = 2
+
+	scopeName    = 1 // InstrumentationScope
+	scopeVersion = 2
+
+	metricName                 = 1 // Metric
+	metricGauge                = 5
+	metricSum                  = 7
+	metricExponentialHistogram = 10
+
+	gaugeDataPoints = 1 // Gauge
+
+	sumDataPoints             = 1 // Sum
+	sumAggregationTemporality = 2
+	sumIsMonotonic            = 3
+
+	numberDataPointStartTimeUnixNano = 2 // NumberDataPoint
+	numberDataPointTimeUnixNano      = 3
+	numberDataPointAsDouble          = 4
+	numberDataPointAttributes        = 7
+
+	exponentialHistogramDataPoints             = 1 // ExponentialHistogram
+	exponentialHistogramAggregationTemporality = 2
+
+	exponentialHistogramDataPointAttributes        = 1 // ExponentialHistogramDataPoint
+	exponentialHistogramDataPointStartTimeUnixNano = 2
+	exponentialHistogramDataPointTimeUnixNano      = 3
+	exponentialHistogramDataPointCount             = 4
+	exponentialHistogramDataPointSum               = 5
+	exponentialHistogramDataPointScale             = 6
+	exponentialHistogramDataPointZeroCount         = 7
+	exponentialHistogramDataPointPositive          = 8
+	exponentialHistogramDataPointNegative          = 9
+	exponentialHistogramDataPointMin               = 12
+	exponentialHistogramDataPointMax               = 13
+
+	bucketsOffset       = 1 // ExponentialHistogramDataPoint.Buckets
+	bucketsBucketCounts = 2
+
+	keyValueKey   = 1 // KeyValue
+	keyValueValue = 2
+
+	anyValueStringValue = 1 // AnyValue
+
+	aggregationTemporalityDelta = 1
+)
+
+const (
+	scopeNameAgent     = "datadog-agent"
+	hostNameAttribute  = "host.name"
+	deviceAttribute    = "device"
+	exponentialScale   = 6
+	nanosecondsPerSec  = 1e9
+	sketchGamma        = 1 + 2.0/128
+	sketchMinimumValue = 1e-9
+)
+
+// sketchBias is the bias of the keys of the sketches built with the default quantile configuration:
+// the bin of key k holds the values close to sketchGamma^(k-sketchBias).
+var sketchBias = -int(math.Floor(math.Log(sketchMinimumValue)/math.Log(sketchGamma))) + 1
+
+// MarshalSeries returns an ExportMetricsServiceRequest with the series. Gauges are converted to OTLP gauges,
+// counts to delta sums and rates, multiplied by their interval, to delta sums.
+func MarshalSeries(series []*metrics.Serie) ([]byte, error) {
+	return marshalRequest(series, func(serie *metrics.Serie) string { return serie.Host }, writeSerie)
+}
+
+// MarshalSketches returns an ExportMetricsServiceRequest with the sketches converted to delta exponential histograms.
+func MarshalSketches(sketches []*metrics.SketchSeries) ([]byte, error) {
+	return marshalRequest(sketches, func(sketch *metrics.SketchSeries) string { return sketch.Host }, writeSketch)
+}
+
+// marshalRequest writes one ResourceMetrics per host, in the order the hosts appear in the items
+func marshalRequest[T any](items []T, host func(T) string, writeMetric func(*molecule.ProtoStream, T) error) ([]byte, error) {
+	var hosts []string
+	itemsPerHost := make(map[string][]T)
+	for _, item := range items {
+		h := host(item)
+		if _, found := itemsPerHost[h]; !found {
+			hosts = append(hosts, h)
+		}
+		itemsPerHost[h] = append(itemsPerHost[h], item)
+	}
+
+	buf := &bytes.Buffer{}
+	ps := molecule.NewProtoStream(buf)
+	for _, h := range hosts {
+		err := ps.Embedded(requestResourceMetrics, func(ps *molecule.ProtoStream) error {
+			err := ps.Embedded(resourceMetricsResource, func(ps *molecule.ProtoStream) error {
+				if h == "" {
+					return nil
+				}
+				return writeAttribute(ps, resourceAttributes, hostNameAttribute, h)
+			})
+			if err != nil {
+				return err
+			}
+			return ps.Embedded(resourceMetricsScopeMetrics, func(ps *molecule.ProtoStream) error {
+				err := ps.Embedded(scopeMetricsScope, func(ps *molecule.ProtoStream) error {
+					err := ps.String(scopeName, scopeNameAgent)
+					if err != nil {
+						return err
+					}
+					return ps.String(scopeVersion, version.AgentVersion)
+				})
+				if err != nil {
+					return err
+				}
+				for _, item := range itemsPerHost[h] {
+					err = ps.Embedded(scopeMetricsMetrics, func(ps *molecule.ProtoStream) error {
+						return writeMetric(ps, item)
+					})
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func writeSerie(ps *molecule.ProtoStream, serie *metrics.Serie) error {
+	err := ps.String(metricName, serie.Name)
+	if err != nil {
+		return err
+	}
+	attributes := tagsToAttributes(serie.Tags, serie.Device)
+
+	switch {
+	case serie.MType == metrics.APICountType || (serie.MType == metrics.APIRateType && serie.Interval > 0):
+		multiplier := 1.0
+		if serie.MType == metrics.APIRateType {
+			multiplier = float64(serie.Interval)
+		}
+		return ps.Embedded(metricSum, func(ps *molecule.ProtoStream) error {
+			for _, p := range serie.Points {
+				err := ps.Embedded(sumDataPoints, func(ps *molecule.ProtoStream) error {
+					return writeNumberDataPoint(ps, attributes, p.Ts-float64(serie.Interval), p.Ts, p.Value*multiplier)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			err := ps.Int32(sumAggregationTemporality, aggregationTemporalityDelta)
+			if err != nil {
+				return err
+			}
+			return ps.Bool(sumIsMonotonic, serie.MType == metrics.APICountType)
+		})
+	default:
+		return ps.Embedded(metricGauge, func(ps *molecule.ProtoStream) error {
+			for _, p := range serie.Points {
+				err := ps.Embedded(gaugeDataPoints, func(ps *molecule.ProtoStream) error {
+					return writeNumberDataPoint(ps, attributes, 0, p.Ts, p.Value)
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func writeNumberDataPoint(ps *molecule.ProtoStream, attributes []attribute, start, ts, value float64) error {
+	if start > 0 {
+		err := ps.Fixed64(numberDataPointStartTimeUnixNano, secondsToNanoseconds(start))
+		if err != nil {
+			return err
+		}
+	}
+	err := ps.Fixed64(numberDataPointTimeUnixNano, secondsToNanoseconds(ts))
+	if err != nil {
+		return err
+	}
+	err = ps.Double(numberDataPointAsDouble, value)
+	if err != nil {
+		return err
+	}
+	return writeAttributes(ps, numberDataPointAttributes, attributes)
+}
+
+func writeSketch(ps *molecule.ProtoStream, sketch *metrics.SketchSeries) error {
+	err := ps.String(metricName, sketch.Name)
+	if err != nil {
+		return err
+	}
+	attributes := tagsToAttributes(sketch.Tags, "")
+
+	return ps.Embedded(metricExponentialHistogram, func(ps *molecule.ProtoStream) error {
+		for _, p := range sketch.Points {
+			if p.Sketch == nil {
+				continue
+			}
+			err := ps.Embedded(exponentialHistogramDataPoints, func(ps *molecule.ProtoStream) error {
+				return writeExponentialHistogramDataPoint(ps, attributes, sketch.Interval, p)
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return ps.Int32(exponentialHistogramAggregationTemporality, aggregationTemporalityDelta)
+	})
+}
+
+func writeExponentialHistogramDataPoint(ps *molecule.ProtoStream, attributes []attribute, interval int64, p metrics.SketchPoint) error {
+	positive, negative := exponentialBuckets{}, exponentialBuckets{}
+	var zeroCount uint64
+	keys, counts := p.Sketch.Cols()
+	for i, k := range keys {
+		switch {
+		case k > 0:
+			positive.add(k, uint64(counts[i]))
+		case k < 0:
+			negative.add(-k, uint64(counts[i]))
+		default:
+			zeroCount += uint64(counts[i])
+		}
+	}
+
+	err := writeAttributes(ps, exponentialHistogramDataPointAttributes, attributes)
+	if err != nil {
+		return err
+	}
+	if interval > 0 {
+		err = ps.Fixed64(exponentialHistogramDataPointStartTimeUnixNano, secondsToNanoseconds(float64(p.Ts-interval)))
+		if err != nil {
+			return err
+		}
+	}
+	err = ps.Fixed64(exponentialHistogramDataPointTimeUnixNano, secondsToNanoseconds(float64(p.Ts)))
+	if err != nil {
+		return err
+	}
+	err = ps.Fixed64(exponentialHistogramDataPointCount, zeroCount+positive.total+negative.total)
+	if err != nil {
+		return err
+	}
+	err = ps.Double(exponentialHistogramDataPointSum, p.Sketch.Basic.Sum)
+	if err != nil {
+		return err
+	}
+	err = ps.Sint32(exponentialHistogramDataPointScale, exponentialScale)
+	if err != nil {
+		return err
+	}
+	err = ps.Fixed64(exponentialHistogramDataPointZeroCount, zeroCount)
+	if err != nil {
+		return err
+	}
+	err = positive.write(ps, exponentialHistogramDataPointPositive)
+	if err != nil {
+		return err
+	}
+	err = negative.write(ps, exponentialHistogramDataPointNegative)
+	if err != nil {
+		return err
+	}
+	err = ps.Double(exponentialHistogramDataPointMin, p.Sketch.Basic.Min)
+	if err != nil {
+		return err
+	}
+	return ps.Double(exponentialHistogramDataPointMax, p.Sketch.Basic.Max)
+}
+
+// exponentialBuckets accumulates the counts of the sketch bins of one sign in OTLP exponential buckets
+type exponentialBuckets struct {
+	counts map[int32]uint64
+	total  uint64
+}
+
+// add adds the count of the sketch bin of the (positive) key k to the exponential bucket holding its value
+func (b *exponentialBuckets) add(k int32, count uint64) {
+	if b.counts == nil {
+		b.counts = make(map[int32]uint64)
+	}
+	value := math.Pow(sketchGamma, float64(int(k)-sketchBias))
+	b.counts[exponentialBucketIndex(value)] += count
+	b.total += count
+}
+
+func (b *exponentialBuckets) write(ps *molecule.ProtoStream, fieldNumber int) error {
+	if len(b.counts) == 0 {
+		return nil
+	}
+	low, high := int32(math.MaxInt32), int32(math.MinInt32)
+	for index := range b.counts {
+		low = min(low, index)
+		high = max(high, index)
+	}
+	counts := make([]uint64, high-low+1)
+	for index, count := range b.counts {
+		counts[index-low] = count
+	}
+	return ps.Embedded(fieldNumber, func(ps *molecule.ProtoStream) error {
+		err := ps.Sint32(bucketsOffset, low)
+		if err != nil {
+			return err
+		}
+		return ps.Uint64Packed(bucketsBucketCounts, counts)
+	})
+}
+
+// exponentialBucketIndex returns the index of the bucket (base^index, base^(index+1)] holding the value,
+// where base = 2^(2^-exponentialScale)
+func exponentialBucketIndex(value float64) int32 {
+	return int32(math.Ceil(math.Log2(value)*(1<<exponentialScale))) - 1
+}
+
+type attribute struct {
+	key   string
+	value string
+}
+
+// tagsToAttributes converts the `key:value` tags to attributes. Tags without a value become attributes
+// with an empty value and the values of the tags sharing the same key are joined with a comma.
+func tagsToAttributes(tags tagset.CompositeTags, device string) []attribute {
+	var attributes []attribute
+	indexes := make(map[string]int)
+	add := func(key, value string) {
+		if i, found := indexes[key]; found {
+			attributes[i].value += "," + value
+			return
+		}
+		indexes[key] = len(attributes)
+		attributes = append(attributes, attribute{key: key, value: value})
+	}
+
+	tags.ForEach(func(tag string) {
+		key, value, _ := strings.Cut(tag, ":")
+		add(key, value)
+	})
+	if device != "" {
+		add(deviceAttribute, device)
+	}
+	return attributes
+}
+
+func writeAttributes(ps *molecule.ProtoStream, fieldNumber int, attributes []attribute) error {
+	for _, a := range attributes {
+		err := writeAttribute(ps, fieldNumber, a.key, a.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeAttribute(ps *molecule.ProtoStream, fieldNumber int, key, value string) error {
+	return ps.Embedded(fieldNumber, func(ps *molecule.ProtoStream) error {
+		err := ps.String(keyValueKey, key)
+		if err != nil {
+			return err
+		}
+		return ps.Embedded(keyValueValue, func(ps *molecule.ProtoStream) error {
+			return ps.String(anyValueStringValue, value)
+		})
+	})
+}
+
+func secondsToNanoseconds(ts float64) uint64 {
+	return uint64(ts * nanosecondsPerSec)
+}
@@ -0,0 +1,211 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test
+
+package otlp
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/quantile"
+	"github.com/protocolbuffers/protoscope"
+	"github.com/richardartoul/molecule"
+	"github.com/richardartoul/molecule/src/codec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+	"github.com/DataDog/datadog-agent/pkg/version"
+)
+
+func requireProtoscope(t *testing.T, expected string, payload []byte) {
+	t.Helper()
+	res, err := protoscope.NewScanner(expected).Exec()
+	require.NoError(t, err)
+	require.Equal(t, res, payload, "payload was:\n%s", protoscope.Write(payload, protoscope.WriterOptions{}))
+}
+
+// fieldValues returns the values of the field at the end of the path of embedded messages
+func fieldValues(t *testing.T, payload []byte, path ...int32) []molecule.Value {
+	t.Helper()
+	var values []molecule.Value
+	err := molecule.MessageEach(codec.NewBuffer(payload), func(fieldNum int32, value molecule.Value) (bool, error) {
+		if fieldNum != path[0] {
+			return true, nil
+		}
+		if len(path) == 1 {
+			values = append(values, value)
+			return true, nil
+		}
+		embedded, err := value.AsBytesSafe()
+		require.NoError(t, err)
+		values = append(values, fieldValues(t, embedded, path[1:]...)...)
+		return true, nil
+	})
+	require.NoError(t, err)
+	return values
+}
+
+func TestMarshalSeries(t *testing.T) {
+	series := []*metrics.Serie{
+		{
+			Name:   "gauge",
+			Host:   "host1",
+			MType:  metrics.APIGaugeType,
+			Tags:   tagset.CompositeTagsFromSlice([]string{"env:prod", "standalone"}),
+			Points: []metrics.Point{{Ts: 10, Value: 1.5}},
+		},
+		{
+			Name:     "count",
+			Host:     "host2",
+			MType:    metrics.APICountType,
+			Interval: 10,
+			Tags:     tagset.CompositeTagsFromSlice([]string{"team:a", "team:b"}),
+			Points:   []metrics.Point{{Ts: 20, Value: 3}},
+		},
+		{
+			Name:     "rate",
+			Host:     "host1",
+			MType:    metrics.APIRateType,
+			Interval: 10,
+			Device:   "sda",
+			Points:   []metrics.Point{{Ts: 20, Value: 0.5}},
+		},
+	}
+
+	payload, err := MarshalSeries(series)
+	require.NoError(t, err)
+
+	scope := fmt.Sprintf(`1: { 1: {"datadog-agent"} 2: {%q} }`, version.AgentVersion)
+	requireProtoscope(t, fmt.Sprintf(`
+	1: {
+		1: { 1: { 1: {"host.name"} 2: { 1: {"host1"} } } }
+		2: {
+			%[1]s
+			2: {
+				1: {"gauge"}
+				5: { 1: {
+					3: 10000000000i64
+					4: 1.5
+					7: { 1: {"env"} 2: { 1: {"prod"} } }
+					7: { 1: {"standalone"} 2: {} }
+				} }
+			}
+			2: {
+				1: {"rate"}
+				7: {
+					1: {
+						2: 10000000000i64
+						3: 20000000000i64
+						4: 5.0
+						7: { 1: {"device"} 2: { 1: {"sda"} } }
+					}
+					2: 1
+				}
+			}
+		}
+	}
+	1: {
+		1: { 1: { 1: {"host.name"} 2: { 1: {"host2"} } } }
+		2: {
+			%[1]s
+			2: {
+				1: {"count"}
+				7: {
+					1: {
+						2: 10000000000i64
+						3: 20000000000i64
+						4: 3.0
+						7: { 1: {"team"} 2: { 1: {"a,b"} } }
+					}
+					2: 1
+					3: 1
+				}
+			}
+		}
+	}`, scope), payload)
+}
+
+func TestMarshalSketches(t *testing.T) {
+	values := []float64{0, 1, 1, 2, 1000, -3}
+	sketch := &quantile.Sketch{}
+	sketch.Insert(quantile.Default(), values...)
+
+	payload, err := MarshalSketches([]*metrics.SketchSeries{{
+		Name:     "distribution",
+		Host:     "host",
+		Interval: 10,
+		Points:   []metrics.SketchPoint{{Sketch: sketch, Ts: 20}},
+	}})
+	require.NoError(t, err)
+
+	// ExportMetricsServiceRequest > ResourceMetrics > ScopeMetrics > Metric > ExponentialHistogram > DataPoint
+	histogram := []int32{requestResourceMetrics, resourceMetricsScopeMetrics, scopeMetricsMetrics, metricExponentialHistogram}
+	field := func(fieldNumber ...int32) []molecule.Value {
+		return fieldValues(t, payload, slices.Concat(histogram, []int32{exponentialHistogramDataPoints}, fieldNumber)...)
+	}
+
+	temporality := fieldValues(t, payload, slices.Concat(histogram, []int32{exponentialHistogramAggregationTemporality})...)
+	require.Len(t, temporality, 1)
+	assert.EqualValues(t, aggregationTemporalityDelta, temporality[0].Number)
+
+	count, err := field(exponentialHistogramDataPointCount)[0].AsFixed64()
+	require.NoError(t, err)
+	assert.EqualValues(t, len(values), count)
+	zeroCount, err := field(exponentialHistogramDataPointZeroCount)[0].AsFixed64()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, zeroCount)
+	start, err := field(exponentialHistogramDataPointStartTimeUnixNano)[0].AsFixed64()
+	require.NoError(t, err)
+	assert.EqualValues(t, 10*nanosecondsPerSec, start)
+	sum, err := field(exponentialHistogramDataPointSum)[0].AsDouble()
+	require.NoError(t, err)
+	assert.Equal(t, 1001.0, sum)
+	maximum, err := field(exponentialHistogramDataPointMax)[0].AsDouble()
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, maximum)
+
+	// Each value is in the bucket of its index or in a neighbour one, as the sketch bins are approximate
+	buckets := func(fieldNumber int32) map[int32]uint64 {
+		offset, err := field(fieldNumber, bucketsOffset)[0].AsSint32()
+		require.NoError(t, err)
+		packed, err := field(fieldNumber, bucketsBucketCounts)[0].AsBytesSafe()
+		require.NoError(t, err)
+		counts := make(map[int32]uint64)
+		index := offset
+		err = molecule.PackedRepeatedEach(codec.NewBuffer(packed), codec.FieldType_UINT64, func(value molecule.Value) (bool, error) {
+			if value.Number > 0 {
+				counts[index] = value.Number
+			}
+			index++
+			return true, nil
+		})
+		require.NoError(t, err)
+		return counts
+	}
+	positive := buckets(exponentialHistogramDataPointPositive)
+	assert.Len(t, positive, 3)
+	for _, v := range []float64{1, 2, 1000} {
+		index := exponentialBucketIndex(v)
+		assert.NotZero(t, positive[index-1]+positive[index]+positive[index+1], "no bucket for %f", v)
+	}
+	negative := buckets(exponentialHistogramDataPointNegative)
+	index := exponentialBucketIndex(3)
+	assert.EqualValues(t, 1, negative[index-1]+negative[index]+negative[index+1])
+}
+
+func TestExponentialBucketIndex(t *testing.T) {
+	base := math.Pow(2, math.Pow(2, -exponentialScale))
+	for _, v := range []float64{1e-6, 0.5, 1, 1.5, 2, 1e3, 1e9} {
+		index := exponentialBucketIndex(v)
+		assert.Less(t, math.Pow(base, float64(index)), v*(1+1e-9))
+		assert.GreaterOrEqual(t, math.Pow(base, float64(index+1))*(1+1e-9), v)
+	}
+}
@@ -37,8 +37,10 @@ func TestCompressorSimple(t *testing.T) {
 	tests := map[string]struct {
 		kind string
 	}{
-		"zlib": {kind: compression.ZlibKind},
-		"zstd": {kind: compression.ZstdKind},
+		"zlib":   {kind: compression.ZlibKind},
+		"zstd":   {kind: compression.ZstdKind},
+		"lz4":    {kind: compression.Lz4Kind},
+		"snappy": {kind: compression.SnappyKind},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -72,8 +74,10 @@ func TestCompressorAddItemErrCodeWithEmptyCompressor(t *testing.T) {
 	tests := map[string]struct {
 		kind string
 	}{
-		"zlib": {kind: compression.ZlibKind},
-		"zstd": {kind: compression.ZstdKind},
+		"zlib":   {kind: compression.ZlibKind},
+		"zstd":   {kind: compression.ZstdKind},
+		"lz4":    {kind: compression.Lz4Kind},
+		"snappy": {kind: compression.SnappyKind},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -119,8 +123,10 @@ func TestOnePayloadSimple(t *testing.T) {
 	tests := map[string]struct {
 		kind string
 	}{
-		"zlib": {kind: compression.ZlibKind},
-		"zstd": {kind: compression.ZstdKind},
+		"zlib":   {kind: compression.ZlibKind},
+		"zstd":   {kind: compression.ZstdKind},
+		"lz4":    {kind: compression.Lz4Kind},
+		"snappy": {kind: compression.SnappyKind},
 	}
 	logger := logmock.New(t)
 	for name, tc := range tests {
@@ -150,8 +156,10 @@ func TestMaxCompressedSizePayload(t *testing.T) {
 		kind           string
 		maxPayloadSize int
 	}{
-		"zlib": {kind: compression.ZlibKind, maxPayloadSize: 22},
-		"zstd": {kind: compression.ZstdKind, maxPayloadSize: 90},
+		"zlib":   {kind: compression.ZlibKind, maxPayloadSize: 22},
+		"zstd":   {kind: compression.ZstdKind, maxPayloadSize: 90},
+		"lz4":    {kind: compression.Lz4Kind, maxPayloadSize: 90},
+		"snappy": {kind: compression.SnappyKind, maxPayloadSize: 90},
 	}
 	logger := logmock.New(t)
 	for name, tc := range tests {
@@ -206,8 +214,10 @@ func TestTwoPayload(t *testing.T) {
 		kind           string
 		maxPayloadSize int
 	}{
-		"zlib": {kind: compression.ZlibKind, maxPayloadSize: 22},
-		"zstd": {kind: compression.ZstdKind, maxPayloadSize: 70},
+		"zlib":   {kind: compression.ZlibKind, maxPayloadSize: 22},
+		"zstd":   {kind: compression.ZstdKind, maxPayloadSize: 70},
+		"lz4":    {kind: compression.Lz4Kind, maxPayloadSize: 46},
+		"snappy": {kind: compression.SnappyKind, maxPayloadSize: 33},
 	}
 	logger := logmock.New(t)
 	for name, tc := range tests {
@@ -237,8 +247,10 @@ func TestLockedCompressorProducesSamePayloads(t *testing.T) {
 	tests := map[string]struct {
 		kind string
 	}{
-		"zlib": {kind: compression.ZlibKind},
-		"zstd": {kind: compression.ZstdKind},
+		"zlib":   {kind: compression.ZlibKind},
+		"zstd":   {kind: compression.ZstdKind},
+		"lz4":    {kind: compression.Lz4Kind},
+		"snappy": {kind: compression.SnappyKind},
 	}
 	logger := logmock.New(t)
 	for name, tc := range tests {
@@ -269,8 +281,10 @@ func TestBuildWithOnErrItemTooBigPolicyMetadata(t *testing.T) {
 		kind                       string
 		maxUncompressedPayloadSize int
 	}{
-		"zlib": {kind: compression.ZlibKind, maxUncompressedPayloadSize: 40},
-		"zstd": {kind: compression.ZstdKind, maxUncompressedPayloadSize: 170},
+		"zlib":   {kind: compression.ZlibKind, maxUncompressedPayloadSize: 40},
+		"zstd":   {kind: compression.ZstdKind, maxUncompressedPayloadSize: 170},
+		"lz4":    {kind: compression.Lz4Kind, maxUncompressedPayloadSize: 170},
+		"snappy": {kind: compression.SnappyKind, maxUncompressedPayloadSize: 170},
 	}
 	logger := logmock.New(t)
 	for name, tc := range tests {
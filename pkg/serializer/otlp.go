@@ -0,0 +1,56 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package serializer
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer/internal/otlp"
+)
+
+// otlpSerieSource exports the series read from the source to the OTLP endpoint while they are
+// serialized for the Datadog intake, the source being read only once.
+type otlpSerieSource struct {
+	metrics.SerieSource
+	batcher *otlp.Batcher[*metrics.Serie]
+}
+
+func (s *otlpSerieSource) MoveNext() bool {
+	if !s.SerieSource.MoveNext() {
+		return false
+	}
+	s.batcher.Add(s.SerieSource.Current())
+	return true
+}
+
+// otlpSketchesSource exports the sketches read from the source, see otlpSerieSource
+type otlpSketchesSource struct {
+	metrics.SketchesSource
+	batcher *otlp.Batcher[*metrics.SketchSeries]
+}
+
+func (s *otlpSketchesSource) MoveNext() bool {
+	if !s.SketchesSource.MoveNext() {
+		return false
+	}
+	s.batcher.Add(s.SketchesSource.Current())
+	return true
+}
+
+// sendIterableSeriesToOTLP sends the series to the Datadog intake and to the OTLP endpoint
+func (s *Serializer) sendIterableSeriesToOTLP(serieSource metrics.SerieSource) error {
+	source := &otlpSerieSource{SerieSource: serieSource, batcher: s.otlpExporter.NewSeriesBatcher()}
+	err := s.sendIterableSeries(source)
+	source.batcher.Flush()
+	return err
+}
+
+// sendSketchToOTLP sends the sketches to the Datadog intake and to the OTLP endpoint
+func (s *Serializer) sendSketchToOTLP(sketches metrics.SketchesSource) error {
+	source := &otlpSketchesSource{SketchesSource: sketches, batcher: s.otlpExporter.NewSketchesBatcher()}
+	err := s.sendSketch(source)
+	source.batcher.Flush()
+	return err
+}
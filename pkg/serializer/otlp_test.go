@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test && zlib && zstd
+
+package serializer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	forwarder "github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder"
+	metricscompressionimpl "github.com/DataDog/datadog-agent/comp/serializer/metricscompression/impl"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
+)
+
+func TestSendToOTLP(t *testing.T) {
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	f := &forwarder.MockedForwarder{}
+	mockConfig := configmock.New(t)
+	mockConfig.SetWithoutSource("otlp_metrics_export.enabled", true)
+	mockConfig.SetWithoutSource("otlp_metrics_export.endpoint", server.URL)
+	compressor := metricscompressionimpl.NewCompressorReq(metricscompressionimpl.Requires{Cfg: mockConfig}).Comp
+	s := NewSerializer(f, nil, compressor, mockConfig, logmock.New(t), "testhost")
+	require.NotNil(t, s.otlpExporter)
+
+	f.On("SubmitSeries", mock.Anything, s.protobufExtraHeadersWithCompression).Return(nil).Times(1)
+	f.On("SubmitSketchSeries", mock.Anything, s.protobufExtraHeadersWithCompression).Return(nil).Times(1)
+
+	series := metrics.Series{&metrics.Serie{Name: "foo", MType: metrics.APIGaugeType, Points: []metrics.Point{{Ts: 10, Value: 1}}}}
+	require.NoError(t, s.SendIterableSeries(metricsserializer.CreateSerieSource(series)))
+	require.NoError(t, s.SendSketch(metrics.NewSketchesSourceTestWithSketch()))
+	s.Stop()
+
+	// The metrics are sent to both the Datadog intake and the OTLP endpoint
+	f.AssertExpectations(t)
+	assert.Equal(t, 2, requests)
+}
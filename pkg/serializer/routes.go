@@ -0,0 +1,110 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package serializer
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	"github.com/DataDog/datadog-agent/pkg/config/utils"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+// metricRoute is a forwarder route filtering series and sketches on their name and tags.
+// The payloads it builds are only sent to the domain of the route by the forwarder.
+type metricRoute struct {
+	domain   string
+	series   bool
+	sketches bool
+	prefixes []string
+	tags     map[string]struct{}
+}
+
+// newMetricRoutes returns the routes of the configuration that filter metrics
+func newMetricRoutes(routes []utils.ForwarderRoute) []*metricRoute {
+	var metricRoutes []*metricRoute
+	for _, route := range routes {
+		if !route.HasMetricPredicates() {
+			continue
+		}
+		r := &metricRoute{
+			domain:   route.Domain,
+			series:   route.AcceptsPayloadType(utils.RoutePayloadSeries),
+			sketches: route.AcceptsPayloadType(utils.RoutePayloadSketches),
+			prefixes: route.MetricPrefixes,
+		}
+		if len(route.Tags) > 0 {
+			r.tags = make(map[string]struct{}, len(route.Tags))
+			for _, tag := range route.Tags {
+				r.tags[tag] = struct{}{}
+			}
+		}
+		metricRoutes = append(metricRoutes, r)
+	}
+	return metricRoutes
+}
+
+// match returns whether a metric matches the name prefix and tag predicates of the route
+func (r *metricRoute) match(name string, tags tagset.CompositeTags) bool {
+	if len(r.prefixes) > 0 {
+		matched := false
+		for _, prefix := range r.prefixes {
+			if strings.HasPrefix(name, prefix) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(r.tags) > 0 {
+		return tags.Find(func(tag string) bool {
+			_, found := r.tags[tag]
+			return found
+		})
+	}
+	return true
+}
+
+func (r *metricRoute) matchSerie(serie *metrics.Serie) bool {
+	return r.match(serie.Name, serie.Tags)
+}
+
+func (r *metricRoute) matchSketch(sketch *metrics.SketchSeries) bool {
+	return r.match(sketch.Name, sketch.Tags)
+}
+
+// routePayloads marks the payloads built for the route so that the forwarder only sends them to its domain
+func (r *metricRoute) routePayloads(payloads transaction.BytesPayloads) transaction.BytesPayloads {
+	for _, payload := range payloads {
+		payload.Route = r.domain
+	}
+	return payloads
+}
+
+// seriesRoutes returns the routes selecting series
+func (s *Serializer) seriesRoutes() []*metricRoute {
+	var routes []*metricRoute
+	for _, route := range s.metricRoutes {
+		if route.series {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// sketchesRoutes returns the routes selecting sketches
+func (s *Serializer) sketchesRoutes() []*metricRoute {
+	var routes []*metricRoute
+	for _, route := range s.metricRoutes {
+		if route.sketches {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
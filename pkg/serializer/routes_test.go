@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build test && zlib && zstd
+
+package serializer
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	logmock "github.com/DataDog/datadog-agent/comp/core/log/mock"
+	forwarder "github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder"
+	"github.com/DataDog/datadog-agent/comp/forwarder/defaultforwarder/transaction"
+	metricscompressionimpl "github.com/DataDog/datadog-agent/comp/serializer/metricscompression/impl"
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	"github.com/DataDog/datadog-agent/pkg/config/utils"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/tagset"
+)
+
+func TestMetricRouteMatch(t *testing.T) {
+	routes := newMetricRoutes([]utils.ForwarderRoute{
+		{Domain: "https://checks.example.com", PayloadTypes: []string{utils.RoutePayloadServiceChecks}},
+		{Domain: "https://app.datadoghq.eu", MetricPrefixes: []string{"system.", "kubernetes."}, Tags: []string{"env:prod"}},
+	})
+	require.Len(t, routes, 1)
+	route := routes[0]
+	assert.True(t, route.series)
+	assert.True(t, route.sketches)
+
+	assert.True(t, route.match("system.cpu.user", tagset.CompositeTagsFromSlice([]string{"host:a", "env:prod"})))
+	assert.True(t, route.match("kubernetes.cpu.usage", tagset.NewCompositeTags([]string{"host:a"}, []string{"env:prod"})))
+	assert.False(t, route.match("system.cpu.user", tagset.CompositeTagsFromSlice([]string{"env:staging"})))
+	assert.False(t, route.match("custom.metric", tagset.CompositeTagsFromSlice([]string{"env:prod"})))
+}
+
+func newRoutesTestSerializer(t *testing.T, f forwarder.Forwarder) *Serializer {
+	mockConfig := configmock.New(t)
+	mockConfig.SetWithoutSource("forwarder_routes", []map[string]interface{}{
+		{"domain": "https://app.datadoghq.eu", "payload_types": []string{"series", "sketches"}, "metric_prefixes": []string{"system."}},
+	})
+	compressor := metricscompressionimpl.NewCompressorReq(metricscompressionimpl.Requires{Cfg: mockConfig}).Comp
+	return NewSerializer(f, nil, compressor, mockConfig, logmock.New(t), "testhost")
+}
+
+// routedPayloads returns the decompressed payloads sent for each route, "" standing for every domain
+func routedPayloads(t *testing.T, s *Serializer, payloads transaction.BytesPayloads) map[string][][]byte {
+	routed := map[string][][]byte{}
+	for _, payload := range payloads {
+		content, err := s.Strategy.Decompress(payload.GetContent())
+		require.NoError(t, err)
+		routed[payload.Route] = append(routed[payload.Route], content)
+	}
+	return routed
+}
+
+func TestSendSeriesWithRoutes(t *testing.T) {
+	f := &forwarder.MockedForwarder{}
+	s := newRoutesTestSerializer(t, f)
+
+	var payloads transaction.BytesPayloads
+	f.On("SubmitSeries", mock.Anything, s.protobufExtraHeadersWithCompression).Return(nil).Run(func(args mock.Arguments) {
+		payloads = args.Get(0).(transaction.BytesPayloads)
+	}).Times(1)
+
+	err := s.SendIterableSeries(metricsserializer.CreateSerieSource(metrics.Series{
+		&metrics.Serie{Name: "system.cpu.user"},
+		&metrics.Serie{Name: "custom.metric"},
+	}))
+	require.NoError(t, err)
+	f.AssertExpectations(t)
+
+	routed := routedPayloads(t, s, payloads)
+	require.Len(t, routed[""], 1)
+	assert.True(t, bytes.Contains(routed[""][0], []byte("system.cpu.user")))
+	assert.True(t, bytes.Contains(routed[""][0], []byte("custom.metric")))
+
+	require.Len(t, routed["https://app.datadoghq.eu"], 1)
+	assert.True(t, bytes.Contains(routed["https://app.datadoghq.eu"][0], []byte("system.cpu.user")))
+	assert.False(t, bytes.Contains(routed["https://app.datadoghq.eu"][0], []byte("custom.metric")))
+}
+
+func TestSendSketchWithRoutes(t *testing.T) {
+	f := &forwarder.MockedForwarder{}
+	s := newRoutesTestSerializer(t, f)
+
+	var payloads transaction.BytesPayloads
+	f.On("SubmitSketchSeries", mock.Anything, s.protobufExtraHeadersWithCompression).Return(nil).Run(func(args mock.Arguments) {
+		payloads = args.Get(0).(transaction.BytesPayloads)
+	}).Times(1)
+
+	sketches := metrics.NewSketchesSourceTest()
+	sketches.Append(&metrics.SketchSeries{Name: "system.load", Host: "fakehost"})
+	sketches.Append(&metrics.SketchSeries{Name: "custom.latency", Host: "fakehost"})
+	require.NoError(t, s.SendSketch(sketches))
+	f.AssertExpectations(t)
+
+	routed := routedPayloads(t, s, payloads)
+	require.Len(t, routed[""], 1)
+	assert.True(t, bytes.Contains(routed[""][0], []byte("custom.latency")))
+
+	require.Len(t, routed["https://app.datadoghq.eu"], 1)
+	assert.True(t, bytes.Contains(routed["https://app.datadoghq.eu"][0], []byte("system.load")))
+	assert.False(t, bytes.Contains(routed["https://app.datadoghq.eu"][0], []byte("custom.latency")))
+}
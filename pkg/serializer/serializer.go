@@ -21,11 +21,13 @@ import (
 	orchestratorForwarder "github.com/DataDog/datadog-agent/comp/forwarder/orchestrator/orchestratorinterface"
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
+	"github.com/DataDog/datadog-agent/pkg/config/utils"
 	"github.com/DataDog/datadog-agent/pkg/metrics"
 	"github.com/DataDog/datadog-agent/pkg/metrics/event"
 	"github.com/DataDog/datadog-agent/pkg/metrics/servicecheck"
 	"github.com/DataDog/datadog-agent/pkg/process/util/api/headers"
 	metricsserializer "github.com/DataDog/datadog-agent/pkg/serializer/internal/metrics"
+	"github.com/DataDog/datadog-agent/pkg/serializer/internal/otlp"
 	"github.com/DataDog/datadog-agent/pkg/serializer/internal/stream"
 	"github.com/DataDog/datadog-agent/pkg/serializer/marshaler"
 	"github.com/DataDog/datadog-agent/pkg/serializer/split"
@@ -127,6 +129,12 @@ type Serializer struct {
 	enableSketchProtobufStream    bool
 	hostname                      string
 	logger                        log.Component
+
+	// metricRoutes are the forwarder routes filtering series and sketches before they are split per domain
+	metricRoutes []*metricRoute
+
+	// otlpExporter sends series and sketches to an OTLP endpoint in addition to the Datadog intake, when enabled
+	otlpExporter *otlp.Exporter
 }
 
 // NewSerializer returns a new Serializer initialized
@@ -158,6 +166,19 @@ func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder orchestr
 
 	initExtraHeaders(s)
 
+	routes, err := utils.GetForwarderRoutes(config)
+	if err != nil {
+		logger.Errorf("Ignoring the forwarder routes: %v", err)
+	}
+	s.metricRoutes = newMetricRoutes(routes)
+	if len(s.metricRoutes) > 0 && (!config.GetBool("use_v2_api.series") || !s.enableSketchProtobufStream) {
+		logger.Warn("forwarder routes filtering metrics require 'use_v2_api.series' and 'enable_sketch_stream_payload_serialization': the domains of these routes will not receive the other metric payloads")
+	}
+
+	if otlp.IsEnabled(config) {
+		s.otlpExporter = otlp.NewExporter(config, logger)
+	}
+
 	if !s.enableEvents {
 		logger.Warn("event payloads are disabled: all events will be dropped")
 	}
@@ -181,6 +202,13 @@ func NewSerializer(forwarder forwarder.Forwarder, orchestratorForwarder orchestr
 	return s
 }
 
+// Stop stops the background senders of the serializer, once the last payloads have been sent to it
+func (s *Serializer) Stop() {
+	if s.otlpExporter != nil {
+		s.otlpExporter.Stop()
+	}
+}
+
 func (s Serializer) serializePayload(
 	jsonMarshaler marshaler.JSONMarshaler,
 	protoMarshaler marshaler.ProtoMarshaler,
@@ -335,6 +363,13 @@ func (s *Serializer) SendIterableSeries(serieSource metrics.SerieSource) error {
 		return nil
 	}
 
+	if s.otlpExporter != nil {
+		return s.sendIterableSeriesToOTLP(serieSource)
+	}
+	return s.sendIterableSeries(serieSource)
+}
+
+func (s *Serializer) sendIterableSeries(serieSource metrics.SerieSource) error {
 	seriesSerializer := metricsserializer.CreateIterableSeries(serieSource)
 	useV1API := !s.config.GetBool("use_v2_api.series")
 
@@ -350,30 +385,49 @@ func (s *Serializer) SendIterableSeries(serieSource metrics.SerieSource) error {
 		failoverActiveForMRF, allowlistForMRF := s.getFailoverAllowlist()
 		failoverActiveForAutoscaling, allowlistForAutoscaling := s.getAutoscalingFailoverMetrics()
 		failoverActive := (failoverActiveForMRF && len(allowlistForMRF) > 0) || (failoverActiveForAutoscaling && len(allowlistForAutoscaling) > 0)
-		if failoverActive {
-			var filtered transaction.BytesPayloads
-			var localAutoscalingFaioverPayloads transaction.BytesPayloads
-			seriesBytesPayloads, filtered, localAutoscalingFaioverPayloads, err = seriesSerializer.MarshalSplitCompressMultiple(s.config, s.Strategy,
-				func(s *metrics.Serie) bool { // Filter for MRF
-					_, allowed := allowlistForMRF[s.Name]
-					return allowed
-				},
-				func(s *metrics.Serie) bool { // Filter for Autoscaling
-					_, allowed := allowlistForAutoscaling[s.Name]
-					return allowed
-				})
-
-			for _, seriesBytesPayload := range seriesBytesPayloads {
-				seriesBytesPayload.Destination = transaction.PrimaryOnly
+		routes := s.seriesRoutes()
+		if failoverActive || len(routes) > 0 {
+			var filterFuncs []func(s *metrics.Serie) bool
+			if failoverActive {
+				filterFuncs = append(filterFuncs,
+					func(s *metrics.Serie) bool { // Filter for MRF
+						_, allowed := allowlistForMRF[s.Name]
+						return allowed
+					},
+					func(s *metrics.Serie) bool { // Filter for Autoscaling
+						_, allowed := allowlistForAutoscaling[s.Name]
+						return allowed
+					})
 			}
-			for _, seriesBytesPayload := range filtered {
-				seriesBytesPayload.Destination = transaction.SecondaryOnly
+			for _, route := range routes {
+				filterFuncs = append(filterFuncs, route.matchSerie)
 			}
-			for _, seriesBytesPayload := range localAutoscalingFaioverPayloads {
-				seriesBytesPayload.Destination = transaction.LocalOnly
+
+			var payloads []transaction.BytesPayloads
+			payloads, err = seriesSerializer.MarshalSplitCompressFiltered(s.config, s.Strategy, filterFuncs...)
+			if err == nil {
+				seriesBytesPayloads = payloads[0]
+				payloads = payloads[1:]
+				if failoverActive {
+					filtered, localAutoscalingFaioverPayloads := payloads[0], payloads[1]
+					payloads = payloads[2:]
+
+					for _, seriesBytesPayload := range seriesBytesPayloads {
+						seriesBytesPayload.Destination = transaction.PrimaryOnly
+					}
+					for _, seriesBytesPayload := range filtered {
+						seriesBytesPayload.Destination = transaction.SecondaryOnly
+					}
+					for _, seriesBytesPayload := range localAutoscalingFaioverPayloads {
+						seriesBytesPayload.Destination = transaction.LocalOnly
+					}
+					seriesBytesPayloads = append(seriesBytesPayloads, filtered...)
+					seriesBytesPayloads = append(seriesBytesPayloads, localAutoscalingFaioverPayloads...)
+				}
+				for i, route := range routes {
+					seriesBytesPayloads = append(seriesBytesPayloads, route.routePayloads(payloads[i])...)
+				}
 			}
-			seriesBytesPayloads = append(seriesBytesPayloads, filtered...)
-			seriesBytesPayloads = append(seriesBytesPayloads, localAutoscalingFaioverPayloads...)
 		} else {
 			seriesBytesPayloads, err = seriesSerializer.MarshalSplitCompress(marshaler.NewBufferContext(), s.config, s.Strategy)
 			for _, seriesBytesPayload := range seriesBytesPayloads {
@@ -438,24 +492,49 @@ func (s *Serializer) SendSketch(sketches metrics.SketchesSource) error {
 		s.logger.Debug("sketches payloads are disabled: dropping it")
 		return nil
 	}
+	if s.otlpExporter != nil {
+		return s.sendSketchToOTLP(sketches)
+	}
+	return s.sendSketch(sketches)
+}
+
+func (s *Serializer) sendSketch(sketches metrics.SketchesSource) error {
 	sketchesSerializer := metricsserializer.SketchSeriesList{SketchesSource: sketches}
 	if s.enableSketchProtobufStream {
 		failoverActive, allowlist := s.getFailoverAllowlist()
-		if failoverActive && len(allowlist) > 0 {
-			payloads, filteredPayloads, err := sketchesSerializer.MarshalSplitCompressMultiple(s.config, s.Strategy, func(ss *metrics.SketchSeries) bool {
-				_, allowed := allowlist[ss.Name]
-				return allowed
-			}, s.logger)
+		failoverActive = failoverActive && len(allowlist) > 0
+		routes := s.sketchesRoutes()
+		if failoverActive || len(routes) > 0 {
+			var filterFuncs []func(ss *metrics.SketchSeries) bool
+			if failoverActive {
+				filterFuncs = append(filterFuncs, func(ss *metrics.SketchSeries) bool {
+					_, allowed := allowlist[ss.Name]
+					return allowed
+				})
+			}
+			for _, route := range routes {
+				filterFuncs = append(filterFuncs, route.matchSketch)
+			}
+
+			filteredPayloads, err := sketchesSerializer.MarshalSplitCompressFiltered(s.config, s.Strategy, s.logger, filterFuncs...)
 			if err != nil {
 				return fmt.Errorf("dropping sketch payload: %v", err)
 			}
-			for _, payload := range payloads {
-				payload.Destination = transaction.PrimaryOnly
+			payloads := filteredPayloads[0]
+			filteredPayloads = filteredPayloads[1:]
+			if failoverActive {
+				for _, payload := range payloads {
+					payload.Destination = transaction.PrimaryOnly
+				}
+				for _, payload := range filteredPayloads[0] {
+					payload.Destination = transaction.SecondaryOnly
+				}
+				payloads = append(payloads, filteredPayloads[0]...)
+				filteredPayloads = filteredPayloads[1:]
 			}
-			for _, payload := range filteredPayloads {
-				payload.Destination = transaction.SecondaryOnly
+			for i, route := range routes {
+				payloads = append(payloads, route.routePayloads(filteredPayloads[i])...)
 			}
-			payloads = append(payloads, filteredPayloads...)
 
 			return s.Forwarder.SubmitSketchSeries(payloads, s.protobufExtraHeadersWithCompression)
 		} else {
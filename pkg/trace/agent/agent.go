@@ -104,6 +104,10 @@ type Agent struct {
 	Statsd                statsd.ClientInterface
 	Timing                timing.Reporter
 
+	// DryRun holds the payloads and sampling decisions produced by the agent when
+	// running in dry run mode. It is nil otherwise.
+	DryRun *writer.DryRunBuffer
+
 	// obfuscator is used to obfuscate sensitive data from various span
 	// tags based on their type. It is lazy initialized with obfuscatorConf in obfuscate.go
 	obfuscator     *obfuscate.Obfuscator
@@ -146,7 +150,15 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 		oconf.Statsd = statsd
 	}
 	timing := timing.New(statsd)
-	statsWriter := writer.NewStatsWriter(conf, telemetryCollector, statsd, timing)
+	var dryRun *writer.DryRunBuffer
+	var statsWriter *writer.DatadogStatsWriter
+	if conf.DryRun {
+		log.Warn("Trace agent running in dry run mode: no traces nor stats will be sent to Datadog.")
+		dryRun = writer.NewDryRunBuffer(conf.DryRunBufferSize)
+		statsWriter = writer.NewDryRunStatsWriter(conf, dryRun, statsd, timing)
+	} else {
+		statsWriter = writer.NewStatsWriter(conf, telemetryCollector, statsd, timing)
+	}
 	agnt := &Agent{
 		Concentrator:          stats.NewConcentrator(conf, statsWriter, time.Now(), statsd),
 		ClientStatsAggregator: stats.NewClientStatsAggregator(conf, statsWriter, statsd),
@@ -167,12 +179,17 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig, telemetryCollector
 		DebugServer:           api.NewDebugServer(conf),
 		Statsd:                statsd,
 		Timing:                timing,
+		DryRun:                dryRun,
 	}
 	agnt.SamplerMetrics.Add(agnt.PrioritySampler, agnt.ErrorsSampler, agnt.NoPrioritySampler, agnt.RareSampler)
 	agnt.Receiver = api.NewHTTPReceiver(conf, dynConf, in, agnt, telemetryCollector, statsd, timing)
 	agnt.OTLPReceiver = api.NewOTLPReceiver(in, conf, statsd, timing)
 	agnt.RemoteConfigHandler = remoteconfighandler.New(conf, agnt.PrioritySampler, agnt.RareSampler, agnt.ErrorsSampler)
-	agnt.TraceWriter = writer.NewTraceWriter(conf, agnt.PrioritySampler, agnt.ErrorsSampler, agnt.RareSampler, telemetryCollector, statsd, timing, comp)
+	if dryRun != nil {
+		agnt.TraceWriter = writer.NewDryRunTraceWriter(dryRun)
+	} else {
+		agnt.TraceWriter = writer.NewTraceWriter(conf, agnt.PrioritySampler, agnt.ErrorsSampler, agnt.RareSampler, telemetryCollector, statsd, timing, comp)
+	}
 	return agnt
 }
 
@@ -640,6 +657,9 @@ func (a *Agent) runSamplers(now time.Time, ts *info.TagStats, pt traceutil.Proce
 	samplingPriority := sampler.PriorityNone
 	defer func() {
 		a.SamplerMetrics.RecordMetricsKey(keep, sampler.NewMetricsKey(pt.Root.Service, pt.TracerEnv, samplerName, samplingPriority))
+		if a.DryRun != nil {
+			a.recordSamplingDecision(now, pt, samplerName, samplingPriority, keep)
+		}
 	}()
 	// ETS: chunks that don't contain errors (or spans with exception span events) are all dropped.
 	if a.conf.ErrorTrackingStandalone {
@@ -719,20 +739,13 @@ func (a *Agent) runSamplers(now time.Time, ts *info.TagStats, pt traceutil.Proce
 
 func traceContainsError(trace pb.Trace, considerExceptionEvents bool) bool {
 	for _, span := range trace {
-		if span.Error != 0 || (considerExceptionEvents && spanContainsExceptionSpanEvent(span)) {
+		if span.Error != 0 || (considerExceptionEvents && traceutil.HasExceptionSpanEvent(span)) {
 			return true
 		}
 	}
 	return false
 }
 
-func spanContainsExceptionSpanEvent(span *pb.Span) bool {
-	if hasExceptionSpanEvents, ok := span.Meta["_dd.span_events.has_exception"]; ok && hasExceptionSpanEvents == "true" {
-		return true
-	}
-	return false
-}
-
 func filteredByTags(root *pb.Span, require, reject []*config.Tag, requireRegex, rejectRegex []*config.TagRegex) bool {
 	for _, tag := range reject {
 		if v, ok := root.Meta[tag.K]; ok && (tag.V == "" || v == tag.V) {
@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/writer"
+)
+
+// defaultDryRunQueryLimit is the number of entries returned by the dry run endpoints
+// when no limit is given.
+const defaultDryRunQueryLimit = 20
+
+// recordSamplingDecision stores the sampling decision taken on pt into the dry run buffer.
+func (a *Agent) recordSamplingDecision(now time.Time, pt traceutil.ProcessedTrace, name sampler.Name, priority sampler.SamplingPriority, keep bool) {
+	verdict := "dropped"
+	if keep {
+		verdict = "kept"
+	}
+	reason := fmt.Sprintf("%s by the %s sampler", verdict, name)
+	if priority != sampler.PriorityNone {
+		reason += fmt.Sprintf(" (sampling priority %d)", priority)
+	}
+	a.DryRun.AddSamplingDecision(writer.SamplingDecision{
+		Time:     now,
+		TraceID:  pt.Root.TraceID,
+		Service:  pt.Root.Service,
+		Name:     pt.Root.Name,
+		Resource: pt.Root.Resource,
+		Env:      pt.TracerEnv,
+		Sampler:  name.String(),
+		Priority: int(priority),
+		Keep:     keep,
+		Reason:   reason,
+	})
+}
+
+// DryRunHandler returns the handler serving the content of the dry run buffer. It is nil
+// when the agent does not run in dry run mode. The following routes are served, relative
+// to the prefix under which the handler is mounted:
+//
+//   - /traces: the most recent tracer payloads, optionally filtered by "service" and "env".
+//   - /stats: the most recent stats payloads, optionally filtered by "service" and "env".
+//   - /sampling: the most recent sampling decisions, optionally filtered by "service",
+//     "env", "sampler" and "keep".
+//
+// All routes accept a "limit" parameter bounding the number of returned entries.
+func (a *Agent) DryRunHandler(prefix string) http.Handler {
+	if a.DryRun == nil {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix+"/traces", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit, err := dryRunLimit(q.Get("limit"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		service, env := q.Get("service"), q.Get("env")
+		payloads := a.DryRun.TracerPayloads(limit, func(p *pb.TracerPayload) bool {
+			if env != "" && p.Env != env {
+				return false
+			}
+			return service == "" || tracerPayloadHasService(p, service)
+		})
+		writeDryRunJSON(w, payloads)
+	})
+	mux.HandleFunc(prefix+"/stats", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit, err := dryRunLimit(q.Get("limit"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		service, env := q.Get("service"), q.Get("env")
+		payloads := a.DryRun.StatsPayloads(limit, func(p *pb.StatsPayload) bool {
+			for _, cp := range p.Stats {
+				if (env == "" || cp.Env == env) && (service == "" || clientStatsPayloadHasService(cp, service)) {
+					return true
+				}
+			}
+			return false
+		})
+		writeDryRunJSON(w, payloads)
+	})
+	mux.HandleFunc(prefix+"/sampling", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		limit, err := dryRunLimit(q.Get("limit"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var keep *bool
+		if v := q.Get("keep"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				http.Error(w, "keep must be a boolean", http.StatusBadRequest)
+				return
+			}
+			keep = &b
+		}
+		service, env, name := q.Get("service"), q.Get("env"), q.Get("sampler")
+		decisions := a.DryRun.SamplingDecisions(limit, func(d writer.SamplingDecision) bool {
+			return (service == "" || d.Service == service) &&
+				(env == "" || d.Env == env) &&
+				(name == "" || d.Sampler == name) &&
+				(keep == nil || d.Keep == *keep)
+		})
+		writeDryRunJSON(w, decisions)
+	})
+	return mux
+}
+
+// dryRunLimit parses the "limit" query parameter of the dry run endpoints.
+func dryRunLimit(v string) (int, error) {
+	if v == "" {
+		return defaultDryRunQueryLimit, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("limit must be a positive integer")
+	}
+	return n, nil
+}
+
+func writeDryRunJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("Error encoding dry run response: %v", err)
+	}
+}
+
+func tracerPayloadHasService(p *pb.TracerPayload, service string) bool {
+	for _, chunk := range p.Chunks {
+		for _, span := range chunk.Spans {
+			if span.Service == service {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func clientStatsPayloadHasService(p *pb.ClientStatsPayload, service string) bool {
+	if p.Service == service {
+		return true
+	}
+	for _, b := range p.Stats {
+		for _, g := range b.Stats {
+			if g.Service == service {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	gzip "github.com/DataDog/datadog-agent/comp/trace/compression/impl-gzip"
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/telemetry"
+	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/trace/writer"
+)
+
+func TestDryRun(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.DryRun = true
+	cfg.DryRunBufferSize = 10
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector(), &statsd.NoOpClient{}, gzip.NewComponent())
+	require.NotNil(t, agnt.DryRun)
+	require.IsType(t, &writer.DryRunTraceWriter{}, agnt.TraceWriter)
+
+	keep := testutil.TracerPayloadWithChunk(testutil.RandomTraceChunk(1, 1))
+	keep.Chunks[0].Priority = int32(sampler.PriorityUserKeep)
+	keep.Chunks[0].Spans[0].Service = "kept-service"
+	drop := testutil.TracerPayloadWithChunk(testutil.RandomTraceChunk(1, 1))
+	drop.Chunks[0].Priority = int32(sampler.PriorityUserDrop)
+	drop.Chunks[0].Spans[0].Service = "dropped-service"
+	for _, tp := range []*pb.TracerPayload{keep, drop} {
+		agnt.Process(&api.Payload{
+			TracerPayload: tp,
+			Source:        agnt.Receiver.Stats.GetTagStats(info.Tags{}),
+		})
+	}
+	agnt.StatsWriter.Write(&pb.StatsPayload{Stats: []*pb.ClientStatsPayload{{
+		Service: "kept-service",
+		Stats:   []*pb.ClientStatsBucket{{Stats: []*pb.ClientGroupedStats{{Service: "kept-service", Hits: 1}}}},
+	}}})
+
+	h := agnt.DryRunHandler("/debug/dryrun")
+	get := func(t *testing.T, url string, v interface{}) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), v))
+	}
+
+	t.Run("traces", func(t *testing.T) {
+		var payloads []*pb.TracerPayload
+		get(t, "/debug/dryrun/traces?service=kept-service", &payloads)
+		require.Len(t, payloads, 1)
+		assert.Equal(t, "kept-service", payloads[0].Chunks[0].Spans[0].Service)
+	})
+
+	t.Run("stats", func(t *testing.T) {
+		var payloads []*pb.StatsPayload
+		get(t, "/debug/dryrun/stats", &payloads)
+		require.Len(t, payloads, 1)
+		get(t, "/debug/dryrun/stats?service=unknown", &payloads)
+		assert.Empty(t, payloads)
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		var decisions []writer.SamplingDecision
+		get(t, "/debug/dryrun/sampling", &decisions)
+		require.Len(t, decisions, 2)
+		// most recent first
+		assert.Equal(t, "dropped-service", decisions[0].Service)
+		assert.False(t, decisions[0].Keep)
+		assert.Equal(t, "kept-service", decisions[1].Service)
+		assert.True(t, decisions[1].Keep)
+		assert.Equal(t, "priority", decisions[1].Sampler)
+		assert.Equal(t, "kept by the priority sampler (sampling priority 2)", decisions[1].Reason)
+
+		get(t, "/debug/dryrun/sampling?keep=false&limit=5", &decisions)
+		require.Len(t, decisions, 1)
+		assert.Equal(t, "dropped-service", decisions[0].Service)
+	})
+
+	t.Run("bad-limit", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/dryrun/traces?limit=-1", nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestDryRunHandlerDisabled(t *testing.T) {
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector(), &statsd.NoOpClient{}, gzip.NewComponent())
+	assert.Nil(t, agnt.DryRun)
+	assert.Nil(t, agnt.DryRunHandler("/debug/dryrun"))
+}
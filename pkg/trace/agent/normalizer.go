@@ -180,16 +180,38 @@ func (a *Agent) normalize(ts *info.TagStats, s *pb.Span) error {
 	}
 
 	if len(s.SpanLinks) > 0 {
-		for _, link := range s.SpanLinks {
-			if val, ok := link.Attributes["link.name"]; ok {
-				link.Attributes["link.name"], err = traceutil.NormalizeName(val)
-				if err != nil {
-					log.Debugf("Fixing malformed trace. 'link.name' attribute in span link is invalid (reason=%q), setting link.Attributes[\"link.name\"]=%s", err, link.Attributes["link.name"])
-				}
+		a.normalizeSpanLinks(ts, s)
+	}
+	return nil
+}
+
+// normalizeSpanLinks drops the span links of s which do not reference a span and fixes
+// the fields of the remaining ones.
+func (a *Agent) normalizeSpanLinks(ts *info.TagStats, s *pb.Span) {
+	links := s.SpanLinks[:0]
+	for _, link := range s.SpanLinks {
+		if !traceutil.IsValidSpanLink(link) {
+			ts.SpansMalformed.InvalidSpanLink.Inc()
+			log.Debugf("Fixing malformed trace. Span link has a zero trace or span ID (reason:invalid_span_link), dropping it: %v", link)
+			continue
+		}
+		if val, ok := link.Attributes["link.name"]; ok {
+			var err error
+			link.Attributes["link.name"], err = traceutil.NormalizeName(val)
+			if err != nil {
+				log.Debugf("Fixing malformed trace. 'link.name' attribute in span link is invalid (reason=%q), setting link.Attributes[\"link.name\"]=%s", err, link.Attributes["link.name"])
 			}
 		}
+		if link.Flags != 0 {
+			// W3C trace flags are only considered set when their high bit is set
+			link.Flags |= 1 << 31
+		}
+		links = append(links, link)
 	}
-	return nil
+	if len(links) == 0 {
+		links = nil
+	}
+	s.SpanLinks = links
 }
 
 // setChunkAttributes takes a trace chunk and from the root span
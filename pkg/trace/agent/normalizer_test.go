@@ -182,6 +182,30 @@ func TestNormalizeSpanLinkName(t *testing.T) {
 	assert.Equal(t, validLinkNameSpan.SpanLinks[0].Attributes["link.name"], "valid_name")
 }
 
+func TestNormalizeSpanLinks(t *testing.T) {
+	a := &Agent{conf: config.New()}
+	ts := newTagStats()
+
+	s := newTestSpan()
+	s.SpanLinks = []*pb.SpanLink{
+		{TraceID: 1, SpanID: 2},
+		{TraceID: 1},
+		{TraceIDHigh: 1, SpanID: 3, Flags: 1},
+		{SpanID: 4},
+	}
+	assert.NoError(t, a.normalize(ts, s))
+	assert.Equal(t, []*pb.SpanLink{
+		{TraceID: 1, SpanID: 2},
+		{TraceIDHigh: 1, SpanID: 3, Flags: 1<<31 | 1},
+	}, s.SpanLinks)
+	assert.Equal(t, tsMalformed(&info.SpansMalformed{InvalidSpanLink: *atomic.NewInt64(2)}), ts)
+
+	ts = newTagStats()
+	s.SpanLinks = []*pb.SpanLink{{TraceID: 1}}
+	assert.NoError(t, a.normalize(ts, s))
+	assert.Nil(t, s.SpanLinks)
+}
+
 func TestNormalizeLongName(t *testing.T) {
 	a := &Agent{conf: config.New()}
 	ts := newTagStats()
@@ -36,6 +36,9 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 	for _, spanEvent := range span.SpanEvents {
 		a.obfuscateSpanEvent(spanEvent)
 	}
+	for _, spanLink := range span.SpanLinks {
+		a.obfuscateSpanLink(spanLink)
+	}
 
 	if a.conf.Obfuscation != nil && a.conf.Obfuscation.CreditCards.Enabled {
 		for k, v := range span.Meta {
@@ -81,6 +84,7 @@ func (a *Agent) obfuscateSpan(span *pb.Span) {
 		}
 		span.Meta[tagMemcachedCommand] = o.ObfuscateMemcachedString(span.Meta[tagMemcachedCommand])
 	case "web", "http":
+		obfuscateHTTPURLAttributes(o, span)
 		if span.Meta == nil || span.Meta[tagHTTPURL] == "" {
 			return
 		}
@@ -126,7 +130,8 @@ func (a *Agent) obfuscateSpanEvent(spanEvent *pb.SpanEvent) {
 			case pb.AttributeAnyValue_BOOL_VALUE:
 				continue // Booleans can't be credit cards
 			case pb.AttributeAnyValue_ARRAY_VALUE:
-				a.ccObfuscateAttributeArray(v, k, strValue)
+				a.ccObfuscateAttributeArray(v, k)
+				continue
 			}
 			newVal := a.obfuscator.ObfuscateCreditCardNumber(k, strValue)
 			if newVal != strValue {
@@ -136,7 +141,10 @@ func (a *Agent) obfuscateSpanEvent(spanEvent *pb.SpanEvent) {
 	}
 }
 
-func (a *Agent) ccObfuscateAttributeArray(v *pb.AttributeAnyValue, k string, strValue string) {
+func (a *Agent) ccObfuscateAttributeArray(v *pb.AttributeAnyValue, k string) {
+	if v.ArrayValue == nil {
+		return
+	}
 	var arrStrValue string
 	for _, vElement := range v.ArrayValue.Values {
 		switch vElement.Type {
@@ -150,12 +158,43 @@ func (a *Agent) ccObfuscateAttributeArray(v *pb.AttributeAnyValue, k string, str
 			continue // Booleans can't be credit cards
 		}
 		newVal := a.obfuscator.ObfuscateCreditCardNumber(k, arrStrValue)
-		if newVal != strValue {
+		if newVal != arrStrValue {
 			*vElement = pb.AttributeArrayValue{Type: pb.AttributeArrayValue_STRING_VALUE, StringValue: newVal}
 		}
 	}
 }
 
+// obfuscateSpanLink obfuscates any credit-card like attribute of a span link, when enabled.
+func (a *Agent) obfuscateSpanLink(spanLink *pb.SpanLink) {
+	if a.conf.Obfuscation == nil || !a.conf.Obfuscation.CreditCards.Enabled || spanLink == nil {
+		return
+	}
+	for k, v := range spanLink.Attributes {
+		if newV := a.obfuscator.ObfuscateCreditCardNumber(k, v); newV != v {
+			log.Debugf("obfuscating possible credit card under span link attribute %s", k)
+			spanLink.Attributes[k] = newV
+		}
+	}
+}
+
+// obfuscateHTTPURLAttributes obfuscates the http.url attributes found in the span events and
+// span links of a web span, the same way the http.url meta tag is.
+func obfuscateHTTPURLAttributes(o *obfuscate.Obfuscator, span *pb.Span) {
+	for _, spanEvent := range span.SpanEvents {
+		if spanEvent == nil {
+			continue
+		}
+		if v, ok := spanEvent.Attributes[tagHTTPURL]; ok && v != nil && v.Type == pb.AttributeAnyValue_STRING_VALUE && v.StringValue != "" {
+			v.StringValue = o.ObfuscateURLString(v.StringValue)
+		}
+	}
+	for _, spanLink := range span.SpanLinks {
+		if v := spanLink.Attributes[tagHTTPURL]; v != "" {
+			spanLink.Attributes[tagHTTPURL] = o.ObfuscateURLString(v)
+		}
+	}
+}
+
 func (a *Agent) obfuscateStatsGroup(b *pb.ClientGroupedStats) {
 	o := a.lazyInitObfuscator()
 
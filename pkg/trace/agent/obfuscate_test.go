@@ -534,6 +534,54 @@ func TestObfuscateSpanEvent(t *testing.T) {
 	}
 }
 
+func TestObfuscateSpanLink(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.Obfuscation = &config.ObfuscationConfig{
+		CreditCards: obfuscate.CreditCardsConfig{Enabled: true},
+	}
+	agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector(), &statsd.NoOpClient{}, gzip.NewComponent())
+	span := &pb.Span{
+		Resource: "rrr",
+		Type:     "aaa",
+		SpanLinks: []*pb.SpanLink{
+			{TraceID: 1, SpanID: 2, Attributes: map[string]string{"card": "5105-1051-0510-5100", "link.name": "name"}},
+		},
+	}
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, map[string]string{"card": "?", "link.name": "name"}, span.SpanLinks[0].Attributes)
+}
+
+func TestObfuscateHTTPURLAttributes(t *testing.T) {
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	defer cancelFunc()
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	cfg.Obfuscation = &config.ObfuscationConfig{HTTP: obfuscate.HTTPConfig{
+		RemovePathDigits:  true,
+		RemoveQueryString: true,
+	}}
+	agnt := NewAgent(ctx, cfg, telemetry.NewNoopCollector(), &statsd.NoOpClient{}, gzip.NewComponent())
+	span := &pb.Span{
+		Resource: "rrr",
+		Type:     "http",
+		SpanEvents: []*pb.SpanEvent{{
+			Name: "redirect",
+			Attributes: map[string]*pb.AttributeAnyValue{
+				"http.url": {Type: pb.AttributeAnyValue_STRING_VALUE, StringValue: "http://mysite.mydomain/1/2?q=asd"},
+			},
+		}},
+		SpanLinks: []*pb.SpanLink{
+			{TraceID: 1, SpanID: 2, Attributes: map[string]string{"http.url": "http://mysite.mydomain/1/2?q=asd"}},
+		},
+	}
+	agnt.obfuscateSpan(span)
+	assert.Equal(t, "http://mysite.mydomain/?/??", span.SpanEvents[0].Attributes["http.url"].StringValue)
+	assert.Equal(t, "http://mysite.mydomain/?/??", span.SpanLinks[0].Attributes["http.url"])
+}
+
 func TestLexerObfuscation(t *testing.T) {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	cfg := config.New()
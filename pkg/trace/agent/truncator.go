@@ -13,8 +13,8 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 )
 
-// Truncate checks that the span resource, meta and metrics are within the max length
-// and modifies them if they are not
+// Truncate checks that the span resource, meta, metrics, span links and span events are
+// within the max length and modifies them if they are not
 func (a *Agent) Truncate(s *pb.Span) {
 	r, ok := a.TruncateResource(s.Resource)
 	if !ok {
@@ -58,6 +58,73 @@ func (a *Agent) Truncate(s *pb.Span) {
 			s.Metrics[k] = v
 		}
 	}
+	for _, link := range s.SpanLinks {
+		truncateSpanLink(link)
+	}
+	for _, event := range s.SpanEvents {
+		truncateSpanEvent(event)
+	}
+}
+
+// truncateSpanLink applies the meta length limits to the attributes and tracestate of a span link.
+func truncateSpanLink(link *pb.SpanLink) {
+	for k, v := range link.Attributes {
+		modified := false
+		if len(k) > MaxMetaKeyLen {
+			log.Debugf("span.truncate: truncating span link attribute key (max %d chars): %s", MaxMetaKeyLen, k)
+			delete(link.Attributes, k)
+			k = traceutil.TruncateUTF8(k, MaxMetaKeyLen) + "..."
+			modified = true
+		}
+		if len(v) > MaxMetaValLen {
+			v = traceutil.TruncateUTF8(v, MaxMetaValLen) + "..."
+			modified = true
+		}
+		if modified {
+			link.Attributes[k] = v
+		}
+	}
+	if len(link.Tracestate) > MaxMetaValLen {
+		link.Tracestate = traceutil.TruncateUTF8(link.Tracestate, MaxMetaValLen) + "..."
+	}
+}
+
+// truncateSpanEvent applies the meta length limits to the name and attributes of a span event.
+// String values nested in array attributes are truncated as well.
+func truncateSpanEvent(event *pb.SpanEvent) {
+	if event == nil {
+		return
+	}
+	if len(event.Name) > MaxMetaKeyLen {
+		log.Debugf("span.truncate: truncating span event name (max %d chars): %s", MaxMetaKeyLen, event.Name)
+		event.Name = traceutil.TruncateUTF8(event.Name, MaxMetaKeyLen) + "..."
+	}
+	for k, v := range event.Attributes {
+		if len(k) > MaxMetaKeyLen {
+			log.Debugf("span.truncate: truncating span event attribute key (max %d chars): %s", MaxMetaKeyLen, k)
+			delete(event.Attributes, k)
+			k = traceutil.TruncateUTF8(k, MaxMetaKeyLen) + "..."
+			event.Attributes[k] = v
+		}
+		if v == nil {
+			continue
+		}
+		switch v.Type {
+		case pb.AttributeAnyValue_STRING_VALUE:
+			if len(v.StringValue) > MaxMetaValLen {
+				v.StringValue = traceutil.TruncateUTF8(v.StringValue, MaxMetaValLen) + "..."
+			}
+		case pb.AttributeAnyValue_ARRAY_VALUE:
+			if v.ArrayValue == nil {
+				continue
+			}
+			for _, elem := range v.ArrayValue.Values {
+				if elem.Type == pb.AttributeArrayValue_STRING_VALUE && len(elem.StringValue) > MaxMetaValLen {
+					elem.StringValue = traceutil.TruncateUTF8(elem.StringValue, MaxMetaValLen) + "..."
+				}
+			}
+		}
+	}
 }
 
 const (
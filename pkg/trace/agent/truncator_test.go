@@ -131,6 +131,54 @@ func TestTruncateStructuredMetaTag(t *testing.T) {
 	}
 }
 
+func TestTruncateSpanLinks(t *testing.T) {
+	a := &Agent{conf: config.New()}
+	s := testSpan()
+	key := strings.Repeat("k", MaxMetaKeyLen+1)
+	val := strings.Repeat("v", MaxMetaValLen+1)
+	s.SpanLinks = []*pb.SpanLink{{
+		TraceID:    1,
+		SpanID:     2,
+		Attributes: map[string]string{key: "a", "b": val},
+		Tracestate: val,
+	}}
+	a.Truncate(s)
+	link := s.SpanLinks[0]
+	assert.Equal(t, "a", link.Attributes[key[:MaxMetaKeyLen]+"..."])
+	assert.Equal(t, val[:MaxMetaValLen]+"...", link.Attributes["b"])
+	assert.Equal(t, val[:MaxMetaValLen]+"...", link.Tracestate)
+	assert.Len(t, link.Attributes, 2)
+}
+
+func TestTruncateSpanEvents(t *testing.T) {
+	a := &Agent{conf: config.New()}
+	s := testSpan()
+	key := strings.Repeat("k", MaxMetaKeyLen+1)
+	val := strings.Repeat("v", MaxMetaValLen+1)
+	s.SpanEvents = []*pb.SpanEvent{{
+		Name: key,
+		Attributes: map[string]*pb.AttributeAnyValue{
+			key: {Type: pb.AttributeAnyValue_INT_VALUE, IntValue: 1},
+			"str": {Type: pb.AttributeAnyValue_STRING_VALUE, StringValue: val},
+			"arr": {
+				Type: pb.AttributeAnyValue_ARRAY_VALUE,
+				ArrayValue: &pb.AttributeArray{Values: []*pb.AttributeArrayValue{
+					{Type: pb.AttributeArrayValue_STRING_VALUE, StringValue: val},
+					{Type: pb.AttributeArrayValue_STRING_VALUE, StringValue: "short"},
+				}},
+			},
+		},
+	}}
+	a.Truncate(s)
+	event := s.SpanEvents[0]
+	assert.Equal(t, key[:MaxMetaKeyLen]+"...", event.Name)
+	assert.Len(t, event.Attributes, 3)
+	assert.EqualValues(t, 1, event.Attributes[key[:MaxMetaKeyLen]+"..."].IntValue)
+	assert.Equal(t, val[:MaxMetaValLen]+"...", event.Attributes["str"].StringValue)
+	assert.Equal(t, val[:MaxMetaValLen]+"...", event.Attributes["arr"].ArrayValue.Values[0].StringValue)
+	assert.Equal(t, "short", event.Attributes["arr"].ArrayValue.Values[1].StringValue)
+}
+
 func TestTruncateResource(t *testing.T) {
 	a := &Agent{conf: config.New()}
 	t.Run("over", func(t *testing.T) {
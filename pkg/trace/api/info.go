@@ -78,7 +78,7 @@ func (r *HTTPReceiver) makeInfoHandler() (hash string, handler http.HandlerFunc)
 		}
 	}
 
-	txt, err := json.MarshalIndent(struct {
+	type agentInfo struct {
 		Version                string        `json:"version"`
 		GitCommit              string        `json:"git_commit"`
 		Endpoints              []string      `json:"endpoints"`
@@ -92,7 +92,8 @@ func (r *HTTPReceiver) makeInfoHandler() (hash string, handler http.HandlerFunc)
 		PeerTags               []string      `json:"peer_tags"`
 		SpanKindsStatsComputed []string      `json:"span_kinds_stats_computed"`
 		ObfuscationVersion     int           `json:"obfuscation_version"`
-	}{
+	}
+	info := agentInfo{
 		Version:                r.conf.AgentVersion,
 		GitCommit:              r.conf.GitCommit,
 		Endpoints:              all,
@@ -120,12 +121,26 @@ func (r *HTTPReceiver) makeInfoHandler() (hash string, handler http.HandlerFunc)
 			Obfuscation:            oconf,
 		},
 		PeerTags: r.conf.ConfiguredPeerTags(),
-	}, "", "\t")
+	}
+	txt, err := json.MarshalIndent(info, "", "\t")
 	if err != nil {
 		panic(fmt.Errorf("Error making /info handler: %v", err))
 	}
 	h := sha256.Sum256(txt)
 	return fmt.Sprintf("%x", h), func(w http.ResponseWriter, _ *http.Request) {
+		// The rates computed by the priority sampler change continuously, so they are
+		// left out of the hash, which only tracks changes of the agent's configuration.
+		txt, err := json.MarshalIndent(struct {
+			agentInfo
+			RateByService map[string]float64 `json:"rate_by_service"`
+		}{
+			agentInfo:     info,
+			RateByService: r.dynConf.RateByService.GetNewState("").Rates,
+		}, "", "\t")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 		fmt.Fprintf(w, "%s", txt)
 	}
 }
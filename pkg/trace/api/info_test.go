@@ -306,6 +306,7 @@ func TestInfoHandler(t *testing.T) {
 		"peer_tags":                 nil,
 		"span_kinds_stats_computed": nil,
 		"obfuscation_version":       nil,
+		"rate_by_service":           nil,
 		"config": map[string]interface{}{
 			"default_env":               nil,
 			"target_tps":                nil,
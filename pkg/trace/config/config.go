@@ -209,6 +209,26 @@ type ReplaceRule struct {
 	Repl string `mapstructure:"repl"`
 }
 
+// SamplerBudget specifies the share of the priority sampler's target TPS given to a service,
+// to an env, or to a service within an env.
+type SamplerBudget struct {
+	// Service specifies the service the budget applies to. If empty, the budget applies to
+	// the env as a whole, which is first given its share before it is split between its services.
+	Service string `mapstructure:"service" json:"service"`
+
+	// Env specifies the env the budget applies to. If empty, a service budget applies to the
+	// service in all envs.
+	Env string `mapstructure:"env" json:"env"`
+
+	// Weight specifies the share of the target TPS given to the service or env, relative to the
+	// others. It defaults to 1.
+	Weight float64 `mapstructure:"weight" json:"weight"`
+
+	// MaxTPS caps the number of traces per second given to the service or env. A value of 0
+	// means no cap.
+	MaxTPS float64 `mapstructure:"max_tps" json:"max_tps"`
+}
+
 // WriterConfig specifies configuration for an API writer.
 type WriterConfig struct {
 	// ConnectionLimit specifies the maximum number of concurrent outgoing
@@ -331,11 +351,12 @@ type AgentConfig struct {
 	Endpoints []*Endpoint
 
 	// Concentrator
-	BucketInterval         time.Duration // the size of our pre-aggregation per bucket
-	ExtraAggregators       []string      // DEPRECATED
-	PeerTagsAggregation    bool          // enables/disables stats aggregation for peer entity tags, used by Concentrator and ClientStatsAggregator
-	ComputeStatsBySpanKind bool          // enables/disables the computing of stats based on a span's `span.kind` field
-	PeerTags               []string      // additional tags to use for peer entity stats aggregation
+	BucketInterval              time.Duration // the size of our pre-aggregation per bucket
+	ExtraAggregators            []string      // DEPRECATED
+	PeerTagsAggregation         bool          // enables/disables stats aggregation for peer entity tags, used by Concentrator and ClientStatsAggregator
+	ComputeStatsBySpanKind      bool          // enables/disables the computing of stats based on a span's `span.kind` field
+	ExceptionSpanEventsAsErrors bool          // counts spans carrying an exception span event as errors in stats
+	PeerTags                    []string      // additional tags to use for peer entity stats aggregation
 
 	// Sampler configuration
 	ExtraSampleRate float64
@@ -344,6 +365,14 @@ type AgentConfig struct {
 	MaxEPS          float64
 	MaxRemoteTPS    float64
 
+	// PrioritySamplerBudgets specifies how the priority sampler shares TargetTPS between envs
+	// and services. Services without a budget are given a weight of 1, and envs without a budget
+	// weigh as much as all of their services.
+	PrioritySamplerBudgets []*SamplerBudget
+	// PrioritySamplerMinRate is the minimum sampling rate the priority sampler guarantees to every
+	// service, regardless of its traffic. Keeping it low ensures that TargetTPS is still respected.
+	PrioritySamplerMinRate float64
+
 	// Rare Sampler configuration
 	RareSamplerEnabled        bool
 	RareSamplerTPS            int
@@ -388,6 +417,12 @@ type AgentConfig struct {
 	// case, the sender will drop failed payloads when it is unable to enqueue
 	// them for another retry.
 	MaxSenderRetries int
+	// DryRun reports whether the writers should keep the payloads they produce in a local
+	// ring buffer, queryable through the debug server, instead of sending them to the intake.
+	DryRun bool
+	// DryRunBufferSize is the number of traces, stats payloads and sampling decisions kept in
+	// the dry run buffer. If not set (0) it will default to 100.
+	DryRunBufferSize int
 	// HTTP client used in writer connections. If nil, default client values will be used.
 	HTTPClientFunc func() *http.Client `json:"-"`
 	// HTTP Transport used in writer connections. If nil, default transport values will be used.
@@ -55,6 +55,13 @@ func (f Replacer) Replace(trace pb.Trace) {
 						}
 					}
 				}
+				for _, spanLink := range s.SpanLinks {
+					for keyAttr, val := range spanLink.Attributes {
+						if !strings.HasPrefix(keyAttr, hiddenTagPrefix) {
+							spanLink.Attributes[keyAttr] = re.ReplaceAllString(val, str)
+						}
+					}
+				}
 			case "resource.name":
 				s.Resource = re.ReplaceAllString(s.Resource, str)
 			default:
@@ -75,6 +82,11 @@ func (f Replacer) Replace(trace pb.Trace) {
 						}
 					}
 				}
+				for _, spanLink := range s.SpanLinks {
+					if val, ok := spanLink.Attributes[key]; ok {
+						spanLink.Attributes[key] = re.ReplaceAllString(val, str)
+					}
+				}
 			}
 		}
 	}
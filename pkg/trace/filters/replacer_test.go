@@ -222,6 +222,48 @@ func TestReplacer(t *testing.T) {
 			}
 		}
 	})
+	t.Run("span links", func(tt *testing.T) {
+		for _, testCase := range []struct {
+			rules     [][3]string
+			got, want map[string]string
+		}{
+			{
+				rules: [][3]string{
+					{"http.url", "(token/)([^/]*)", "${1}?"},
+					{"custom.tag", "(/foo/bar/).*", "${1}extra"},
+				},
+				got: map[string]string{
+					"http.url":   "some/guid/token/abcdef/abc",
+					"custom.tag": "/foo/bar/foo",
+					"other.url":  "some/guid/token/abcdef/abc",
+				},
+				want: map[string]string{
+					"http.url":   "some/guid/token/?/abc",
+					"custom.tag": "/foo/bar/extra",
+					"other.url":  "some/guid/token/abcdef/abc",
+				},
+			},
+			{
+				rules: [][3]string{
+					{"*", "(token/)([^/]*)", "${1}?"},
+				},
+				got: map[string]string{
+					"other.url": "some/guid/token/abcdef/abc",
+					"_special":  "some/guid/token/abcdef/abc",
+				},
+				want: map[string]string{
+					"other.url": "some/guid/token/?/abc",
+					"_special":  "some/guid/token/abcdef/abc",
+				},
+			},
+		} {
+			rules := parseRulesFromString(testCase.rules)
+			tr := NewReplacer(rules)
+			root := &pb.Span{SpanLinks: []*pb.SpanLink{{TraceID: 1, SpanID: 2, Attributes: testCase.got}}}
+			tr.Replace(pb.Trace{root})
+			assert.Equal(tt, testCase.want, root.SpanLinks[0].Attributes)
+		}
+	})
 }
 
 func parseRulesFromString(rules [][3]string) []*config.ReplaceRule {
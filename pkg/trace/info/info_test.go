@@ -486,6 +486,7 @@ func TestPublishReceiverStats(t *testing.T) {
 				atom(14),
 				atom(15),
 				atom(16),
+				atom(17),
 			},
 			TracesFiltered:     atom(4),
 			TracesPriorityNone: atom(5),
@@ -544,6 +545,7 @@ func TestPublishReceiverStats(t *testing.T) {
 				"InvalidStartDate":      14.0,
 				"InvalidDuration":       15.0,
 				"InvalidHTTPStatusCode": 16.0,
+				"InvalidSpanLink":       17.0,
 			},
 			"SpansReceived": 10.0,
 			"TracerVersion": "",
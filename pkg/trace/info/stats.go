@@ -283,6 +283,8 @@ type SpansMalformed struct {
 	InvalidDuration atomic.Int64
 	// InvalidHTTPStatusCode is when a span's metadata contains an invalid http status code
 	InvalidHTTPStatusCode atomic.Int64
+	// InvalidSpanLink is when a span link has a zero trace or span ID
+	InvalidSpanLink atomic.Int64
 }
 
 func (s *SpansMalformed) tagCounters() map[string]*atomic.Int64 {
@@ -303,6 +305,7 @@ func (s *SpansMalformed) tagCounters() map[string]*atomic.Int64 {
 		"invalid_start_date":       &s.InvalidStartDate,
 		"invalid_duration":         &s.InvalidDuration,
 		"invalid_http_status_code": &s.InvalidHTTPStatusCode,
+		"invalid_span_link":        &s.InvalidSpanLink,
 	}
 }
 
@@ -448,6 +451,7 @@ func (s *Stats) update(recent *Stats) {
 	s.SpansMalformed.InvalidStartDate.Add(recent.SpansMalformed.InvalidStartDate.Load())
 	s.SpansMalformed.InvalidDuration.Add(recent.SpansMalformed.InvalidDuration.Load())
 	s.SpansMalformed.InvalidHTTPStatusCode.Add(recent.SpansMalformed.InvalidHTTPStatusCode.Load())
+	s.SpansMalformed.InvalidSpanLink.Add(recent.SpansMalformed.InvalidSpanLink.Load())
 	s.TracesFiltered.Add(recent.TracesFiltered.Load())
 	s.TracesPriorityNone.Add(recent.TracesPriorityNone.Load())
 	s.ClientDroppedP0Traces.Add(recent.ClientDroppedP0Traces.Load())
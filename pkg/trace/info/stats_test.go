@@ -65,6 +65,7 @@ func TestSpansMalformed(t *testing.T) {
 			"base_service_invalid":     0,
 			"invalid_start_date":       0,
 			"invalid_http_status_code": 0,
+			"invalid_span_link":        0,
 			"invalid_duration":         0,
 			"duplicate_span_id":        0,
 			"service_empty":            1,
@@ -227,6 +228,7 @@ func TestReceiverStats(t *testing.T) {
 		stats.SpansMalformed.InvalidStartDate.Store(14)
 		stats.SpansMalformed.InvalidDuration.Store(15)
 		stats.SpansMalformed.InvalidHTTPStatusCode.Store(16)
+		stats.SpansMalformed.InvalidSpanLink.Store(17)
 		return &ReceiverStats{
 			Stats: map[Tags]*TagStats{
 				tags: {
@@ -240,7 +242,7 @@ func TestReceiverStats(t *testing.T) {
 	t.Run("PublishAndReset", func(t *testing.T) {
 		rs := testStats()
 		rs.PublishAndReset(statsclient)
-		assert.EqualValues(t, 44, len(statsclient.CountCalls))
+		assert.EqualValues(t, 45, len(statsclient.CountCalls))
 		assertStatsAreReset(t, rs)
 	})
 
@@ -262,7 +264,7 @@ func TestReceiverStats(t *testing.T) {
 		logs := strings.Split(b.String(), "\n")
 		assert.Equal(t, "[INFO] [lang:go lang_version:1.12 lang_vendor:gov interpreter:gcc tracer_version:1.33 endpoint_version:v0.4 service:service] -> traces received: 1, traces filtered: 4, traces amount: 9 bytes, events extracted: 13, events sampled: 14",
 			logs[0])
-		assert.Equal(t, "[WARN] [lang:go lang_version:1.12 lang_vendor:gov interpreter:gcc tracer_version:1.33 endpoint_version:v0.4 service:service] -> traces_dropped(decoding_error:1, empty_trace:3, foreign_span:6, payload_too_large:2, span_id_zero:5, timeout:7, trace_id_zero:4, unexpected_eof:8), spans_malformed(base_service_invalid:10, base_service_truncate:9, duplicate_span_id:1, invalid_duration:15, invalid_http_status_code:16, invalid_start_date:14, peer_service_invalid:8, peer_service_truncate:7, resource_empty:12, service_empty:2, service_invalid:4, service_truncate:3, span_name_empty:5, span_name_invalid:11, span_name_truncate:6, type_truncate:13). Enable debug logging for more details.",
+		assert.Equal(t, "[WARN] [lang:go lang_version:1.12 lang_vendor:gov interpreter:gcc tracer_version:1.33 endpoint_version:v0.4 service:service] -> traces_dropped(decoding_error:1, empty_trace:3, foreign_span:6, payload_too_large:2, span_id_zero:5, timeout:7, trace_id_zero:4, unexpected_eof:8), spans_malformed(base_service_invalid:10, base_service_truncate:9, duplicate_span_id:1, invalid_duration:15, invalid_http_status_code:16, invalid_span_link:17, invalid_start_date:14, peer_service_invalid:8, peer_service_truncate:7, resource_empty:12, service_empty:2, service_invalid:4, service_truncate:3, span_name_empty:5, span_name_invalid:11, span_name_truncate:6, type_truncate:13). Enable debug logging for more details.",
 			logs[1])
 
 		assertStatsAreReset(t, rs)
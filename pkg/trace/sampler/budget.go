@@ -0,0 +1,169 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+// budgetAllocator shares the target TPS of the priority sampler between services following
+// the configured budgets. When env budgets are configured, the target TPS is first shared
+// between envs, then between the services of each env.
+type budgetAllocator struct {
+	// minRate is the fraction of the seen TPS of every signature which is reserved
+	// before the rest of the target TPS is shared.
+	minRate float64
+	// envBudgets maps envs to their budget.
+	envBudgets map[string]*config.SamplerBudget
+	// serviceBudgets maps services in a given env to their budget.
+	serviceBudgets map[ServiceSignature]*config.SamplerBudget
+	// services returns the service signature of each known signature.
+	services func() map[Signature]ServiceSignature
+}
+
+// newBudgetAllocator returns a budgetAllocator using the given budgets and minimum rate.
+func newBudgetAllocator(budgets []*config.SamplerBudget, minRate float64, services func() map[Signature]ServiceSignature) *budgetAllocator {
+	b := &budgetAllocator{
+		minRate:        minRate,
+		envBudgets:     make(map[string]*config.SamplerBudget),
+		serviceBudgets: make(map[ServiceSignature]*config.SamplerBudget),
+		services:       services,
+	}
+	for _, budget := range budgets {
+		if budget.Service == "" {
+			b.envBudgets[budget.Env] = budget
+		} else {
+			b.serviceBudgets[ServiceSignature{Name: budget.Service, Env: budget.Env}] = budget
+		}
+	}
+	return b
+}
+
+// serviceBudget returns the weight and TPS cap of the given service. Budgets configured for
+// the service in its env take precedence over budgets configured for the service in all envs.
+func (b *budgetAllocator) serviceBudget(svc ServiceSignature) (weight, maxTPS float64) {
+	budget, ok := b.serviceBudgets[svc]
+	if !ok {
+		budget, ok = b.serviceBudgets[ServiceSignature{Name: svc.Name}]
+	}
+	if !ok {
+		return 1, 0
+	}
+	return budgetWeight(budget), budget.MaxTPS
+}
+
+func budgetWeight(budget *config.SamplerBudget) float64 {
+	if budget.Weight <= 0 {
+		return 1
+	}
+	return budget.Weight
+}
+
+// allocate returns the TPS allocated to each signature given their seen TPS.
+func (b *budgetAllocator) allocate(targetTPS float64, sigs []Signature, seenTPSs []float64) []float64 {
+	services := b.services()
+	tps := make([]float64, len(sigs))
+	demand := make([]float64, len(sigs))
+	weights := make([]float64, len(sigs))
+	// group signatures by env, only used when env budgets are configured
+	envs := make(map[string][]int)
+	for i, sig := range sigs {
+		// reserve the guaranteed minimum rate first
+		tps[i] = seenTPSs[i] * b.minRate
+		targetTPS -= tps[i]
+		demand[i] = seenTPSs[i] - tps[i]
+
+		svc := services[sig]
+		var maxTPS float64
+		weights[i], maxTPS = b.serviceBudget(svc)
+		if maxTPS > 0 {
+			demand[i] = capDemand(demand[i], maxTPS-tps[i])
+		}
+		envs[svc.Env] = append(envs[svc.Env], i)
+	}
+	targetTPS = max(targetTPS, 0)
+
+	if len(b.envBudgets) == 0 {
+		for i, share := range weightedFairShare(targetTPS, demand, weights) {
+			tps[i] += share
+		}
+		return tps
+	}
+
+	envNames := make([]string, 0, len(envs))
+	for env := range envs {
+		envNames = append(envNames, env)
+	}
+	sort.Strings(envNames)
+	envDemand := make([]float64, len(envNames))
+	envWeights := make([]float64, len(envNames))
+	for e, env := range envNames {
+		var reserved float64
+		for _, i := range envs[env] {
+			envDemand[e] += demand[i]
+			reserved += tps[i]
+			// by default, envs weigh as much as their services
+			envWeights[e] += weights[i]
+		}
+		if budget, ok := b.envBudgets[env]; ok {
+			envWeights[e] = budgetWeight(budget)
+			if budget.MaxTPS > 0 {
+				envDemand[e] = capDemand(envDemand[e], budget.MaxTPS-reserved)
+			}
+		}
+	}
+	for e, envShare := range weightedFairShare(targetTPS, envDemand, envWeights) {
+		idx := envs[envNames[e]]
+		d := make([]float64, len(idx))
+		w := make([]float64, len(idx))
+		for j, i := range idx {
+			d[j], w[j] = demand[i], weights[i]
+		}
+		for j, share := range weightedFairShare(envShare, d, w) {
+			tps[idx[j]] += share
+		}
+	}
+	return tps
+}
+
+// capDemand bounds demand to the remaining TPS allowed by a cap.
+func capDemand(demand, remaining float64) float64 {
+	return min(demand, max(remaining, 0))
+}
+
+// weightedFairShare splits target between consumers following a weighted max-min fair
+// allocation: each consumer i receives min(demand[i], weights[i]*λ), where λ is chosen such
+// that the whole target is used, or that all demands are met.
+func weightedFairShare(target float64, demand, weights []float64) []float64 {
+	n := len(demand)
+	order := make([]int, n)
+	var totalWeight float64
+	for i := range demand {
+		order[i] = i
+		totalWeight += weights[i]
+	}
+	// serve consumers by increasing demand per unit of weight
+	sort.Slice(order, func(a, b int) bool {
+		return demand[order[a]]/weights[order[a]] < demand[order[b]]/weights[order[b]]
+	})
+	shares := make([]float64, n)
+	for k, i := range order {
+		if demand[i]*totalWeight <= target*weights[i] {
+			shares[i] = demand[i]
+			target -= demand[i]
+			totalWeight -= weights[i]
+			continue
+		}
+		// the demand of this consumer, and of all the following ones, exceed their fair share
+		for _, j := range order[k:] {
+			shares[j] = target * weights[j] / totalWeight
+		}
+		break
+	}
+	return shares
+}
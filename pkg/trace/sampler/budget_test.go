@@ -0,0 +1,175 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package sampler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+func TestWeightedFairShare(t *testing.T) {
+	tts := []struct {
+		name     string
+		target   float64
+		demand   []float64
+		weights  []float64
+		expected []float64
+	}{
+		{
+			name:     "uniform",
+			target:   2,
+			demand:   []float64{0, 10, 100, 3, 0},
+			weights:  []float64{1, 1, 1, 1, 1},
+			expected: []float64{0, 2.0 / 3, 2.0 / 3, 2.0 / 3, 0},
+		},
+		{
+			name:     "spread unused",
+			target:   10,
+			demand:   []float64{0, 10, 100, 3, 0},
+			weights:  []float64{1, 1, 1, 1, 1},
+			expected: []float64{0, 3.5, 3.5, 3, 0},
+		},
+		{
+			name:     "weighted",
+			target:   12,
+			demand:   []float64{100, 100, 100},
+			weights:  []float64{1, 2, 3},
+			expected: []float64{2, 4, 6},
+		},
+		{
+			name:     "weighted with unused",
+			target:   12,
+			demand:   []float64{1, 100, 100},
+			weights:  []float64{3, 1, 2},
+			expected: []float64{1, 11.0 / 3, 22.0 / 3},
+		},
+		{
+			name:     "all satisfied",
+			target:   100,
+			demand:   []float64{1, 2, 3},
+			weights:  []float64{1, 1, 1},
+			expected: []float64{1, 2, 3},
+		},
+	}
+	for _, tc := range tts {
+		t.Run(tc.name, func(t *testing.T) {
+			shares := weightedFairShare(tc.target, tc.demand, tc.weights)
+			require.Len(t, shares, len(tc.expected))
+			for i := range shares {
+				assert.InDelta(t, tc.expected[i], shares[i], 1e-9, "consumer %d", i)
+			}
+		})
+	}
+}
+
+func TestWeightedFairShareMatchesComputeTPSPerSig(t *testing.T) {
+	seen := []float64{10, 0, 100, 3, 0}
+	for _, target := range []float64{0, 2, 10, 23.5, 53.5} {
+		perSig := computeTPSPerSig(target, seen)
+		shares := weightedFairShare(target, seen, []float64{1, 1, 1, 1, 1})
+		for i, s := range seen {
+			assert.InDelta(t, min(s, perSig), shares[i], 1e-9)
+		}
+	}
+}
+
+func TestBudgetAllocator(t *testing.T) {
+	services := map[Signature]ServiceSignature{
+		1: {Name: "chatty", Env: "prod"},
+		2: {Name: "small", Env: "prod"},
+		3: {Name: "web", Env: "prod"},
+		4: {Name: "web", Env: "staging"},
+	}
+	lookup := func() map[Signature]ServiceSignature { return services }
+	sigs := []Signature{1, 2, 3, 4}
+
+	t.Run("service weights", func(t *testing.T) {
+		b := newBudgetAllocator([]*config.SamplerBudget{
+			{Service: "web", Weight: 2},
+			{Service: "web", Env: "staging", Weight: 1},
+		}, 0, lookup)
+		tps := b.allocate(10, sigs, []float64{1000, 1, 1000, 1000})
+		// small keeps all of its traffic, the rest is shared 1:2:1
+		assert.InDelta(t, 9.0/4, tps[0], 1e-9)
+		assert.InDelta(t, 1, tps[1], 1e-9)
+		assert.InDelta(t, 9.0/2, tps[2], 1e-9)
+		assert.InDelta(t, 9.0/4, tps[3], 1e-9)
+	})
+
+	t.Run("service cap", func(t *testing.T) {
+		b := newBudgetAllocator([]*config.SamplerBudget{
+			{Service: "chatty", Env: "prod", MaxTPS: 1},
+		}, 0, lookup)
+		tps := b.allocate(10, sigs, []float64{1000, 1, 1000, 1000})
+		assert.InDelta(t, 1, tps[0], 1e-9)
+		assert.InDelta(t, 1, tps[1], 1e-9)
+		assert.InDelta(t, 4, tps[2], 1e-9)
+		assert.InDelta(t, 4, tps[3], 1e-9)
+	})
+
+	t.Run("env budgets", func(t *testing.T) {
+		b := newBudgetAllocator([]*config.SamplerBudget{
+			{Env: "prod", Weight: 3},
+			{Env: "staging", Weight: 1},
+		}, 0, lookup)
+		tps := b.allocate(8, sigs, []float64{1000, 1, 1000, 1000})
+		// prod gets 6, staging 2
+		assert.InDelta(t, 2.5, tps[0], 1e-9)
+		assert.InDelta(t, 1, tps[1], 1e-9)
+		assert.InDelta(t, 2.5, tps[2], 1e-9)
+		assert.InDelta(t, 2, tps[3], 1e-9)
+	})
+
+	t.Run("env cap", func(t *testing.T) {
+		b := newBudgetAllocator([]*config.SamplerBudget{
+			{Env: "staging", MaxTPS: 0.5},
+		}, 0, lookup)
+		tps := b.allocate(8, sigs, []float64{1000, 1, 1000, 1000})
+		assert.InDelta(t, 0.5, tps[3], 1e-9)
+		assert.InDelta(t, 7.5, tps[0]+tps[1]+tps[2], 1e-9)
+	})
+
+	t.Run("min rate", func(t *testing.T) {
+		b := newBudgetAllocator(nil, 0.001, lookup)
+		tps := b.allocate(4, sigs, []float64{1000, 1, 0, 0})
+		// 1.001 TPS are reserved, the rest is shared between the two active services
+		assert.InDelta(t, 1+(4-1.001-1+0.001), tps[0], 1e-9)
+		assert.InDelta(t, 1, tps[1], 1e-9)
+		assert.Zero(t, tps[2])
+	})
+}
+
+func TestPrioritySamplerBudgets(t *testing.T) {
+	conf := &config.AgentConfig{
+		ExtraSampleRate: 1.0,
+		TargetTPS:       10,
+		PrioritySamplerBudgets: []*config.SamplerBudget{
+			{Service: "chatty", MaxTPS: 2},
+		},
+		PrioritySamplerMinRate: 0.5,
+	}
+	s := NewPrioritySampler(conf, &DynamicConfig{})
+	now := time.Now()
+	chatty := s.catalog.register(ServiceSignature{Name: "chatty", Env: "prod"})
+	other := s.catalog.register(ServiceSignature{Name: "other", Env: "prod"})
+	s.sampler.countWeightedSig(now, chatty, float32(100*bucketDuration.Seconds()))
+	s.sampler.countWeightedSig(now, other, float32(100*bucketDuration.Seconds()))
+	s.sampler.countWeightedSig(now.Add(bucketDuration+time.Nanosecond), chatty, 0)
+
+	rates, _ := s.sampler.getAllSignatureSampleRates()
+	// chatty would be capped at 2 TPS, but the minimum rate wins
+	assert.Equal(t, 0.5, rates[chatty])
+	assert.Equal(t, 0.5, rates[other])
+
+	s.updateRates()
+	state := s.rateByService.GetNewState("")
+	assert.Equal(t, 0.5, state.Rates["service:chatty,env:prod"])
+}
@@ -91,3 +91,15 @@ func (cat *serviceKeyCatalog) ratesByService(agentEnv string, rates map[Signatur
 	rbs[ServiceSignature{}] = defaultRate
 	return rbs
 }
+
+// servicesBySignature returns a map of the signatures registered in the catalog to their
+// service signature.
+func (cat *serviceKeyCatalog) servicesBySignature() map[Signature]ServiceSignature {
+	cat.mu.Lock()
+	defer cat.mu.Unlock()
+	services := make(map[Signature]ServiceSignature, len(cat.items))
+	for key, el := range cat.items {
+		services[el.Value.(catalogEntry).sig] = key
+	}
+	return services
+}
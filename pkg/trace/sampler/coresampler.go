@@ -49,6 +49,12 @@ type Sampler struct {
 	targetTPS *atomic.Float64
 	// extraRate is an extra raw sampling rate to apply on top of the sampler rate
 	extraRate float64
+	// minRate is the lowest rate the sampler can compute for a signature
+	minRate float64
+
+	// allocateTPS distributes targetTPS between signatures given their seen TPS, returning
+	// the TPS allocated to each of them. If nil, targetTPS is spread uniformly (see computeTPSPerSig).
+	allocateTPS func(targetTPS float64, sigs []Signature, seenTPSs []float64) []float64
 }
 
 // newSampler returns an initialized Sampler
@@ -127,7 +133,16 @@ func (s *Sampler) updateRates(previousBucket, newBucket int64) {
 	_, allSigsSeen := zeroAndGetMax(s.allSigsSeen, previousBucket, newBucket)
 	s.allSigsSeen = allSigsSeen
 
-	tpsPerSig := computeTPSPerSig(s.targetTPS.Load(), seenTPSs)
+	var tpsPerSig []float64
+	if s.allocateTPS != nil {
+		tpsPerSig = s.allocateTPS(s.targetTPS.Load(), sigs, seenTPSs)
+	} else {
+		tps := computeTPSPerSig(s.targetTPS.Load(), seenTPSs)
+		tpsPerSig = make([]float64, len(sigs))
+		for i := range tpsPerSig {
+			tpsPerSig[i] = tps
+		}
+	}
 
 	s.muRates.Lock()
 	defer s.muRates.Unlock()
@@ -135,8 +150,8 @@ func (s *Sampler) updateRates(previousBucket, newBucket int64) {
 	for i, sig := range sigs {
 		seenTPS := seenTPSs[i]
 		rate := 1.0
-		if tpsPerSig < seenTPS && seenTPS > 0 {
-			rate = tpsPerSig / seenTPS
+		if tpsPerSig[i] < seenTPS && seenTPS > 0 {
+			rate = tpsPerSig[i] / seenTPS
 		}
 		// capping increase rate to 20%
 		if prevRate, ok := s.rates[sig]; ok && prevRate != 0 {
@@ -144,6 +159,9 @@ func (s *Sampler) updateRates(previousBucket, newBucket int64) {
 				rate = prevRate * maxRateIncrease
 			}
 		}
+		if rate < s.minRate {
+			rate = s.minRate
+		}
 		if rate > 1.0 {
 			rate = 1.0
 		}
@@ -256,7 +274,7 @@ func (s *Sampler) defaultRate() float64 {
 	if s.lowestRate < rate && s.lowestRate != 0 {
 		return s.lowestRate
 	}
-	return rate
+	return max(rate, s.minRate)
 }
 
 func (s *Sampler) size() int64 {
@@ -55,6 +55,10 @@ func NewPrioritySampler(conf *config.AgentConfig, dynConf *DynamicConfig) *Prior
 		rateByService: &dynConf.RateByService,
 		catalog:       newServiceLookup(conf.MaxCatalogEntries),
 	}
+	if len(conf.PrioritySamplerBudgets) > 0 || conf.PrioritySamplerMinRate > 0 {
+		s.sampler.minRate = conf.PrioritySamplerMinRate
+		s.sampler.allocateTPS = newBudgetAllocator(conf.PrioritySamplerBudgets, conf.PrioritySamplerMinRate, s.catalog.servicesBySignature).allocate
+	}
 	return s
 }
 
@@ -52,8 +52,9 @@ type Concentrator struct {
 func NewConcentrator(conf *config.AgentConfig, writer Writer, now time.Time, statsd statsd.ClientInterface) *Concentrator {
 	bsize := conf.BucketInterval.Nanoseconds()
 	sc := NewSpanConcentrator(&SpanConcentratorConfig{
-		ComputeStatsBySpanKind: conf.ComputeStatsBySpanKind,
-		BucketInterval:         bsize,
+		ComputeStatsBySpanKind:      conf.ComputeStatsBySpanKind,
+		BucketInterval:              bsize,
+		ExceptionSpanEventsAsErrors: conf.ExceptionSpanEventsAsErrors,
 	}, now)
 	_, disabledCIDStats := conf.Features["disable_cid_stats"]
 	c := Concentrator{
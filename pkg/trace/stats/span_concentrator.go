@@ -22,6 +22,8 @@ type SpanConcentratorConfig struct {
 	ComputeStatsBySpanKind bool
 	// BucketInterval the size of our pre-aggregation per bucket
 	BucketInterval int64
+	// ExceptionSpanEventsAsErrors counts spans carrying an exception span event as errors
+	ExceptionSpanEventsAsErrors bool
 }
 
 // StatSpan holds all the required fields from a span needed to calculate stats
@@ -79,7 +81,8 @@ func peerTagKeysToAggregateForSpan(spanKind string, baseService string, peerTagK
 
 // SpanConcentrator produces time bucketed statistics from a stream of raw spans.
 type SpanConcentrator struct {
-	computeStatsBySpanKind bool
+	computeStatsBySpanKind      bool
+	exceptionSpanEventsAsErrors bool
 	// bucket duration in nanoseconds
 	bsize int64
 	// Timestamp of the oldest time bucket for which we allow data.
@@ -99,19 +102,25 @@ type SpanConcentrator struct {
 // NewSpanConcentrator builds a new SpanConcentrator object
 func NewSpanConcentrator(cfg *SpanConcentratorConfig, now time.Time) *SpanConcentrator {
 	sc := &SpanConcentrator{
-		computeStatsBySpanKind: cfg.ComputeStatsBySpanKind,
-		bsize:                  cfg.BucketInterval,
-		oldestTs:               alignTs(now.UnixNano(), cfg.BucketInterval),
-		bufferLen:              defaultBufferLen,
-		mu:                     sync.Mutex{},
-		buckets:                make(map[int64]*RawBucket),
+		computeStatsBySpanKind:      cfg.ComputeStatsBySpanKind,
+		exceptionSpanEventsAsErrors: cfg.ExceptionSpanEventsAsErrors,
+		bsize:                       cfg.BucketInterval,
+		oldestTs:                    alignTs(now.UnixNano(), cfg.BucketInterval),
+		bufferLen:                   defaultBufferLen,
+		mu:                          sync.Mutex{},
+		buckets:                     make(map[int64]*RawBucket),
 	}
 	return sc
 }
 
 // NewStatSpanFromPB is a helper version of NewStatSpan that builds a StatSpan from a pb.Span.
+// When enabled, spans recording an exception in their span events are counted as errors.
 func (sc *SpanConcentrator) NewStatSpanFromPB(s *pb.Span, peerTags []string) (statSpan *StatSpan, ok bool) {
-	return sc.NewStatSpan(s.Service, s.Resource, s.Name, s.Type, s.ParentID, s.Start, s.Duration, s.Error, s.Meta, s.Metrics, peerTags)
+	spanError := s.Error
+	if spanError == 0 && sc.exceptionSpanEventsAsErrors && traceutil.HasExceptionSpanEvent(s) {
+		spanError = 1
+	}
+	return sc.NewStatSpan(s.Service, s.Resource, s.Name, s.Type, s.ParentID, s.Start, s.Duration, spanError, s.Meta, s.Metrics, peerTags)
 }
 
 // NewStatSpan builds a StatSpan from the required fields for stats calculation
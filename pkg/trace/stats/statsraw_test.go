@@ -82,6 +82,30 @@ func TestGrainWithPeerTags(t *testing.T) {
 			})
 		}
 	})
+	t.Run("exception span events", func(t *testing.T) {
+		span := &pb.Span{
+			Service:    "thing",
+			Name:       "other",
+			Resource:   "yo",
+			Metrics:    map[string]float64{"_dd.measured": 1},
+			SpanEvents: []*pb.SpanEvent{{Name: "exception"}},
+		}
+		for _, enabled := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%t", enabled), func(t *testing.T) {
+				sci := NewSpanConcentrator(&SpanConcentratorConfig{
+					ExceptionSpanEventsAsErrors: enabled,
+					BucketInterval:              (time.Duration(10) * time.Second).Nanoseconds(),
+				}, time.Now())
+				s, ok := sci.NewStatSpanFromPB(span, nil)
+				assert.True(t, ok)
+				if enabled {
+					assert.EqualValues(t, 1, s.error)
+				} else {
+					assert.EqualValues(t, 0, s.error)
+				}
+			})
+		}
+	})
 	t.Run("service override", func(t *testing.T) {
 		for _, spanKind := range []string{"client", "internal"} {
 			t.Run(spanKind, func(t *testing.T) {
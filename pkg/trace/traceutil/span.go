@@ -23,6 +23,10 @@ const (
 	tracerTopLevelKey = "_dd.top_level"
 	// partialVersionKey is a metric carrying the snapshot seq number in the case the span is a partial snapshot
 	partialVersionKey = "_dd.partial_version"
+	// hasExceptionSpanEventsKey is a meta flag set on spans carrying at least one exception span event
+	hasExceptionSpanEventsKey = "_dd.span_events.has_exception"
+	// exceptionSpanEventName is the name of span events recording an exception
+	exceptionSpanEventName = "exception"
 )
 
 // HasTopLevel returns true if span is top-level.
@@ -173,3 +177,23 @@ func GetMetric(s *pb.Span, key string) (float64, bool) {
 	val, ok := s.Metrics[key]
 	return val, ok
 }
+
+// HasExceptionSpanEvent returns true if the span records an exception in one of its span events,
+// either through the _dd.span_events.has_exception flag or through an event named "exception".
+func HasExceptionSpanEvent(s *pb.Span) bool {
+	if s.Meta[hasExceptionSpanEventsKey] == "true" {
+		return true
+	}
+	for _, e := range s.SpanEvents {
+		if e != nil && e.Name == exceptionSpanEventName {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidSpanLink returns true if the span link references a span, i.e. it has a non-zero
+// trace ID and span ID.
+func IsValidSpanLink(l *pb.SpanLink) bool {
+	return l != nil && (l.TraceID != 0 || l.TraceIDHigh != 0) && l.SpanID != 0
+}
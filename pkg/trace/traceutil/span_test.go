@@ -215,3 +215,30 @@ func TestIsPartialSnapshot(t *testing.T) {
 	span.Metrics = map[string]float64{"_dd.partial_version": float64(rand.Uint32())}
 	assert.True(IsPartialSnapshot(span), "Any value in partialVersion key will mark the span as incomplete")
 }
+
+func TestHasExceptionSpanEvent(t *testing.T) {
+	assert := assert.New(t)
+	span := &pb.Span{}
+
+	assert.False(HasExceptionSpanEvent(span))
+
+	span.SpanEvents = []*pb.SpanEvent{{Name: "log"}}
+	assert.False(HasExceptionSpanEvent(span), "only exception events are considered")
+
+	span.SpanEvents = append(span.SpanEvents, &pb.SpanEvent{Name: "exception"})
+	assert.True(HasExceptionSpanEvent(span))
+
+	span.SpanEvents = nil
+	span.Meta = map[string]string{"_dd.span_events.has_exception": "true"}
+	assert.True(HasExceptionSpanEvent(span), "span events may have been serialized in meta")
+}
+
+func TestIsValidSpanLink(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(IsValidSpanLink(&pb.SpanLink{TraceID: 1, SpanID: 2}))
+	assert.True(IsValidSpanLink(&pb.SpanLink{TraceIDHigh: 1, SpanID: 2}))
+	assert.False(IsValidSpanLink(&pb.SpanLink{SpanID: 2}), "trace ID is zero")
+	assert.False(IsValidSpanLink(&pb.SpanLink{TraceID: 1}), "span ID is zero")
+	assert.False(IsValidSpanLink(nil))
+}
@@ -0,0 +1,209 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"sync"
+	"time"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/log"
+	"github.com/DataDog/datadog-agent/pkg/trace/timing"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+)
+
+// defaultDryRunBufferSize is the number of entries of each kind kept by a DryRunBuffer
+// when no size is configured.
+const defaultDryRunBufferSize = 100
+
+// SamplingDecision describes the outcome of running the samplers on a trace chunk.
+type SamplingDecision struct {
+	// Time is the moment at which the decision was taken.
+	Time time.Time `json:"time"`
+	// TraceID is the ID of the trace the chunk belongs to.
+	TraceID uint64 `json:"trace_id"`
+	// Service, Name and Resource are taken from the root span of the chunk.
+	Service  string `json:"service"`
+	Name     string `json:"name"`
+	Resource string `json:"resource"`
+	// Env is the tracer env of the chunk.
+	Env string `json:"env"`
+	// Sampler is the name of the sampler which took the decision.
+	Sampler string `json:"sampler"`
+	// Priority is the sampling priority found on the chunk, if any.
+	Priority int `json:"priority"`
+	// Keep reports whether the chunk was kept.
+	Keep bool `json:"keep"`
+	// Reason is a human readable explanation of the decision.
+	Reason string `json:"reason"`
+}
+
+// ring is a fixed size circular buffer keeping the most recent values added to it.
+type ring[T any] struct {
+	values []T
+	next   int
+	full   bool
+}
+
+func newRing[T any](size int) *ring[T] {
+	return &ring[T]{values: make([]T, size)}
+}
+
+func (r *ring[T]) add(v T) {
+	r.values[r.next] = v
+	r.next++
+	if r.next == len(r.values) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// recent returns up to n values, most recent first, for which keep returns true.
+// A non-positive n returns all matching values.
+func (r *ring[T]) recent(n int, keep func(T) bool) []T {
+	size := r.next
+	if r.full {
+		size = len(r.values)
+	}
+	if n <= 0 || n > size {
+		n = size
+	}
+	out := make([]T, 0, n)
+	for i := 1; i <= size && len(out) < n; i++ {
+		v := r.values[(r.next-i+len(r.values))%len(r.values)]
+		if keep == nil || keep(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// DryRunBuffer holds the most recent tracer payloads, stats payloads and sampling decisions
+// produced by the agent when running in dry run mode (apm_config.dry_run.enabled). Nothing
+// stored in it is ever sent to the intake. It is safe for concurrent use.
+type DryRunBuffer struct {
+	mu        sync.RWMutex
+	traces    *ring[*pb.TracerPayload]
+	stats     *ring[*pb.StatsPayload]
+	decisions *ring[SamplingDecision]
+}
+
+// NewDryRunBuffer returns a DryRunBuffer keeping at most size entries of each kind.
+func NewDryRunBuffer(size int) *DryRunBuffer {
+	if size <= 0 {
+		size = defaultDryRunBufferSize
+	}
+	return &DryRunBuffer{
+		traces:    newRing[*pb.TracerPayload](size),
+		stats:     newRing[*pb.StatsPayload](size),
+		decisions: newRing[SamplingDecision](size),
+	}
+}
+
+// AddTracerPayload records a tracer payload which would have been sent to the intake.
+func (b *DryRunBuffer) AddTracerPayload(p *pb.TracerPayload) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.traces.add(p)
+}
+
+// AddStatsPayload records a stats payload which would have been sent to the intake.
+func (b *DryRunBuffer) AddStatsPayload(p *pb.StatsPayload) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stats.add(p)
+}
+
+// AddSamplingDecision records a sampling decision.
+func (b *DryRunBuffer) AddSamplingDecision(d SamplingDecision) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.decisions.add(d)
+}
+
+// TracerPayloads returns up to n recorded tracer payloads, most recent first, for which
+// keep returns true. A nil keep matches all payloads.
+func (b *DryRunBuffer) TracerPayloads(n int, keep func(*pb.TracerPayload) bool) []*pb.TracerPayload {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.traces.recent(n, keep)
+}
+
+// StatsPayloads returns up to n recorded stats payloads, most recent first, for which
+// keep returns true. A nil keep matches all payloads.
+func (b *DryRunBuffer) StatsPayloads(n int, keep func(*pb.StatsPayload) bool) []*pb.StatsPayload {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.stats.recent(n, keep)
+}
+
+// SamplingDecisions returns up to n recorded sampling decisions, most recent first, for
+// which keep returns true. A nil keep matches all decisions.
+func (b *DryRunBuffer) SamplingDecisions(n int, keep func(SamplingDecision) bool) []SamplingDecision {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.decisions.recent(n, keep)
+}
+
+// DryRunTraceWriter implements the agent's TraceWriter interface by recording sampled
+// chunks into a DryRunBuffer instead of sending them to the intake.
+type DryRunTraceWriter struct {
+	buf   *DryRunBuffer
+	stats *info.TraceWriterInfo
+}
+
+// NewDryRunTraceWriter returns a DryRunTraceWriter recording into buf.
+func NewDryRunTraceWriter(buf *DryRunBuffer) *DryRunTraceWriter {
+	log.Info("Trace writer running in dry run mode: traces will not be sent to the intake.")
+	return &DryRunTraceWriter{
+		buf:   buf,
+		stats: &info.TraceWriterInfo{},
+	}
+}
+
+// WriteChunks records the sampled chunks.
+func (w *DryRunTraceWriter) WriteChunks(pkg *SampledChunks) {
+	w.stats.Spans.Add(pkg.SpanCount)
+	w.stats.Traces.Add(int64(len(pkg.TracerPayload.Chunks)))
+	w.stats.Events.Add(pkg.EventCount)
+	if len(pkg.TracerPayload.Chunks) > 0 {
+		w.buf.AddTracerPayload(pkg.TracerPayload)
+	}
+}
+
+// Stop implements TraceWriter. There is nothing to flush in dry run mode.
+func (w *DryRunTraceWriter) Stop() {}
+
+// FlushSync implements TraceWriter. There is nothing to flush in dry run mode.
+func (w *DryRunTraceWriter) FlushSync() error { return nil }
+
+// UpdateAPIKey implements TraceWriter. API keys are not used in dry run mode.
+func (w *DryRunTraceWriter) UpdateAPIKey(_, _ string) {}
+
+// NewDryRunStatsWriter returns a DatadogStatsWriter which records the stats payloads it
+// would have sent into buf instead of sending them to the intake. It must be started using Run.
+func NewDryRunStatsWriter(
+	cfg *config.AgentConfig,
+	buf *DryRunBuffer,
+	statsd statsd.ClientInterface,
+	timing timing.Reporter,
+) *DatadogStatsWriter {
+	log.Info("Stats writer running in dry run mode: stats will not be sent to the intake.")
+	return &DatadogStatsWriter{
+		stats:     &info.StatsWriterInfo{},
+		stop:      make(chan struct{}),
+		flushChan: make(chan chan struct{}),
+		syncMode:  cfg.SynchronousFlushing,
+		easylog:   log.NewThrottled(5, 10*time.Second), // no more than 5 messages every 10 seconds
+		conf:      cfg,
+		statsd:    statsd,
+		timing:    timing,
+		dryRun:    buf,
+	}
+}
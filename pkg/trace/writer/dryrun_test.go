@@ -0,0 +1,63 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package writer
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/timing"
+)
+
+func TestRing(t *testing.T) {
+	r := newRing[int](3)
+	assert.Empty(t, r.recent(0, nil))
+	r.add(1)
+	r.add(2)
+	assert.Equal(t, []int{2, 1}, r.recent(0, nil))
+	r.add(3)
+	r.add(4)
+	assert.Equal(t, []int{4, 3, 2}, r.recent(0, nil))
+	assert.Equal(t, []int{4, 3}, r.recent(2, nil))
+	assert.Equal(t, []int{4, 2}, r.recent(5, func(v int) bool { return v%2 == 0 }))
+}
+
+func TestDryRunTraceWriter(t *testing.T) {
+	buf := NewDryRunBuffer(2)
+	w := NewDryRunTraceWriter(buf)
+	for _, env := range []string{"a", "b", "c"} {
+		w.WriteChunks(&SampledChunks{
+			TracerPayload: &pb.TracerPayload{Env: env, Chunks: []*pb.TraceChunk{{}}},
+			SpanCount:     1,
+		})
+	}
+	// empty payloads are not recorded
+	w.WriteChunks(&SampledChunks{TracerPayload: &pb.TracerPayload{Env: "d"}})
+	payloads := buf.TracerPayloads(0, nil)
+	assert.Len(t, payloads, 2)
+	assert.Equal(t, "c", payloads[0].Env)
+	assert.Equal(t, "b", payloads[1].Env)
+	assert.EqualValues(t, 3, w.stats.Spans.Load())
+	assert.NoError(t, w.FlushSync())
+}
+
+func TestDryRunStatsWriter(t *testing.T) {
+	buf := NewDryRunBuffer(0)
+	cfg := config.New()
+	w := NewDryRunStatsWriter(cfg, buf, &statsd.NoOpClient{}, timing.NoopReporter{})
+	w.Write(&pb.StatsPayload{Stats: []*pb.ClientStatsPayload{{
+		Env:   "env",
+		Stats: []*pb.ClientStatsBucket{{Stats: []*pb.ClientGroupedStats{{Service: "svc", Hits: 1}}}},
+	}}})
+	payloads := buf.StatsPayloads(0, nil)
+	assert.Len(t, payloads, 1)
+	assert.Equal(t, "env", payloads[0].Stats[0].Env)
+	assert.EqualValues(t, 1, w.stats.StatsEntries.Load())
+}
@@ -53,6 +53,9 @@ type DatadogStatsWriter struct {
 	payloads  []*pb.StatsPayload // payloads buffered for sync mode
 	flushChan chan chan struct{}
 
+	// dryRun, when non-nil, receives the payloads instead of the senders.
+	dryRun *DryRunBuffer
+
 	easylog *log.ThrottledLogger
 	statsd  statsd.ClientInterface
 	timing  timing.Reporter
@@ -162,6 +165,10 @@ func (w *DatadogStatsWriter) addStats(sp *pb.StatsPayload) {
 
 // SendPayload sends a stats payload to the Datadog backend.
 func (w *DatadogStatsWriter) SendPayload(p *pb.StatsPayload) {
+	if w.dryRun != nil {
+		w.dryRun.AddStatsPayload(p)
+		return
+	}
 	req := newPayload(map[string]string{
 		headerLanguages:    strings.Join(info.Languages(), "|"),
 		"Content-Type":     "application/msgpack",
@@ -189,3 +189,8 @@ func parsePSI(fr fileReader, path string, somePsi, fullPsi *PSIStats) error {
 		return nil
 	})
 }
+
+// ParsePSIFile parses a pressure stall information file, like the host-wide files of /proc/pressure
+func ParsePSIFile(path string, somePsi, fullPsi *PSIStats) error {
+	return parsePSI(defaultFileReader, path, somePsi, fullPsi)
+}
@@ -20,6 +20,12 @@ const ZstdKind = "zstd"
 // GzipKind  defines a const value for the gzip compressor
 const GzipKind = "gzip"
 
+// Lz4Kind defines a const value for the lz4 compressor
+const Lz4Kind = "lz4"
+
+// SnappyKind defines a const value for the snappy compressor
+const SnappyKind = "snappy"
+
 // NoneKind defines a const value for disabling compression
 const NoneKind = "none"
 
@@ -32,6 +38,12 @@ const ZstdEncoding = "zstd"
 // GzipEncoding is the content-encoding value for Gzip
 const GzipEncoding = "gzip"
 
+// Lz4Encoding is the content-encoding value for the LZ4 frame format
+const Lz4Encoding = "lz4"
+
+// SnappyEncoding is the content-encoding value for the Snappy framing format
+const SnappyEncoding = "x-snappy-framed"
+
 // Compressor is the interface that a given compression algorithm
 // needs to implement
 type Compressor interface {
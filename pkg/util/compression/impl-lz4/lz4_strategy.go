@@ -0,0 +1,78 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package lz4impl provides a set of functions for compressing with lz4
+package lz4impl
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pierrec/lz4/v4"
+
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+)
+
+const (
+	// blockSize is the size of the frame blocks. The default of 4MB would allocate that much
+	// for every payload being built.
+	blockSize = 64 << 10
+	// frameOverhead is the maximum size of the frame header and of the end mark and content checksum
+	frameOverhead = 19 + 4 + 4
+	// blockOverhead is the size of the header of each block
+	blockOverhead = 4
+)
+
+// Lz4Strategy is the strategy for when serializer_compressor_kind is lz4. Payloads use the LZ4
+// frame format, compressed at the fastest level.
+type Lz4Strategy struct{}
+
+// New returns a new Lz4Strategy
+func New() compression.Compressor {
+	return &Lz4Strategy{}
+}
+
+// Compress will compress the data with lz4
+func (s *Lz4Strategy) Compress(src []byte) ([]byte, error) {
+	var b bytes.Buffer
+	b.Grow(s.CompressBound(len(src)))
+	w := newWriter(&b)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Decompress will decompress the data with lz4
+func (s *Lz4Strategy) Decompress(src []byte) ([]byte, error) {
+	return io.ReadAll(lz4.NewReader(bytes.NewReader(src)))
+}
+
+// CompressBound returns the worst case size needed for a destination buffer.
+// Blocks which cannot be compressed are stored as is, so the overhead is limited to the frame
+// and block headers. Every flush may end a block early, which is covered by the extra block.
+func (s *Lz4Strategy) CompressBound(sourceLen int) int {
+	return sourceLen + frameOverhead + (sourceLen/blockSize+1)*blockOverhead
+}
+
+// ContentEncoding returns the content encoding value for lz4
+func (s *Lz4Strategy) ContentEncoding() string {
+	return compression.Lz4Encoding
+}
+
+// NewStreamCompressor returns a new lz4 Writer
+func (s *Lz4Strategy) NewStreamCompressor(output *bytes.Buffer) compression.StreamCompressor {
+	return newWriter(output)
+}
+
+func newWriter(output io.Writer) *lz4.Writer {
+	w := lz4.NewWriter(output)
+	// the options are valid, Apply cannot fail
+	_ = w.Apply(lz4.BlockSizeOption(lz4.Block64Kb), lz4.ConcurrencyOption(1), lz4.CompressionLevelOption(lz4.Fast))
+	return w
+}
@@ -0,0 +1,75 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package snappyimpl provides a set of functions for compressing with snappy
+package snappyimpl
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/klauspost/compress/s2"
+
+	"github.com/DataDog/datadog-agent/pkg/util/compression"
+)
+
+const (
+	// chunkSize is the maximum size of the uncompressed data of a chunk in the Snappy framing format
+	chunkSize = 64 << 10
+	// streamIdentifierSize is the size of the chunk starting every stream
+	streamIdentifierSize = 10
+	// chunkOverhead is the size of the header and checksum of each chunk
+	chunkOverhead = 4 + 4
+)
+
+// SnappyStrategy is the strategy for when serializer_compressor_kind is snappy. Payloads use
+// the Snappy framing format, so that they can be built as streams.
+type SnappyStrategy struct{}
+
+// New returns a new SnappyStrategy
+func New() compression.Compressor {
+	return &SnappyStrategy{}
+}
+
+// Compress will compress the data with snappy
+func (s *SnappyStrategy) Compress(src []byte) ([]byte, error) {
+	var b bytes.Buffer
+	b.Grow(s.CompressBound(len(src)))
+	w := newWriter(&b)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Decompress will decompress the data with snappy
+func (s *SnappyStrategy) Decompress(src []byte) ([]byte, error) {
+	return io.ReadAll(s2.NewReader(bytes.NewReader(src)))
+}
+
+// CompressBound returns the worst case size needed for a destination buffer.
+// Chunks which cannot be compressed are stored as is, so the overhead is limited to the stream
+// identifier and the chunk headers. The two extra chunks cover a flush ending a chunk early and
+// the last chunk written when the stream is closed.
+func (s *SnappyStrategy) CompressBound(sourceLen int) int {
+	return sourceLen + streamIdentifierSize + (sourceLen/chunkSize+2)*chunkOverhead
+}
+
+// ContentEncoding returns the content encoding value for snappy
+func (s *SnappyStrategy) ContentEncoding() string {
+	return compression.SnappyEncoding
+}
+
+// NewStreamCompressor returns a new snappy Writer
+func (s *SnappyStrategy) NewStreamCompressor(output *bytes.Buffer) compression.StreamCompressor {
+	return newWriter(output)
+}
+
+func newWriter(output io.Writer) *s2.Writer {
+	return s2.NewWriter(output, s2.WriterSnappyCompat(), s2.WriterConcurrency(1))
+}
@@ -11,7 +11,9 @@ package selector
 import (
 	common "github.com/DataDog/datadog-agent/pkg/util/compression"
 	implgzip "github.com/DataDog/datadog-agent/pkg/util/compression/impl-gzip"
+	impllz4 "github.com/DataDog/datadog-agent/pkg/util/compression/impl-lz4"
 	implnoop "github.com/DataDog/datadog-agent/pkg/util/compression/impl-noop"
+	implsnappy "github.com/DataDog/datadog-agent/pkg/util/compression/impl-snappy"
 	implzlib "github.com/DataDog/datadog-agent/pkg/util/compression/impl-zlib"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -29,6 +31,10 @@ func NewCompressor(kind string, level int) common.Compressor {
 		return implgzip.New(implgzip.Requires{
 			Level: level,
 		})
+	case common.Lz4Kind:
+		return impllz4.New()
+	case common.SnappyKind:
+		return implsnappy.New()
 	case common.NoneKind:
 		return implnoop.New()
 	default:
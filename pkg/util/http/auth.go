@@ -0,0 +1,294 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+// Authentication types supported by the `authentication` sections of the configuration
+const (
+	AuthTypeOAuth2ClientCredentials = "oauth2_client_credentials"
+	AuthTypeStaticHeaders           = "static_headers"
+	AuthTypeHMAC                    = "hmac"
+)
+
+// oauth2TokenExpiryMargin is how long before its expiration an OAuth2 token is renewed
+const oauth2TokenExpiryMargin = 30 * time.Second
+
+// Authenticator authenticates the requests sent by the Agent, for instance to a gateway
+// in front of the intake. Authenticate is called before each attempt to send a request.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthenticatorFactory builds an Authenticator from the `authentication` section at `prefix`
+type AuthenticatorFactory func(cfg pkgconfigmodel.Reader, prefix string) (Authenticator, error)
+
+var (
+	authenticatorFactoriesMu sync.RWMutex
+	authenticatorFactories   = map[string]AuthenticatorFactory{
+		AuthTypeOAuth2ClientCredentials: newOAuth2ClientCredentialsAuthenticator,
+		AuthTypeStaticHeaders:           newStaticHeadersAuthenticator,
+		AuthTypeHMAC:                    newHMACAuthenticator,
+	}
+)
+
+// RegisterAuthenticator makes an authentication type available in the `authentication` sections
+// of the configuration.
+func RegisterAuthenticator(authType string, factory AuthenticatorFactory) {
+	authenticatorFactoriesMu.Lock()
+	defer authenticatorFactoriesMu.Unlock()
+	authenticatorFactories[authType] = factory
+}
+
+// NewAuthenticator returns the Authenticator configured in the `authentication` section at `prefix`,
+// or nil when the section does not set a type.
+func NewAuthenticator(cfg pkgconfigmodel.Reader, prefix string) (Authenticator, error) {
+	authType := cfg.GetString(prefix + ".type")
+	if authType == "" {
+		return nil, nil
+	}
+
+	authenticatorFactoriesMu.RLock()
+	factory, found := authenticatorFactories[authType]
+	authenticatorFactoriesMu.RUnlock()
+	if !found {
+		return nil, fmt.Errorf("unknown authentication type %q in %s", authType, prefix)
+	}
+	return factory(cfg, prefix)
+}
+
+// WithAuthentication returns a RoundTripper authenticating the requests with the authenticator before
+// sending them with `transport`. It returns `transport` when the authenticator is nil.
+func WithAuthentication(transport http.RoundTripper, authenticator Authenticator) http.RoundTripper {
+	if authenticator == nil {
+		return transport
+	}
+	return &authenticatingTransport{transport: transport, authenticator: authenticator}
+}
+
+// tokenInvalidator is implemented by the authenticators caching a token, which must be renewed
+// when it is rejected.
+type tokenInvalidator interface {
+	invalidateToken()
+}
+
+type authenticatingTransport struct {
+	transport     http.RoundTripper
+	authenticator Authenticator
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *authenticatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A RoundTripper must not modify the request: the transactions reuse their headers between attempts
+	authenticated := req.Clone(req.Context())
+	if err := t.authenticator.Authenticate(authenticated); err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("could not authenticate the request: %w", err)
+	}
+
+	resp, err := t.transport.RoundTrip(authenticated)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		if invalidator, ok := t.authenticator.(tokenInvalidator); ok {
+			invalidator.invalidateToken()
+		}
+	}
+	return resp, err
+}
+
+// CloseIdleConnections closes the idle connections of the underlying transport
+func (t *authenticatingTransport) CloseIdleConnections() {
+	if closer, ok := t.transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// staticHeadersAuthenticator adds fixed headers to the requests. The values can reference secrets
+// with the `ENC[]` notation.
+type staticHeadersAuthenticator struct {
+	headers map[string]string
+}
+
+func newStaticHeadersAuthenticator(cfg pkgconfigmodel.Reader, prefix string) (Authenticator, error) {
+	headers := cfg.GetStringMapString(prefix + ".headers")
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("%s.headers must be set for the %s authentication", prefix, AuthTypeStaticHeaders)
+	}
+	return &staticHeadersAuthenticator{headers: headers}, nil
+}
+
+func (a *staticHeadersAuthenticator) Authenticate(req *http.Request) error {
+	for name, value := range a.headers {
+		req.Header.Set(name, value)
+	}
+	return nil
+}
+
+// oauth2ClientCredentialsAuthenticator sets the bearer token obtained with the OAuth2 client
+// credentials grant (RFC 6749 section 4.4), renewed before it expires or when it is rejected.
+type oauth2ClientCredentialsAuthenticator struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	client       *http.Client
+	token        *APIToken
+}
+
+func newOAuth2ClientCredentialsAuthenticator(cfg pkgconfigmodel.Reader, prefix string) (Authenticator, error) {
+	a := &oauth2ClientCredentialsAuthenticator{
+		tokenURL:     cfg.GetString(prefix + ".oauth2.token_url"),
+		clientID:     cfg.GetString(prefix + ".oauth2.client_id"),
+		clientSecret: cfg.GetString(prefix + ".oauth2.client_secret"),
+		scopes:       cfg.GetStringSlice(prefix + ".oauth2.scopes"),
+		client: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: CreateHTTPTransport(cfg),
+		},
+	}
+	if a.tokenURL == "" || a.clientID == "" {
+		return nil, fmt.Errorf("%s.oauth2.token_url and %s.oauth2.client_id must be set for the %s authentication", prefix, prefix, AuthTypeOAuth2ClientCredentials)
+	}
+	a.token = NewAPIToken(a.fetchToken)
+	return a, nil
+}
+
+func (a *oauth2ClientCredentialsAuthenticator) Authenticate(req *http.Request) error {
+	token, err := a.token.Get(req.Context())
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (a *oauth2ClientCredentialsAuthenticator) invalidateToken() {
+	a.token.Lock()
+	defer a.token.Unlock()
+	a.token.ExpirationDate = time.Time{}
+}
+
+func (a *oauth2ClientCredentialsAuthenticator) fetchToken(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(a.scopes) > 0 {
+		form.Set("scope", strings.Join(a.scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(a.clientID), url.QueryEscape(a.clientSecret))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not fetch the OAuth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("could not read the OAuth2 token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("could not fetch the OAuth2 token: unexpected response status %q", resp.Status)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", time.Time{}, fmt.Errorf("could not parse the OAuth2 token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("the OAuth2 token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return "", time.Time{}, fmt.Errorf("unsupported OAuth2 token type %q", token.TokenType)
+	}
+
+	// Tokens without an expiration are renewed every hour
+	lifetime := time.Hour
+	if token.ExpiresIn > 0 {
+		lifetime = time.Duration(token.ExpiresIn) * time.Second
+	}
+	return token.AccessToken, time.Now().Add(max(lifetime-oauth2TokenExpiryMargin, lifetime/2)), nil
+}
+
+// hmacAuthenticator signs the requests with HMAC-SHA256. The signed string is made of the method,
+// the path and query, the timestamp and the SHA-256 of the body, separated by new lines:
+//
+//	POST\n/api/v2/series?param=value\n1700000000\n<hex sha256 of the body>
+//
+// and the signature is sent as `HMAC-SHA256 KeyId=<key id>, Timestamp=<timestamp>, Signature=<base64 signature>`.
+type hmacAuthenticator struct {
+	keyID  string
+	secret []byte
+	header string
+	now    func() time.Time
+}
+
+func newHMACAuthenticator(cfg pkgconfigmodel.Reader, prefix string) (Authenticator, error) {
+	a := &hmacAuthenticator{
+		keyID:  cfg.GetString(prefix + ".hmac.key_id"),
+		secret: []byte(cfg.GetString(prefix + ".hmac.secret")),
+		header: cfg.GetString(prefix + ".hmac.header"),
+		now:    time.Now,
+	}
+	if len(a.secret) == 0 {
+		return nil, fmt.Errorf("%s.hmac.secret must be set for the %s authentication", prefix, AuthTypeHMAC)
+	}
+	if a.header == "" {
+		a.header = "Authorization"
+	}
+	return a, nil
+}
+
+func (a *hmacAuthenticator) Authenticate(req *http.Request) error {
+	bodyHash := sha256.New()
+	if req.GetBody != nil {
+		// Read a copy of the body so that the request can still be sent
+		body, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(bodyHash, body)
+		_ = body.Close()
+		if err != nil {
+			return err
+		}
+	} else if req.Body != nil && req.Body != http.NoBody {
+		return fmt.Errorf("cannot sign a request whose body cannot be read twice")
+	}
+
+	timestamp := strconv.FormatInt(a.now().Unix(), 10)
+	mac := hmac.New(sha256.New, a.secret)
+	_, _ = io.WriteString(mac, strings.Join([]string{req.Method, req.URL.RequestURI(), timestamp, hex.EncodeToString(bodyHash.Sum(nil))}, "\n"))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	req.Header.Set(a.header, fmt.Sprintf("HMAC-SHA256 KeyId=%s, Timestamp=%s, Signature=%s", a.keyID, timestamp, signature))
+	return nil
+}
@@ -0,0 +1,194 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	configmock "github.com/DataDog/datadog-agent/pkg/config/mock"
+	pkgconfigmodel "github.com/DataDog/datadog-agent/pkg/config/model"
+)
+
+// newAuthenticatedClient returns a client authenticating its requests with the `forwarder_authentication` section
+func newAuthenticatedClient(t *testing.T, cfg pkgconfigmodel.Reader) (*http.Client, Authenticator) {
+	authenticator, err := NewAuthenticator(cfg, "forwarder_authentication")
+	require.NoError(t, err)
+	require.NotNil(t, authenticator)
+	return &http.Client{Transport: WithAuthentication(CreateHTTPTransport(cfg), authenticator)}, authenticator
+}
+
+func TestNewAuthenticator(t *testing.T) {
+	cfg := configmock.New(t)
+	authenticator, err := NewAuthenticator(cfg, "forwarder_authentication")
+	require.NoError(t, err)
+	assert.Nil(t, authenticator)
+
+	transport := CreateHTTPTransport(cfg)
+	assert.Equal(t, transport, WithAuthentication(transport, nil))
+
+	cfg.SetWithoutSource("forwarder_authentication.type", "kerberos")
+	_, err = NewAuthenticator(cfg, "forwarder_authentication")
+	assert.ErrorContains(t, err, "unknown authentication type")
+
+	for _, authType := range []string{AuthTypeStaticHeaders, AuthTypeOAuth2ClientCredentials, AuthTypeHMAC} {
+		cfg.SetWithoutSource("forwarder_authentication.type", authType)
+		_, err = NewAuthenticator(cfg, "forwarder_authentication")
+		assert.Error(t, err, "%s requires some settings", authType)
+	}
+}
+
+func TestRegisterAuthenticator(t *testing.T) {
+	RegisterAuthenticator("test", func(_ pkgconfigmodel.Reader, _ string) (Authenticator, error) {
+		return &staticHeadersAuthenticator{headers: map[string]string{"X-Test": "registered"}}, nil
+	})
+	defer func() {
+		authenticatorFactoriesMu.Lock()
+		delete(authenticatorFactories, "test")
+		authenticatorFactoriesMu.Unlock()
+	}()
+
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("forwarder_authentication.type", "test")
+	authenticator, err := NewAuthenticator(cfg, "forwarder_authentication")
+	require.NoError(t, err)
+	assert.IsType(t, &staticHeadersAuthenticator{}, authenticator)
+}
+
+func TestStaticHeadersAuthenticator(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer server.Close()
+
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("forwarder_authentication.type", AuthTypeStaticHeaders)
+	cfg.SetWithoutSource("forwarder_authentication.headers", map[string]string{"X-Gateway-Key": "secret"})
+	client, _ := newAuthenticatedClient(t, cfg)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("DD-Api-Key", "api_key")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "secret", received.Get("X-Gateway-Key"))
+	assert.Equal(t, "api_key", received.Get("DD-Api-Key"))
+	// The headers of the request are not modified
+	assert.Empty(t, req.Header.Get("X-Gateway-Key"))
+}
+
+func TestOAuth2ClientCredentialsAuthenticator(t *testing.T) {
+	var tokenRequests atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "agent" || clientSecret != "client_secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "metrics logs", r.PostForm.Get("scope"))
+
+		n := tokenRequests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "Bearer", "expires_in": 3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	var rejectNext atomic.Bool
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if rejectNext.Swap(false) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("forwarder_authentication.type", AuthTypeOAuth2ClientCredentials)
+	cfg.SetWithoutSource("forwarder_authentication.oauth2.token_url", tokenServer.URL)
+	cfg.SetWithoutSource("forwarder_authentication.oauth2.client_id", "agent")
+	cfg.SetWithoutSource("forwarder_authentication.oauth2.client_secret", "client_secret")
+	cfg.SetWithoutSource("forwarder_authentication.oauth2.scopes", []string{"metrics", "logs"})
+	client, authenticator := newAuthenticatedClient(t, cfg)
+
+	send := func() int {
+		resp, err := client.Post(server.URL, "application/json", bytes.NewReader([]byte("{}")))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// The token is fetched once and reused until it expires
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, int32(1), tokenRequests.Load())
+	assert.Equal(t, []string{"Bearer token1", "Bearer token1"}, authorizations)
+	expiration := authenticator.(*oauth2ClientCredentialsAuthenticator).token.ExpirationDate
+	assert.WithinDuration(t, time.Now().Add(time.Hour-oauth2TokenExpiryMargin), expiration, time.Minute)
+
+	// A rejected token is renewed
+	rejectNext.Store(true)
+	assert.Equal(t, http.StatusUnauthorized, send())
+	assert.Equal(t, http.StatusOK, send())
+	assert.Equal(t, int32(2), tokenRequests.Load())
+	assert.Equal(t, "Bearer token2", authorizations[3])
+
+	// The requests fail when no token can be fetched
+	cfg.SetWithoutSource("forwarder_authentication.oauth2.client_secret", "wrong")
+	client, _ = newAuthenticatedClient(t, cfg)
+	_, err := client.Post(server.URL, "application/json", bytes.NewReader([]byte("{}")))
+	assert.ErrorContains(t, err, "could not fetch the OAuth2 token")
+	assert.Len(t, authorizations, 4)
+}
+
+func TestHMACAuthenticator(t *testing.T) {
+	var received http.Header
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		receivedBody, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	cfg := configmock.New(t)
+	cfg.SetWithoutSource("forwarder_authentication.type", AuthTypeHMAC)
+	cfg.SetWithoutSource("forwarder_authentication.hmac.key_id", "agent-key")
+	cfg.SetWithoutSource("forwarder_authentication.hmac.secret", "hmac_secret")
+	cfg.SetWithoutSource("forwarder_authentication.hmac.header", "X-Signature")
+	client, authenticator := newAuthenticatedClient(t, cfg)
+	authenticator.(*hmacAuthenticator).now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	body := []byte(`{"series": []}`)
+	resp, err := client.Post(server.URL+"/api/v2/series?foo=bar", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte("hmac_secret"))
+	mac.Write([]byte("POST\n/api/v2/series?foo=bar\n1700000000\n" + hex.EncodeToString(bodyHash[:])))
+	expected := "HMAC-SHA256 KeyId=agent-key, Timestamp=1700000000, Signature=" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	assert.Equal(t, expected, received.Get("X-Signature"))
+	assert.Equal(t, body, receivedBody)
+}
@@ -0,0 +1,55 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSInstanceConfig holds the TLS options of the check instances sending HTTP requests, it is meant to be embedded
+// inline in their configuration
+type TLSInstanceConfig struct {
+	TLSVerify     *bool  `yaml:"tls_verify"`
+	TLSCACert     string `yaml:"tls_ca_cert"`
+	TLSCert       string `yaml:"tls_cert"`
+	TLSPrivateKey string `yaml:"tls_private_key"`
+}
+
+// TLSConfig returns the TLS configuration of the requests of the instance. The server certificate is verified unless
+// tls_verify is false, against the certificate authorities of tls_ca_cert if set, and the client certificate of
+// tls_cert is presented if set.
+func (c *TLSInstanceConfig) TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.TLSVerify != nil && !*c.TLSVerify, //nolint:gosec // disabled on purpose by the user
+	}
+	if c.TLSCACert != "" {
+		caCert, err := os.ReadFile(c.TLSCACert)
+		if err != nil {
+			return nil, fmt.Errorf("could not read tls_ca_cert: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("no certificate found in tls_ca_cert %s", c.TLSCACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.TLSCert != "" {
+		// the private key can be in the certificate file
+		privateKey := c.TLSPrivateKey
+		if privateKey == "" {
+			privateKey = c.TLSCert
+		}
+		cert, err := tls.LoadX509KeyPair(c.TLSCert, privateKey)
+		if err != nil {
+			return nil, fmt.Errorf("could not load tls_cert: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,39 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package http
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSInstanceConfig(t *testing.T) {
+	tlsConfig, err := (&TLSInstanceConfig{}).TLSConfig()
+	require.NoError(t, err)
+	assert.False(t, tlsConfig.InsecureSkipVerify)
+	assert.Nil(t, tlsConfig.RootCAs)
+	assert.Empty(t, tlsConfig.Certificates)
+
+	verify := false
+	tlsConfig, err = (&TLSInstanceConfig{TLSVerify: &verify}).TLSConfig()
+	require.NoError(t, err)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+
+	_, err = (&TLSInstanceConfig{TLSCACert: "/does/not/exist"}).TLSConfig()
+	assert.ErrorContains(t, err, "could not read tls_ca_cert")
+
+	invalidCACert := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(invalidCACert, []byte("not a certificate"), 0o600))
+	_, err = (&TLSInstanceConfig{TLSCACert: invalidCACert}).TLSConfig()
+	assert.ErrorContains(t, err, "no certificate found in tls_ca_cert")
+
+	_, err = (&TLSInstanceConfig{TLSCert: "/does/not/exist"}).TLSConfig()
+	assert.ErrorContains(t, err, "could not load tls_cert")
+}
@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package prometheus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Format is the exposition format of the metrics returned by an endpoint
+type Format int
+
+const (
+	// FormatText is the Prometheus text format
+	FormatText Format = iota
+	// FormatOpenMetrics is the OpenMetrics text format
+	FormatOpenMetrics
+	// FormatProtobuf is the Prometheus protobuf format, made of length-delimited MetricFamily messages
+	FormatProtobuf
+)
+
+// AcceptHeader is the Accept header to send to the endpoints to get their metrics in any of the supported
+// formats, the protobuf format being preferred.
+const AcceptHeader = "application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7," +
+	"text/plain;version=0.0.4;q=0.5," +
+	"application/openmetrics-text;version=1.0.0;q=0.4," +
+	"*/*;q=0.1"
+
+// FormatFromContentType returns the format of a response from its Content-Type header, falling back to the
+// Prometheus text format.
+func FormatFromContentType(contentType string) Format {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return FormatText
+	}
+	switch mediaType {
+	case "application/vnd.google.protobuf":
+		if params["encoding"] == "delimited" || params["encoding"] == "" {
+			return FormatProtobuf
+		}
+	case "application/openmetrics-text":
+		return FormatOpenMetrics
+	}
+	return FormatText
+}
+
+// ParseMetricsWithFormat parses the metrics from the input data in the given format. The lines containing
+// text matching the filter are ignored in the text formats.
+func ParseMetricsWithFormat(data []byte, format Format, filter []string) ([]*MetricFamily, error) {
+	switch format {
+	case FormatProtobuf:
+		decoder := expfmt.NewDecoder(bytes.NewReader(data), expfmt.NewFormat(expfmt.TypeProtoDelim))
+		var families []*dto.MetricFamily
+		for {
+			family := &dto.MetricFamily{}
+			if err := decoder.Decode(family); err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("could not decode the protobuf metrics: %w", err)
+			}
+			families = append(families, family)
+		}
+		return toMetricFamilies(families)
+	case FormatOpenMetrics:
+		return ParseMetricsWithFilter(openMetricsToText(data), filter)
+	default:
+		return ParseMetricsWithFilter(data, filter)
+	}
+}
+
+// openMetricsToText converts the OpenMetrics text format to the Prometheus text format:
+//   - the `# TYPE` lines of the counters and info metrics are renamed after their `_total` and `_info` samples
+//   - the `_created` samples of the counters, histograms and summaries are dropped
+//   - the info and stateset metrics become gauges, the other types become untyped
+//   - the timestamps and exemplars are dropped, as well as the `# HELP`, `# UNIT` and `# EOF` lines
+func openMetricsToText(data []byte) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	// the metric families having `_created` samples
+	withCreated := map[string]bool{}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) < 4 || fields[1] != "TYPE" {
+				// # HELP, # UNIT, # EOF and comments
+				continue
+			}
+			name := fields[2]
+			switch fields[3] {
+			case "counter":
+				fields[2] = name + "_total"
+				withCreated[name] = true
+			case "histogram", "summary":
+				withCreated[name] = true
+			case "info":
+				fields[2] = name + "_info"
+				fields[3] = "gauge"
+			case "stateset":
+				fields[3] = "gauge"
+			case "gauge":
+			default:
+				fields[3] = "untyped"
+			}
+			out.WriteString(strings.Join(fields[:4], " ") + "\n")
+			continue
+		}
+
+		name, labels, rest := splitSample(line)
+		if name == "" {
+			continue
+		}
+		if family, found := strings.CutSuffix(name, "_created"); found && withCreated[family] {
+			continue
+		}
+		// keep the value only, dropping the timestamp (in seconds in OpenMetrics) and the exemplar
+		value, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		out.WriteString(name + labels + " " + value + "\n")
+	}
+	return out.Bytes()
+}
+
+// splitSample splits a sample line into the metric name, the labels between braces and the rest of the line
+func splitSample(line string) (string, string, string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", "", ""
+	}
+	nameEnd := strings.IndexAny(line, "{ \t")
+	if nameEnd == -1 {
+		return "", "", ""
+	}
+	name := line[:nameEnd]
+	if line[nameEnd] != '{' {
+		return name, "", line[nameEnd:]
+	}
+
+	// find the closing brace, ignoring the ones in quoted label values
+	inQuotes := false
+	for i := nameEnd + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			inQuotes = !inQuotes
+		case '}':
+			if !inQuotes {
+				return name, line[nameEnd : i+1], line[i+1:]
+			}
+		}
+	}
+	return "", "", ""
+}
@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package prometheus
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// samplesByFamily returns the samples of each family, formatted as `name{labels} => value`
+func samplesByFamily(families []*MetricFamily) map[string][]string {
+	samples := map[string][]string{}
+	for _, family := range families {
+		for _, sample := range family.Samples {
+			samples[family.Name+" "+family.Type] = append(samples[family.Name+" "+family.Type], sample.String())
+		}
+		sort.Strings(samples[family.Name+" "+family.Type])
+	}
+	return samples
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	for contentType, expected := range map[string]Format{
+		"":                          FormatText,
+		"text/plain; version=0.0.4": FormatText,
+		"application/openmetrics-text; version=1.0.0; charset=utf-8":                                   FormatOpenMetrics,
+		"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited": FormatProtobuf,
+		"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=text":      FormatText,
+	} {
+		if format := FormatFromContentType(contentType); format != expected {
+			t.Errorf("expected format %d for %q, got %d", expected, contentType, format)
+		}
+	}
+}
+
+func TestParseOpenMetrics(t *testing.T) {
+	data := `# HELP requests Requests received.
+# TYPE requests counter
+# UNIT requests requests
+requests_total{path="/a{b}",code="200"} 12 1700000000.123 # {trace_id="abc"} 1.0
+requests_created{path="/a{b}",code="200"} 1600000000
+# TYPE build info
+build_info{version="1.2.3"} 1
+# TYPE latency_seconds histogram
+latency_seconds_bucket{le="0.1"} 3
+latency_seconds_bucket{le="+Inf"} 5
+latency_seconds_sum 0.9
+latency_seconds_count 5
+latency_seconds_created 1600000000
+# TYPE temperature gauge
+temperature 21.5
+# TYPE state stateset
+state{state="up"} 1
+# TYPE mystery unknown
+mystery 3
+# EOF
+`
+	families, err := ParseMetricsWithFormat([]byte(data), FormatOpenMetrics, nil)
+	if err != nil {
+		t.Fatalf("parsing metrics failed with %s", err)
+	}
+
+	expected := map[string][]string{
+		"requests_total COUNTER": {`requests_total{code="200", path="/a{b}"} => 12`},
+		"build_info GAUGE":       {`build_info{version="1.2.3"} => 1`},
+		"latency_seconds HISTOGRAM": {
+			`latency_seconds_bucket{le="+Inf"} => 5`,
+			`latency_seconds_bucket{le="0.1"} => 3`,
+			`latency_seconds_count => 5`,
+			`latency_seconds_sum => 0.9`,
+		},
+		"temperature GAUGE": {`temperature => 21.5`},
+		"state GAUGE":       {`state{state="up"} => 1`},
+		"mystery UNTYPED":   {`mystery => 3`},
+	}
+	actual := samplesByFamily(families)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d families, got %v", len(expected), actual)
+	}
+	for family, samples := range expected {
+		for i, sample := range samples {
+			// the samples are formatted with their timestamp
+			if len(actual[family]) != len(samples) || !bytes.HasPrefix([]byte(actual[family][i]), []byte(sample+" @")) {
+				t.Errorf("unexpected samples for %s: %v", family, actual[family])
+				break
+			}
+		}
+	}
+}
+
+func TestParseProtobuf(t *testing.T) {
+	name := func(s string) *string { return &s }
+	value := func(f float64) *float64 { return &f }
+	counter := dto.MetricType_COUNTER
+	gauge := dto.MetricType_GAUGE
+
+	var buf bytes.Buffer
+	encoder := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	for _, family := range []*dto.MetricFamily{
+		{
+			Name: name("requests_total"),
+			Type: &counter,
+			Metric: []*dto.Metric{
+				{Label: []*dto.LabelPair{{Name: name("code"), Value: name("200")}}, Counter: &dto.Counter{Value: value(12)}},
+				{Label: []*dto.LabelPair{{Name: name("code"), Value: name("500")}}, Counter: &dto.Counter{Value: value(1)}},
+			},
+		},
+		{
+			Name:   name("temperature"),
+			Type:   &gauge,
+			Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: value(21.5)}}},
+		},
+	} {
+		if err := encoder.Encode(family); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	families, err := ParseMetricsWithFormat(buf.Bytes(), FormatProtobuf, nil)
+	if err != nil {
+		t.Fatalf("parsing metrics failed with %s", err)
+	}
+	actual := samplesByFamily(families)
+	if len(actual["requests_total COUNTER"]) != 2 || len(actual["temperature GAUGE"]) != 1 {
+		t.Errorf("unexpected families: %v", actual)
+	}
+
+	if _, err := ParseMetricsWithFormat([]byte("not protobuf"), FormatProtobuf, nil); err == nil {
+		t.Error("expected an error for an invalid protobuf payload")
+	}
+}
@@ -2,14 +2,16 @@ module github.com/DataDog/datadog-agent/pkg/util/prometheus
 
 go 1.23.0
 
-require github.com/prometheus/common v0.62.0
+require (
+	github.com/prometheus/client_model v0.6.1
+	github.com/prometheus/common v0.62.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
-	github.com/prometheus/client_model v0.6.1 // indirect
 	google.golang.org/protobuf v1.36.5 // indirect
 )
 
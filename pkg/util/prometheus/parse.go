@@ -9,6 +9,7 @@ Package prometheus provides utility functions to deal with prometheus endpoints
 package prometheus
 
 import (
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/expfmt"
 	"github.com/prometheus/common/model"
 )
@@ -31,8 +32,22 @@ func ParseMetricsWithFilter(data []byte, filter []string) ([]*MetricFamily, erro
 		return nil, err
 	}
 
-	var metrics []*MetricFamily
+	families := make([]*dto.MetricFamily, 0, len(mf))
 	for _, family := range mf {
+		families = append(families, family)
+	}
+	return toMetricFamilies(families)
+}
+
+// ParseMetrics parses prometheus-formatted metrics from the input data.
+func ParseMetrics(data []byte) ([]*MetricFamily, error) {
+	return ParseMetricsWithFilter(data, nil)
+}
+
+// toMetricFamilies extracts the samples of the decoded metric families
+func toMetricFamilies(families []*dto.MetricFamily) ([]*MetricFamily, error) {
+	var metrics []*MetricFamily
+	for _, family := range families {
 		samples, err := expfmt.ExtractSamples(&expfmt.DecodeOptions{Timestamp: model.Now()}, family)
 		if err != nil {
 			return nil, err
@@ -46,8 +61,3 @@ func ParseMetricsWithFilter(data []byte, filter []string) ([]*MetricFamily, erro
 	}
 	return metrics, nil
 }
-
-// ParseMetrics parses prometheus-formatted metrics from the input data.
-func ParseMetrics(data []byte) ([]*MetricFamily, error) {
-	return ParseMetricsWithFilter(data, nil)
-}
@@ -6,6 +6,7 @@
 package scrubber
 
 import (
+	"bytes"
 	"fmt"
 	"regexp"
 	"slices"
@@ -150,6 +151,18 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 		[]byte(`$1 "********"`),
 	)
 	tokenReplacer.LastUpdated = defaultVersion
+	secretReplacer := matchYAMLKeyEnding(
+		`secret`,
+		[]string{"secret"},
+		[]byte(`$1 "********"`),
+	)
+	secretReplacer.LastUpdated = parseVersion("7.66.0")
+	secretIDReplacer := matchYAMLKeyEnding(
+		`secret_id`,
+		[]string{"secret_id"},
+		[]byte(`$1 "********"`),
+	)
+	secretIDReplacer.LastUpdated = parseVersion("7.66.0")
 	snmpReplacer := matchYAMLKey(
 		`(community_string|auth[Kk]ey|priv[Kk]ey|community|authentication_key|privacy_key|Authorization|authorization)`,
 		[]string{"community_string", "authKey", "authkey", "privKey", "privkey", "community", "authentication_key", "privacy_key", "Authorization", "authorization"},
@@ -162,6 +175,24 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 		[]byte(`$1 "********"`),
 	)
 	snmpMultilineReplacer.LastUpdated = parseVersion("7.34.0") // https://github.com/DataDog/datadog-agent/pull/10305
+	// The values of the headers sent by the Agent, for instance to authenticate with a gateway, are scrubbed while
+	// their names are kept. The block mappings are handled by headersMultilineReplacer.
+	headersReplacer := Replacer{
+		Regex:        regexp.MustCompile(`(^\s*(?:-\s+)?headers\s*:)[ \t]*(?:\{[ \t]*[^\s}]|[^\s{]).*`),
+		YAMLKeyRegex: regexp.MustCompile(`^headers$`),
+		Hints:        []string{"headers"},
+		Repl:         []byte(`$1 "********"`),
+		ProcessValue: scrubMapValues,
+
+		LastUpdated: parseVersion("7.66.0"),
+	}
+	headersMultilineReplacer := Replacer{
+		Regex:    regexp.MustCompile(`(?m)^[ \t]*(?:-[ \t]+)?headers[ \t]*:[ \t]*$(?:\n[ \t]+.*)*`),
+		Hints:    []string{"headers"},
+		ReplFunc: scrubIndentedValues,
+
+		LastUpdated: parseVersion("7.66.0"),
+	}
 	certReplacer := Replacer{
 		/*
 		   Try to match as accurately as possible. RFC 7468's ABNF
@@ -224,13 +255,17 @@ func AddDefaultReplacers(scrubber *Scrubber) {
 	scrubber.AddReplacer(SingleLine, yamlPasswordReplacer)
 	scrubber.AddReplacer(SingleLine, passwordReplacer)
 	scrubber.AddReplacer(SingleLine, tokenReplacer)
+	scrubber.AddReplacer(SingleLine, secretReplacer)
+	scrubber.AddReplacer(SingleLine, secretIDReplacer)
 	scrubber.AddReplacer(SingleLine, snmpReplacer)
+	scrubber.AddReplacer(SingleLine, headersReplacer)
 
 	scrubber.AddReplacer(SingleLine, apiKeyYaml)
 	scrubber.AddReplacer(SingleLine, appKeyYaml)
 
 	scrubber.AddReplacer(MultiLine, snmpMultilineReplacer)
 	scrubber.AddReplacer(MultiLine, certReplacer)
+	scrubber.AddReplacer(MultiLine, headersMultilineReplacer)
 
 	dynamicReplacersMutex.Lock()
 	for _, r := range dynamicReplacers {
@@ -320,6 +355,55 @@ func matchYAMLKeyWithListValue(key string, hints string, repl []byte) Replacer {
 	}
 }
 
+// scrubMapValues replaces the values of a YAML mapping, keeping its keys, or the whole value if it is not a mapping
+func scrubMapValues(data interface{}) interface{} {
+	switch m := data.(type) {
+	case map[string]interface{}:
+		for k := range m {
+			m[k] = defaultReplacement
+		}
+		return m
+	case map[interface{}]interface{}:
+		for k := range m {
+			m[k] = defaultReplacement
+		}
+		return m
+	case nil:
+		return nil
+	}
+	return defaultReplacement
+}
+
+var (
+	headersKeyRegex    = regexp.MustCompile(`^[ \t]*(?:-[ \t]+)?headers[ \t]*:[ \t]*$`)
+	indentedValueRegex = regexp.MustCompile(`^([ \t]*[^:]+:)[ \t]*\S.*$`)
+)
+
+// scrubIndentedValues replaces the values of the lines of YAML `headers` block mappings, which are more indented
+// than their key
+func scrubIndentedValues(block []byte) []byte {
+	lines := bytes.Split(block, []byte("\n"))
+	indent := -1
+	for i, line := range lines {
+		if headersKeyRegex.Match(line) {
+			indent = len(line) - len(bytes.TrimLeft(line, " \t-"))
+			continue
+		}
+		trimmed := bytes.TrimLeft(line, " \t")
+		if indent < 0 || len(line)-len(trimmed) <= indent {
+			indent = -1
+			continue
+		}
+		if indentedValueRegex.Match(line) {
+			lines[i] = indentedValueRegex.ReplaceAll(line, []byte(`$1 "********"`))
+		} else if len(trimmed) > 0 {
+			// continuation of a multi-line value
+			lines[i] = append(slices.Clone(line[:len(line)-len(trimmed)]), defaultReplacement...)
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
 // ScrubFile scrubs credentials from the given file, using the
 // default scrubber.
 func ScrubFile(filePath string) ([]byte, error) {
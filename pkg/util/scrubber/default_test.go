@@ -556,6 +556,11 @@ password: foo
 auth_token: bar
 auth_token_file_path: /foo/bar/baz
 kubelet_auth_token_path: /foo/bar/kube_token
+forwarder_authentication:
+  oauth2:
+    client_secret: bar
+  hmac:
+    secret: baz
 # comment to strip
 network_devices:
   snmp_traps:
@@ -570,6 +575,11 @@ password: "********"
 auth_token: "********"
 auth_token_file_path: /foo/bar/baz
 kubelet_auth_token_path: /foo/bar/kube_token
+forwarder_authentication:
+  oauth2:
+    client_secret: "********"
+  hmac:
+    secret: "********"
 network_devices:
   snmp_traps:
     community_strings: "********"
@@ -619,6 +629,78 @@ func TestAuthorization(t *testing.T) {
 		`  authorization: "********"`)
 }
 
+func TestAuthenticationHeaders(t *testing.T) {
+	assertClean(t,
+		`forwarder_authentication:
+  type: static_headers
+  headers:
+    X-Gateway-Key: foo
+    X-Tenant: |
+      bar
+  include_headers: true
+logs_config:
+  authentication:
+    headers: {X-Gateway-Key: foo}
+  use_http: true`,
+		`forwarder_authentication:
+  type: static_headers
+  headers:
+    X-Gateway-Key: "********"
+    X-Tenant: "********"
+      ********
+  include_headers: true
+logs_config:
+  authentication:
+    headers: "********"
+  use_http: true`)
+	assertClean(t,
+		`instances:
+- headers:
+    X-Api-Key: foo
+  url: http://localhost
+- headers:
+    X-Api-Key: bar`,
+		`instances:
+- headers:
+    X-Api-Key: "********"
+  url: http://localhost
+- headers:
+    X-Api-Key: "********"`)
+}
+
+func TestSecretBackendConfig(t *testing.T) {
+	assertClean(t,
+		`secret_backend_type: vault
+secret_backend_config:
+  address: https://vault.example.com
+  approle:
+    role_id: datadog-agent
+    secret_id: 3f1a6c2e-8d4b-4e4f-9a57-6b1f0c2d9e8a
+    secret_id_file: /etc/datadog-agent/secret_id
+  headers:
+    X-Vault-Request: "true"`,
+		`secret_backend_type: vault
+secret_backend_config:
+  address: https://vault.example.com
+  approle:
+    role_id: datadog-agent
+    secret_id: "********"
+    secret_id_file: /etc/datadog-agent/secret_id
+  headers:
+    X-Vault-Request: "********"`)
+	assertClean(t,
+		`secret_backend_config:
+  url: https://secrets.example.com/resolve
+  headers:
+    Authorization: Bearer abc
+    X-Api-Key: foo`,
+		`secret_backend_config:
+  url: https://secrets.example.com/resolve
+  headers:
+    Authorization: "********"
+    X-Api-Key: "********"`)
+}
+
 func TestScrubCommandsEnv(t *testing.T) {
 	testCases := []struct {
 		name     string
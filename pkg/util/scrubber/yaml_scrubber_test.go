@@ -186,3 +186,45 @@ yet_another_key: 'dddd'`
 		require.YAMLEq(t, expected, scrubbed)
 	})
 }
+
+func TestScrubYamlAuthenticationHeaders(t *testing.T) {
+	contents := `forwarder_authentication:
+  type: static_headers
+  headers:
+    X-Gateway-Key: foo
+    X-Tenant: bar
+logs_config:
+  authentication:
+    headers: {}`
+
+	scrubbed, err := ScrubYamlString(contents)
+	require.NoError(t, err)
+	expected := `forwarder_authentication:
+  type: static_headers
+  headers:
+    X-Gateway-Key: '********'
+    X-Tenant: '********'
+logs_config:
+  authentication:
+    headers: {}`
+	require.YAMLEq(t, expected, scrubbed)
+}
+
+func TestScrubYamlSecretBackendConfig(t *testing.T) {
+	contents := `secret_backend_config:
+  approle:
+    role_id: datadog-agent
+    secret_id: 3f1a6c2e-8d4b-4e4f-9a57-6b1f0c2d9e8a
+  headers:
+    X-Api-Key: foo`
+
+	scrubbed, err := ScrubYamlString(contents)
+	require.NoError(t, err)
+	expected := `secret_backend_config:
+  approle:
+    role_id: datadog-agent
+    secret_id: '********'
+  headers:
+    X-Api-Key: '********'`
+	require.YAMLEq(t, expected, scrubbed)
+}